@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// playerSampleInterval is how often concurrent players are counted
+	// towards the day's peak.
+	playerSampleInterval = time.Minute
+	// activityRetentionDays is how long per-day activity is kept.
+	activityRetentionDays = 90
+	activityDayLayout     = "2006-01-02"
+)
+
+// SessionActivity records that a session was used on a day, by an
+// authenticated REST request or by joining a game over WebSocket.
+type SessionActivity struct {
+	SessionID string `gorm:"primaryKey"`
+	Day       string `gorm:"primaryKey;index"`
+}
+
+// PlayerPeak is the most players connected over WebSocket at once on a day.
+type PlayerPeak struct {
+	Day     string `gorm:"primaryKey"`
+	Players int64  `gorm:"not null"`
+	At      time.Time
+}
+
+var (
+	// activeSeen holds the sessions already marked active on activeSeenDay
+	// by this instance, so marking costs a write only once a day per
+	// session.
+	activeSeenMu  sync.Mutex
+	activeSeenDay string
+	activeSeen    = map[string]bool{}
+)
+
+func activityDay(t time.Time) string {
+	return t.UTC().Format(activityDayLayout)
+}
+
+// markActive counts sessionID as active today.
+func markActive(db *gorm.DB, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	day := activityDay(time.Now())
+
+	activeSeenMu.Lock()
+	if activeSeenDay != day {
+		activeSeenDay = day
+		activeSeen = map[string]bool{}
+	}
+	if activeSeen[sessionID] {
+		activeSeenMu.Unlock()
+		return
+	}
+	activeSeen[sessionID] = true
+	activeSeenMu.Unlock()
+
+	err := withOperation(db, "activity_mark").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&SessionActivity{SessionID: sessionID, Day: day}).Error
+	if err != nil {
+		errorf("Failed to mark session active: %v", err)
+		activeSeenMu.Lock()
+		delete(activeSeen, sessionID)
+		activeSeenMu.Unlock()
+	}
+}
+
+// activeSessions counts the distinct sessions active over the days days
+// ending today.
+func activeSessions(db *gorm.DB, days int) (int64, error) {
+	since := activityDay(time.Now().AddDate(0, 0, 1-days))
+	var count int64
+	err := db.Model(&SessionActivity{}).Where("day >= ?", since).Distinct("session_id").Count(&count).Error
+	return count, err
+}
+
+// concurrentPlayers counts the sessions the WebSocket servers currently
+// hold in a game.
+func concurrentPlayers(db *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&WSMember{}).Distinct("session_id").Count(&count).Error
+	return count, err
+}
+
+func todaysPeak(db *gorm.DB) (int64, error) {
+	var peak PlayerPeak
+	err := db.Where("day = ?", activityDay(time.Now())).Limit(1).Find(&peak).Error
+	return peak.Players, err
+}
+
+// samplePlayers raises today's peak to the current number of players if it
+// is higher.
+func samplePlayers(db *gorm.DB) {
+	players, err := concurrentPlayers(db)
+	if err != nil {
+		errorf("Failed to count concurrent players: %v", err)
+		return
+	}
+	now := time.Now()
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"players": players, "at": now}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "player_peaks.players < ?", Vars: []interface{}{players}}}},
+	}).Create(&PlayerPeak{Day: activityDay(now), Players: players, At: now}).Error
+	if err != nil {
+		errorf("Failed to record player peak: %v", err)
+	}
+}
+
+// sweepActivity drops activity and peaks older than activityRetentionDays.
+func sweepActivity(db *gorm.DB) {
+	cutoff := activityDay(time.Now().AddDate(0, 0, -activityRetentionDays))
+	if err := db.Where("day < ?", cutoff).Delete(&SessionActivity{}).Error; err != nil {
+		errorf("Activity sweep failed: %v", err)
+	}
+	if err := db.Where("day < ?", cutoff).Delete(&PlayerPeak{}).Error; err != nil {
+		errorf("Player peak sweep failed: %v", err)
+	}
+}
+
+// registerActivityMetrics exposes usage as gauges read from the database at
+// scrape time, so every instance reports the same figures.
+func registerActivityMetrics(db *gorm.DB) {
+	db = withOperation(db, "activity_metrics")
+	// Each read gets as long as a request would, so a scrape can't hang on
+	// a locked database.
+	gauge := func(read func(db *gorm.DB) (int64, error)) func() float64 {
+		return func() float64 {
+			ctx, cancel := context.WithTimeout(context.Background(), config.HandlerTimeout)
+			defer cancel()
+			n, err := read(readReplica(db.WithContext(ctx)))
+			if err != nil {
+				errorf("Failed to read activity metric: %v", err)
+			}
+			return float64(n)
+		}
+	}
+	metrics.newGaugeFunc("active_sessions_daily", "Distinct sessions active today (UTC).", gauge(func(db *gorm.DB) (int64, error) { return activeSessions(db, 1) }))
+	metrics.newGaugeFunc("active_sessions_weekly", "Distinct sessions active over the last 7 days.", gauge(func(db *gorm.DB) (int64, error) { return activeSessions(db, 7) }))
+	metrics.newGaugeFunc("concurrent_players", "Sessions currently in a game over WebSocket.", gauge(concurrentPlayers))
+	metrics.newGaugeFunc("concurrent_players_peak_daily", "Most sessions in a game at once today (UTC).", gauge(todaysPeak))
+}
+
+// activityStats reports daily and weekly active sessions, current players
+// and, for each of the last days days, the active sessions and player peak.
+func activityStats(db *gorm.DB, c *gin.Context) {
+	db = readReplica(withRequest(db, c, "admin_activity"))
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days < 1 || days > activityRetentionDays {
+		respondError(c, "invalid_days")
+		return
+	}
+
+	daily, err := activeSessions(db, 1)
+	if err != nil {
+		respondError(c, "activity_failed")
+		return
+	}
+	weekly, err := activeSessions(db, 7)
+	if err != nil {
+		respondError(c, "activity_failed")
+		return
+	}
+	players, err := concurrentPlayers(db)
+	if err != nil {
+		respondError(c, "activity_failed")
+		return
+	}
+
+	since := activityDay(time.Now().AddDate(0, 0, 1-days))
+	var perDay []struct {
+		Day      string
+		Sessions int64
+	}
+	err = db.Model(&SessionActivity{}).
+		Select("day, COUNT(*) AS sessions").
+		Where("day >= ?", since).
+		Group("day").
+		Scan(&perDay).Error
+	if err != nil {
+		respondError(c, "activity_failed")
+		return
+	}
+	var peaks []PlayerPeak
+	if err := db.Where("day >= ?", since).Find(&peaks).Error; err != nil {
+		respondError(c, "activity_failed")
+		return
+	}
+
+	sessions := make(map[string]int64, len(perDay))
+	for _, d := range perDay {
+		sessions[d.Day] = d.Sessions
+	}
+	peakByDay := make(map[string]PlayerPeak, len(peaks))
+	for _, p := range peaks {
+		peakByDay[p.Day] = p
+	}
+
+	history := make([]gin.H, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		day := activityDay(time.Now().AddDate(0, 0, -i))
+		entry := gin.H{
+			"day":             day,
+			"active_sessions": sessions[day],
+			"peak_players":    peakByDay[day].Players,
+		}
+		if peak, ok := peakByDay[day]; ok {
+			entry["peak_at"] = peak.At
+		}
+		history = append(history, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"daily_active":       daily,
+		"weekly_active":      weekly,
+		"concurrent_players": players,
+		"days":               history,
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requireService only lets requests through that carry the token shared with
+// the WebSocket server, for the routes it calls on its players' behalf.
+func requireService() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if config.ServiceToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(config.ServiceToken)) != 1 {
+			abortWithError(c, "invalid_service_token")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requireAdmin only lets requests through that carry the configured admin
+// token as a bearer token. With no token configured admin routes are off.
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.AdminToken == "" {
+			abortWithError(c, "admin_disabled")
+			return
+		}
+
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(config.AdminToken)) != 1 {
+			abortWithError(c, "invalid_admin_token")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func cardInfo(card Card) gin.H {
+	return gin.H{
+		"card_id":  card.ID,
+		"img_path": card.ImgPath,
+		"pack":     card.Pack,
+		"tags":     splitList(card.Tags),
+	}
+}
+
+func listCards(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_cards")
+
+	filter := cardFilter{
+		Tags: splitList(c.Query("tags")),
+		Pack: strings.ToLower(c.Query("pack")),
+	}
+
+	var cards []Card
+	if err := filter.apply(db.Model(&Card{})).Order("id").Find(&cards).Error; err != nil {
+		respondError(c, "cards_failed")
+		return
+	}
+
+	result := make([]gin.H, 0, len(cards))
+	for _, card := range cards {
+		result = append(result, cardInfo(card))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func updateCardMetadata(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_cards")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, "invalid_card_id")
+		return
+	}
+
+	var json struct {
+		Pack *string   `json:"pack"`
+		Tags *[]string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+
+	var card Card
+	if err := db.First(&card, id).Error; err != nil {
+		respondError(c, "card_not_found")
+		return
+	}
+
+	if json.Pack != nil {
+		pack := strings.ToLower(strings.TrimSpace(*json.Pack))
+		if pack == "" {
+			respondError(c, "pack_empty")
+			return
+		}
+		card.Pack = pack
+	}
+	if json.Tags != nil {
+		card.Tags = joinList(*json.Tags)
+	}
+
+	if err := db.Save(&card).Error; err != nil {
+		respondError(c, "card_update_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, cardInfo(card))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	old := config.ServiceToken
+	config.ServiceToken = "test-service-token"
+	defer func() { config.ServiceToken = old }()
+
+	r := gin.New()
+	r.POST("/hand/play", requireService(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"Bearer test-service-token", http.StatusOK},
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/hand/play", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("Authorization %q: status %d, want %d", tt.header, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// announcementLevels are the levels clients know how to show.
+var announcementLevels = map[string]bool{"info": true, "warning": true, maintenanceLevel: true}
+
+// sendAnnouncement has the WebSocket server push an Announcement to the
+// game's clients, or to all of them when gameID is empty. A non-zero
+// shutdownAt tells clients when a planned shutdown is. It returns how many
+// connections it went to.
+func sendAnnouncement(message, level, gameID string, shutdownAt time.Time) (int, error) {
+	body := gin.H{"message": message, "level": level, "game_id": gameID}
+	if !shutdownAt.IsZero() {
+		body["shutdown_at"] = shutdownAt.Unix()
+	}
+	var result struct {
+		Clients int `json:"clients"`
+	}
+	if err := postWS("/announcements", body, &result); err != nil {
+		return 0, err
+	}
+	return result.Clients, nil
+}
+
+// announce pushes an Announcement, such as a maintenance warning or an event
+// notice, through the WebSocket server to every connected client, or only
+// to those in game_id when one is given.
+func announce(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_announce")
+
+	var json struct {
+		Message string `json:"message"`
+		Level   string `json:"level"`
+		GameID  string `json:"game_id"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+	message := strings.TrimSpace(json.Message)
+	if message == "" {
+		respondError(c, "announcement_required")
+		return
+	}
+	level := json.Level
+	if level == "" {
+		level = "info"
+	}
+	if !announcementLevels[level] {
+		respondError(c, "invalid_request")
+		return
+	}
+
+	clients, err := sendAnnouncement(message, level, json.GameID, time.Time{})
+	if err != nil {
+		errorf("Failed to send announcement to the WebSocket server: %v", err)
+		respondError(c, "announce_failed")
+		return
+	}
+
+	target := json.GameID
+	if target == "" {
+		target = "*"
+	}
+	recordAudit(db, c, "announce", target, fmt.Sprintf("level=%s message=%q clients=%d", level, message, clients))
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_id": json.GameID,
+		"level":   level,
+		"clients": clients,
+	})
+}
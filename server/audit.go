@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AuditEntry records an admin action that changed something players can
+// see, so it can be traced back later.
+type AuditEntry struct {
+	ID         uint   `gorm:"primaryKey"`
+	Action     string `gorm:"not null;index"`
+	Target     string `gorm:"index"`
+	Detail     string
+	RemoteAddr string
+	OccurredAt time.Time `gorm:"not null;index"`
+}
+
+func auditInfo(entry AuditEntry) gin.H {
+	return gin.H{
+		"id":          entry.ID,
+		"action":      entry.Action,
+		"target":      entry.Target,
+		"detail":      entry.Detail,
+		"remote_addr": entry.RemoteAddr,
+		"occurred_at": entry.OccurredAt,
+	}
+}
+
+// recordAudit logs an admin action on target along with where the request
+// came from.
+func recordAudit(db *gorm.DB, c *gin.Context, action, target, detail string) {
+	entry := AuditEntry{
+		Action:     action,
+		Target:     target,
+		Detail:     detail,
+		RemoteAddr: c.ClientIP(),
+		OccurredAt: time.Now(),
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		errorf("Failed to audit %s of %s: %v", action, target, err)
+	}
+	writeAuditLog(entry)
+}
+
+// listAudit returns the newest audit entries first, optionally only those
+// for one action or target.
+func listAudit(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_audit")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		respondError(c, "invalid_limit")
+		return
+	}
+
+	query := db.Model(&AuditEntry{})
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if target := c.Query("target"); target != "" {
+		query = query.Where("target = ?", target)
+	}
+
+	var entries []AuditEntry
+	if err := query.Order("id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		respondError(c, "audit_failed")
+		return
+	}
+
+	result := make([]gin.H, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, auditInfo(entry))
+	}
+	c.JSON(http.StatusOK, result)
+}
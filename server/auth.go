@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const sessionUserKey = "session_user"
+
+// requireSession authenticates a player by the session ID they send as a
+// bearer token, so it stays out of URLs and request logs, and stores their
+// user for sessionUserFrom.
+func requireSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := bearerSession(c)
+		if sessionID == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			abortWithError(c, "authorization_required")
+			return
+		}
+
+		if !authenticate(db, c, sessionID) {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			abortWithError(c, "invalid_session_id")
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireBodySession is requireSession for the older endpoints that take the
+// session as a session_id field in a JSON or form body. A bearer token is
+// accepted there too. The body is left for the handler to bind.
+func requireBodySession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := bearerSession(c)
+		if sessionID == "" {
+			sessionID = bodySession(c)
+		}
+		if sessionID == "" {
+			abortWithError(c, "session_required")
+			return
+		}
+
+		if !authenticate(db, c, sessionID) {
+			abortWithError(c, "invalid_session_id")
+			return
+		}
+		c.Next()
+	}
+}
+
+// optionalSession is requireSession for endpoints that anyone may call but
+// that tell an authenticated player a little more. Requests without a bearer
+// token go through as they are.
+func optionalSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := bearerSession(c)
+		if sessionID == "" {
+			c.Next()
+			return
+		}
+
+		if !authenticate(db, c, sessionID) {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			abortWithError(c, "invalid_session_id")
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticate looks up the user sessionID belongs to and stores them for
+// sessionUserFrom, reporting whether there is one.
+func authenticate(db *gorm.DB, c *gin.Context, sessionID string) bool {
+	var user User
+	if err := withRequest(db, c, "auth").Where("session_id = ?", sessionID).First(&user).Error; err != nil {
+		return false
+	}
+
+	markActive(db.WithContext(c.Request.Context()), sessionID)
+	c.Set(sessionUserKey, user)
+	return true
+}
+
+func bearerSession(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// bodySession reads the session_id field of the request body, putting a JSON
+// body back so the handler can still bind it.
+func bodySession(c *gin.Context) string {
+	if c.ContentType() != gin.MIMEJSON {
+		return c.PostForm("session_id")
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	var body struct {
+		SessionID string `json:"session_id"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return ""
+	}
+	return body.SessionID
+}
+
+// sessionUserFrom returns the user requireSession or requireBodySession
+// authenticated.
+func sessionUserFrom(c *gin.Context) User {
+	return c.MustGet(sessionUserKey).(User)
+}
+
+// optionalSessionUserFrom returns the user optionalSession authenticated, if
+// the request had one.
+func optionalSessionUserFrom(c *gin.Context) (User, bool) {
+	user, ok := c.Get(sessionUserKey)
+	if !ok {
+		return User{}, false
+	}
+	return user.(User), true
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// receiveAvatar reads an avatar sent either with the form, as image, or as
+// a finished resumable upload named by upload_id, which was charged against
+// the rate limit when it was opened. It responds and returns false if
+// there's none or it is rejected.
+func receiveAvatar(db *gorm.DB, c *gin.Context, sessionID string) (string, []byte, bool) {
+	if uploadID := c.PostForm("upload_id"); uploadID != "" {
+		return claimAvatar(db, c, uploadID)
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		respondError(c, "file_missing")
+		return "", nil, false
+	}
+	if !allowUpload(c, sessionID, 1) {
+		return "", nil, false
+	}
+	data, uerr := readUpload(file)
+	if uerr != nil {
+		uerr.respond(c)
+		return "", nil, false
+	}
+	return secureFilename(file.Filename), data, true
+}
+
+// updateAvatar replaces the player's avatar and bumps its revision, which
+// is what tells other clients to fetch it again.
+func updateAvatar(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "update_avatar")
+	user := sessionUserFrom(c)
+
+	filename, data, ok := receiveAvatar(db, c, user.SessionID)
+	if !ok {
+		return
+	}
+
+	// Each revision gets a file of its own, so nothing still serving the
+	// old one reads a half-written new one.
+	revision := user.AvatarRevision + 1
+	imagePath := filepath.Join(config.UploadFolder, fmt.Sprintf("%d-%d-%s", user.ID, revision, filename))
+	if err := writeFileContext(c.Request.Context(), imagePath, data); err != nil {
+		respondError(c, "file_save_failed")
+		return
+	}
+	result := db.Model(&User{}).
+		Where("id = ? AND avatar_revision = ?", user.ID, user.AvatarRevision).
+		Updates(map[string]interface{}{"image_path": imagePath, "avatar_revision": revision})
+	if result.Error != nil || result.RowsAffected == 0 {
+		os.Remove(imagePath)
+		respondError(c, "user_update_failed")
+		return
+	}
+	if user.ImagePath != imagePath {
+		os.Remove(user.ImagePath)
+	}
+
+	user.ImagePath, user.AvatarRevision = imagePath, revision
+	userInfo(c, user)
+}
+
+// parseAvatarRevisions reads the avatars a client has cached from a list of
+// session:revision pairs. Malformed pairs are skipped.
+func parseAvatarRevisions(list string) map[string]uint {
+	known := map[string]uint{}
+	for _, pair := range strings.Split(list, ",") {
+		sessionID, revision, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(revision, 10, 32)
+		if err != nil {
+			continue
+		}
+		known[sessionID] = uint(n)
+	}
+	return known
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Each request body may be up to MaxBodyBytes, or MaxDeckBodyBytes on the
+// routes that take a whole deck as JSON, so a client can't make a handler
+// read an endless body into memory. The WebSocket server's event batches
+// may be up to MaxEventBodyBytes. The image upload routes are bounded by
+// the upload policy instead, and the endpoints that take nothing but a
+// session by sessionBodyBytes.
+const (
+	defaultMaxBodyBytes      = 64 << 10
+	defaultMaxDeckBodyBytes  = 64 << 20
+	defaultMaxEventBodyBytes = 16 << 20
+	sessionBodyBytes         = 4 << 10
+	// multipartOverhead is what a multipart body may carry besides its
+	// image.
+	multipartOverhead = 64 << 10
+)
+
+// deckBodyRoutes take a deck of base64 images in one JSON body.
+var deckBodyRoutes = map[string]bool{
+	"/createCustomDeck": true,
+}
+
+// eventBodyRoutes take a batch of game events from the WebSocket server.
+var eventBodyRoutes = map[string]bool{
+	"/events": true,
+}
+
+// imageBodyRoutes take an image as a multipart form.
+var imageBodyRoutes = map[string]bool{
+	"/register":                   true,
+	"/me/avatar":                  true,
+	"/decks/uploads/:id/cards/:n": true,
+	"/chat/images":                true,
+}
+
+// sessionBodyRoutes take nothing but a session in their body.
+var sessionBodyRoutes = map[string]bool{
+	"/user-info":  true,
+	"/exit":       true,
+	"/connect":    true,
+	"/disconnect": true,
+}
+
+// unlimitedBodyRoutes check the size of what they're sent themselves.
+var unlimitedBodyRoutes = map[string]bool{
+	"/uploads/:id": true,
+}
+
+const limitedBodyKey = "limited_body"
+
+var bodiesTooLarge = metrics.newCounter("http_bodies_too_large_total", "Requests refused for a body over their route's limit.", "route")
+
+func loadBodyLimits() {
+	if n, err := strconv.ParseInt(os.Getenv("BODY_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		config.MaxBodyBytes = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("DECK_BODY_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		config.MaxDeckBodyBytes = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("EVENT_BODY_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		config.MaxEventBodyBytes = n
+	}
+}
+
+// bodyLimit is the most a route's request body may hold, or 0 for no limit.
+func bodyLimit(route string) int64 {
+	switch {
+	case unlimitedBodyRoutes[route]:
+		return 0
+	case deckBodyRoutes[route]:
+		return config.MaxDeckBodyBytes
+	case eventBodyRoutes[route]:
+		return config.MaxEventBodyBytes
+	case imageBodyRoutes[route]:
+		return config.MaxUploadBytes + multipartOverhead
+	case sessionBodyRoutes[route]:
+		return sessionBodyBytes
+	}
+	return config.MaxBodyBytes
+}
+
+// limitedBody notes when a body ran past its limit, so the error the handler
+// answers with can be put down to that.
+type limitedBody struct {
+	io.ReadCloser
+	limit    int64
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitBody caps each request's body at its route's limit. A body that
+// says up front it's too large is refused straight away; one that turns out
+// to be is cut off, and the handler's answer becomes request_too_large.
+func limitBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := bodyLimit(c.FullPath())
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			bodiesTooLarge.Inc(c.FullPath())
+			respondError(c, "request_too_large", limit)
+			c.Abort()
+			return
+		}
+
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, limit), limit: limit}
+		c.Request.Body = body
+		c.Set(limitedBodyKey, body)
+		c.Next()
+
+		if body.exceeded {
+			bodiesTooLarge.Inc(c.FullPath())
+		}
+	}
+}
+
+// bodyTooLarge reports whether the request's body ran past its limit, and
+// what the limit was.
+func bodyTooLarge(c *gin.Context) (int64, bool) {
+	body, ok := c.Get(limitedBodyKey)
+	if !ok {
+		return 0, false
+	}
+	limited := body.(*limitedBody)
+	return limited.limit, limited.exceeded
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// minRoomCapacity is the smallest player cap a host can set; a round needs
+// at least two players.
+const minRoomCapacity = 2
+
+// loadRoomCapacity overrides the default and maximum room sizes from the
+// environment.
+func loadRoomCapacity() {
+	if n, err := strconv.Atoi(os.Getenv("ROOM_CAPACITY_MAX")); err == nil && n >= minRoomCapacity {
+		config.MaxRoomCapacity = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("ROOM_CAPACITY")); err == nil && n >= minRoomCapacity {
+		config.DefaultRoomCapacity = n
+	}
+	if config.DefaultRoomCapacity > config.MaxRoomCapacity {
+		config.DefaultRoomCapacity = config.MaxRoomCapacity
+	}
+}
+
+func validCapacity(capacity int) bool {
+	return capacity >= minRoomCapacity && capacity <= config.MaxRoomCapacity
+}
+
+// capacity is the room's player cap, falling back to the server default for
+// rooms created without one.
+func (s RoomSettings) capacity() int {
+	if s.Capacity > 0 {
+		return s.Capacity
+	}
+	return config.DefaultRoomCapacity
+}
+
+func roomCapacity(db *gorm.DB, gameID string) int {
+	var settings RoomSettings
+	if err := db.Where("game_id = ?", gameID).First(&settings).Error; err != nil {
+		return config.DefaultRoomCapacity
+	}
+	return settings.capacity()
+}
+
+func setRoomCapacity(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "room_capacity")
+
+	var json struct {
+		SessionID string `json:"session_id"`
+		GameID    string `json:"game_id"`
+		Capacity  int    `json:"capacity"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" || json.GameID == "" {
+		respondError(c, "game_and_session_required")
+		return
+	}
+	if !validCapacity(json.Capacity) {
+		respondError(c, "invalid_capacity", minRoomCapacity, config.MaxRoomCapacity)
+		return
+	}
+
+	var settings RoomSettings
+	if err := db.Where("game_id = ?", json.GameID).First(&settings).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
+	}
+	if settings.HostSession != json.SessionID {
+		respondError(c, "not_host")
+		return
+	}
+
+	var members int64
+	db.Model(&Room{}).Where("game_id = ?", json.GameID).Count(&members)
+	if int64(json.Capacity) < members {
+		respondError(c, "capacity_below_members", members)
+		return
+	}
+
+	db.Model(&settings).Update("capacity", json.Capacity)
+
+	c.JSON(http.StatusOK, gin.H{"game_id": json.GameID, "capacity": json.Capacity})
+}
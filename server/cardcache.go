@@ -0,0 +1,107 @@
+package main
+
+import (
+	"container/list"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCardCacheBytes = 64 << 20
+	// cardCacheMaxEntry keeps any one image from taking much of the cache;
+	// bigger ones are streamed from disk each time.
+	cardCacheMaxEntry = 4 << 20
+)
+
+var cardCacheLookups = metrics.newCounter("card_cache_lookups_total", "Card image reads by whether the cache had them.", "result")
+
+// cardImageCache keeps recently dealt card images in memory, least recently
+// used first out, so busy games don't read the same files over and over.
+// Reads of the files themselves happen outside its lock, so concurrent games
+// never wait on each other's disk access.
+type cardImageCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type cardCacheEntry struct {
+	path    string
+	modTime time.Time
+	data    []byte
+}
+
+var cardCache = &cardImageCache{
+	maxBytes: defaultCardCacheBytes,
+	entries:  make(map[string]*list.Element),
+	order:    list.New(),
+}
+
+// loadCardCache sizes the card image cache from CARD_CACHE_BYTES; 0 turns
+// it off.
+func loadCardCache() {
+	if n, err := strconv.ParseInt(os.Getenv("CARD_CACHE_BYTES"), 10, 64); err == nil && n >= 0 {
+		cardCache.maxBytes = n
+	}
+}
+
+// get returns the cached bytes of the file at path if they're still what
+// info, the file's current state, says it holds.
+func (c *cardImageCache) get(path string, info os.FileInfo) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[path]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cardCacheEntry)
+	if !entry.modTime.Equal(info.ModTime()) || int64(len(entry.data)) != info.Size() {
+		c.removeLocked(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.data, true
+}
+
+func (c *cardImageCache) put(path string, info os.FileInfo, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[path]; ok {
+		c.removeLocked(elem)
+	}
+	c.entries[path] = c.order.PushFront(&cardCacheEntry{path: path, modTime: info.ModTime(), data: data})
+	c.size += int64(len(data))
+	for c.size > c.maxBytes {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+func (c *cardImageCache) removeLocked(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cardCacheEntry)
+	delete(c.entries, entry.path)
+	c.size -= int64(len(entry.data))
+}
+
+// read returns the image at path, from the cache or from disk, in which case
+// it is cached. Images too big to cache come back nil with a nil error, for
+// the caller to stream from the file.
+func (c *cardImageCache) read(path string, info os.FileInfo) ([]byte, error) {
+	if data, ok := c.get(path, info); ok {
+		cardCacheLookups.Inc("hit")
+		return data, nil
+	}
+	cardCacheLookups.Inc("miss")
+	if info.Size() > cardCacheMaxEntry || info.Size() > c.maxBytes {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c.put(path, info, data)
+	return data, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const imageKindChat = "chat"
+
+// ChatImage is an image a room member uploaded to attach to chat messages.
+// Chat carries only its ID; the WebSocket server swaps that for a signed URL
+// before relaying, so image bytes never go over the socket.
+type ChatImage struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameID    string `gorm:"not null;index"`
+	SessionID string `gorm:"not null"`
+	Data      []byte
+	CreatedAt time.Time
+}
+
+func uploadChatImage(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "chat_image_upload")
+
+	sessionID := c.PostForm("session_id")
+	gameID := c.PostForm("game_id")
+	if sessionID == "" || gameID == "" {
+		respondError(c, "game_and_session_required")
+		return
+	}
+
+	var member Room
+	if err := db.Where("game_id = ? AND session_id = ?", gameID, sessionID).First(&member).Error; err != nil {
+		respondError(c, "not_room_member")
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		respondError(c, "file_missing")
+		return
+	}
+	if !allowUpload(c, sessionID, 1) {
+		return
+	}
+	data, uerr := readUpload(file)
+	if uerr != nil {
+		uerr.respond(c)
+		return
+	}
+
+	chatImage := ChatImage{GameID: gameID, SessionID: sessionID, Data: data}
+	if err := db.Create(&chatImage).Error; err != nil {
+		respondError(c, "file_save_failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"image_id":  chatImage.ID,
+		"image_url": signedImageURL(imageKindChat, chatImage.ID),
+	})
+}
+
+// chatImageURL checks that an attachment was uploaded by the sender into the
+// same game and hands back a fresh signed URL for it. The WebSocket server
+// calls it before relaying a chat message that references an image.
+func chatImageURL(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "chat_image_url")
+
+	var chatImage ChatImage
+	if err := db.Select("id", "game_id", "session_id").First(&chatImage, c.Param("id")).Error; err != nil {
+		respondError(c, "image_not_found")
+		return
+	}
+	if chatImage.GameID != c.Query("game_id") || chatImage.SessionID != c.Query("session_id") {
+		respondError(c, "image_not_found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"image_id": chatImage.ID, "image_url": signedImageURL(imageKindChat, chatImage.ID)})
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The daily challenge is a situation everyone answers on their own time,
+// outside any room. Each UTC day gets one situation per language. A player
+// is dealt a hand for it, submits one card, and votes for one card someone
+// else submitted. Submissions are anonymous and unscored until the day is
+// over, when the results are published with who played what.
+
+// DailyChallenge is the situation chosen for a language on a day.
+type DailyChallenge struct {
+	ID          uint   `gorm:"primaryKey"`
+	Day         string `gorm:"not null;uniqueIndex:idx_daily_challenge"`
+	Language    string `gorm:"not null;uniqueIndex:idx_daily_challenge"`
+	SituationID uint   `gorm:"not null"`
+	CreatedAt   time.Time
+}
+
+// DailyHandCard is a card dealt to a player for a daily challenge. Players
+// are known by login here, as in the game history, so a hand survives the
+// player exiting and coming back.
+type DailyHandCard struct {
+	ID          uint   `gorm:"primaryKey"`
+	ChallengeID uint   `gorm:"not null;index:idx_daily_hand"`
+	Login       string `gorm:"not null;index:idx_daily_hand"`
+	CardID      uint   `gorm:"not null"`
+}
+
+// DailySubmission is the card a player chose for a daily challenge.
+type DailySubmission struct {
+	ID          uint   `gorm:"primaryKey"`
+	ChallengeID uint   `gorm:"not null;uniqueIndex:idx_daily_submission"`
+	Login       string `gorm:"not null;uniqueIndex:idx_daily_submission"`
+	CardID      uint   `gorm:"not null"`
+	CreatedAt   time.Time
+}
+
+// DailyVote is a player's vote for another player's submission.
+type DailyVote struct {
+	ID           uint   `gorm:"primaryKey"`
+	ChallengeID  uint   `gorm:"not null;uniqueIndex:idx_daily_vote"`
+	VoterLogin   string `gorm:"not null;uniqueIndex:idx_daily_vote"`
+	SubmissionID uint   `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+// todaysChallenge returns the day's challenge for language, choosing it on
+// the first request of the day. Situations that were a recent daily are
+// skipped until the language has run through all of them.
+func todaysChallenge(db *gorm.DB, language string) (DailyChallenge, error) {
+	day := activityDay(time.Now())
+	var challenge DailyChallenge
+	err := db.Where("day = ? AND language = ?", day, language).First(&challenge).Error
+	if err != gorm.ErrRecordNotFound {
+		return challenge, err
+	}
+
+	pool := db.Model(&Situation{}).Where("language = ?", language).Session(&gorm.Session{})
+	var count int64
+	if err := pool.Count(&count).Error; err != nil {
+		return challenge, err
+	}
+	var recent []uint
+	err = db.Model(&DailyChallenge{}).
+		Where("language = ?", language).
+		Order("day DESC").
+		Limit(int(count)-1).
+		Pluck("situation_id", &recent).Error
+	if err != nil {
+		return challenge, err
+	}
+
+	var situation Situation
+	if err := pickRandom(excludeIDs(pool, recent), &situation); err != nil {
+		return challenge, err
+	}
+
+	// Two instances choosing at once both insert; the first one wins and
+	// the other reads it back.
+	db.Clauses(clause.OnConflict{DoNothing: true}).Create(&DailyChallenge{
+		Day:         day,
+		Language:    language,
+		SituationID: situation.ID,
+	})
+	err = db.Where("day = ? AND language = ?", day, language).First(&challenge).Error
+	return challenge, err
+}
+
+// dailyHand returns the cards login was dealt for challenge, dealing them
+// on the first call.
+func dailyHand(db *gorm.DB, challenge DailyChallenge, login string) ([]Card, error) {
+	unlock := lockGame("daily:" + login)
+	defer unlock()
+
+	var cardIDs []uint
+	err := db.Model(&DailyHandCard{}).
+		Where("challenge_id = ? AND login = ?", challenge.ID, login).
+		Order("id").
+		Pluck("card_id", &cardIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []Card
+	if len(cardIDs) > 0 {
+		err := db.Where("id IN ?", cardIDs).Order("id").Find(&cards).Error
+		return cards, err
+	}
+
+	cards, err = pickRandomN[Card](db.Model(&Card{}), handSize)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].ID < cards[j].ID })
+	for _, card := range cards {
+		if err := db.Create(&DailyHandCard{ChallengeID: challenge.ID, Login: login, CardID: card.ID}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return cards, nil
+}
+
+// daily returns today's challenge. An authenticated player also gets their
+// hand and what they have submitted and voted for so far.
+func daily(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "daily")
+
+	language := c.DefaultQuery("lang", "en")
+	challenge, err := todaysChallenge(db, language)
+	if err == gorm.ErrRecordNotFound {
+		respondError(c, "no_situations")
+		return
+	}
+	if err != nil {
+		respondError(c, "daily_failed")
+		return
+	}
+
+	var situation Situation
+	if err := db.First(&situation, challenge.SituationID).Error; err != nil {
+		respondError(c, "daily_failed")
+		return
+	}
+	var submissions int64
+	db.Model(&DailySubmission{}).Where("challenge_id = ?", challenge.ID).Count(&submissions)
+
+	day, _ := time.Parse(activityDayLayout, challenge.Day)
+	result := gin.H{
+		"challenge_id": challenge.ID,
+		"day":          challenge.Day,
+		"language":     challenge.Language,
+		"text":         situation.Text,
+		"submissions":  submissions,
+		"closes_at":    day.AddDate(0, 0, 1),
+	}
+
+	user, ok := optionalSessionUserFrom(c)
+	if !ok {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	hand, err := dailyHand(db, challenge, user.Login)
+	if err == errNotEnoughRows {
+		respondError(c, "no_card_images")
+		return
+	}
+	if err != nil {
+		respondError(c, "daily_failed")
+		return
+	}
+	stream := newImageStream()
+	cards := make([]gin.H, 0, len(hand))
+	for _, card := range hand {
+		cardImg, err := cardImage(stream, card)
+		if os.IsNotExist(err) {
+			respondError(c, "file_not_found")
+			return
+		}
+		if err != nil {
+			respondError(c, "image_read_failed")
+			return
+		}
+		cards = append(cards, gin.H{"card_id": card.ID, "card_img": cardImg})
+	}
+	result["hand"] = cards
+
+	var submission DailySubmission
+	if err := db.Where("challenge_id = ? AND login = ?", challenge.ID, user.Login).First(&submission).Error; err == nil {
+		result["submitted_card_id"] = submission.CardID
+	}
+	var vote DailyVote
+	if err := db.Where("challenge_id = ? AND voter_login = ?", challenge.ID, user.Login).First(&vote).Error; err == nil {
+		result["voted_submission_id"] = vote.SubmissionID
+	}
+	stream.respond(c, http.StatusOK, result)
+}
+
+// openChallenge loads a challenge that still takes submissions and votes,
+// responding with an error if there isn't one.
+func openChallenge(db *gorm.DB, c *gin.Context, id uint) (DailyChallenge, bool) {
+	var challenge DailyChallenge
+	if err := db.First(&challenge, id).Error; err != nil {
+		respondError(c, "daily_not_found")
+		return challenge, false
+	}
+	if challenge.Day != activityDay(time.Now()) {
+		respondError(c, "daily_closed")
+		return challenge, false
+	}
+	return challenge, true
+}
+
+// submitDaily records the card the player picked from their daily hand.
+func submitDaily(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "daily_submit")
+	user := sessionUserFrom(c)
+
+	var json struct {
+		ChallengeID uint `json:"challenge_id"`
+		CardID      uint `json:"card_id"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.ChallengeID == 0 || json.CardID == 0 {
+		respondError(c, "daily_fields_required")
+		return
+	}
+	challenge, ok := openChallenge(db, c, json.ChallengeID)
+	if !ok {
+		return
+	}
+
+	var dealt int64
+	db.Model(&DailyHandCard{}).
+		Where("challenge_id = ? AND login = ? AND card_id = ?", challenge.ID, user.Login, json.CardID).
+		Count(&dealt)
+	if dealt == 0 {
+		respondError(c, "card_not_dealt")
+		return
+	}
+
+	submission := DailySubmission{ChallengeID: challenge.ID, Login: user.Login, CardID: json.CardID}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&submission)
+	if result.Error != nil {
+		respondError(c, "daily_failed")
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondError(c, "already_submitted")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"challenge_id": challenge.ID, "submission_id": submission.ID, "card_id": submission.CardID})
+}
+
+// voteDaily records the player's vote for another player's submission.
+func voteDaily(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "daily_vote")
+	user := sessionUserFrom(c)
+
+	var json struct {
+		SubmissionID uint `json:"submission_id"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.SubmissionID == 0 {
+		respondError(c, "daily_fields_required")
+		return
+	}
+
+	var submission DailySubmission
+	if err := db.First(&submission, json.SubmissionID).Error; err != nil {
+		respondError(c, "submission_not_found")
+		return
+	}
+	challenge, ok := openChallenge(db, c, submission.ChallengeID)
+	if !ok {
+		return
+	}
+	if submission.Login == user.Login {
+		respondError(c, "own_submission")
+		return
+	}
+
+	vote := DailyVote{ChallengeID: challenge.ID, VoterLogin: user.Login, SubmissionID: submission.ID}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&vote)
+	if result.Error != nil {
+		respondError(c, "daily_failed")
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondError(c, "already_voted")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"challenge_id": challenge.ID, "submission_id": submission.ID})
+}
+
+// dailySubmissions lists a challenge's submissions. While the challenge is
+// open they come in a random order without logins or votes, for players to
+// vote on; once it has closed they come ranked by votes, with who submitted
+// each.
+func dailySubmissions(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "daily_submissions")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, "daily_not_found")
+		return
+	}
+	var challenge DailyChallenge
+	if err := db.First(&challenge, id).Error; err != nil {
+		respondError(c, "daily_not_found")
+		return
+	}
+	open := challenge.Day == activityDay(time.Now())
+
+	var submissions []DailySubmission
+	if err := db.Where("challenge_id = ?", challenge.ID).Order("id").Find(&submissions).Error; err != nil {
+		respondError(c, "daily_failed")
+		return
+	}
+	var tallies []struct {
+		SubmissionID uint
+		Votes        int
+	}
+	if !open {
+		err := db.Model(&DailyVote{}).
+			Select("submission_id, COUNT(*) AS votes").
+			Where("challenge_id = ? AND voter_login NOT IN (?)", challenge.ID, db.Model(&ShadowBan{}).Select("login")).
+			Group("submission_id").
+			Scan(&tallies).Error
+		if err != nil {
+			respondError(c, "daily_failed")
+			return
+		}
+	}
+	votes := make(map[uint]int, len(tallies))
+	for _, t := range tallies {
+		votes[t.SubmissionID] = t.Votes
+	}
+	if open {
+		rand.Shuffle(len(submissions), func(i, j int) { submissions[i], submissions[j] = submissions[j], submissions[i] })
+	} else {
+		sort.SliceStable(submissions, func(i, j int) bool {
+			return votes[submissions[i].ID] > votes[submissions[j].ID]
+		})
+	}
+
+	stream := newImageStream()
+	entries := make([]gin.H, 0, len(submissions))
+	for _, submission := range submissions {
+		var card Card
+		if err := db.First(&card, submission.CardID).Error; err != nil {
+			continue
+		}
+		cardImg, err := cardImage(stream, card)
+		if err != nil {
+			continue
+		}
+		entry := gin.H{
+			"submission_id": submission.ID,
+			"card_id":       card.ID,
+			"card_img":      cardImg,
+		}
+		if !open {
+			entry["login"] = submission.Login
+			entry["votes"] = votes[submission.ID]
+		}
+		entries = append(entries, entry)
+	}
+
+	stream.respond(c, http.StatusOK, gin.H{
+		"challenge_id": challenge.ID,
+		"day":          challenge.Day,
+		"open":         open,
+		"submissions":  entries,
+	})
+}
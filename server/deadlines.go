@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Each request has HandlerTimeout to finish its database and file work, or
+// TransferTimeout on the routes that move images in or out, so a request
+// stuck behind an SQLite lock or a slow disk gives up instead of keeping its
+// goroutine for good.
+const (
+	defaultHandlerTimeout  = 10 * time.Second
+	defaultTransferTimeout = 2 * time.Minute
+)
+
+// transferRoutes are the routes that get TransferTimeout, keyed by the
+// path they were registered with.
+var transferRoutes = map[string]bool{
+	"/register":                   true,
+	"/user-info":                  true,
+	"/me/avatar":                  true,
+	"/cards":                      true,
+	"/hand":                       true,
+	"/createCustomDeck":           true,
+	"/createCustomSituationDeck":  true,
+	"/decks/uploads/:id/cards/:n": true,
+	"/decks/uploads/:id/finalize": true,
+	"/uploads":                    true,
+	"/uploads/:id":                true,
+	"/images/:kind/:id":           true,
+	"/images/:kind/:id/:size":     true,
+	"/chat/images":                true,
+}
+
+var handlerTimeouts = metrics.newCounter("http_handler_timeouts_total", "Requests that ran out of time before they finished.", "route")
+
+func loadHandlerTimeouts() {
+	if d, err := time.ParseDuration(os.Getenv("HANDLER_TIMEOUT")); err == nil && d > 0 {
+		config.HandlerTimeout = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("TRANSFER_TIMEOUT")); err == nil && d > 0 {
+		config.TransferTimeout = d
+	}
+}
+
+// requestDeadline gives each request's context its route's timeout.
+func requestDeadline() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := config.HandlerTimeout
+		if transferRoutes[c.FullPath()] {
+			timeout = config.TransferTimeout
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if ctx.Err() == context.DeadlineExceeded {
+			warnf("%s %s ran out of time after %v", c.Request.Method, c.FullPath(), timeout)
+			handlerTimeouts.Inc(c.FullPath())
+		}
+	}
+}
+
+// requestTimedOut reports whether the request has run out of time.
+func requestTimedOut(c *gin.Context) bool {
+	return c.Request.Context().Err() == context.DeadlineExceeded
+}
+
+// withRequest is withOperation for a handler: the queries also stop when
+// the request is cancelled or runs out of time.
+func withRequest(db *gorm.DB, c *gin.Context, operation string) *gorm.DB {
+	return withOperation(db.WithContext(c.Request.Context()), operation)
+}
+
+// contextReader stops a copy from r once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// readFileContext is os.ReadFile that gives up when ctx is done.
+func readFileContext(ctx context.Context, path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(contextReader{ctx, file})
+}
+
+// writeFileContext is os.WriteFile that gives up when ctx is done, removing
+// what it had written.
+func writeFileContext(ctx context.Context, path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, contextReader{ctx, bytes.NewReader(data)})
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// deckUploadTTL is how long an unfinished chunked deck upload is kept.
+const deckUploadTTL = 24 * time.Hour
+
+// DeckUpload is a custom deck being uploaded one card at a time. Cards can
+// be sent in any order and resent until the upload is finalized into a
+// deck.
+type DeckUpload struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID string `gorm:"not null;index"`
+	Creator   string `gorm:"not null"`
+	GameID    string `gorm:"not null"`
+	CardCount int    `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+type DeckUploadCard struct {
+	ID       uint   `gorm:"primaryKey"`
+	UploadID uint   `gorm:"not null;uniqueIndex:idx_upload_position"`
+	Position int    `gorm:"not null;uniqueIndex:idx_upload_position"`
+	CardImg  []byte `gorm:"not null"`
+}
+
+// findDeckUpload loads an upload for its owner, answering 404 otherwise.
+func findDeckUpload(db *gorm.DB, c *gin.Context, sessionID string) (DeckUpload, bool) {
+	var upload DeckUpload
+	if err := db.First(&upload, c.Param("id")).Error; err != nil || upload.SessionID != sessionID {
+		respondError(c, "deck_upload_not_found")
+		return upload, false
+	}
+	return upload, true
+}
+
+// startDeckUpload opens a chunked upload for a deck of cardCount cards.
+func startDeckUpload(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "deck_upload_start")
+
+	var request struct {
+		GameId    string `json:"gameId"`
+		SessionID string `json:"sessionId"`
+		CardCount int    `json:"cardCount"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+	if request.CardCount > config.MaxDeckCards {
+		uerr := &uploadError{Code: uploadErrDeckSize, Args: []interface{}{config.MaxDeckCards}}
+		uerr.respond(c)
+		return
+	}
+	if request.CardCount < 1 {
+		respondError(c, "invalid_card_count")
+		return
+	}
+	user, ok := sessionUser(db, c, request.SessionID)
+	if !ok {
+		return
+	}
+
+	upload := DeckUpload{
+		SessionID: request.SessionID,
+		Creator:   user.Login,
+		GameID:    request.GameId,
+		CardCount: request.CardCount,
+	}
+	if err := db.Create(&upload).Error; err != nil {
+		respondError(c, "deck_create_failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"uploadId": upload.ID, "cardCount": upload.CardCount})
+}
+
+// uploadDeckCard stores card n (1-based) of a chunked upload as a multipart
+// image, replacing any earlier attempt at the same card.
+func uploadDeckCard(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "deck_upload_card")
+
+	sessionID := c.PostForm("session_id")
+	upload, ok := findDeckUpload(db, c, sessionID)
+	if !ok {
+		return
+	}
+	position, err := strconv.Atoi(c.Param("n"))
+	if err != nil || position < 1 || position > upload.CardCount {
+		respondError(c, "invalid_card_position", upload.CardCount)
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		respondError(c, "file_missing")
+		return
+	}
+	if !allowUpload(c, sessionID, 1) {
+		return
+	}
+	data, uerr := readUpload(file)
+	if uerr != nil {
+		uerr.Card = position
+		uerr.respond(c)
+		return
+	}
+
+	if !storeDeckCard(db, c, upload, position, data) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"uploadId": upload.ID, "card": position})
+}
+
+// storeDeckCard normalizes a validated card image and saves it at its
+// position in a chunked upload, replacing any earlier attempt. It responds
+// and returns false when the card would go over the creator's quota or
+// can't be saved.
+func storeDeckCard(db *gorm.DB, c *gin.Context, upload DeckUpload, position int, data []byte) bool {
+	data = normalizeCardImage(data)
+
+	var pending int64
+	db.Model(&DeckUploadCard{}).
+		Select("COALESCE(SUM(LENGTH(card_img)), 0)").
+		Where("upload_id = ? AND position <> ?", upload.ID, position).
+		Scan(&pending)
+	if !checkDeckQuota(db, c, upload.Creator, pending+int64(len(data))) {
+		return false
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "upload_id"}, {Name: "position"}},
+		DoUpdates: clause.AssignmentColumns([]string{"card_img"}),
+	}).Create(&DeckUploadCard{UploadID: upload.ID, Position: position, CardImg: data}).Error; err != nil {
+		respondError(c, "file_save_failed")
+		return false
+	}
+	return true
+}
+
+// finalizeDeckUpload turns a complete chunked upload into a deck. While
+// cards are missing it answers 409 with their positions so the client knows
+// what to resend.
+func finalizeDeckUpload(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "deck_upload_finalize")
+
+	var request struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+	upload, ok := findDeckUpload(db, c, request.SessionID)
+	if !ok {
+		return
+	}
+
+	var cards []DeckUploadCard
+	if err := db.Where("upload_id = ?", upload.ID).Order("position").Find(&cards).Error; err != nil {
+		respondError(c, "deck_create_failed")
+		return
+	}
+	received := map[int]bool{}
+	cardImgs := make([][]byte, 0, len(cards))
+	for _, card := range cards {
+		received[card.Position] = true
+		cardImgs = append(cardImgs, card.CardImg)
+	}
+	var missing []int
+	for position := 1; position <= upload.CardCount; position++ {
+		if !received[position] {
+			missing = append(missing, position)
+		}
+	}
+	if len(missing) > 0 {
+		body := errorBody(c, "deck_upload_incomplete", len(missing))
+		body["missing"] = missing
+		c.JSON(errorStatus("deck_upload_incomplete"), body)
+		return
+	}
+	if !checkDeckQuota(db, c, upload.Creator, deckBytes(cardImgs)) {
+		return
+	}
+
+	deckID, code := storeCustomDeck(db, upload.GameID, upload.Creator, cardImgs)
+	if code != "" {
+		respondError(c, code)
+		return
+	}
+	deleteDeckUploads(db, []uint{upload.ID})
+
+	c.JSON(http.StatusOK, gin.H{"deckId": deckID})
+}
+
+func deleteDeckUploads(db *gorm.DB, ids []uint) {
+	db.Where("upload_id IN ?", ids).Delete(&DeckUploadCard{})
+	db.Where("id IN ?", ids).Delete(&DeckUpload{})
+}
+
+// sweepDeckUploads drops chunked uploads that were never finalized.
+func sweepDeckUploads(db *gorm.DB) {
+	var ids []uint
+	db.Model(&DeckUpload{}).Where("created_at < ?", time.Now().Add(-deckUploadTTL)).Pluck("id", &ids)
+	if len(ids) > 0 {
+		deleteDeckUploads(db, ids)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// idleRoomTTL is how long a game with nobody on the WebSocket server and no
+// logged activity is kept before the sweep closes it.
+const idleRoomTTL = 2 * time.Hour
+
+// closeEmptyGame closes a game the WebSocket server reports has nobody left
+// in it, so its rooms don't linger when a player's disconnect never reached
+// this service. Only the WebSocket server, holding the service token,
+// may say so.
+func closeEmptyGame(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "ws_game_empty")
+
+	gameID := c.Param("game_id")
+	unlock := lockGame(gameID)
+	defer unlock()
+
+	var rooms, settings int64
+	db.Model(&Room{}).Where("game_id = ?", gameID).Count(&rooms)
+	db.Model(&RoomSettings{}).Where("game_id = ?", gameID).Count(&settings)
+	closed := rooms > 0 || settings > 0
+	if closed {
+		infof("Closing game_id %s left empty with %d rooms", gameID, rooms)
+		closeGame(db, gameID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"game_id": gameID, "closed": closed})
+}
+
+// sweepIdleRooms closes games that the empty notice missed: nobody is in
+// them on the WebSocket server as far as this service knows, and nothing
+// has happened in them for idleRoomTTL.
+func sweepIdleRooms(db *gorm.DB) {
+	cutoff := time.Now().Add(-idleRoomTTL)
+	var gameIDs []string
+	db.Model(&RoomSettings{}).
+		Where("created_at < ?", cutoff).
+		Where("game_id NOT IN (?)", db.Model(&WSMember{}).Select("game_id")).
+		Where("game_id NOT IN (?)", db.Model(&GameEvent{}).Select("game_id").Where("occurred_at >= ?", cutoff)).
+		Pluck("game_id", &gameIDs)
+	for _, gameID := range gameIDs {
+		unlock := lockGame(gameID)
+		infof("Closing idle game_id %s", gameID)
+		closeGame(db, gameID)
+		unlock()
+	}
+}
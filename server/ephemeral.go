@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gorm.io/gorm"
+)
+
+// Database modes. The default keeps the database in DatabaseURI, or in the
+// one DATABASE_URL names, which may be a postgres:// URL. The
+// ephemeral ones are for integration tests and load tests that want the
+// whole REST and WebSocket stack without touching the real database:
+// memory keeps it in memory for as long as the process runs, temp in a
+// throwaway file. Both send uploads to a temporary folder too.
+const (
+	databaseModeFile   = "file"
+	databaseModeMemory = "memory"
+	databaseModeTemp   = "temp"
+)
+
+// seedHooks fill a new database once its tables exist, in order. Each must
+// be safe to run against a database that already has its rows.
+var seedHooks = []func(db *gorm.DB) error{
+	func(db *gorm.DB) error { populateSituations(db); return nil },
+	func(db *gorm.DB) error { testCards(db); return nil },
+	seedFromFile,
+}
+
+// addSeedHook runs seed after the built-in seeding, for tests that need
+// their own fixtures.
+func addSeedHook(seed func(db *gorm.DB) error) {
+	seedHooks = append(seedHooks, seed)
+}
+
+// loadDatabaseMode sets up the database DATABASE_MODE asks for.
+func loadDatabaseMode() error {
+	mode := os.Getenv("DATABASE_MODE")
+	switch mode {
+	case "", databaseModeFile:
+		config.DatabaseMode = databaseModeFile
+		if url := os.Getenv("DATABASE_URL"); url != "" {
+			config.DatabaseURI = url
+		}
+		return nil
+	case databaseModeMemory:
+		// The name and shared cache let every pooled connection see the
+		// same database.
+		config.DatabaseURI = "file:meme-battle?mode=memory&cache=shared"
+	case databaseModeTemp:
+		dir, err := os.MkdirTemp("", "meme-battle-db-")
+		if err != nil {
+			return err
+		}
+		config.DatabaseURI = filepath.Join(dir, "users.db")
+	default:
+		return fmt.Errorf("unknown DATABASE_MODE %q", mode)
+	}
+	config.DatabaseMode = mode
+
+	uploads, err := os.MkdirTemp("", "meme-battle-uploads-")
+	if err != nil {
+		return err
+	}
+	config.UploadFolder = uploads
+	infof("Using a %s database at %s, uploads in %s", mode, config.DatabaseURI, uploads)
+	return nil
+}
+
+// prepareDatabase tunes the connection pool for the database mode. An
+// in-memory database is gone once its last connection closes, and shares
+// table locks between connections, so it gets a single connection that is
+// never closed.
+func prepareDatabase(db *gorm.DB) error {
+	if config.DatabaseMode != databaseModeMemory {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	sqlDB.SetConnMaxIdleTime(0)
+	return nil
+}
+
+// seedDatabase runs the seed hooks.
+func seedDatabase(db *gorm.DB) error {
+	for _, seed := range seedHooks {
+		if err := seed(db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedFromFile runs the SQL in DATABASE_SEED, if set.
+func seedFromFile(db *gorm.DB) error {
+	path := os.Getenv("DATABASE_SEED")
+	if path == "" {
+		return nil
+	}
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return db.Exec(string(script)).Error
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GameEvent is one entry of a game's event log as reported by the WebSocket
+// server. Data is the proto message the event carried, kept so games can be
+// replayed. Events outlive the game for moderation and replays. Chat events
+// carry the chat message ID so later edits and deletes can rewrite them.
+type GameEvent struct {
+	ID         uint   `gorm:"primaryKey"`
+	GameID     string `gorm:"not null;index:idx_game_events"`
+	Type       string `gorm:"not null"`
+	SessionID  string
+	Login      string
+	ClassID    int32
+	MessageID  uint64 `gorm:"index"`
+	Data       []byte
+	OccurredAt time.Time `gorm:"not null;index:idx_game_events"`
+}
+
+func eventInfo(event GameEvent) gin.H {
+	return gin.H{
+		"id":          event.ID,
+		"game_id":     event.GameID,
+		"type":        event.Type,
+		"session_id":  event.SessionID,
+		"login":       event.Login,
+		"class_id":    event.ClassID,
+		"message_id":  event.MessageID,
+		"data":        event.Data,
+		"occurred_at": event.OccurredAt,
+	}
+}
+
+// storeEvents appends a batch of game events from the WebSocket server to
+// the log replays are built from. It takes the service token, so nobody else
+// can write into a game's history.
+func storeEvents(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "events")
+
+	var json []struct {
+		GameID     string    `json:"game_id"`
+		Type       string    `json:"type"`
+		SessionID  string    `json:"session_id"`
+		Login      string    `json:"login"`
+		ClassID    int32     `json:"class_id"`
+		MessageID  uint64    `json:"message_id"`
+		Data       []byte    `json:"data"`
+		OccurredAt time.Time `json:"occurred_at"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+
+	// Chat edits and deletes rewrite the stored message instead of being
+	// logged themselves, so history always reads as the chat now stands.
+	// They're applied after the batch is stored since the message they
+	// touch may be in it.
+	events := make([]GameEvent, 0, len(json))
+	var rewrites []GameEvent
+	for _, e := range json {
+		if e.GameID == "" || e.Type == "" {
+			continue
+		}
+		if e.Type == "chat_edit" || e.Type == "chat_delete" {
+			if e.MessageID != 0 {
+				rewrites = append(rewrites, GameEvent{GameID: e.GameID, Type: e.Type, MessageID: e.MessageID, Data: e.Data})
+			}
+			continue
+		}
+		if e.OccurredAt.IsZero() {
+			e.OccurredAt = time.Now()
+		}
+		events = append(events, GameEvent{
+			GameID:     e.GameID,
+			Type:       e.Type,
+			SessionID:  e.SessionID,
+			Login:      e.Login,
+			ClassID:    e.ClassID,
+			MessageID:  e.MessageID,
+			Data:       e.Data,
+			OccurredAt: e.OccurredAt,
+		})
+	}
+	if len(events) > 0 {
+		if err := db.Create(&events).Error; err != nil {
+			respondError(c, "events_store_failed")
+			return
+		}
+	}
+	// Joining a game over WebSocket counts as activity like an
+	// authenticated request does.
+	for _, event := range events {
+		if event.Type == "join" {
+			markActive(db, event.SessionID)
+		}
+	}
+	// Every round logs a start; only a game's first one starts the room.
+	for _, event := range events {
+		if event.Type != "start" {
+			continue
+		}
+		var earlier int64
+		db.Model(&GameEvent{}).Where("game_id = ? AND type = ? AND id < ?", event.GameID, "start", event.ID).Count(&earlier)
+		if earlier == 0 {
+			publishLobbyEvent(db, lobbyRoomStarted, event.GameID)
+		}
+	}
+	for _, rewrite := range rewrites {
+		original := db.Model(&GameEvent{}).Where("game_id = ? AND type = ? AND message_id = ?", rewrite.GameID, "chat", rewrite.MessageID)
+		var err error
+		if rewrite.Type == "chat_edit" {
+			err = original.Update("data", rewrite.Data).Error
+		} else {
+			err = original.Delete(&GameEvent{}).Error
+		}
+		if err != nil {
+			respondError(c, "events_store_failed")
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"stored": len(events)})
+}
+
+func listGameEvents(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_events")
+
+	query := db.Where("game_id = ?", c.Param("game_id"))
+	if eventType := c.Query("type"); eventType != "" {
+		query = query.Where("type = ?", eventType)
+	}
+
+	var events []GameEvent
+	if err := query.Order("occurred_at, id").Find(&events).Error; err != nil {
+		respondError(c, "events_failed")
+		return
+	}
+
+	result := make([]gin.H, 0, len(events))
+	for _, event := range events {
+		result = append(result, eventInfo(event))
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxFavorites caps how many cards one player can keep as favorites.
+const maxFavorites = 200
+
+// CardFavorite is a card a player has favorited. Players are known by
+// login, as in the game history, so favorites outlive the player exiting.
+type CardFavorite struct {
+	ID        uint   `gorm:"primaryKey"`
+	Login     string `gorm:"not null;uniqueIndex:idx_card_favorite"`
+	CardID    uint   `gorm:"not null;uniqueIndex:idx_card_favorite;index"`
+	CreatedAt time.Time
+}
+
+// cardSeen reports whether user has come across a card: dealt it in a game
+// or a daily challenge, or seen it played in a game they were in.
+func cardSeen(db *gorm.DB, user User, cardID uint) bool {
+	var count int64
+	db.Model(&HandCard{}).
+		Where("card_id = ? AND session_id = ?", cardID, user.SessionID).
+		Count(&count)
+	if count > 0 {
+		return true
+	}
+
+	games := db.Model(&GamePlayer{}).Select("game_id").Where("session_id = ?", user.SessionID)
+	db.Model(&HandCard{}).
+		Where("card_id = ? AND played = ? AND game_id IN (?)", cardID, true, games).
+		Count(&count)
+	if count > 0 {
+		return true
+	}
+
+	db.Model(&DailyHandCard{}).Where("card_id = ? AND login = ?", cardID, user.Login).Count(&count)
+	return count > 0
+}
+
+// roomFavorites selects the IDs of the cards favorited by anyone in a game.
+func roomFavorites(db *gorm.DB, gameID string) *gorm.DB {
+	sessions := db.Model(&Room{}).Select("session_id").Where("game_id = ?", gameID)
+	logins := db.Model(&User{}).Select("login").Where("session_id IN (?)", sessions)
+	return db.Model(&CardFavorite{}).Select("card_id").Where("login IN (?)", logins)
+}
+
+// listFavorites returns the player's favorite cards, newest first.
+func listFavorites(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "favorites")
+	user := sessionUserFrom(c)
+
+	var favorites []CardFavorite
+	if err := db.Where("login = ?", user.Login).Order("id DESC").Find(&favorites).Error; err != nil {
+		respondError(c, "favorites_failed")
+		return
+	}
+
+	stream := newImageStream()
+	cards := make([]gin.H, 0, len(favorites))
+	for _, favorite := range favorites {
+		var card Card
+		if err := db.First(&card, favorite.CardID).Error; err != nil {
+			continue
+		}
+		cardImg, err := cardImage(stream, card)
+		if err != nil {
+			continue
+		}
+		cards = append(cards, gin.H{
+			"card_id":      card.ID,
+			"card_img":     cardImg,
+			"favorited_at": favorite.CreatedAt,
+		})
+	}
+
+	stream.respond(c, http.StatusOK, gin.H{"cards": cards})
+}
+
+// addFavorite favorites a card the player has seen. Favoriting a card twice
+// is harmless.
+func addFavorite(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "favorite_add")
+	user := sessionUserFrom(c)
+
+	cardID, err := strconv.ParseUint(c.Param("card_id"), 10, 64)
+	if err != nil {
+		respondError(c, "card_not_found")
+		return
+	}
+	var card Card
+	if err := db.First(&card, cardID).Error; err != nil {
+		respondError(c, "card_not_found")
+		return
+	}
+	if !cardSeen(db, user, card.ID) {
+		respondError(c, "card_not_seen")
+		return
+	}
+
+	var count int64
+	db.Model(&CardFavorite{}).Where("login = ? AND card_id <> ?", user.Login, card.ID).Count(&count)
+	if count >= maxFavorites {
+		respondError(c, "favorites_full", maxFavorites)
+		return
+	}
+
+	err = db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&CardFavorite{Login: user.Login, CardID: card.ID}).Error
+	if err != nil {
+		respondError(c, "favorites_failed")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func removeFavorite(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "favorite_remove")
+	user := sessionUserFrom(c)
+
+	if err := db.Where("login = ? AND card_id = ?", user.Login, c.Param("card_id")).Delete(&CardFavorite{}).Error; err != nil {
+		respondError(c, "favorites_failed")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// flagReloadInterval is how often each instance rereads flags from the
+// database, which bounds how long a change made through another instance
+// takes to apply here.
+const flagReloadInterval = 30 * time.Second
+
+// Feature flags known to the server.
+const (
+	flagCustomDecks    = "custom_decks"
+	flagDailyChallenge = "daily_challenge"
+	flagWSMsgpack      = "ws_msgpack"
+)
+
+// featureFlag says whether a feature is on and, while it is being rolled
+// out, for what percentage of callers.
+type featureFlag struct {
+	Enabled bool `json:"enabled"`
+	Percent int  `json:"percent"`
+}
+
+// FeatureFlag overrides a flag's configured default. Deleting the row puts
+// the default back.
+type FeatureFlag struct {
+	Name      string `gorm:"primaryKey"`
+	Enabled   bool   `gorm:"not null"`
+	Percent   int    `gorm:"not null;default:100"`
+	UpdatedAt time.Time
+}
+
+// flagDefaults holds each known flag's default, which FEATURE_FLAGS can
+// change.
+var flagDefaults = map[string]featureFlag{
+	flagCustomDecks:    {Enabled: true, Percent: 100},
+	flagDailyChallenge: {Enabled: true, Percent: 100},
+	flagWSMsgpack:      {Enabled: true, Percent: 100},
+}
+
+var (
+	flagsMu sync.RWMutex
+	flags   = map[string]featureFlag{}
+)
+
+// loadFlagDefaults applies FEATURE_FLAGS, a comma-separated list of
+// name=on, name=off or name=<percent> entries.
+func loadFlagDefaults() {
+	for _, entry := range strings.Split(os.Getenv("FEATURE_FLAGS"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		if _, known := flagDefaults[name]; !known {
+			warnf("Ignoring unknown feature flag %q", name)
+			continue
+		}
+		flag, err := parseFlag(value)
+		if err != nil {
+			warnf("Ignoring feature flag %s: %v", name, err)
+			continue
+		}
+		flagDefaults[name] = flag
+	}
+}
+
+func parseFlag(value string) (featureFlag, error) {
+	switch value {
+	case "on":
+		return featureFlag{Enabled: true, Percent: 100}, nil
+	case "off":
+		return featureFlag{}, nil
+	}
+	percent, err := strconv.Atoi(value)
+	if err != nil || percent < 0 || percent > 100 {
+		return featureFlag{}, fmt.Errorf("invalid value %q", value)
+	}
+	return featureFlag{Enabled: percent > 0, Percent: percent}, nil
+}
+
+// reloadFlags rebuilds the flags from their defaults and the overrides in
+// the database. On failure the flags in use are kept.
+func reloadFlags(db *gorm.DB) error {
+	var overrides []FeatureFlag
+	if err := db.Find(&overrides).Error; err != nil {
+		return err
+	}
+	loaded := make(map[string]featureFlag, len(flagDefaults))
+	for name, flag := range flagDefaults {
+		loaded[name] = flag
+	}
+	for _, o := range overrides {
+		if _, known := flagDefaults[o.Name]; known {
+			loaded[o.Name] = featureFlag{Enabled: o.Enabled, Percent: o.Percent}
+		}
+	}
+
+	flagsMu.Lock()
+	flags = loaded
+	flagsMu.Unlock()
+	return nil
+}
+
+func runFlagReloader(db *gorm.DB) {
+	db = withOperation(db, "flags_reload")
+	for range time.Tick(flagReloadInterval) {
+		ctx, cancel := context.WithTimeout(context.Background(), flagReloadInterval)
+		err := reloadFlags(db.WithContext(ctx))
+		cancel()
+		if err != nil {
+			errorf("Failed to reload feature flags: %v", err)
+		}
+	}
+}
+
+// featureEnabled reports whether the feature is on for key. A partly rolled
+// out feature is on for the same keys every time, so a caller doesn't see it
+// come and go between requests.
+func featureEnabled(name, key string) bool {
+	flagsMu.RLock()
+	flag, ok := flags[name]
+	flagsMu.RUnlock()
+	if !ok || !flag.Enabled {
+		return false
+	}
+	if flag.Percent >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + key))
+	return int(h.Sum32()%100) < flag.Percent
+}
+
+// rolloutKey identifies the caller for partly rolled out features: their
+// session when they send one as a bearer token, otherwise their address.
+func rolloutKey(c *gin.Context) string {
+	if sessionID := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); sessionID != "" {
+		return sessionID
+	}
+	return c.ClientIP()
+}
+
+// requireFeature rejects requests while the feature is off for the caller.
+func requireFeature(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !featureEnabled(name, rolloutKey(c)) {
+			abortWithError(c, "feature_disabled")
+			return
+		}
+		c.Next()
+	}
+}
+
+// listFeatures tells a client which features are on for them.
+func listFeatures(c *gin.Context) {
+	key := rolloutKey(c)
+	result := gin.H{}
+	for name := range flagDefaults {
+		result[name] = featureEnabled(name, key)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// wsFeatures hands the WebSocket server the flags themselves, which it
+// evaluates per connection the same way featureEnabled does.
+func wsFeatures(c *gin.Context) {
+	flagsMu.RLock()
+	defer flagsMu.RUnlock()
+	c.JSON(http.StatusOK, flags)
+}
+
+func flagInfo(name string, flag featureFlag, overridden bool) gin.H {
+	return gin.H{
+		"name":       name,
+		"enabled":    flag.Enabled,
+		"percent":    flag.Percent,
+		"default":    flagDefaults[name],
+		"overridden": overridden,
+	}
+}
+
+func listFlags(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_flags")
+
+	var overrides []FeatureFlag
+	if err := db.Find(&overrides).Error; err != nil {
+		respondError(c, "flags_failed")
+		return
+	}
+	overridden := make(map[string]featureFlag, len(overrides))
+	for _, o := range overrides {
+		overridden[o.Name] = featureFlag{Enabled: o.Enabled, Percent: o.Percent}
+	}
+
+	names := make([]string, 0, len(flagDefaults))
+	for name := range flagDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		flag, ok := overridden[name]
+		if !ok {
+			flag = flagDefaults[name]
+		}
+		result = append(result, flagInfo(name, flag, ok))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// setFlag overrides a flag on every instance. It applies here at once and
+// on the others at their next reload.
+func setFlag(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_flags")
+
+	name := c.Param("name")
+	if _, known := flagDefaults[name]; !known {
+		respondError(c, "flag_not_found")
+		return
+	}
+
+	var json struct {
+		Enabled bool `json:"enabled"`
+		Percent *int `json:"percent"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+	percent := 100
+	if json.Percent != nil {
+		percent = *json.Percent
+	}
+	if percent < 0 || percent > 100 {
+		respondError(c, "invalid_percent")
+		return
+	}
+
+	override := FeatureFlag{Name: name, Enabled: json.Enabled, Percent: percent}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&override).Error; err != nil {
+		respondError(c, "flags_failed")
+		return
+	}
+	if err := reloadFlags(db); err != nil {
+		errorf("Failed to reload feature flags: %v", err)
+	}
+
+	recordAudit(db, c, "set_flag", name, fmt.Sprintf("enabled=%t percent=%d", override.Enabled, override.Percent))
+	c.JSON(http.StatusOK, flagInfo(name, featureFlag{Enabled: override.Enabled, Percent: override.Percent}, true))
+}
+
+// resetFlag drops a flag's override so its default applies again.
+func resetFlag(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_flags")
+
+	name := c.Param("name")
+	if _, known := flagDefaults[name]; !known {
+		respondError(c, "flag_not_found")
+		return
+	}
+	if err := db.Where("name = ?", name).Delete(&FeatureFlag{}).Error; err != nil {
+		respondError(c, "flags_failed")
+		return
+	}
+	if err := reloadFlags(db); err != nil {
+		errorf("Failed to reload feature flags: %v", err)
+	}
+
+	recordAudit(db, c, "reset_flag", name, "")
+	c.JSON(http.StatusOK, flagInfo(name, flagDefaults[name], false))
+}
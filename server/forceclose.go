@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadWSURL overrides where the WebSocket server's admin endpoints are
+// reached from WS_URL.
+func loadWSURL() {
+	if base := os.Getenv("WS_URL"); base != "" {
+		config.WSURL = strings.TrimSuffix(base, "/")
+	}
+}
+
+// postWS sends an admin request to the WebSocket server and decodes its
+// JSON reply into result.
+func postWS(path string, body interface{}, result interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", config.WSURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+config.AdminToken)
+
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// closeWSRoom asks the WebSocket server to tell the game's clients it was
+// closed and drop them. It returns how many connections were in the game.
+func closeWSRoom(gameID, reason string) (int, error) {
+	var result struct {
+		Clients int `json:"clients"`
+	}
+	if err := postWS("/games/"+url.PathEscape(gameID)+"/close", gin.H{"reason": reason}, &result); err != nil {
+		return 0, err
+	}
+	return result.Clients, nil
+}
+
+// forceCloseRoom destroys a stuck room: its clients are told it was closed
+// and dropped from the WebSocket server, then its rows are deleted here. The
+// rows go even when the WebSocket server can't be reached, since a room
+// stuck there is often why it is being closed; notified says whether it was.
+func forceCloseRoom(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_close_room")
+
+	var json struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&json); err != nil {
+			respondError(c, "invalid_request")
+			return
+		}
+	}
+	reason := strings.TrimSpace(json.Reason)
+	if reason == "" {
+		reason = "Closed by an administrator"
+	}
+
+	gameID := c.Param("game_id")
+	unlock := lockGame(gameID)
+	defer unlock()
+
+	var rooms, settings, members int64
+	db.Model(&Room{}).Where("game_id = ?", gameID).Count(&rooms)
+	db.Model(&RoomSettings{}).Where("game_id = ?", gameID).Count(&settings)
+	db.Model(&WSMember{}).Where("game_id = ?", gameID).Count(&members)
+	if rooms == 0 && settings == 0 && members == 0 {
+		respondError(c, "game_not_found")
+		return
+	}
+
+	clients, err := closeWSRoom(gameID, reason)
+	notified := err == nil
+	if err != nil {
+		errorf("Failed to close game_id %s on the WebSocket server: %v", gameID, err)
+	}
+	closeGame(db, gameID)
+
+	recordAudit(db, c, "close_room", gameID, fmt.Sprintf("reason=%q rooms=%d clients=%d notified=%t", reason, rooms, clients, notified))
+	infof("Admin closed game_id %s with %d rooms: %s", gameID, rooms, reason)
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_id":  gameID,
+		"rooms":    rooms,
+		"clients":  clients,
+		"notified": notified,
+	})
+}
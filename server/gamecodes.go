@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	minGameIDLength = 4
+	maxGameIDLength = 32
+	// maxGameIDAttempts is how many codes host tries before giving up on a
+	// crowded code space.
+	maxGameIDAttempts = 10
+)
+
+// numericAlphabet is used for codes that are easy to read out loud.
+const numericAlphabet = "0123456789"
+
+// loadGameIDs overrides the game code length and alphabet from the
+// environment. GAME_ID_NUMERIC makes numeric codes the default for rooms
+// that don't ask either way.
+func loadGameIDs() {
+	if n, err := strconv.Atoi(os.Getenv("GAME_ID_LENGTH")); err == nil && n >= minGameIDLength && n <= maxGameIDLength {
+		config.GameIDLength = n
+	}
+	if alphabet := uniqueRunes(os.Getenv("GAME_ID_ALPHABET")); len([]rune(alphabet)) >= 2 {
+		config.GameIDAlphabet = alphabet
+	}
+	if numeric, err := strconv.ParseBool(os.Getenv("GAME_ID_NUMERIC")); err == nil {
+		config.GameIDNumeric = numeric
+	}
+}
+
+// uniqueRunes drops repeated and blank characters, which would skew which
+// codes come up or make them awkward to type.
+func uniqueRunes(s string) string {
+	var b strings.Builder
+	seen := map[rune]bool{}
+	for _, r := range s {
+		if seen[r] || strings.TrimSpace(string(r)) == "" {
+			continue
+		}
+		seen[r] = true
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func randomGameID(numeric bool) string {
+	letters := []rune(config.GameIDAlphabet)
+	if numeric {
+		letters = []rune(numericAlphabet)
+	}
+	b := make([]rune, config.GameIDLength)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// createRoomSettings stores settings under a fresh game code. The code is
+// checked against the rooms in play, and the unique game ID catches a race
+// with another host, so a collision just means trying another code. It
+// returns false when every attempt collided.
+func createRoomSettings(db *gorm.DB, settings *RoomSettings, numeric bool) bool {
+	for i := 0; i < maxGameIDAttempts; i++ {
+		settings.GameID = randomGameID(numeric)
+		var taken int64
+		db.Model(&Room{}).Where("game_id = ?", settings.GameID).Count(&taken)
+		if taken > 0 {
+			continue
+		}
+		// A suspended game keeps its code until it expires.
+		db.Model(&SuspendedGame{}).Where("game_id = ?", settings.GameID).Count(&taken)
+		if taken > 0 {
+			continue
+		}
+		if err := db.Create(settings).Error; err == nil {
+			return true
+		}
+		settings.ID = 0
+	}
+	return false
+}
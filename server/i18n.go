@@ -0,0 +1,737 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultLanguage = "en"
+
+var supportedLanguages = map[string]bool{"en": true, "ru": true}
+
+// errorCatalog maps stable error codes to their message in each supported
+// language. Messages may take fmt arguments.
+var errorCatalog = map[string]map[string]string{
+	"activity_failed": {
+		"en": "Failed to load activity statistics",
+		"ru": "Не удалось загрузить статистику активности",
+	},
+	"admin_disabled": {
+		"en": "Admin API is disabled",
+		"ru": "API администратора отключён",
+	},
+	"already_connected": {
+		"en": "Session already connected to this game",
+		"ru": "Сессия уже подключена к этой игре",
+	},
+	"already_submitted": {
+		"en": "You have already submitted a card for this challenge",
+		"ru": "Вы уже отправили карту для этого задания",
+	},
+	"already_voted": {
+		"en": "You have already voted in this challenge",
+		"ru": "Вы уже проголосовали в этом задании",
+	},
+	"announce_failed": {
+		"en": "Failed to send the announcement",
+		"ru": "Не удалось отправить объявление",
+	},
+	"announcement_required": {
+		"en": "An announcement message is required",
+		"ru": "Требуется текст объявления",
+	},
+	"audit_failed": {
+		"en": "Failed to load the audit log",
+		"ru": "Не удалось загрузить журнал аудита",
+	},
+	"authorization_required": {
+		"en": "A session bearer token is required",
+		"ru": "Требуется токен сессии",
+	},
+	"capacity_below_members": {
+		"en": "The room already has %d members",
+		"ru": "В комнате уже %d участников",
+	},
+	"card_already_played": {
+		"en": "Card was already played",
+		"ru": "Эта карта уже сыграна",
+	},
+	"card_lookup_fields_required": {
+		"en": "Game ID, session ID and exactly one of card_id and custom_card_id are required",
+		"ru": "Требуются идентификаторы игры и сессии и ровно один из card_id и custom_card_id",
+	},
+	"card_not_dealt": {
+		"en": "Card was not dealt to this player",
+		"ru": "Эта карта не была выдана этому игроку",
+	},
+	"card_not_found": {
+		"en": "Card not found",
+		"ru": "Карта не найдена",
+	},
+	"card_not_played": {
+		"en": "Card was not played in this game",
+		"ru": "Эта карта не была сыграна в этой игре",
+	},
+	"card_not_seen": {
+		"en": "You can only favorite cards you have seen",
+		"ru": "Можно добавить в избранное только карты, которые вы видели",
+	},
+	"card_update_failed": {
+		"en": "Failed to update card",
+		"ru": "Не удалось обновить карту",
+	},
+	"cards_exhausted": {
+		"en": "Not enough cards available",
+		"ru": "Недостаточно доступных карт",
+	},
+	"cards_failed": {
+		"en": "Failed to get cards",
+		"ru": "Не удалось получить карты",
+	},
+	"code_create_failed": {
+		"en": "Failed to create code",
+		"ru": "Не удалось создать код",
+	},
+	"code_generate_failed": {
+		"en": "Failed to generate code",
+		"ru": "Не удалось сгенерировать код",
+	},
+	"daily_closed": {
+		"en": "This daily challenge has closed",
+		"ru": "Это ежедневное задание уже закрыто",
+	},
+	"daily_failed": {
+		"en": "Daily challenge request failed",
+		"ru": "Не удалось выполнить запрос ежедневного задания",
+	},
+	"daily_fields_required": {
+		"en": "challenge_id and card_id or submission_id are required",
+		"ru": "Требуются challenge_id и card_id или submission_id",
+	},
+	"daily_not_found": {
+		"en": "Daily challenge not found",
+		"ru": "Ежедневное задание не найдено",
+	},
+	"deck_clear_failed": {
+		"en": "Failed to clear decks",
+		"ru": "Не удалось удалить колоды",
+	},
+	"deck_create_failed": {
+		"en": "Failed to create custom deck",
+		"ru": "Не удалось создать колоду",
+	},
+	"deck_id_failed": {
+		"en": "Failed to get max deck ID",
+		"ru": "Не удалось получить идентификатор колоды",
+	},
+	"deck_no_situations": {
+		"en": "Deck has no situations",
+		"ru": "В колоде нет ситуаций",
+	},
+	"deck_not_found": {
+		"en": "Deck not found",
+		"ru": "Колода не найдена",
+	},
+	"deck_quota_exceeded": {
+		"en": "Deck storage quota exceeded: %d of %d bytes used",
+		"ru": "Превышена квота на колоды: занято %d из %d байт",
+	},
+	"deck_too_small": {
+		"en": "Not enough cards in the deck",
+		"ru": "В колоде недостаточно карт",
+	},
+	"deck_upload_incomplete": {
+		"en": "%d cards are still missing",
+		"ru": "Не хватает карт: %d",
+	},
+	"deck_upload_not_found": {
+		"en": "Deck upload not found",
+		"ru": "Загрузка колоды не найдена",
+	},
+	"decks_failed": {
+		"en": "Failed to get decks",
+		"ru": "Не удалось получить колоды",
+	},
+	"events_failed": {
+		"en": "Failed to get events",
+		"ru": "Не удалось получить события",
+	},
+	"events_store_failed": {
+		"en": "Failed to store events",
+		"ru": "Не удалось сохранить события",
+	},
+	"favorites_failed": {
+		"en": "Failed to update favorites",
+		"ru": "Не удалось обновить избранное",
+	},
+	"favorites_full": {
+		"en": "You can keep at most %d favorite cards",
+		"ru": "В избранном может быть не больше %d карт",
+	},
+	"feature_disabled": {
+		"en": "This feature is not available",
+		"ru": "Эта функция недоступна",
+	},
+	"file_missing": {
+		"en": "Failed to get file",
+		"ru": "Не удалось получить файл",
+	},
+	"file_not_found": {
+		"en": "File not found",
+		"ru": "Файл не найден",
+	},
+	"file_save_failed": {
+		"en": "Failed to save file",
+		"ru": "Не удалось сохранить файл",
+	},
+	"file_too_large": {
+		"en": "Image is larger than %d bytes",
+		"ru": "Изображение больше %d байт",
+	},
+	"flag_not_found": {
+		"en": "Unknown feature flag",
+		"ru": "Неизвестный флаг функции",
+	},
+	"flags_failed": {
+		"en": "Failed to load or update feature flags",
+		"ru": "Не удалось загрузить или изменить флаги функций",
+	},
+	"game_and_session_required": {
+		"en": "Game ID and session ID are required",
+		"ru": "Требуются идентификаторы игры и сессии",
+	},
+	"game_id_required": {
+		"en": "Game ID is required",
+		"ru": "Требуется идентификатор игры",
+	},
+	"game_id_unavailable": {
+		"en": "No free game code, try again",
+		"ru": "Нет свободного кода игры, попробуйте ещё раз",
+	},
+	"game_not_found": {
+		"en": "Game not found",
+		"ru": "Игра не найдена",
+	},
+	"game_running": {
+		"en": "Game is still running",
+		"ru": "Игра ещё идёт",
+	},
+	"games_failed": {
+		"en": "Failed to get games",
+		"ru": "Не удалось получить игры",
+	},
+	"image_not_found": {
+		"en": "Image not found",
+		"ru": "Изображение не найдено",
+	},
+	"image_read_failed": {
+		"en": "Failed to read image file",
+		"ru": "Не удалось прочитать изображение",
+	},
+	"image_too_large": {
+		"en": "Image is larger than %dx%d",
+		"ru": "Изображение больше %dx%d",
+	},
+	"invalid_admin_token": {
+		"en": "Invalid admin token",
+		"ru": "Неверный токен администратора",
+	},
+	"invalid_card_id": {
+		"en": "Invalid card ID",
+		"ru": "Неверный идентификатор карты",
+	},
+	"invalid_card_count": {
+		"en": "A deck needs at least one card",
+		"ru": "В колоде должна быть хотя бы одна карта",
+	},
+	"invalid_card_position": {
+		"en": "Card number must be between 1 and %d",
+		"ru": "Номер карты должен быть от 1 до %d",
+	},
+	"invalid_code": {
+		"en": "Invalid or used code",
+		"ru": "Код недействителен или уже использован",
+	},
+	"invalid_content_type": {
+		"en": "Upload data must be sent as application/offset+octet-stream",
+		"ru": "Данные загрузки должны передаваться как application/offset+octet-stream",
+	},
+	"invalid_count": {
+		"en": "Invalid count",
+		"ru": "Неверное количество",
+	},
+	"invalid_capacity": {
+		"en": "Capacity must be between %d and %d",
+		"ru": "Вместимость должна быть от %d до %d",
+	},
+	"invalid_custom_ratio": {
+		"en": "custom_ratio must be between 0 and 1",
+		"ru": "custom_ratio должен быть от 0 до 1",
+	},
+	"invalid_event_id": {
+		"en": "Invalid event ID",
+		"ru": "Неверный идентификатор события",
+	},
+	"invalid_expiry": {
+		"en": "Invalid expiry",
+		"ru": "Неверный срок действия",
+	},
+	"invalid_favorites_ratio": {
+		"en": "favorites_ratio must be between 0 and 1",
+		"ru": "favorites_ratio должен быть от 0 до 1",
+	},
+	"invalid_image": {
+		"en": "File is not a supported image",
+		"ru": "Файл не является поддерживаемым изображением",
+	},
+	"invalid_image_id": {
+		"en": "Invalid image ID",
+		"ru": "Неверный идентификатор изображения",
+	},
+	"invalid_days": {
+		"en": "days must be between 1 and 90",
+		"ru": "days должен быть от 1 до 90",
+	},
+	"invalid_ip_list": {
+		"en": "Invalid IP list: %v",
+		"ru": "Неверный список IP: %v",
+	},
+	"invalid_limit": {
+		"en": "limit must be between 1 and 100",
+		"ru": "limit должен быть от 1 до 100",
+	},
+	"invalid_offset": {
+		"en": "offset must not be negative",
+		"ru": "offset не может быть отрицательным",
+	},
+	"invalid_log_level": {
+		"en": "level must be debug, info, warn or error",
+		"ru": "level должен быть debug, info, warn или error",
+	},
+	"invalid_qr_scale": {
+		"en": "scale must be between 1 and %d",
+		"ru": "scale должен быть от 1 до %d",
+	},
+	"invalid_percent": {
+		"en": "percent must be between 0 and 100",
+		"ru": "percent должен быть от 0 до 100",
+	},
+	"invalid_region": {
+		"en": "Invalid region",
+		"ru": "Недопустимый регион",
+	},
+	"invalid_replay_mode": {
+		"en": "mode must be timed or step",
+		"ru": "mode должен быть timed или step",
+	},
+	"invalid_request": {
+		"en": "Invalid request body",
+		"ru": "Неверное тело запроса",
+	},
+	"invalid_service_token": {
+		"en": "Invalid service token",
+		"ru": "Неверный служебный токен",
+	},
+	"invalid_session_id": {
+		"en": "Invalid session_id",
+		"ru": "Неверный session_id",
+	},
+	"invalid_shutdown_time": {
+		"en": "shutdown_at must be in the future",
+		"ru": "shutdown_at должен быть в будущем",
+	},
+	"invalid_signature": {
+		"en": "Invalid signature",
+		"ru": "Неверная подпись",
+	},
+	"invalid_speed": {
+		"en": "speed must be a positive number",
+		"ru": "speed должен быть положительным числом",
+	},
+	"invalid_upload_kind": {
+		"en": "Upload kind must be avatar or card",
+		"ru": "Тип загрузки должен быть avatar или card",
+	},
+	"invalid_upload_length": {
+		"en": "Upload-Length must be a positive number of bytes",
+		"ru": "Upload-Length должен быть положительным числом байт",
+	},
+	"ip_blocked": {
+		"en": "Access from your network is not allowed",
+		"ru": "Доступ из вашей сети запрещён",
+	},
+	"leaderboard_failed": {
+		"en": "Failed to load the leaderboard",
+		"ru": "Не удалось загрузить таблицу лидеров",
+	},
+	"link_expired": {
+		"en": "Link expired",
+		"ru": "Срок действия ссылки истёк",
+	},
+	"lobby_full": {
+		"en": "Lobby is full",
+		"ru": "Лобби заполнено",
+	},
+	"login_empty": {
+		"en": "Login can't be empty",
+		"ru": "Логин не может быть пустым",
+	},
+	"login_exists": {
+		"en": "Login exists",
+		"ru": "Логин уже занят",
+	},
+	"maintenance": {
+		"en": "The server is under maintenance; new games can't be started right now",
+		"ru": "Сервер на обслуживании; новые игры сейчас начать нельзя",
+	},
+	"maintenance_failed": {
+		"en": "Failed to read or update maintenance mode",
+		"ru": "Не удалось прочитать или изменить режим обслуживания",
+	},
+	"missing_session_id": {
+		"en": "Missing session_id",
+		"ru": "Не указан session_id",
+	},
+	"no_card_images": {
+		"en": "No cards image available",
+		"ru": "Нет доступных изображений карт",
+	},
+	"no_cards_dealt": {
+		"en": "No cards dealt",
+		"ru": "Карты не розданы",
+	},
+	"no_events": {
+		"en": "No events for this game",
+		"ru": "Для этой игры нет событий",
+	},
+	"no_finished_games": {
+		"en": "No finished games for this user",
+		"ru": "У пользователя нет завершённых игр",
+	},
+	"no_open_rooms": {
+		"en": "No open rooms to join",
+		"ru": "Нет открытых комнат",
+	},
+	"no_situations": {
+		"en": "No situations available",
+		"ru": "Нет доступных ситуаций",
+	},
+	"not_host": {
+		"en": "Only the host can change the deck mode",
+		"ru": "Только хозяин комнаты может менять режим колоды",
+	},
+	"not_in_game": {
+		"en": "User not in any game",
+		"ru": "Пользователь не состоит ни в одной игре",
+	},
+	"not_room_member": {
+		"en": "You are not a member of this room",
+		"ru": "Вы не участник этой комнаты",
+	},
+	"observe_failed": {
+		"en": "Failed to start observing the game",
+		"ru": "Не удалось начать наблюдение за игрой",
+	},
+	"one_card_id_required": {
+		"en": "Exactly one of card_id and custom_card_id is required",
+		"ru": "Требуется ровно один из card_id и custom_card_id",
+	},
+	"own_submission": {
+		"en": "You cannot vote for your own submission",
+		"ru": "Нельзя голосовать за свою карту",
+	},
+	"pack_empty": {
+		"en": "Pack can't be empty",
+		"ru": "Набор не может быть пустым",
+	},
+	"pack_required": {
+		"en": "Pack is required",
+		"ru": "Требуется набор",
+	},
+	"pack_unlock_failed": {
+		"en": "Failed to unlock pack",
+		"ru": "Не удалось открыть набор",
+	},
+	"pack_update_failed": {
+		"en": "Failed to update pack",
+		"ru": "Не удалось обновить набор",
+	},
+	"play_failed": {
+		"en": "Failed to play card",
+		"ru": "Не удалось сыграть карту",
+	},
+	"qr_encode_failed": {
+		"en": "Failed to generate QR code",
+		"ru": "Не удалось создать QR-код",
+	},
+	"quick_match_failed": {
+		"en": "Failed to find a room",
+		"ru": "Не удалось найти комнату",
+	},
+	"request_timed_out": {
+		"en": "The server took too long to answer; try again",
+		"ru": "Сервер слишком долго отвечал; попробуйте ещё раз",
+	},
+	"request_too_large": {
+		"en": "The request body is larger than %d bytes",
+		"ru": "Тело запроса больше %d байт",
+	},
+	"resume_failed": {
+		"en": "Failed to resume the game",
+		"ru": "Не удалось возобновить игру",
+	},
+	"room_list_failed": {
+		"en": "Failed to list rooms",
+		"ru": "Не удалось получить список комнат",
+	},
+	"room_name_too_long": {
+		"en": "Room name must be at most %d characters",
+		"ru": "Название комнаты должно быть не длиннее %d символов",
+	},
+	"session_and_code_required": {
+		"en": "Session ID and code are required",
+		"ru": "Требуются идентификатор сессии и код",
+	},
+	"session_id_required": {
+		"en": "Session ID is required",
+		"ru": "Требуется идентификатор сессии",
+	},
+	"session_required": {
+		"en": "A session_id or session bearer token is required",
+		"ru": "Требуется session_id или токен сессии",
+	},
+	"shadow_ban_failed": {
+		"en": "Failed to read or change shadow bans",
+		"ru": "Не удалось прочитать или изменить теневые блокировки",
+	},
+	"situation_deck_create_failed": {
+		"en": "Failed to create custom situation deck",
+		"ru": "Не удалось создать колоду ситуаций",
+	},
+	"submission_not_found": {
+		"en": "Submission not found",
+		"ru": "Отправленная карта не найдена",
+	},
+	"suspend_failed": {
+		"en": "Failed to suspend the game",
+		"ru": "Не удалось приостановить игру",
+	},
+	"suspension_expired": {
+		"en": "The suspended game can no longer be resumed",
+		"ru": "Приостановленную игру больше нельзя возобновить",
+	},
+	"thumbnail_not_found": {
+		"en": "Thumbnail not found",
+		"ru": "Превью не найдено",
+	},
+	"too_many_cards": {
+		"en": "A deck can have at most %d cards",
+		"ru": "В колоде может быть не больше %d карт",
+	},
+	"too_many_sessions": {
+		"en": "At most %d sessions can be looked up at once",
+		"ru": "За один раз можно запросить не больше %d сессий",
+	},
+	"unsupported_extension": {
+		"en": "File extension isn't allowed",
+		"ru": "Недопустимое расширение файла",
+	},
+	"unsupported_format": {
+		"en": "Images in %s format aren't allowed",
+		"ru": "Изображения в формате %s не допускаются",
+	},
+	"upload_incomplete": {
+		"en": "Upload isn't finished yet",
+		"ru": "Загрузка ещё не завершена",
+	},
+	"upload_not_found": {
+		"en": "Upload not found",
+		"ru": "Загрузка не найдена",
+	},
+	"upload_offset_mismatch": {
+		"en": "Upload is at offset %d",
+		"ru": "Загрузка находится на смещении %d",
+	},
+	"upload_rate_limited": {
+		"en": "Too many uploads, try again in %d seconds",
+		"ru": "Слишком много загрузок, повторите через %d с",
+	},
+	"user_create_failed": {
+		"en": "Failed to create user",
+		"ru": "Не удалось создать пользователя",
+	},
+	"user_not_found": {
+		"en": "User not found",
+		"ru": "Пользователь не найден",
+	},
+	"user_update_failed": {
+		"en": "Failed to update user",
+		"ru": "Не удалось обновить пользователя",
+	},
+	"vote_failed": {
+		"en": "Failed to record vote",
+		"ru": "Не удалось записать голос",
+	},
+	"vote_fields_required": {
+		"en": "Game ID, session ID and chosen ID are required",
+		"ru": "Требуются идентификаторы игры, сессии и выбранного игрока",
+	},
+	"ws_members_failed": {
+		"en": "Failed to store WebSocket sessions",
+		"ru": "Не удалось сохранить сессии WebSocket",
+	},
+}
+
+// requestLanguage picks the first language from Accept-Language, by
+// preference, that the catalog has messages in.
+func requestLanguage(c *gin.Context) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if lang == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		candidates = append(candidates, candidate{lang, q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, cand := range candidates {
+		if cand.q > 0 && supportedLanguages[cand.lang] {
+			return cand.lang
+		}
+	}
+	return defaultLanguage
+}
+
+// errorBody builds an error response with the code and its message in the
+// request's language.
+func errorBody(c *gin.Context, code string, args ...interface{}) gin.H {
+	messages, ok := errorCatalog[code]
+	if !ok {
+		return gin.H{"error": code, "code": code}
+	}
+	message, ok := messages[requestLanguage(c)]
+	if !ok {
+		message = messages[defaultLanguage]
+	}
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	return gin.H{"error": message, "code": code}
+}
+
+// errorStatuses gives the HTTP status of each error code that isn't a plain
+// 400. Codes ending in _failed are server-side failures and always 500.
+var errorStatuses = map[string]int{
+	"authorization_required": http.StatusUnauthorized,
+	"invalid_admin_token":    http.StatusUnauthorized,
+	"invalid_service_token":  http.StatusUnauthorized,
+	"invalid_session_id":     http.StatusUnauthorized,
+	"session_required":       http.StatusUnauthorized,
+
+	"admin_disabled":    http.StatusForbidden,
+	"card_not_seen":     http.StatusForbidden,
+	"feature_disabled":  http.StatusForbidden,
+	"invalid_signature": http.StatusForbidden,
+	"ip_blocked":        http.StatusForbidden,
+	"lobby_full":        http.StatusForbidden,
+	"not_host":          http.StatusForbidden,
+	"not_room_member":   http.StatusForbidden,
+	"own_submission":    http.StatusForbidden,
+
+	"card_not_dealt":        http.StatusNotFound,
+	"card_not_found":        http.StatusNotFound,
+	"card_not_played":       http.StatusNotFound,
+	"daily_not_found":       http.StatusNotFound,
+	"deck_not_found":        http.StatusNotFound,
+	"deck_upload_not_found": http.StatusNotFound,
+	"file_not_found":        http.StatusNotFound,
+	"flag_not_found":        http.StatusNotFound,
+	"game_not_found":        http.StatusNotFound,
+	"image_not_found":       http.StatusNotFound,
+	"invalid_code":          http.StatusNotFound,
+	"no_cards_dealt":        http.StatusNotFound,
+	"no_events":             http.StatusNotFound,
+	"no_finished_games":     http.StatusNotFound,
+	"no_open_rooms":         http.StatusNotFound,
+	"no_situations":         http.StatusNotFound,
+	"not_in_game":           http.StatusNotFound,
+	"submission_not_found":  http.StatusNotFound,
+	"thumbnail_not_found":   http.StatusNotFound,
+	"upload_not_found":      http.StatusNotFound,
+	"user_not_found":        http.StatusNotFound,
+
+	"already_connected":      http.StatusConflict,
+	"already_submitted":      http.StatusConflict,
+	"already_voted":          http.StatusConflict,
+	"capacity_below_members": http.StatusConflict,
+	"card_already_played":    http.StatusConflict,
+	"cards_exhausted":        http.StatusConflict,
+	"daily_closed":           http.StatusConflict,
+	"deck_too_small":         http.StatusConflict,
+	"deck_upload_incomplete": http.StatusConflict,
+	"favorites_full":         http.StatusConflict,
+	"game_running":           http.StatusConflict,
+	"no_card_images":         http.StatusConflict,
+	"upload_incomplete":      http.StatusConflict,
+	"upload_offset_mismatch": http.StatusConflict,
+
+	"link_expired":         http.StatusGone,
+	"suspension_expired":   http.StatusGone,
+	"deck_quota_exceeded":  http.StatusRequestEntityTooLarge,
+	"file_too_large":       http.StatusRequestEntityTooLarge,
+	"request_too_large":    http.StatusRequestEntityTooLarge,
+	"invalid_content_type": http.StatusUnsupportedMediaType,
+	"upload_rate_limited":  http.StatusTooManyRequests,
+	"game_id_unavailable":  http.StatusServiceUnavailable,
+	"maintenance":          http.StatusServiceUnavailable,
+	"request_timed_out":    http.StatusServiceUnavailable,
+}
+
+// errorStatus is the HTTP status an error code is answered with.
+func errorStatus(code string) int {
+	if status, ok := errorStatuses[code]; ok {
+		return status
+	}
+	if strings.HasSuffix(code, "_failed") {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// respondError answers with the code's status and localized message. A
+// failure that came from the request running out of time is reported as
+// that instead, since trying again may well work.
+func respondError(c *gin.Context, code string, args ...interface{}) {
+	if strings.HasSuffix(code, "_failed") && requestTimedOut(c) {
+		code, args = "request_timed_out", nil
+	}
+	if limit, ok := bodyTooLarge(c); ok {
+		code, args = "request_too_large", []interface{}{limit}
+	}
+	c.JSON(errorStatus(code), errorBody(c, code, args...))
+}
+
+func abortWithError(c *gin.Context, code string) {
+	var args []interface{}
+	if limit, ok := bodyTooLarge(c); ok {
+		code, args = "request_too_large", []interface{}{limit}
+	}
+	c.AbortWithStatusJSON(errorStatus(code), errorBody(c, code, args...))
+}
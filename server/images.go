@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// imageThumbnailSize bounds the thumbnails image URLs can ask for.
+	imageThumbnailSize = 256
+
+	thumbnailTile    = 128
+	thumbnailColumns = 3
+	thumbnailRows    = 2
+)
+
+// scaleToFit resizes src with nearest-neighbour sampling so that it fits in a
+// w×h box while keeping its aspect ratio.
+func scaleToFit(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	dw, dh := w, sh*w/sw
+	if dh > h {
+		dw, dh = sw*h/sh, h
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy := b.Min.Y + y*sh/dh
+		for x := 0; x < dw; x++ {
+			sx := b.Min.X + x*sw/dw
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+	return dst
+}
+
+// makeCollage lays out up to thumbnailColumns×thumbnailRows of the given
+// images as a grid and returns it JPEG-encoded. Images that fail to decode
+// are skipped.
+func makeCollage(images [][]byte) ([]byte, error) {
+	canvas := image.NewRGBA(image.Rect(0, 0, thumbnailColumns*thumbnailTile, thumbnailRows*thumbnailTile))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{color.RGBA{32, 32, 32, 255}}, image.Point{}, draw.Src)
+
+	slot := 0
+	for _, data := range images {
+		if slot >= thumbnailColumns*thumbnailRows {
+			break
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+
+		tile := scaleToFit(img, thumbnailTile, thumbnailTile)
+		x := (slot%thumbnailColumns)*thumbnailTile + (thumbnailTile-tile.Bounds().Dx())/2
+		y := (slot/thumbnailColumns)*thumbnailTile + (thumbnailTile-tile.Bounds().Dy())/2
+		draw.Draw(canvas, tile.Bounds().Add(image.Pt(x, y)), tile, image.Point{}, draw.Over)
+		slot++
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizeCardImage re-encodes a validated card image so what gets stored,
+// and dealt to every player, is at most CardMaxWidth×CardMaxHeight at
+// CardQuality, encoded by encodeImage. An image already within bounds is
+// kept as it was if re-encoding wouldn't make it smaller, as is one that
+// can't be decoded.
+func normalizeCardImage(data []byte) []byte {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		warnf("Storing card image as uploaded, can't decode it: %v", err)
+		return data
+	}
+
+	b := img.Bounds()
+	resized := b.Dx() > config.CardMaxWidth || b.Dy() > config.CardMaxHeight
+	if resized {
+		img = scaleToFit(img, config.CardMaxWidth, config.CardMaxHeight)
+	}
+
+	encoded, err := encodeImage(img, config.CardQuality)
+	if err != nil {
+		warnf("Storing card image as uploaded, can't re-encode it: %v", err)
+		return data
+	}
+	if !resized && len(encoded) >= len(data) {
+		return data
+	}
+	return encoded
+}
+
+// encodeImage encodes img as PNG if it has transparency and as JPEG at
+// quality otherwise.
+func encodeImage(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// thumbnailImage scales an image down to fit imageThumbnailSize square,
+// keeping transparency as PNG and otherwise encoding JPEG. Images already
+// that small are returned as they are.
+func thumbnailImage(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if b.Dx() <= imageThumbnailSize && b.Dy() <= imageThumbnailSize {
+		return data, nil
+	}
+	return encodeImage(scaleToFit(img, imageThumbnailSize, imageThumbnailSize), 80)
+}
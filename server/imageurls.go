@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	imageKindAvatar = "avatar"
+	imageKindCard   = "card"
+
+	defaultImageURLTTL = 15 * time.Minute
+
+	// Image sizes a signed URL can ask for. The bare URL serves the full
+	// image as well.
+	imageSizeThumb = "thumb"
+	imageSizeFull  = "full"
+
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "public, no-cache"
+)
+
+// loadImageURLSecret returns the key image URLs are signed with. Without a
+// configured secret a random one is used, so URLs don't survive a restart.
+func loadImageURLSecret() []byte {
+	if secret := os.Getenv("IMAGE_URL_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic("failed to generate image URL secret")
+	}
+	return secret
+}
+
+func imageSignature(kind string, id uint, expires int64) string {
+	mac := hmac.New(sha256.New, config.ImageURLSecret)
+	fmt.Fprintf(mac, "%s/%d/%d", kind, id, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedImageURL returns a path that serves the image for ImageURLTTL. URLs
+// are only handed out in responses to room members, so the signature stands
+// in for membership when the image is fetched.
+func signedImageURL(kind string, id uint) string {
+	expires := time.Now().Add(config.ImageURLTTL).Unix()
+	return fmt.Sprintf("/images/%s/%d?exp=%d&sig=%s", kind, id, expires, imageSignature(kind, id, expires))
+}
+
+// signedThumbnailURL is signedImageURL for a thumbnail of the image. The
+// full image is at the same URL with /full in place of /thumb, under the
+// same signature, so listings can carry thumbnails alone and still let
+// clients open any image in full.
+func signedThumbnailURL(kind string, id uint) string {
+	expires := time.Now().Add(config.ImageURLTTL).Unix()
+	return fmt.Sprintf("/images/%s/%d/%s?exp=%d&sig=%s", kind, id, imageSizeThumb, expires, imageSignature(kind, id, expires))
+}
+
+// serveImage serves the image a signed URL names, or with the size
+// parameter thumb a thumbnail of it.
+func serveImage(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "image")
+
+	kind := c.Param("kind")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, "invalid_image_id")
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil {
+		respondError(c, "invalid_expiry")
+		return
+	}
+
+	expected := imageSignature(kind, uint(id), expires)
+	if !hmac.Equal([]byte(c.Query("sig")), []byte(expected)) {
+		respondError(c, "invalid_signature")
+		return
+	}
+	if time.Now().Unix() > expires {
+		respondError(c, "link_expired")
+		return
+	}
+
+	// Avatars are files, served from disk, as are custom cards moved out by
+	// migrate-data; the other kinds are stored in the database.
+	var data []byte
+	var path string
+	switch kind {
+	case imageKindAvatar:
+		var user User
+		if err := db.First(&user, id).Error; err != nil {
+			respondError(c, "image_not_found")
+			return
+		}
+		path = user.ImagePath
+	case imageKindCard:
+		var card customDeck
+		if err := db.First(&card, id).Error; err != nil {
+			respondError(c, "image_not_found")
+			return
+		}
+		data, path = card.CardImg, card.ImgPath
+	case imageKindChat:
+		var chatImage ChatImage
+		if err := db.First(&chatImage, id).Error; err != nil {
+			respondError(c, "image_not_found")
+			return
+		}
+		data = chatImage.Data
+	default:
+		respondError(c, "image_not_found")
+		return
+	}
+
+	switch c.Param("size") {
+	case "", imageSizeFull:
+	case imageSizeThumb:
+		if path != "" {
+			if data, err = readFileContext(c.Request.Context(), path); err != nil {
+				respondError(c, "image_not_found")
+				return
+			}
+			path = ""
+		}
+		if data, err = thumbnailImage(data); err != nil {
+			respondError(c, "image_read_failed")
+			return
+		}
+	default:
+		respondError(c, "image_not_found")
+		return
+	}
+
+	// A card or chat image ID always names the same bytes, so whoever holds
+	// a link can keep the image for good; the unguessable URL is what guards
+	// it. An avatar keeps its user's ID when it is replaced, so it is only
+	// kept until the ETag says otherwise. ServeContent answers conditional
+	// and range requests.
+	if kind == imageKindAvatar {
+		c.Header("Cache-Control", revalidateCacheControl)
+	} else {
+		c.Header("Cache-Control", immutableCacheControl)
+	}
+	if path == "" {
+		c.Header("ETag", contentETag(data))
+		http.ServeContent(c.Writer, c.Request, "", time.Time{}, bytes.NewReader(data))
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		respondError(c, "image_not_found")
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		respondError(c, "image_read_failed")
+		return
+	}
+	c.Header("ETag", contentETag([]byte(fmt.Sprintf("%s\x00%d\x00%d", path, info.Size(), info.ModTime().UnixNano()))))
+	http.ServeContent(c.Writer, c.Request, "", info.ModTime(), file)
+}
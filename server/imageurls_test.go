@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestServeAvatarRevalidates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oldSecret, oldTTL := config.ImageURLSecret, config.ImageURLTTL
+	config.ImageURLSecret, config.ImageURLTTL = []byte("test-secret"), time.Minute
+	defer func() { config.ImageURLSecret, config.ImageURLTTL = oldSecret, oldTTL }()
+
+	db, err := gorm.Open(sqlite.Open("file:avatars?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "1-1-a.png"), filepath.Join(dir, "1-2-a.png")
+	for path, data := range map[string]string{first: "first avatar", second: "second avatar, replacing it"} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	user := User{Login: "alice", SessionID: "s1", ImagePath: first, AvatarRevision: 1}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/images/:kind/:id", func(c *gin.Context) { serveImage(db, c) })
+	url := signedImageURL(imageKindAvatar, user.ID)
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("")
+	if w.Code != http.StatusOK || w.Body.String() != "first avatar" {
+		t.Fatalf("first fetch: %d %q", w.Code, w.Body.String())
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != revalidateCacheControl {
+		t.Errorf("Cache-Control %q, want %q", cc, revalidateCacheControl)
+	}
+	etag := w.Header().Get("ETag")
+	if w := get(etag); w.Code != http.StatusNotModified {
+		t.Errorf("unchanged avatar: status %d, want 304", w.Code)
+	}
+
+	db.Model(&User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{"image_path": second, "avatar_revision": 2})
+	w = get(etag)
+	if w.Code != http.StatusOK || w.Body.String() != "second avatar, replacing it" {
+		t.Errorf("replaced avatar at the same URL: %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultQRScale = 8
+	maxQRScale     = 32
+)
+
+// loadInviteURL overrides the base of invite links from INVITE_URL, such as
+// an app's deep link scheme or a web page that opens it.
+func loadInviteURL() {
+	if base := os.Getenv("INVITE_URL"); base != "" {
+		config.InviteURL = base
+	}
+}
+
+// inviteLink is the link that joins gameID.
+func inviteLink(gameID string) string {
+	link, err := url.Parse(config.InviteURL)
+	if err != nil {
+		return config.InviteURL + "?game_id=" + url.QueryEscape(gameID)
+	}
+	query := link.Query()
+	query.Set("game_id", gameID)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// roomQR returns a PNG QR code of the room's invite link, for the host to
+// show so friends can join by scanning it. scale sets the pixels per
+// module.
+func roomQR(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "room_qr")
+
+	scale := defaultQRScale
+	if value := c.Query("scale"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxQRScale {
+			respondError(c, "invalid_qr_scale", maxQRScale)
+			return
+		}
+		scale = n
+	}
+
+	var settings RoomSettings
+	if err := db.Where("game_id = ?", c.Param("game_id")).First(&settings).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
+	}
+
+	link := inviteLink(settings.GameID)
+	qr, err := encodeQR([]byte(link))
+	if err != nil {
+		respondError(c, "qr_encode_failed")
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, qr.image(scale)); err != nil {
+		respondError(c, "qr_encode_failed")
+		return
+	}
+
+	etag := contentETag(buf.Bytes())
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Invite-Link", link)
+	if notModified(c, etag) {
+		return
+	}
+	c.Data(http.StatusOK, "image/png", buf.Bytes())
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IP_ALLOW and IP_DENY list networks, comma separated, in CIDR form or as
+// single addresses; IP_ALLOW_FILE and IP_DENY_FILE name files listing more,
+// one per line, with # comments. A client on a denied network is turned
+// away, and so is one outside every allowed network when any are listed.
+// The client is where the request came from unless that is one of
+// TrustedProxies, so a forwarded-for header can't talk its way past.
+// The files are read again on reload, so operators can block a network
+// without a proxy in front or a restart.
+type ipLists struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+var currentIPLists atomic.Pointer[ipLists]
+
+var ipBlocked = metrics.newCounter("http_ip_blocked_total", "Requests refused by the IP allow and deny lists.")
+
+func init() {
+	currentIPLists.Store(&ipLists{})
+}
+
+// permits reports whether ip may use the server.
+func (l *ipLists) permits(ip net.IP) bool {
+	for _, network := range l.deny {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	if len(l.allow) == 0 {
+		return true
+	}
+	for _, network := range l.allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadIPLists reads the lists from the environment and their files and, if
+// they all parse, puts them in place of the current ones.
+func loadIPLists() (*ipLists, error) {
+	allow, err := readIPList("IP_ALLOW", "IP_ALLOW_FILE")
+	if err != nil {
+		return nil, err
+	}
+	deny, err := readIPList("IP_DENY", "IP_DENY_FILE")
+	if err != nil {
+		return nil, err
+	}
+	lists := &ipLists{allow: allow, deny: deny}
+	currentIPLists.Store(lists)
+	return lists, nil
+}
+
+func readIPList(env, fileEnv string) ([]*net.IPNet, error) {
+	entries := strings.Split(os.Getenv(env), ",")
+	if path := os.Getenv(fileEnv); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			entries = append(entries, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		network, err := parseNetwork(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", env, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// parseNetwork reads a CIDR network, or a single address as a network of
+// one.
+func parseNetwork(entry string) (*net.IPNet, error) {
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		return network, err
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q", entry)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// filterIPs turns away requests from networks the lists don't permit.
+func filterIPs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := net.ParseIP(c.ClientIP())
+		if ip != nil && !currentIPLists.Load().permits(ip) {
+			ipBlocked.Inc()
+			abortWithError(c, "ip_blocked")
+			return
+		}
+		c.Next()
+	}
+}
+
+// reloadIPLists rereads the lists here and, unless ws is false, on the
+// WebSocket server too; ws_updated says whether that worked. Lists that
+// don't parse leave the current ones in place.
+func reloadIPLists(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_ip_lists")
+
+	var json struct {
+		WS *bool `json:"ws"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil && err != io.EOF {
+		respondError(c, "invalid_request")
+		return
+	}
+
+	lists, err := loadIPLists()
+	if err != nil {
+		respondError(c, "invalid_ip_list", err)
+		return
+	}
+
+	wsUpdated := false
+	if json.WS == nil || *json.WS {
+		var result struct{}
+		if err := postWS("/ip-lists/reload", gin.H{}, &result); err != nil {
+			errorf("Failed to reload the WebSocket server's IP lists: %v", err)
+		} else {
+			wsUpdated = true
+		}
+	}
+
+	recordAudit(db, c, "reload_ip_lists", "*", fmt.Sprintf("allow=%d deny=%d ws_updated=%t", len(lists.allow), len(lists.deny), wsUpdated))
+	c.JSON(http.StatusOK, gin.H{"allow": len(lists.allow), "deny": len(lists.deny), "ws_updated": wsUpdated})
+}
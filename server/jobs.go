@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sweepInterval is how often the orphan sweeper runs.
+const sweepInterval = 10 * time.Minute
+
+// JobLease marks which instance currently runs a periodic job. Every
+// instance tries to take or renew the lease before each run, so when several
+// share the database exactly one of them does the work, and another takes
+// over once the holder stops renewing.
+type JobLease struct {
+	Name      string `gorm:"primaryKey"`
+	Holder    string `gorm:"not null"`
+	ExpiresAt time.Time
+}
+
+// loadInstanceID names this process for job leases, from INSTANCE_ID or
+// else the host name and pid.
+func loadInstanceID() string {
+	if id := os.Getenv("INSTANCE_ID"); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "server"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// acquireLease takes the named lease for holder until ttl from now. It
+// succeeds when the lease is free, expired or already held by holder.
+func acquireLease(db *gorm.DB, name, holder string, ttl time.Duration) bool {
+	now := time.Now()
+	renewed := db.Model(&JobLease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", name, holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": now.Add(ttl)})
+	if renewed.Error == nil && renewed.RowsAffected > 0 {
+		return true
+	}
+	created := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&JobLease{
+		Name:      name,
+		Holder:    holder,
+		ExpiresAt: now.Add(ttl),
+	})
+	return created.Error == nil && created.RowsAffected > 0
+}
+
+// runPeriodic runs job every interval for as long as this instance holds
+// the job's lease. The lease outlives a couple of intervals so a slow run
+// doesn't hand it over, but a dead holder is replaced soon after.
+func runPeriodic(db *gorm.DB, name string, interval time.Duration, job func(db *gorm.DB)) {
+	db = withOperation(db, "job_"+name)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		// A run can't take longer than the interval, or a stuck one would
+		// hold the lease through every run after it.
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if acquireLease(db.WithContext(ctx), name, config.InstanceID, 3*interval) {
+			job(db.WithContext(ctx))
+		}
+		cancel()
+	}
+}
+
+// sweepOrphans deletes per-game rows left behind by games that were closed
+// without going through closeGame, such as WebSocket registry entries
+// written after the game ended.
+func sweepOrphans(db *gorm.DB) {
+	live := db.Model(&RoomSettings{}).Select("game_id")
+	for _, model := range []interface{}{&ChatImage{}, &WSMember{}} {
+		result := db.Where("game_id NOT IN (?)", live).Delete(model)
+		if result.Error != nil {
+			errorf("Orphan sweep failed: %v", result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			infof("Swept %d orphaned %T rows", result.RowsAffected, model)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// leaderboardWeek is the window of the weekly leaderboard.
+const leaderboardWeek = 7 * 24 * time.Hour
+
+// leaderboardEntry is one player's standing. Players are ranked by games
+// won, then by votes their cards received; players level on both share a
+// rank.
+type leaderboardEntry struct {
+	Rank  int    `json:"rank"`
+	Login string `json:"login"`
+	Wins  int    `json:"wins"`
+	Votes int    `json:"votes"`
+	Games int    `json:"games"`
+}
+
+// leaderboard ranks every player with a finished game, or with one that
+// ended within the last week. Players are told apart by login, as in
+// userStats, since their user rows go when they exit. A player who sends
+// their session as a bearer token also gets their own standing, wherever it
+// falls in the pages.
+func leaderboard(db *gorm.DB, c *gin.Context, window time.Duration) {
+	db = readReplica(withRequest(db, c, "leaderboard"))
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		respondError(c, "invalid_limit")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, "invalid_offset")
+		return
+	}
+
+	board := db.Model(&GamePlayer{}).
+		Joins("JOIN game_summaries ON game_summaries.game_id = game_players.game_id").
+		Select("game_players.login AS login, COUNT(*) AS games, " +
+			"SUM(CASE WHEN game_summaries.winner = game_players.login THEN 1 ELSE 0 END) AS wins, " +
+			"COALESCE(SUM(game_players.score), 0) AS votes").
+		Group("game_players.login")
+	if window > 0 {
+		board = board.Where("game_summaries.ended_at >= ?", time.Now().Add(-window))
+	}
+	ranked := db.Table("(?) AS board", board).
+		Select("login, games, wins, votes, RANK() OVER (ORDER BY wins DESC, votes DESC) AS rank")
+
+	var total int64
+	if err := db.Table("(?) AS board", board).Count(&total).Error; err != nil {
+		respondError(c, "leaderboard_failed")
+		return
+	}
+	entries := []leaderboardEntry{}
+	err = db.Table("(?) AS ranked", ranked).
+		Order("rank, login").
+		Limit(limit).
+		Offset(offset).
+		Scan(&entries).Error
+	if err != nil {
+		respondError(c, "leaderboard_failed")
+		return
+	}
+
+	result := gin.H{"entries": entries, "total": total, "limit": limit, "offset": offset}
+	if user, ok := optionalSessionUserFrom(c); ok {
+		var own []leaderboardEntry
+		if err := db.Table("(?) AS ranked", ranked).Where("login = ?", user.Login).Scan(&own).Error; err != nil {
+			respondError(c, "leaderboard_failed")
+			return
+		}
+		if len(own) > 0 {
+			result["me"] = own[0]
+		} else {
+			result["me"] = nil
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
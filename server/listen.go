@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// splitAddrs parses a comma-separated list of listen addresses.
+func splitAddrs(value string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// listen opens a listener for addr, which is either a TCP host:port or
+// unix:/path/to/socket. A stale socket file left by a previous run is
+// removed first.
+func listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		// Let a reverse proxy running as another user connect.
+		if err := os.Chmod(path, 0o660); err != nil {
+			ln.Close()
+			return nil, err
+		}
+		return ln, nil
+	}
+	return net.Listen("tcp", addr)
+}
+
+// serve serves handler on every address and returns the first error any of
+// them hits.
+func serve(handler http.Handler, addrs []string) error {
+	errs := make(chan error, len(addrs))
+	for _, addr := range addrs {
+		ln, err := listen(addr)
+		if err != nil {
+			return err
+		}
+		go func() { errs <- http.Serve(ln, handler) }()
+	}
+	return <-errs
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// lobbyPollInterval is how often an open lobby stream checks for new
+	// events.
+	lobbyPollInterval = time.Second
+	// lobbyKeepAlive is how long a quiet lobby stream waits before sending a
+	// comment to keep proxies from closing it.
+	lobbyKeepAlive = 15 * time.Second
+	// lobbyEventTTL is how long lobby events are kept for streams resuming
+	// with Last-Event-ID.
+	lobbyEventTTL = time.Hour
+)
+
+const (
+	lobbyRoomCreated = "room_created"
+	lobbyRoomFilled  = "room_filled"
+	lobbyRoomStarted = "room_started"
+	lobbyRoomClosed  = "room_closed"
+)
+
+// LobbyEvent is a change to the room list. Events go through the database
+// like everything else the instances share, so a stream sees rooms created
+// on any of them.
+type LobbyEvent struct {
+	ID         uint   `gorm:"primaryKey"`
+	Type       string `gorm:"not null"`
+	GameID     string `gorm:"not null"`
+	Name       string
+	Players    int64     `gorm:"not null"`
+	Capacity   int       `gorm:"not null"`
+	OccurredAt time.Time `gorm:"not null;index"`
+}
+
+// publishLobbyEvent records a change to gameID's room along with how full it
+// is now.
+func publishLobbyEvent(db *gorm.DB, eventType, gameID string) {
+	var players int64
+	db.Model(&Room{}).Where("game_id = ?", gameID).Count(&players)
+	var settings RoomSettings
+	db.Where("game_id = ?", gameID).Limit(1).Find(&settings)
+	event := LobbyEvent{
+		Type:       eventType,
+		GameID:     gameID,
+		Name:       settings.Name,
+		Players:    players,
+		Capacity:   settings.capacity(),
+		OccurredAt: time.Now(),
+	}
+	if err := db.Create(&event).Error; err != nil {
+		errorf("Failed to publish %s for game_id %s: %v", eventType, gameID, err)
+	}
+}
+
+// lobbyEvents streams room list changes as server-sent events, for clients
+// that want to follow the lobby without a WebSocket connection. A new stream
+// starts with whatever happens next; one resuming after Last-Event-ID or the
+// from parameter first gets what it missed, as far back as lobbyEventTTL.
+func lobbyEvents(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "lobby_events")
+
+	from := c.GetHeader("Last-Event-ID")
+	if from == "" {
+		from = c.Query("from")
+	}
+	var after uint64
+	if from != "" {
+		var err error
+		if after, err = strconv.ParseUint(from, 10, 64); err != nil {
+			respondError(c, "invalid_event_id")
+			return
+		}
+	} else {
+		var last LobbyEvent
+		if err := db.Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+			respondError(c, "events_failed")
+			return
+		}
+		after = uint64(last.ID)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	poll := time.NewTicker(lobbyPollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		select {
+		case <-poll.C:
+		case <-ctx.Done():
+			return
+		}
+
+		var events []LobbyEvent
+		if err := db.Where("id > ?", after).Order("id").Find(&events).Error; err != nil {
+			errorf("Lobby stream poll failed: %v", err)
+			continue
+		}
+		for _, event := range events {
+			after = uint64(event.ID)
+			payload, err := json.Marshal(gin.H{
+				"game_id":     event.GameID,
+				"name":        event.Name,
+				"players":     event.Players,
+				"capacity":    event.Capacity,
+				"occurred_at": event.OccurredAt,
+			})
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+		}
+		if len(events) == 0 && time.Since(lastWrite) < lobbyKeepAlive {
+			continue
+		}
+		if len(events) == 0 {
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		}
+		c.Writer.Flush()
+		lastWrite = time.Now()
+	}
+}
+
+// sweepLobbyEvents drops events too old for any stream to resume from.
+func sweepLobbyEvents(db *gorm.DB) {
+	db.Where("occurred_at < ?", time.Now().Add(-lobbyEventTTL)).Delete(&LobbyEvent{})
+}
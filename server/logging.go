@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// logLevel is how severe a log message is. Messages below the current level
+// are dropped; LOG_LEVEL sets it at startup and admins can change it while
+// the server runs, to turn on debug output while looking into a problem.
+type logLevel int32
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+var currentLogLevel atomic.Int32
+
+func init() {
+	currentLogLevel.Store(int32(levelInfo))
+}
+
+func (l logLevel) String() string {
+	return logLevelNames[l]
+}
+
+func parseLogLevel(name string) (logLevel, bool) {
+	for i, n := range logLevelNames {
+		if strings.EqualFold(name, n) {
+			return logLevel(i), true
+		}
+	}
+	return 0, false
+}
+
+// loadLogLevel sets the starting level from LOG_LEVEL.
+func loadLogLevel() {
+	name := os.Getenv("LOG_LEVEL")
+	if name == "" {
+		return
+	}
+	level, ok := parseLogLevel(name)
+	if !ok {
+		log.Printf("Ignoring unknown LOG_LEVEL %q", name)
+		return
+	}
+	currentLogLevel.Store(int32(level))
+}
+
+func logAt(level logLevel, format string, args ...interface{}) {
+	if int32(level) < currentLogLevel.Load() {
+		return
+	}
+	log.Output(3, strings.ToUpper(level.String())+" "+fmt.Sprintf(format, args...))
+}
+
+func debugf(format string, args ...interface{}) { logAt(levelDebug, format, args...) }
+func infof(format string, args ...interface{})  { logAt(levelInfo, format, args...) }
+func warnf(format string, args ...interface{})  { logAt(levelWarn, format, args...) }
+func errorf(format string, args ...interface{}) { logAt(levelError, format, args...) }
+
+// setWSLogLevel changes the WebSocket server's log level.
+func setWSLogLevel(level logLevel) error {
+	var result struct {
+		Level string `json:"level"`
+	}
+	return postWS("/log-level", gin.H{"level": level.String()}, &result)
+}
+
+func getLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": logLevel(currentLogLevel.Load()).String()})
+}
+
+// setLogLevel changes the log level here and, unless ws is false, on the
+// WebSocket server too; ws_updated says whether that worked.
+func setLogLevel(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_log_level")
+
+	var json struct {
+		Level string `json:"level"`
+		WS    *bool  `json:"ws"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+	level, ok := parseLogLevel(json.Level)
+	if !ok {
+		respondError(c, "invalid_log_level")
+		return
+	}
+
+	previous := logLevel(currentLogLevel.Swap(int32(level)))
+	log.Printf("Admin changed log level from %s to %s", previous, level)
+
+	wsUpdated := false
+	if json.WS == nil || *json.WS {
+		if err := setWSLogLevel(level); err != nil {
+			errorf("Failed to change the WebSocket server's log level: %v", err)
+		} else {
+			wsUpdated = true
+		}
+	}
+
+	recordAudit(db, c, "set_log_level", "*", fmt.Sprintf("level=%s ws_updated=%t", level, wsUpdated))
+	c.JSON(http.StatusOK, gin.H{"level": level.String(), "ws_updated": wsUpdated})
+}
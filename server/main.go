@@ -1,37 +1,146 @@
 package main
 
 import (
-	"encoding/base64"
+	"encoding/json"
 	"bufio"
+	"context"
+	"io"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-var lock = &sync.Mutex{}
-
 type Config struct {
-	DatabaseURI       string
+	DatabaseURI string
+	// DatabaseMode is file, or memory or temp for a throwaway database.
+	DatabaseMode      string
 	UploadFolder      string
 	UploadCards       string
 	AllowedExtensions map[string]bool
+	MaxUploadBytes    int64
+	MaxImageWidth     int
+	MaxImageHeight    int
+	MaxDeckCards      int
+	AdminToken        string
+	// ServiceToken is shared with the WebSocket server, which presents it on
+	// the routes only it may call. Neither service starts without it.
+	ServiceToken string
+	// CardMaxWidth and CardMaxHeight bound the stored size of custom card
+	// images, which are re-encoded at CardQuality when they're ingested.
+	CardMaxWidth  int
+	CardMaxHeight int
+	CardQuality   int
+	// Listen holds the addresses the API is served on: host:port or
+	// unix:/path. With AdminListen set, the admin API and metrics are only
+	// served there instead.
+	Listen      []string
+	AdminListen string
+	// ImageURLSecret signs the expiring image URLs handed to room members.
+	ImageURLSecret []byte
+	ImageURLTTL    time.Duration
+	// DefaultRoomCapacity is the player cap of a new room; hosts can change
+	// it up to MaxRoomCapacity.
+	DefaultRoomCapacity int
+	MaxRoomCapacity     int
+	// UploadsPerMinute and UploadsPerDay cap how many images one uploader
+	// can send; a deck counts once per card, so the per-minute cap has to
+	// leave room for a full deck.
+	UploadsPerMinute int
+	UploadsPerDay    int
+	// DeckQuotaBytes caps the total size of the card images in each
+	// player's custom decks.
+	DeckQuotaBytes int64
+	// InstanceID identifies this process when several share the database,
+	// so only one of them runs the periodic jobs.
+	InstanceID string
+	// GameIDLength and GameIDAlphabet shape new game codes; with
+	// GameIDNumeric rooms get digit-only codes unless the host asks
+	// otherwise.
+	GameIDLength   int
+	GameIDAlphabet string
+	GameIDNumeric  bool
+	// InviteURL is the base of the links in invite QR codes; the game ID is
+	// added as the game_id parameter.
+	InviteURL string
+	// WSURL is where the WebSocket server takes admin requests, which carry
+	// AdminToken.
+	WSURL string
+	// RegionNetworks places clients that don't hint a region, for
+	// quick-match to prefer rooms hosted nearby.
+	RegionNetworks []regionNetwork
+	// SuspendTTL is how long the players of a suspended game have to
+	// resume it.
+	SuspendTTL time.Duration
+	// ObserveModerated tells players when an admin is watching their game,
+	// unless the admin opts out when asking to.
+	ObserveModerated bool
+	// HandlerTimeout bounds the database and file work of a request, and
+	// TransferTimeout that of one moving images in or out.
+	HandlerTimeout  time.Duration
+	TransferTimeout time.Duration
+	// MaxBodyBytes caps the request bodies of most routes,
+	// MaxDeckBodyBytes those of the routes taking a whole deck as JSON and
+	// MaxEventBodyBytes the WebSocket server's batches of game events.
+	MaxBodyBytes      int64
+	MaxDeckBodyBytes  int64
+	MaxEventBodyBytes int64
+	// TrustedProxies are the proxies whose X-Forwarded-For is believed when
+	// working out a client's address; by default none are.
+	TrustedProxies []string
 }
 
+const (
+	maxCardsPerRequest = 10
+	handSize           = 6
+)
+
 var config = Config{
 	DatabaseURI:       "users10.db",
 	UploadFolder:      "uploads",
 	UploadCards:       "cards",
 	AllowedExtensions: map[string]bool{"png": true, "jpg": true, "jpeg": true},
+	MaxUploadBytes:    5 << 20,
+	MaxImageWidth:     4096,
+	MaxImageHeight:    4096,
+	MaxDeckCards:      200,
+	CardMaxWidth:      1024,
+	CardMaxHeight:     1024,
+	CardQuality:       80,
+	Listen:            []string{":8080"},
+	ImageURLTTL:       defaultImageURLTTL,
+
+	DefaultRoomCapacity: 4,
+	MaxRoomCapacity:     8,
+
+	UploadsPerMinute: 250,
+	UploadsPerDay:    2000,
+	DeckQuotaBytes:   100 << 20,
+
+	GameIDLength:   6,
+	GameIDAlphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+
+	InviteURL: "memebattle://join",
+
+	WSURL: "http://localhost:8765",
+
+	SuspendTTL: defaultSuspendTTL,
+
+	HandlerTimeout:  defaultHandlerTimeout,
+	TransferTimeout: defaultTransferTimeout,
+
+	MaxBodyBytes:      defaultMaxBodyBytes,
+	MaxDeckBodyBytes:  defaultMaxDeckBodyBytes,
+	MaxEventBodyBytes: defaultMaxEventBodyBytes,
 }
 
 type User struct {
@@ -39,6 +148,11 @@ type User struct {
 	Login     string `gorm:"unique;not null"`
 	ImagePath string `gorm:"not null"`
 	SessionID string `gorm:"unique;default:'0'"`
+	// Region is roughly where the session connects from, if known.
+	Region string
+	// AvatarRevision goes up every time the avatar changes, so clients can
+	// keep the one they have until it does.
+	AvatarRevision uint `gorm:"not null;default:1"`
 }
 
 type Room struct {
@@ -49,86 +163,494 @@ type Room struct {
 }
 
 type Situation struct {
-	ID   uint   `gorm:"primaryKey"`
-	Text string `gorm:"not null"`
+	ID       uint   `gorm:"primaryKey"`
+	Text     string `gorm:"not null"`
+	Language string `gorm:"not null;default:'en'"`
+	Pack     string `gorm:"not null;default:'default'"`
+	// LastServedAt is when any game last got the situation, zero if none
+	// has.
+	LastServedAt time.Time
+}
+
+type GameSituation struct {
+	ID          uint   `gorm:"primaryKey"`
+	GameID      string `gorm:"not null;index"`
+	SituationID uint   `gorm:"not null"`
+	Custom      bool   `gorm:"not null;default:false"`
+	CreatedAt   time.Time
+}
+
+type DealtCard struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameID    string `gorm:"not null;index"`
+	CardID    uint   `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+type RoomSettings struct {
+	ID            uint   `gorm:"primaryKey"`
+	GameID        string `gorm:"unique;not null"`
+	Name          string
+	Language      string
+	Pack          string
+	CardPack      string
+	CardTags      string
+	ExcludedPacks string
+	SituationDeck uint
+	CustomDeck    uint
+	CustomRatio   float64
+	// FavoritesRatio is the share of standard cards dealt from those the
+	// room's members have favorited, when there are enough.
+	FavoritesRatio float64
+	HostSession    string
+	Round          int
+	Capacity       int
+	// Region is the host's region when the room was created.
+	Region    string
+	CreatedAt time.Time
 }
 
 type Card struct {
 	ID      uint   `gorm:"primaryKey"`
 	ImgPath string `gorm:"not null"`
+	Pack    string `gorm:"not null;default:'default';index"`
+	Tags    string
 }
 
+// customDeck is a card of a custom deck. Its image is kept in CardImg, or
+// in the file at ImgPath once migrate-data has moved it out of the database.
 type customDeck struct {
 	ID      uint   `gorm:"primaryKey"`
 	CardImg []byte `gorm:"not null"`
+	ImgPath string
 	DeckId  uint   `gorm:"not null"`
 	GameId  string `gorm:"not null"`
 }
 
+type customSituationDeck struct {
+	ID     uint   `gorm:"primaryKey"`
+	Text   string `gorm:"not null"`
+	DeckId uint   `gorm:"not null;index"`
+	GameId string `gorm:"not null"`
+}
+
+type DealtCustomCard struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameID    string `gorm:"not null;index"`
+	SessionID string `gorm:"not null"`
+	DeckID    uint   `gorm:"not null"`
+	CardID    uint   `gorm:"not null"`
+	// Round is the round of the game the card was dealt in.
+	Round     int
+	CreatedAt time.Time
+}
+
+// HandCard records a card dealt to a session in a round of a game. Exactly
+// one of CardID and CustomCardID is set.
+type HandCard struct {
+	ID           uint   `gorm:"primaryKey"`
+	GameID       string `gorm:"not null;index:idx_hand"`
+	SessionID    string `gorm:"not null;index:idx_hand"`
+	Round        int    `gorm:"not null"`
+	CardID       uint
+	CustomCardID uint
+	Played       bool `gorm:"not null;default:false"`
+	CreatedAt    time.Time
+}
+
+type DeckPreview struct {
+	ID        uint   `gorm:"primaryKey"`
+	DeckId    uint   `gorm:"unique;not null"`
+	GameId    string `gorm:"not null;index"`
+	CardCount int    `gorm:"not null"`
+	Thumbnail []byte
+	// Creator is the login of the player who uploaded the deck and Bytes the
+	// size of its card images, counted against the creator's quota.
+	Creator   string `gorm:"index"`
+	Bytes     int64  `gorm:"not null;default:0"`
+	CreatedAt time.Time
+}
+
 func main() {
-	db, err := gorm.Open(sqlite.Open(config.DatabaseURI), &gorm.Config{})
+	if len(os.Args) > 1 && os.Args[1] == "migrate-data" {
+		runMigrateData(os.Args[2:])
+		return
+	}
+
+	loadLogLevel()
+	loadLogFiles()
+	config.AdminToken = os.Getenv("ADMIN_TOKEN")
+	config.ServiceToken = os.Getenv("SERVICE_TOKEN")
+	if config.ServiceToken == "" {
+		log.Fatalf("SERVICE_TOKEN must be set to the token the WebSocket server uses")
+	}
+	if listen := splitAddrs(os.Getenv("LISTEN")); len(listen) > 0 {
+		config.Listen = listen
+	}
+	config.AdminListen = os.Getenv("ADMIN_LISTEN")
+	config.ImageURLSecret = loadImageURLSecret()
+	loadUploadPolicy()
+	loadUploadRates()
+	loadDeckQuota()
+	loadRoomCapacity()
+	config.InstanceID = loadInstanceID()
+	loadInviteURL()
+	loadGameIDs()
+	loadWSURL()
+	loadFlagDefaults()
+	loadRegions()
+	loadSuspendTTL()
+	loadObserveModerated()
+	loadCardCache()
+	loadHandlerTimeouts()
+	loadBodyLimits()
+	if ttl, err := time.ParseDuration(os.Getenv("IMAGE_URL_TTL")); err == nil && ttl > 0 {
+		config.ImageURLTTL = ttl
+	}
+
+	config.TrustedProxies = splitList(os.Getenv("TRUSTED_PROXIES"))
+	if _, err := loadIPLists(); err != nil {
+		log.Fatalf("Failed to load the IP lists: %v", err)
+	}
+	if err := loadDatabaseMode(); err != nil {
+		log.Fatalf("Failed to set up the database: %v", err)
+	}
+	db, err := openDatabase(config.DatabaseURI)
 	if err != nil {
 		panic("failed to connect to database")
 	}
+	if err := prepareDatabase(db); err != nil {
+		panic("failed to configure database connections")
+	}
+
+	if err := db.Use(dbMetricsPlugin{}); err != nil {
+		panic("failed to register database metrics")
+	}
+	if err := openReplicas(); err != nil {
+		panic("failed to connect to database replicas")
+	}
+
+	db.AutoMigrate(&User{}, &Room{}, &Situation{}, &Card{}, &customDeck{}, &GameSituation{}, &RoomSettings{}, &DealtCard{}, &Pack{}, &PackEntitlement{}, &PackCode{}, &DeckPreview{}, &customSituationDeck{}, &DealtCustomCard{}, &HandCard{}, &GamePlayer{}, &Vote{}, &GameSummary{}, &GameEvent{}, &ChatImage{}, &WSMember{}, &JobLease{}, &DeckUpload{}, &DeckUploadCard{}, &ResumableUpload{}, &LobbyEvent{}, &AuditEntry{}, &Maintenance{}, &FeatureFlag{}, &SessionActivity{}, &PlayerPeak{}, &SuspendedGame{}, &DailyChallenge{}, &DailyHandCard{}, &DailySubmission{}, &DailyVote{}, &CardFavorite{}, &ShadowBan{})
+
+	if err := reloadFlags(withOperation(db, "flags_reload")); err != nil {
+		panic("failed to load feature flags")
+	}
 
-	db.AutoMigrate(&User{}, &Room{}, &Situation{}, &Card{}, &customDeck{})
+	registerActivityMetrics(db)
 
-	populateSituations(db)
-	testCards(db)
+	if err := seedDatabase(db); err != nil {
+		log.Fatalf("Failed to seed the database: %v", err)
+	}
 
 	r := gin.Default()
-	r.POST("/register", func(c *gin.Context) { register(db, c) })
-	r.POST("/user-info", func(c *gin.Context) { reload(db, c) })
+	if err := r.SetTrustedProxies(config.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	r.Use(filterIPs(), requestDeadline(), limitBody())
+	r.POST("/register", rejectDuringMaintenance(db), func(c *gin.Context) { register(db, c) })
+	r.POST("/user-info", requireBodySession(db), reload)
+	r.GET("/me", requireSession(db), me)
+	r.PUT("/me/avatar", requireSession(db), func(c *gin.Context) { updateAvatar(db, c) })
+	r.GET("/me/favorites", requireSession(db), func(c *gin.Context) { listFavorites(db, c) })
+	r.PUT("/me/favorites/:card_id", requireSession(db), func(c *gin.Context) { addFavorite(db, c) })
+	r.DELETE("/me/favorites/:card_id", requireSession(db), func(c *gin.Context) { removeFavorite(db, c) })
+	r.GET("/users", func(c *gin.Context) { listUsers(db, c) })
 	r.GET("/text", func(c *gin.Context) { getText(db, c) })
 	r.GET("/cards", func(c *gin.Context) { getCard(db, c) })
-	r.POST("/exit", func(c *gin.Context) { exit(db, c) })
+	r.POST("/exit", requireBodySession(db), func(c *gin.Context) { exit(db, c) })
 	r.POST("/disconnect", func(c *gin.Context) { disconnect(db, c) })
-	r.POST("/connect", func(c *gin.Context) { connect(db, c) })
+	r.POST("/connect", requireBodySession(db), func(c *gin.Context) { connect(db, c) })
 	r.GET("/room-stats", func(c *gin.Context) { roomStats(db, c) })
-	r.POST("/host", func(c *gin.Context) { host(db, c) })
-	r.POST("/createCustomDeck", func(c *gin.Context) { CreateCustomDeck(db, c) })
-	r.POST("/generateRandomCustomDeck", func(c *gin.Context) { GenerateRandomCustomDeck(db, c) })
+	r.GET("/lobby/events", func(c *gin.Context) { lobbyEvents(db, c) })
+	r.POST("/host", rejectDuringMaintenance(db), requireBodySession(db), func(c *gin.Context) { host(db, c) })
+	r.POST("/quick-match", func(c *gin.Context) { quickMatch(db, c) })
+	r.POST("/createCustomDeck", requireFeature(flagCustomDecks), func(c *gin.Context) { CreateCustomDeck(db, c) })
+	r.POST("/generateRandomCustomDeck", requireFeature(flagCustomDecks), func(c *gin.Context) { GenerateRandomCustomDeck(db, c) })
+	r.POST("/createCustomSituationDeck", requireFeature(flagCustomDecks), func(c *gin.Context) { CreateCustomSituationDeck(db, c) })
+	r.POST("/room/deck-mode", func(c *gin.Context) { setDeckMode(db, c) })
+	r.GET("/room/:game_id", optionalSession(db), func(c *gin.Context) { roomDetail(db, c) })
+	r.GET("/room/:game_id/host", requireService(), func(c *gin.Context) { roomHost(db, c) })
+	r.POST("/room/capacity", func(c *gin.Context) { setRoomCapacity(db, c) })
+	r.POST("/room/name", func(c *gin.Context) { setRoomName(db, c) })
+	r.POST("/room/suspend", func(c *gin.Context) { suspendGame(db, c) })
+	r.POST("/room/resume", rejectDuringMaintenance(db), func(c *gin.Context) { resumeGame(db, c) })
+	r.GET("/room/:game_id/scores", func(c *gin.Context) { roomScores(db, c) })
+	r.GET("/room/:game_id/qr", func(c *gin.Context) { roomQR(db, c) })
+	r.GET("/hand", func(c *gin.Context) { getHand(db, c) })
+	r.POST("/hand/play", requireService(), func(c *gin.Context) { playCard(db, c) })
+	r.GET("/hand/owner", requireService(), func(c *gin.Context) { cardOwner(db, c) })
+	r.GET("/decks", func(c *gin.Context) { listDecks(db, c) })
+	r.POST("/decks/uploads", requireFeature(flagCustomDecks), func(c *gin.Context) { startDeckUpload(db, c) })
+	r.PUT("/decks/uploads/:id/cards/:n", requireFeature(flagCustomDecks), func(c *gin.Context) { uploadDeckCard(db, c) })
+	r.POST("/decks/uploads/:id/finalize", requireFeature(flagCustomDecks), func(c *gin.Context) { finalizeDeckUpload(db, c) })
+	r.OPTIONS("/uploads", tusOptions)
+	r.POST("/uploads", func(c *gin.Context) { createResumable(db, c) })
+	r.HEAD("/uploads/:id", func(c *gin.Context) { resumableOffset(db, c) })
+	r.PATCH("/uploads/:id", func(c *gin.Context) { appendResumable(db, c) })
+	r.DELETE("/uploads/:id", func(c *gin.Context) { terminateResumable(db, c) })
+	r.GET("/decks/usage", func(c *gin.Context) { deckStorage(db, c) })
+	r.POST("/decks/clear", func(c *gin.Context) { clearDecks(db, c) })
+	r.GET("/decks/:id/thumbnail", func(c *gin.Context) { deckThumbnail(db, c) })
+	r.GET("/packs", func(c *gin.Context) { listPacks(db, c) })
+	r.POST("/packs/unlock", func(c *gin.Context) { unlockPack(db, c) })
+	r.POST("/votes", requireService(), func(c *gin.Context) { recordVote(db, c) })
+	r.POST("/events", requireService(), func(c *gin.Context) { storeEvents(db, c) })
+	r.GET("/features", listFeatures)
+	r.GET("/ws/features", wsFeatures)
+	r.GET("/ws/shadow-bans", requireService(), func(c *gin.Context) { wsShadowBans(db, c) })
+	r.GET("/ws/members", requireService(), func(c *gin.Context) { listWSMembers(db, c) })
+	r.PUT("/ws/games/:game_id/members", requireService(), func(c *gin.Context) { replaceWSMembers(db, c) })
+	r.POST("/ws/games/:game_id/empty", requireService(), func(c *gin.Context) { closeEmptyGame(db, c) })
+	r.POST("/ws/games/:game_id/rounds", requireService(), func(c *gin.Context) { startGameRound(db, c) })
+	r.GET("/games/recent", func(c *gin.Context) { recentGames(db, c) })
+	r.GET("/games/:game_id/replay", func(c *gin.Context) { replayGame(db, c) })
+	r.GET("/users/:login/stats", func(c *gin.Context) { userStats(db, c) })
+	r.GET("/leaderboard", optionalSession(db), func(c *gin.Context) { leaderboard(db, c, 0) })
+	r.GET("/daily", requireFeature(flagDailyChallenge), optionalSession(db), func(c *gin.Context) { daily(db, c) })
+	r.POST("/daily/submissions", requireFeature(flagDailyChallenge), requireSession(db), func(c *gin.Context) { submitDaily(db, c) })
+	r.POST("/daily/votes", requireFeature(flagDailyChallenge), requireSession(db), func(c *gin.Context) { voteDaily(db, c) })
+	r.GET("/daily/:id/submissions", requireFeature(flagDailyChallenge), func(c *gin.Context) { dailySubmissions(db, c) })
+	r.GET("/leaderboard/weekly", optionalSession(db), func(c *gin.Context) { leaderboard(db, c, leaderboardWeek) })
+	r.GET("/images/:kind/:id", func(c *gin.Context) { serveImage(db, c) })
+	r.GET("/images/:kind/:id/:size", func(c *gin.Context) { serveImage(db, c) })
+	r.POST("/chat/images", func(c *gin.Context) { uploadChatImage(db, c) })
+	r.GET("/chat/images/:id", func(c *gin.Context) { chatImageURL(db, c) })
+
+	adminRouter := r
+	if config.AdminListen != "" {
+		adminRouter = gin.Default()
+		adminRouter.SetTrustedProxies(config.TrustedProxies)
+		adminRouter.Use(requestDeadline(), limitBody())
+	}
+	adminRouter.GET("/metrics", metricsHandler)
+
+	admin := adminRouter.Group("/admin", requireAdmin())
+	admin.GET("/cards", func(c *gin.Context) { listCards(db, c) })
+	admin.PATCH("/cards/:id", func(c *gin.Context) { updateCardMetadata(db, c) })
+	admin.PUT("/packs/:name", func(c *gin.Context) { updatePack(db, c) })
+	admin.POST("/pack-codes", func(c *gin.Context) { createPackCode(db, c) })
+	admin.GET("/games/:game_id/events", func(c *gin.Context) { listGameEvents(db, c) })
+	admin.POST("/rooms/:game_id/close", func(c *gin.Context) { forceCloseRoom(db, c) })
+	admin.POST("/rooms/:game_id/observe", func(c *gin.Context) { observeRoom(db, c) })
+	admin.GET("/audit", func(c *gin.Context) { listAudit(db, c) })
+	admin.POST("/announcements", func(c *gin.Context) { announce(db, c) })
+	admin.GET("/maintenance", func(c *gin.Context) { getMaintenance(db, c) })
+	admin.PUT("/maintenance", func(c *gin.Context) { setMaintenance(db, c) })
+	admin.GET("/activity", func(c *gin.Context) { activityStats(db, c) })
+	admin.GET("/flags", func(c *gin.Context) { listFlags(db, c) })
+	admin.PUT("/flags/:name", func(c *gin.Context) { setFlag(db, c) })
+	admin.DELETE("/flags/:name", func(c *gin.Context) { resetFlag(db, c) })
+	admin.GET("/shadow-bans", func(c *gin.Context) { listShadowBans(db, c) })
+	admin.PUT("/shadow-bans/:login", func(c *gin.Context) { setShadowBan(db, c) })
+	admin.DELETE("/shadow-bans/:login", func(c *gin.Context) { liftShadowBan(db, c) })
+	admin.POST("/ip-lists/reload", func(c *gin.Context) { reloadIPLists(db, c) })
+	admin.GET("/log-level", getLogLevel)
+	admin.PUT("/log-level", func(c *gin.Context) { setLogLevel(db, c) })
 
 	os.MkdirAll(config.UploadFolder, os.ModePerm)
-	r.Run(":8080")
+
+	go runFlagReloader(db)
+	go runPeriodic(db, "sweep_orphans", sweepInterval, sweepOrphans)
+	go runPeriodic(db, "sweep_deck_uploads", sweepInterval, sweepDeckUploads)
+	go runPeriodic(db, "sweep_resumable_uploads", sweepInterval, sweepResumableUploads)
+	go runPeriodic(db, "sweep_lobby_events", sweepInterval, sweepLobbyEvents)
+	go runPeriodic(db, "sweep_idle_rooms", sweepInterval, sweepIdleRooms)
+	go runPeriodic(db, "sweep_activity", sweepInterval, sweepActivity)
+	go runPeriodic(db, "sweep_suspended_games", sweepInterval, sweepSuspendedGames)
+	go runPeriodic(db, "sample_players", playerSampleInterval, samplePlayers)
+
+	errs := make(chan error, 2)
+	go func() { errs <- serve(r, config.Listen) }()
+	if adminRouter != r {
+		go func() { errs <- serve(adminRouter, []string{config.AdminListen}) }()
+	}
+	log.Fatal(<-errs)
 }
 
 func CreateCustomDeck(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "deck_insert")
+
 	var request struct {
-		CardImgs [][]byte `json:"cardImgs"`
-		GameId   string   `json:"gameId"`
+		CardImgs  [][]byte `json:"cardImgs"`
+		GameId    string   `json:"gameId"`
+		SessionID string   `json:"sessionId"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, "invalid_request")
+		return
+	}
+	if len(request.CardImgs) > config.MaxDeckCards {
+		uerr := &uploadError{Code: uploadErrDeckSize, Args: []interface{}{config.MaxDeckCards}}
+		uerr.respond(c)
+		return
+	}
+	user, ok := sessionUser(db, c, request.SessionID)
+	if !ok {
+		return
+	}
+	if !allowUpload(c, request.SessionID, len(request.CardImgs)) {
 		return
 	}
+	for i, cardImg := range request.CardImgs {
+		if uerr := checkImage(cardImg); uerr != nil {
+			uerr.Card = i + 1
+			uerr.respond(c)
+			return
+		}
+		request.CardImgs[i] = normalizeCardImage(cardImg)
+	}
+	if !checkDeckQuota(db, c, user.Login, deckBytes(request.CardImgs)) {
+		return
+	}
+
+	newDeckId, code := storeCustomDeck(db, request.GameId, user.Login, request.CardImgs)
+	if code != "" {
+		respondError(c, code)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deckId": newDeckId})
+}
 
+// storeCustomDeck saves already validated card images as a new deck with its
+// preview. On failure it returns the error code to respond with.
+func storeCustomDeck(db *gorm.DB, gameID, creator string, cardImgs [][]byte) (uint, string) {
 	var maxDeckId struct {
 		MaxDeckId uint
 	}
 	if err := db.Model(&customDeck{}).Select("MAX(deck_id) as max_deck_id").Scan(&maxDeckId).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get max deck ID"})
-		return
+		return 0, "deck_id_failed"
 	}
 
 	newDeckId := maxDeckId.MaxDeckId + 1
 
-	for _, cardImg := range request.CardImgs {
+	for _, cardImg := range cardImgs {
 		if err := db.Create(&customDeck{
 			CardImg: cardImg,
 			DeckId:  newDeckId,
-			GameId:  request.GameId,
+			GameId:  gameID,
 		}).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create custom deck"})
+			return 0, "deck_create_failed"
+		}
+	}
+
+	thumbnail, err := makeCollage(cardImgs)
+	if err != nil {
+		errorf("Failed to build thumbnail for deck %d: %v", newDeckId, err)
+	}
+	db.Create(&DeckPreview{
+		DeckId:    newDeckId,
+		GameId:    gameID,
+		CardCount: len(cardImgs),
+		Thumbnail: thumbnail,
+		Creator:   creator,
+		Bytes:     deckBytes(cardImgs),
+	})
+	return newDeckId, ""
+}
+
+func listDecks(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "decks")
+
+	query := db.Model(&DeckPreview{})
+	if gameID := c.Query("gameId"); gameID != "" {
+		query = query.Where("game_id = ?", gameID)
+	}
+
+	var previews []DeckPreview
+	if err := query.Order("deck_id").Find(&previews).Error; err != nil {
+		respondError(c, "decks_failed")
+		return
+	}
+
+	decks := make([]gin.H, 0, len(previews))
+	for _, preview := range previews {
+		decks = append(decks, gin.H{
+			"deckId":    preview.DeckId,
+			"gameId":    preview.GameId,
+			"cardCount": preview.CardCount,
+			"thumbnail": preview.Thumbnail,
+			"createdAt": preview.CreatedAt,
+		})
+	}
+
+	conditionalJSON(c, decks)
+}
+
+func deckThumbnail(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "decks")
+
+	var preview DeckPreview
+	if err := db.Where("deck_id = ?", c.Param("id")).First(&preview).Error; err != nil || len(preview.Thumbnail) == 0 {
+		respondError(c, "thumbnail_not_found")
+		return
+	}
+
+	// Deck IDs can be reused once a deck is cleared, so clients revalidate
+	// instead of caching outright.
+	c.Header("Cache-Control", "no-cache")
+	if notModified(c, contentETag(preview.Thumbnail)) {
+		return
+	}
+	c.Data(http.StatusOK, "image/jpeg", preview.Thumbnail)
+}
+
+func CreateCustomSituationDeck(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "situation_deck_insert")
+
+	var request struct {
+		Texts  []string `json:"texts"`
+		GameId string   `json:"gameId"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+
+	var texts []string
+	for _, text := range request.Texts {
+		if text = strings.TrimSpace(text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		respondError(c, "deck_no_situations")
+		return
+	}
+
+	var maxDeckId struct {
+		MaxDeckId uint
+	}
+	if err := db.Model(&customSituationDeck{}).Select("MAX(deck_id) as max_deck_id").Scan(&maxDeckId).Error; err != nil {
+		respondError(c, "deck_id_failed")
+		return
+	}
+
+	newDeckId := maxDeckId.MaxDeckId + 1
+
+	for _, text := range texts {
+		if err := db.Create(&customSituationDeck{
+			Text:   text,
+			DeckId: newDeckId,
+			GameId: request.GameId,
+		}).Error; err != nil {
+			respondError(c, "situation_deck_create_failed")
 			return
 		}
 	}
 
+	if request.GameId != "" {
+		db.Model(&RoomSettings{}).Where("game_id = ?", request.GameId).Update("situation_deck", newDeckId)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"deckId": newDeckId})
 }
 
 func GenerateRandomCustomDeck(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "deck_deal")
+
 	var request struct {
 		DeckId    uint   `json:"deckId"`
 		GameId    string `json:"gameId"`
@@ -136,189 +658,601 @@ func GenerateRandomCustomDeck(db *gorm.DB, c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, "invalid_request")
 		return
 	}
 
-	var cards []customDeck
-	if err := db.Where("deck_id = ?", request.DeckId).Find(&cards).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cards"})
+	settings := loadRoomSettings(db, request.GameId)
+	selectedCards, dealt, err := newRepository(db).DealCustomHand(request.GameId, request.SessionID, settings.Round, request.DeckId, handSize)
+	if err == errNotEnoughRows {
+		respondError(c, "deck_too_small")
 		return
 	}
-
-	if len(cards) < 6 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Not enough cards in the deck"})
+	if err != nil {
+		respondError(c, "cards_failed")
 		return
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
-
-	selectedCards := cards[:6]
-
 	var cardImgs [][]byte
+	var cardIds []uint
+	var cardUrls []string
+	var hand []HandCard
 	for _, card := range selectedCards {
-		cardImgs = append(cardImgs, card.CardImg)
+		cardImg, err := customCardBytes(c.Request.Context(), card)
+		if err != nil {
+			respondError(c, "cards_failed")
+			return
+		}
+		cardImgs = append(cardImgs, cardImg)
+		cardIds = append(cardIds, card.ID)
+		cardUrls = append(cardUrls, signedImageURL(imageKindCard, card.ID))
+		hand = append(hand, HandCard{CustomCardID: card.ID})
+	}
+	if dealt {
+		recordHand(db, settings, request.SessionID, hand)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"cardImgs":  cardImgs,
+		"cardIds":   cardIds,
+		"cardUrls":  cardUrls,
+		"deckId":    request.DeckId,
 		"gameId":    request.GameId,
 		"sessionId": request.SessionID,
 	})
 }
 
 func register(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "register")
+
 	login := c.PostForm("login")
-	file, err := c.FormFile("image")
 
 	if login == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Login can't be empty"})
+		respondError(c, "login_empty")
 		return
 	}
 
 	var user User
 	if err := db.Where("login = ?", login).First(&user).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Login exists"})
+		respondError(c, "login_exists")
+		return
+	}
+	region, ok := clientRegion(c, c.PostForm("region"))
+	if !ok {
+		respondError(c, "invalid_region")
 		return
 	}
 
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get file"})
+	filename, data, ok := receiveAvatar(db, c, "")
+	if !ok {
 		return
 	}
 
-	if file != nil && allowedFile(file.Filename) {
-		filename := secureFilename(file.Filename)
-		imagePath := filepath.Join(config.UploadFolder, filename)
+	imagePath := filepath.Join(config.UploadFolder, filename)
+
+	if err := writeFileContext(c.Request.Context(), imagePath, data); err != nil {
+		respondError(c, "file_save_failed")
+		return
+	}
 
-		if err := c.SaveUploadedFile(file, imagePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	sessionID := uuid.New().String()
+	user = User{Login: login, ImagePath: imagePath, SessionID: sessionID, Region: region, AvatarRevision: 1}
+	// Create the new user
+	if err := db.Create(&user).Error; err != nil {
+		respondError(c, "user_create_failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"session_id": sessionID})
+}
+
+// reload is the old form of /me, taking the session as a POST field. It is
+// kept while clients move over and flags itself as deprecated.
+func reload(c *gin.Context) {
+	c.Header("Deprecation", "true")
+	c.Header("Link", `</me>; rel="successor-version"`)
+
+	userInfo(c, sessionUserFrom(c))
+}
+
+// me returns the authenticated player's profile.
+func me(c *gin.Context) {
+	userInfo(c, sessionUserFrom(c))
+}
+
+// userInfo returns a player's profile. A client that passes the
+// avatar_revision it has cached gets the avatar only if it has changed.
+func userInfo(c *gin.Context, user User) {
+	// The revision covers everything but image_url, which is re-signed on
+	// every call. A client answered 304 can still use its image_data; if
+	// its image_url has expired it asks again without If-None-Match.
+	revision := strconv.FormatUint(uint64(user.AvatarRevision), 10)
+	etag := contentETag([]byte(user.SessionID + "\x00" + user.Login + "\x00" + revision))
+	c.Header("Cache-Control", "no-cache")
+	if notModified(c, etag) {
+		return
+	}
+
+	known := c.Query("avatar_revision")
+	if known == "" {
+		known = c.PostForm("avatar_revision")
+	}
+	info := gin.H{
+		"session_id":      user.SessionID,
+		"login":           user.Login,
+		"avatar_revision": user.AvatarRevision,
+		"revision":        strings.Trim(etag, `"`),
+	}
+	stream := newImageStream()
+	if known != revision {
+		if _, err := os.Stat(user.ImagePath); err != nil {
+			respondError(c, "image_read_failed")
 			return
 		}
+		info["image_data"] = stream.file(user.ImagePath)
+		info["image_url"] = signedImageURL(imageKindAvatar, user.ID)
+	}
+	stream.respond(c, http.StatusOK, info)
+}
+
+// startGameRound counts the round the host just started. Hands and votes
+// from then on belong to it. Only the WebSocket server, holding the service
+// token, starts rounds.
+func startGameRound(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "ws_round_start")
+
+	gameID := c.Param("game_id")
+	result := db.Model(&RoomSettings{}).Where("game_id = ?", gameID).Update("round", gorm.Expr("round + 1"))
+	if result.Error != nil || result.RowsAffected == 0 {
+		respondError(c, "game_not_found")
+		return
+	}
+
+	var settings RoomSettings
+	db.Where("game_id = ?", gameID).First(&settings)
+	c.JSON(http.StatusOK, gin.H{"game_id": gameID, "round": settings.Round})
+}
+
+func getText(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "text")
+
+	gameID := c.Query("game_id")
+	filter := situationFilter{
+		Language: c.Query("lang"),
+		Pack:     c.Query("pack"),
+	}
+
+	var settings RoomSettings
+	if gameID != "" {
+		if err := db.Where("game_id = ?", gameID).First(&settings).Error; err == nil {
+			if filter.Language == "" {
+				filter.Language = settings.Language
+			}
+			if filter.Pack == "" {
+				filter.Pack = settings.Pack
+			}
+		}
+	}
 
-		sessionID := uuid.New().String()
-		user := User{Login: login, ImagePath: imagePath, SessionID: sessionID}
-		// Create the new user
-		if err := db.Create(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	if settings.SituationDeck != 0 {
+		text, err := newRepository(db).CustomSituationForGame(gameID, settings.SituationDeck)
+		if err != nil {
+			errorf("Error fetching custom situation from deck %d for game_id %s: %v", settings.SituationDeck, gameID, err)
+			respondError(c, "no_situations")
 			return
 		}
+		debugf("Fetched custom situation for game_id %s: %s", gameID, text)
+		c.JSON(http.StatusOK, gin.H{"text": text})
+		return
+	}
 
-		c.JSON(http.StatusCreated, gin.H{"session_id": sessionID})
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+	situation, err := newRepository(db).SituationForGame(gameID, filter)
+	if err != nil {
+		errorf("Error fetching random situation for game_id %s: %v", gameID, err)
+		respondError(c, "no_situations")
+		return
 	}
+	debugf("Fetched situation %d for game_id %s: %s", situation.ID, gameID, situation.Text)
+	c.JSON(http.StatusOK, gin.H{"text": situation.Text})
 }
 
-func reload(db *gorm.DB, c *gin.Context) {
-	sessionID := c.PostForm("session_id")
-	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing session_id"})
+func getCard(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "cards")
+
+	if c.Query("count") != "" {
+		getCards(db, c)
 		return
 	}
 
-	var user User
-	if err := db.Where("session_id = ?", sessionID).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	gameID := c.Query("game_id")
+	stream := newImageStream()
+	dealt, code := dealMixed(db, c, stream, gameID, 1)
+	if code != "" {
+		if code == "deck_too_small" || code == "cards_exhausted" {
+			code = "no_card_images"
+		}
+		respondError(c, code)
 		return
 	}
 
-	imageBytes, err := os.ReadFile(user.ImagePath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read image file"})
+	stream.respond(c, http.StatusOK, dealt[0])
+}
+
+func getCards(db *gorm.DB, c *gin.Context) {
+	gameID := c.Query("game_id")
+	count, err := strconv.Atoi(c.Query("count"))
+	if err != nil || count < 1 || count > maxCardsPerRequest {
+		respondError(c, "invalid_count")
 		return
 	}
 
-	b64image := base64.StdEncoding.EncodeToString(imageBytes)
-	c.JSON(http.StatusOK, gin.H{
-		"session_id": user.SessionID,
-		"login":      user.Login,
-		"image_data": b64image,
-	})
+	stream := newImageStream()
+	dealt, code := dealMixed(db, c, stream, gameID, count)
+	if code != "" {
+		respondError(c, code)
+		return
+	}
+
+	debugf("Dealt %d cards for game_id %s", len(dealt), gameID)
+	stream.respond(c, http.StatusOK, gin.H{"game_id": gameID, "cards": dealt})
 }
 
-func getText(db *gorm.DB, c *gin.Context) {
-	var situation Situation
-	if err := db.Order("RANDOM()").First(&situation).Error; err != nil {
-		log.Printf("Error fetching random situation: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "No situations available"})
+// dealMixed deals count cards for a game. When the host attached a custom
+// deck in mixed mode, roughly CustomRatio of them come from that deck and
+// the rest from the global pool, with FavoritesRatio of those drawn from the
+// members' favorite cards. It returns the dealt cards, whose images
+// are left for stream to send, or an error code.
+func dealMixed(db *gorm.DB, c *gin.Context, stream *imageStream, gameID string, count int) ([]gin.H, string) {
+	settings := loadRoomSettings(db, gameID)
+	repo := newRepository(db)
+
+	customCount := 0
+	if settings.CustomDeck != 0 {
+		customCount = ratioCount(count, settings.CustomRatio)
+	}
+
+	dealt := make([]gin.H, 0, count)
+	var hand []HandCard
+
+	if customCount > 0 {
+		customCards, err := repo.RandomCustomCards(settings.CustomDeck, customCount)
+		if err == errNotEnoughRows {
+			return nil, "deck_too_small"
+		}
+		if err != nil {
+			return nil, "cards_failed"
+		}
+		for _, card := range customCards {
+			cardImg, err := customCardImage(stream, card)
+			if err != nil {
+				return nil, "cards_failed"
+			}
+			hand = append(hand, HandCard{CustomCardID: card.ID})
+			dealt = append(dealt, gin.H{
+				"custom_card_id": card.ID,
+				"deck_id":        card.DeckId,
+				"card_img":       cardImg,
+				"card_url":       signedImageURL(imageKindCard, card.ID),
+			})
+		}
+	}
+
+	if count > customCount {
+		standard := count - customCount
+		filter := dealingFilter(db, c, gameID, settings)
+		var cards []Card
+		var err error
+		if favored := ratioCount(standard, settings.FavoritesRatio); favored > 0 {
+			cards, err = repo.DealFavoredCards(gameID, standard, favored, filter)
+		} else {
+			cards, err = repo.DealCards(gameID, standard, filter)
+		}
+		if err == errNotEnoughRows {
+			return nil, "cards_exhausted"
+		}
+		if err != nil {
+			return nil, "cards_failed"
+		}
+		for _, card := range cards {
+			cardImg, err := cardImage(stream, card)
+			if os.IsNotExist(err) {
+				return nil, "file_not_found"
+			}
+			if err != nil {
+				return nil, "image_read_failed"
+			}
+			hand = append(hand, HandCard{CardID: card.ID})
+			dealt = append(dealt, gin.H{
+				"card_id":  card.ID,
+				"card_img": cardImg,
+			})
+		}
+	}
+
+	recordHand(db, settings, c.Query("session_id"), hand)
+
+	rand.Shuffle(len(dealt), func(i, j int) { dealt[i], dealt[j] = dealt[j], dealt[i] })
+	return dealt, ""
+}
+
+// ratioCount is how many of count cards to draw from a source making up
+// ratio of the deal. A single card comes from it with that probability.
+func ratioCount(count int, ratio float64) int {
+	if ratio <= 0 {
+		return 0
+	}
+	if count == 1 {
+		if rand.Float64() < ratio {
+			return 1
+		}
+		return 0
+	}
+	return int(math.Round(float64(count) * ratio))
+}
+
+// recordHand stores which cards a session was dealt in the game's current
+// round. Anonymous deals aren't recorded.
+func recordHand(db *gorm.DB, settings RoomSettings, sessionID string, hand []HandCard) {
+	if settings.GameID == "" || sessionID == "" {
 		return
 	}
-	log.Printf("Fetched situation: %s", situation.Text)
-	c.JSON(http.StatusOK, gin.H{"text": situation.Text})
+	for _, card := range hand {
+		card.GameID = settings.GameID
+		card.SessionID = sessionID
+		card.Round = settings.Round
+		if err := db.Create(&card).Error; err != nil {
+			errorf("Failed to record dealt card for session_id %s in game_id %s: %v", sessionID, settings.GameID, err)
+		}
+	}
 }
 
-func getCard(db *gorm.DB, c *gin.Context) {
-	var card Card
-	if err := db.Order("RANDOM()").First(&card).Error; err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "No cards image available"})
+func getHand(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "hand")
+
+	gameID := c.Query("game_id")
+	sessionID := c.Query("session_id")
+	if gameID == "" || sessionID == "" {
+		respondError(c, "game_and_session_required")
 		return
 	}
 
-	if _, err := os.Stat(card.ImgPath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+	var latest HandCard
+	if err := db.Where("game_id = ? AND session_id = ?", gameID, sessionID).Order("round DESC").First(&latest).Error; err != nil {
+		respondError(c, "no_cards_dealt")
 		return
 	}
 
-	lock.Lock()
-	imageBytes, err := os.ReadFile(card.ImgPath)
-	lock.Unlock()
+	var hand []HandCard
+	db.Where("game_id = ? AND session_id = ? AND round = ?", gameID, sessionID, latest.Round).Order("id").Find(&hand)
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read image file"})
-		return
+	stream := newImageStream()
+	cards := make([]gin.H, 0, len(hand))
+	for _, dealt := range hand {
+		if dealt.CustomCardID != 0 {
+			var card customDeck
+			if err := db.First(&card, dealt.CustomCardID).Error; err != nil {
+				continue
+			}
+			cardImg, err := customCardImage(stream, card)
+			if err != nil {
+				continue
+			}
+			cards = append(cards, gin.H{
+				"custom_card_id": card.ID,
+				"deck_id":        card.DeckId,
+				"card_img":       cardImg,
+				"card_url":       signedImageURL(imageKindCard, card.ID),
+				"played":         dealt.Played,
+			})
+			continue
+		}
+
+		var card Card
+		if err := db.First(&card, dealt.CardID).Error; err != nil {
+			continue
+		}
+		cardImg, err := cardImage(stream, card)
+		if err != nil {
+			continue
+		}
+		cards = append(cards, gin.H{
+			"card_id":  card.ID,
+			"card_img": cardImg,
+			"played":   dealt.Played,
+		})
 	}
 
-	b64image := base64.StdEncoding.EncodeToString(imageBytes)
-	c.JSON(http.StatusOK, gin.H{"card_img": b64image})
+	stream.respond(c, http.StatusOK, gin.H{
+		"game_id":    gameID,
+		"session_id": sessionID,
+		"round":      latest.Round,
+		"cards":      cards,
+	})
 }
 
-func exit(db *gorm.DB, c *gin.Context) {
+// playCard marks a dealt card as played. The WS server calls it before
+// accepting an Action so players can only play cards they hold.
+func playCard(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "hand_play")
+
 	var json struct {
-		SessionID string `json:"session_id"`
+		GameID       string `json:"game_id"`
+		SessionID    string `json:"session_id"`
+		CardID       uint   `json:"card_id"`
+		CustomCardID uint   `json:"custom_card_id"`
 	}
-	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing session_id"})
+	if err := c.ShouldBindJSON(&json); err != nil || json.GameID == "" || json.SessionID == "" {
+		respondError(c, "game_and_session_required")
+		return
+	}
+	if (json.CardID == 0) == (json.CustomCardID == 0) {
+		respondError(c, "one_card_id_required")
 		return
 	}
 
-	var user User
-	if err := db.Where("session_id = ?", json.SessionID).First(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session_id"})
+	query := db.Where("game_id = ? AND session_id = ?", json.GameID, json.SessionID)
+	if json.CardID != 0 {
+		query = query.Where("card_id = ?", json.CardID)
+	} else {
+		query = query.Where("custom_card_id = ?", json.CustomCardID)
+	}
+
+	var dealt HandCard
+	if err := query.Order("round DESC").First(&dealt).Error; err != nil {
+		respondError(c, "card_not_dealt")
 		return
 	}
 
+	result := db.Model(&HandCard{}).Where("id = ? AND played = ?", dealt.ID, false).Update("played", true)
+	if result.Error != nil {
+		respondError(c, "play_failed")
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondError(c, "card_already_played")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Card played", "round": dealt.Round})
+}
+
+// cardOwner reports whether the session played a card in a game, so the WS
+// server can stop players from voting for their own card. It never says who
+// did play it, as a session ID is a credential.
+func cardOwner(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "hand_owner")
+
+	gameID := c.Query("game_id")
+	sessionID := c.Query("session_id")
+	cardID, _ := strconv.ParseUint(c.Query("card_id"), 10, 64)
+	customCardID, _ := strconv.ParseUint(c.Query("custom_card_id"), 10, 64)
+	if gameID == "" || sessionID == "" || (cardID == 0) == (customCardID == 0) {
+		respondError(c, "card_lookup_fields_required")
+		return
+	}
+
+	query := db.Where("game_id = ? AND played = ?", gameID, true)
+	if cardID != 0 {
+		query = query.Where("card_id = ?", cardID)
+	} else {
+		query = query.Where("custom_card_id = ?", customCardID)
+	}
+
+	var played HandCard
+	if err := query.Order("round DESC").First(&played).Error; err != nil {
+		respondError(c, "card_not_played")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"own": played.SessionID == sessionID, "round": played.Round})
+}
+
+// loadRoomSettings returns the host's settings for a game, or zero settings
+// when there is no game or it has none.
+func loadRoomSettings(db *gorm.DB, gameID string) RoomSettings {
+	var settings RoomSettings
+	if gameID != "" {
+		db.Where("game_id = ?", gameID).First(&settings)
+	}
+	return settings
+}
+
+// dealingFilter combines the card filters passed on the request with the
+// ones the host configured for the game; request parameters win. Locked packs
+// that not every player has unlocked are always excluded.
+func dealingFilter(db *gorm.DB, c *gin.Context, gameID string, settings RoomSettings) cardFilter {
+	filter := cardFilter{
+		Tags:          splitList(c.Query("tags")),
+		Pack:          strings.ToLower(c.Query("pack")),
+		ExcludedPacks: splitList(c.Query("exclude_packs")),
+	}
+
+	if len(filter.Tags) == 0 {
+		filter.Tags = splitList(settings.CardTags)
+	}
+	if filter.Pack == "" {
+		filter.Pack = settings.CardPack
+	}
+	if len(filter.ExcludedPacks) == 0 {
+		filter.ExcludedPacks = splitList(settings.ExcludedPacks)
+	}
+
+	filter.ExcludedPacks = append(filter.ExcludedPacks, lockedPacksForGame(db, gameID)...)
+	return filter
+}
+
+// cardImage returns a card's image as a placeholder in stream's response,
+// out of the card cache or else streamed from its file.
+func cardImage(stream *imageStream, card Card) (json.Marshaler, error) {
+	info, err := os.Stat(card.ImgPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := cardCache.read(card.ImgPath, info)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return stream.file(card.ImgPath), nil
+	}
+	return stream.bytes(data), nil
+}
+
+// customCardImage is cardImage for a custom deck card.
+func customCardImage(stream *imageStream, card customDeck) (json.Marshaler, error) {
+	if card.ImgPath == "" {
+		return stream.bytes(card.CardImg), nil
+	}
+	if _, err := os.Stat(card.ImgPath); err != nil {
+		return nil, err
+	}
+	return stream.file(card.ImgPath), nil
+}
+
+// customCardBytes reads a custom deck card's image.
+func customCardBytes(ctx context.Context, card customDeck) ([]byte, error) {
+	if card.ImgPath == "" {
+		return card.CardImg, nil
+	}
+	return readFileContext(ctx, card.ImgPath)
+}
+
+func exit(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "exit")
+	user := sessionUserFrom(c)
+
 	var room Room
-	if err := db.Where("session_id = ?", json.SessionID).First(&room).Error; err == nil {
+	if err := db.Where("session_id = ?", user.SessionID).First(&room).Error; err == nil {
 		gameID := room.GameID
 		db.Delete(&room)
 
 		var count int64
 		db.Model(&Room{}).Where("game_id = ?", gameID).Count(&count)
 		if count == 0 {
-			db.Where("game_id = ?", gameID).Delete(&Room{})
+			closeGame(db, gameID)
 		}
 	}
 
 	os.Remove(user.ImagePath)
 	db.Delete(&user)
 
-	c.JSON(http.StatusNoContent, gin.H{"message": "User successfully exited and data deleted"})
+	c.Status(http.StatusNoContent)
 }
 
 func disconnect(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "disconnect")
+
 	var json struct {
 		SessionID string `json:"session_id"`
 	}
 	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing session_id"})
+		respondError(c, "missing_session_id")
 		return
 	}
 
 	var room Room
 	if err := db.Where("session_id = ?", json.SessionID).First(&room).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not in any game"})
+		respondError(c, "not_in_game")
 		return
 	}
 
@@ -328,87 +1262,81 @@ func disconnect(db *gorm.DB, c *gin.Context) {
 	var count int64
 	db.Model(&Room{}).Where("game_id = ?", gameID).Count(&count)
 	if count == 0 {
-		db.Where("game_id = ?", gameID).Delete(&Room{})
+		closeGame(db, gameID)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully disconnected from the game"})
 }
 
 func connect(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "connect")
+
 	var json struct {
-		GameID    string `json:"game_id"`
-		SessionID string `json:"session_id"`
+		GameID string `json:"game_id"`
+		// AvatarRevisions are the avatars the client has cached, by
+		// session.
+		AvatarRevisions map[string]uint `json:"avatar_revisions"`
 	}
-	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session ID is required"})
-		return
-	}
-	if json.GameID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Game ID is required"})
-		return
-	}
-
-	var user User
-	if err := db.Where("session_id = ?", json.SessionID).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session_id"})
+	if err := c.ShouldBindJSON(&json); err != nil || json.GameID == "" {
+		respondError(c, "game_id_required")
 		return
 	}
+	user := sessionUserFrom(c)
 
 	var rooms []Room
 	db.Where("game_id = ?", json.GameID).Find(&rooms)
-	if len(rooms) >= 4 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Lobby is full"})
+	capacity := roomCapacity(db, json.GameID)
+	if len(rooms) >= capacity {
+		respondError(c, "lobby_full")
 		return
 	}
 
 	for _, room := range rooms {
-		if room.SessionID == json.SessionID {
-			c.JSON(http.StatusConflict, gin.H{"error": "Session already connected to this game"})
+		if room.SessionID == user.SessionID {
+			respondError(c, "already_connected")
 			return
 		}
 	}
 
-	newRoom := Room{GameID: json.GameID, SessionID: json.SessionID}
+	newRoom := Room{GameID: json.GameID, SessionID: user.SessionID}
 	db.Create(&newRoom)
+	recordPlayer(db, json.GameID, user)
+	if len(rooms)+1 == capacity {
+		publishLobbyEvent(db, lobbyRoomFilled, json.GameID)
+	}
 
-	var usersData []map[string]interface{}
+	sessionIDs := make([]string, 0, len(rooms))
 	for _, room := range rooms {
-		var user User
-		if err := db.Where("session_id = ?", room.SessionID).First(&user).Error; err == nil {
-			imageBytes, err := os.ReadFile(user.ImagePath)
-			if err != nil {
-				continue
-			}
-			b64image := base64.StdEncoding.EncodeToString(imageBytes)
-			usersData = append(usersData, map[string]interface{}{
-				"session_id": user.SessionID,
-				"login":      user.Login,
-				"image_data": b64image,
-			})
-		}
+		sessionIDs = append(sessionIDs, room.SessionID)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"game_id":  json.GameID,
-		"sessions": usersData,
+		"sessions": userProfiles(db, sessionIDs, profileThumbnails, json.AvatarRevisions),
 	})
 }
 
+// roomStats lists the open rooms for the lobby browser. Whether a game has
+// started comes from the WebSocket server's membership in the room store.
 func roomStats(db *gorm.DB, c *gin.Context) {
-	var result []struct {
-		GameID      string
-		PlayerCount int
+	db = withRequest(db, c, "room_stats")
+
+	result, err := newRepository(db).OpenRooms()
+	if err != nil {
+		respondError(c, "room_list_failed")
+		return
 	}
-	db.Raw(`SELECT r.game_id, COUNT(u.id) AS player_count
-		FROM rooms r
-		LEFT JOIN users u ON r.session_id = u.session_id
-		GROUP BY r.game_id`).Scan(&result)
 
 	var roomStats []map[string]interface{}
 	for _, res := range result {
 		roomStats = append(roomStats, map[string]interface{}{
 			"game_id":      res.GameID,
+			"name":         res.Name,
 			"player_count": res.PlayerCount,
+			"capacity":     RoomSettings{Capacity: res.Capacity}.capacity(),
+			"host_login":   res.HostLogin,
+			"started":      res.Started,
+			"created_at":   res.CreatedAt,
 		})
 	}
 
@@ -416,51 +1344,189 @@ func roomStats(db *gorm.DB, c *gin.Context) {
 }
 
 func host(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "host")
+
 	var json struct {
-		SessionID string `json:"session_id"`
+		Language      string   `json:"language"`
+		Pack          string   `json:"pack"`
+		CardPack      string   `json:"card_pack"`
+		CardTags      []string `json:"card_tags"`
+		ExcludedPacks []string `json:"exclude_packs"`
+		Capacity      int      `json:"capacity"`
+		NumericCode   *bool    `json:"numeric_code"`
+		Name          string   `json:"name"`
+		Region        string   `json:"region"`
+		// FavoritesRatio biases dealing toward the members' favorite cards.
+		FavoritesRatio float64 `json:"favorites_ratio"`
 	}
-	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session ID is required"})
+	// Every setting is optional, so a host authenticating with a bearer
+	// token may send no body at all.
+	if err := c.ShouldBindJSON(&json); err != nil && err != io.EOF {
+		respondError(c, "invalid_request")
+		return
+	}
+	if json.Capacity != 0 && !validCapacity(json.Capacity) {
+		respondError(c, "invalid_capacity", minRoomCapacity, config.MaxRoomCapacity)
+		return
+	}
+	if json.FavoritesRatio < 0 || json.FavoritesRatio > 1 {
+		respondError(c, "invalid_favorites_ratio")
+		return
+	}
+	name, ok := cleanRoomName(json.Name)
+	if !ok {
+		respondError(c, "room_name_too_long", maxRoomNameLength)
 		return
 	}
 
-	var user User
-	if err := db.Where("session_id = ?", json.SessionID).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	region, ok := clientRegion(c, json.Region)
+	if !ok {
+		respondError(c, "invalid_region")
 		return
 	}
 
-	gameID := generateGameID()
-	newRoom := Room{GameID: gameID, SessionID: json.SessionID, Cards: "0"}
+	user := sessionUserFrom(c)
+	updateRegion(db, &user, region)
+	if region == "" {
+		region = user.Region
+	}
+
+	numeric := config.GameIDNumeric
+	if json.NumericCode != nil {
+		numeric = *json.NumericCode
+	}
+	settings := RoomSettings{
+		Name:          name,
+		HostSession:   user.SessionID,
+		Language:      json.Language,
+		Pack:          json.Pack,
+		CardPack:      strings.ToLower(json.CardPack),
+		CardTags:      joinList(json.CardTags),
+		ExcludedPacks: joinList(json.ExcludedPacks),
+		Capacity:      json.Capacity,
+		Region:        region,
+
+		FavoritesRatio: json.FavoritesRatio,
+	}
+	if !createRoomSettings(db, &settings, numeric) {
+		respondError(c, "game_id_unavailable")
+		return
+	}
+	gameID := settings.GameID
+	newRoom := Room{GameID: gameID, SessionID: user.SessionID, Cards: "0"}
 	db.Create(&newRoom)
+	recordPlayer(db, gameID, user)
+	publishLobbyEvent(db, lobbyRoomCreated, gameID)
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Room created successfully", "game_id": gameID})
+	c.JSON(http.StatusCreated, gin.H{"message": "Room created successfully", "game_id": gameID, "name": name})
 }
 
-func generateGameID() string {
-	rand.Seed(time.Now().UnixNano())
-	letters := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
-	b := make([]rune, 6)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+// roomHost tells the WebSocket server who hosts a game. The host's session
+// is a credential, so this takes the service token.
+func roomHost(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "room_host")
+
+	var settings RoomSettings
+	if err := db.Where("game_id = ?", c.Param("game_id")).First(&settings).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
 	}
-	return string(b)
+
+	c.JSON(http.StatusOK, gin.H{"game_id": settings.GameID, "session_id": settings.HostSession, "capacity": settings.capacity(), "name": settings.Name})
 }
 
-func allowedFile(filename string) bool {
-	allowedExtensions := map[string]bool{
-		"jpg":  true,
-		"jpeg": true,
-		"png":  true,
+func setDeckMode(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "deck_mode")
+
+	var json struct {
+		SessionID   string  `json:"session_id"`
+		GameID      string  `json:"game_id"`
+		DeckID      uint    `json:"deck_id"`
+		CustomRatio float64 `json:"custom_ratio"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" || json.GameID == "" {
+		respondError(c, "game_and_session_required")
+		return
+	}
+	if json.CustomRatio < 0 || json.CustomRatio > 1 {
+		respondError(c, "invalid_custom_ratio")
+		return
+	}
+
+	var settings RoomSettings
+	if err := db.Where("game_id = ?", json.GameID).First(&settings).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
+	}
+	if settings.HostSession != json.SessionID {
+		respondError(c, "not_host")
+		return
+	}
+
+	if json.DeckID != 0 {
+		var cards int64
+		db.Model(&customDeck{}).Where("deck_id = ?", json.DeckID).Count(&cards)
+		if cards == 0 {
+			respondError(c, "deck_not_found")
+			return
+		}
 	}
-	ext := filepath.Ext(filename)
-	return allowedExtensions[strings.TrimPrefix(ext, ".")]
+
+	db.Model(&settings).Updates(map[string]interface{}{
+		"custom_deck":  json.DeckID,
+		"custom_ratio": json.CustomRatio,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_id":      json.GameID,
+		"deck_id":      json.DeckID,
+		"custom_ratio": json.CustomRatio,
+	})
+}
+
+func closeGame(db *gorm.DB, gameID string) {
+	writeSummary(db, gameID)
+	db.Where("game_id = ?", gameID).Delete(&Room{})
+	db.Where("game_id = ?", gameID).Delete(&Vote{})
+	db.Where("game_id = ?", gameID).Delete(&RoomSettings{})
+	db.Where("game_id = ?", gameID).Delete(&DealtCard{})
+	db.Where("game_id = ?", gameID).Delete(&DealtCustomCard{})
+	db.Where("game_id = ?", gameID).Delete(&ChatImage{})
+	db.Where("game_id = ?", gameID).Delete(&WSMember{})
+	publishLobbyEvent(db, lobbyRoomClosed, gameID)
 }
 
 func secureFilename(filename string) string {
 	return filepath.Base(filename)
 }
 
+// joinList stores a list of tags or pack names as ",a,b," so single entries
+// can be matched with LIKE '%,a,%'.
+func joinList(items []string) string {
+	var cleaned []string
+	for _, item := range items {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			cleaned = append(cleaned, item)
+		}
+	}
+	if len(cleaned) == 0 {
+		return ""
+	}
+	return "," + strings.Join(cleaned, ",") + ","
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getSituationsFromFile(filename string) []string {
 	var situations []string
 
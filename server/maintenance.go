@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maintenanceLevel is the announcement level of maintenance notices.
+const maintenanceLevel = "maintenance"
+
+// Maintenance is the single row holding maintenance mode, kept in the
+// database so every instance turns it on together. While it is on nobody
+// can register or host, but games already running carry on until
+// ShutdownAt.
+type Maintenance struct {
+	ID         uint `gorm:"primaryKey"`
+	Enabled    bool `gorm:"not null;default:false"`
+	Message    string
+	ShutdownAt *time.Time
+	UpdatedAt  time.Time
+}
+
+func maintenanceInfo(m Maintenance) gin.H {
+	return gin.H{
+		"enabled":     m.Enabled,
+		"message":     m.Message,
+		"shutdown_at": m.ShutdownAt,
+		"updated_at":  m.UpdatedAt,
+	}
+}
+
+func loadMaintenance(db *gorm.DB) (Maintenance, error) {
+	var m Maintenance
+	err := db.Where("id = ?", 1).Limit(1).Find(&m).Error
+	return m, err
+}
+
+// rejectDuringMaintenance stops requests that would start something new
+// while maintenance mode is on. If the state can't be read the request goes
+// ahead rather than locking everyone out.
+func rejectDuringMaintenance(db *gorm.DB) gin.HandlerFunc {
+	db = withOperation(db, "maintenance_check")
+	return func(c *gin.Context) {
+		m, err := loadMaintenance(db.WithContext(c.Request.Context()))
+		if err != nil {
+			errorf("Failed to load maintenance mode: %v", err)
+		}
+		if !m.Enabled {
+			c.Next()
+			return
+		}
+
+		body := errorBody(c, "maintenance")
+		body["maintenance_message"] = m.Message
+		body["shutdown_at"] = m.ShutdownAt
+		c.AbortWithStatusJSON(errorStatus("maintenance"), body)
+	}
+}
+
+func getMaintenance(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_maintenance")
+
+	m, err := loadMaintenance(db)
+	if err != nil {
+		respondError(c, "maintenance_failed")
+		return
+	}
+	c.JSON(http.StatusOK, maintenanceInfo(m))
+}
+
+// setMaintenance turns maintenance mode on or off. Turning it on warns every
+// active room through the WebSocket server, with the planned shutdown time
+// when one is given; notified says whether that worked.
+func setMaintenance(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_maintenance")
+
+	var json struct {
+		Enabled    bool       `json:"enabled"`
+		Message    string     `json:"message"`
+		ShutdownAt *time.Time `json:"shutdown_at"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+	if json.Enabled && json.ShutdownAt != nil && !json.ShutdownAt.After(time.Now()) {
+		respondError(c, "invalid_shutdown_time")
+		return
+	}
+
+	m := Maintenance{ID: 1, Enabled: json.Enabled}
+	if json.Enabled {
+		m.Message = strings.TrimSpace(json.Message)
+		m.ShutdownAt = json.ShutdownAt
+	}
+	err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&m).Error
+	if err != nil {
+		respondError(c, "maintenance_failed")
+		return
+	}
+
+	notified := false
+	if m.Enabled {
+		message := m.Message
+		if message == "" {
+			message = "The server is going down for maintenance"
+		}
+		var shutdownAt time.Time
+		if m.ShutdownAt != nil {
+			shutdownAt = *m.ShutdownAt
+		}
+		if _, err := sendAnnouncement(message, maintenanceLevel, "", shutdownAt); err != nil {
+			errorf("Failed to announce maintenance: %v", err)
+		} else {
+			notified = true
+		}
+	}
+
+	recordAudit(db, c, "maintenance", "*", fmt.Sprintf("enabled=%t shutdown_at=%v notified=%t", m.Enabled, m.ShutdownAt, notified))
+	infof("Admin set maintenance mode to %t", m.Enabled)
+
+	result := maintenanceInfo(m)
+	result["notified"] = notified
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const metricsOpKey = "metrics:operation"
+
+var defaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	counts  map[string][]uint64
+	sums    map[string]float64
+	totals  map[string]uint64
+}
+
+// gaugeFunc is a gauge read when metrics are scraped, for values that live
+// elsewhere, such as the database.
+type gaugeFunc struct {
+	name string
+	help string
+	read func() float64
+}
+
+type metricsRegistry struct {
+	mu         sync.Mutex
+	counters   []*counterVec
+	histograms []*histogramVec
+	gauges     []*gaugeFunc
+}
+
+var metrics = &metricsRegistry{}
+
+func (r *metricsRegistry) newCounter(name, help string, labels ...string) *counterVec {
+	c := &counterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+	r.mu.Lock()
+	r.counters = append(r.counters, c)
+	r.mu.Unlock()
+	return c
+}
+
+func (r *metricsRegistry) newHistogram(name, help string, buckets []float64, labels ...string) *histogramVec {
+	h := &histogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		counts:  map[string][]uint64{},
+		sums:    map[string]float64{},
+		totals:  map[string]uint64{},
+	}
+	r.mu.Lock()
+	r.histograms = append(r.histograms, h)
+	r.mu.Unlock()
+	return h
+}
+
+func (r *metricsRegistry) newGaugeFunc(name, help string, read func() float64) {
+	r.mu.Lock()
+	r.gauges = append(r.gauges, &gaugeFunc{name: name, help: help, read: read})
+	r.mu.Unlock()
+}
+
+func (c *counterVec) Add(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+func (c *counterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (h *histogramVec) Observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counts, ok := h.counts[key]
+	if !ok {
+		counts = make([]uint64, len(h.buckets))
+		h.counts[key] = counts
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			counts[i]++
+		}
+	}
+	h.sums[key] += v
+	h.totals[key]++
+}
+
+func formatLabels(names []string, key string, extra ...string) string {
+	var pairs []string
+	if len(names) > 0 {
+		values := strings.Split(key, "\xff")
+		for i, name := range names {
+			value := ""
+			if i < len(values) {
+				value = values[i]
+			}
+			pairs = append(pairs, fmt.Sprintf("%s=%q", name, value))
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *metricsRegistry) write(b *strings.Builder) {
+	r.mu.Lock()
+	counters := append([]*counterVec(nil), r.counters...)
+	histograms := append([]*histogramVec(nil), r.histograms...)
+	gauges := append([]*gaugeFunc(nil), r.gauges...)
+	r.mu.Unlock()
+
+	for _, c := range counters {
+		c.mu.Lock()
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for _, key := range sortedKeys(c.values) {
+			fmt.Fprintf(b, "%s%s %g\n", c.name, formatLabels(c.labels, key), c.values[key])
+		}
+		c.mu.Unlock()
+	}
+
+	for _, h := range histograms {
+		h.mu.Lock()
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+		for _, key := range sortedKeys(h.counts) {
+			for i, bound := range h.buckets {
+				fmt.Fprintf(b, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "le", fmt.Sprintf("%g", bound)), h.counts[key][i])
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "le", "+Inf"), h.totals[key])
+			fmt.Fprintf(b, "%s_sum%s %g\n", h.name, formatLabels(h.labels, key), h.sums[key])
+			fmt.Fprintf(b, "%s_count%s %d\n", h.name, formatLabels(h.labels, key), h.totals[key])
+		}
+		h.mu.Unlock()
+	}
+
+	for _, g := range gauges {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", g.name, g.help, g.name, g.name, g.read())
+	}
+}
+
+func metricsHandler(c *gin.Context) {
+	var b strings.Builder
+	metrics.write(&b)
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+var (
+	dbQueryDuration = metrics.newHistogram("db_query_duration_seconds", "Duration of database queries.", defaultBuckets, "operation", "type", "table")
+	dbQueryRows     = metrics.newCounter("db_query_rows_total", "Rows returned or affected by database queries.", "operation", "type", "table")
+	dbQueryErrors   = metrics.newCounter("db_query_errors_total", "Database queries that returned an error.", "operation", "type", "table")
+)
+
+// dbMetricsPlugin records per-query duration, rows and errors for every GORM
+// callback chain. Handlers tag their queries with withOperation so the
+// metrics can be broken down by what the query was for.
+type dbMetricsPlugin struct{}
+
+func (dbMetricsPlugin) Name() string {
+	return "dbMetrics"
+}
+
+func (p dbMetricsPlugin) Initialize(db *gorm.DB) error {
+	const startKey = "metrics:start"
+
+	before := func(db *gorm.DB) {
+		db.InstanceSet(startKey, time.Now())
+	}
+	after := func(queryType string) func(db *gorm.DB) {
+		return func(db *gorm.DB) {
+			value, ok := db.InstanceGet(startKey)
+			if !ok {
+				return
+			}
+			start := value.(time.Time)
+
+			operation := "unknown"
+			if op, ok := db.Get(metricsOpKey); ok {
+				operation = op.(string)
+			}
+			table := db.Statement.Table
+
+			dbQueryDuration.Observe(time.Since(start).Seconds(), operation, queryType, table)
+			dbQueryRows.Add(float64(db.Statement.RowsAffected), operation, queryType, table)
+			if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+				dbQueryErrors.Inc(operation, queryType, table)
+			}
+		}
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("metrics:before_create", before); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("metrics:after_create", after("create")); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("metrics:before_query", before); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("metrics:after_query", after("query")); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("metrics:before_update", before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("metrics:after_update", after("update")); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("metrics:before_row", before); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("metrics:after_row", after("row")); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw"))
+}
+
+// withOperation tags all queries run through the returned handle with the
+// given operation name in the database metrics.
+func withOperation(db *gorm.DB, operation string) *gorm.DB {
+	return db.Set(metricsOpKey, operation).Session(&gorm.Session{})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	r := &metricsRegistry{}
+	requests := r.newCounter("http_requests_total", "Requests served.", "method", "code")
+	requests.Inc("GET", "200")
+	requests.Add(2, "GET", "200")
+	requests.Inc("POST", "500")
+	r.newCounter("idle_total", "Never incremented.")
+	latency := r.newHistogram("request_seconds", "Request latency.", []float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/cards")
+	latency.Observe(0.5, "/cards")
+	latency.Observe(3, "/cards")
+	latency.Observe(0.1, "/room")
+	r.newGaugeFunc("players", "Players online.", func() float64 { return 1500000 })
+
+	var b strings.Builder
+	r.write(&b)
+	want := `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",code="200"} 3
+http_requests_total{method="POST",code="500"} 1
+# HELP idle_total Never incremented.
+# TYPE idle_total counter
+# HELP request_seconds Request latency.
+# TYPE request_seconds histogram
+request_seconds_bucket{route="/cards",le="0.1"} 1
+request_seconds_bucket{route="/cards",le="1"} 2
+request_seconds_bucket{route="/cards",le="+Inf"} 3
+request_seconds_sum{route="/cards"} 3.55
+request_seconds_count{route="/cards"} 3
+request_seconds_bucket{route="/room",le="0.1"} 1
+request_seconds_bucket{route="/room",le="1"} 1
+request_seconds_bucket{route="/room",le="+Inf"} 1
+request_seconds_sum{route="/room"} 0.1
+request_seconds_count{route="/room"} 1
+# HELP players Players online.
+# TYPE players gauge
+players 1.5e+06
+`
+	if got := b.String(); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMetricsLabelEscaping(t *testing.T) {
+	r := &metricsRegistry{}
+	r.newCounter("odd_total", "Odd label values.", "value").Inc("a\"b\\c\nd")
+
+	var b strings.Builder
+	r.write(&b)
+	// The text format escapes a label value's quotes, backslashes and
+	// newlines.
+	want := `odd_total{value="a\"b\\c\nd"} 1`
+	if !strings.Contains(b.String(), want+"\n") {
+		t.Errorf("exposition:\n%s\nwant a line %s", b.String(), want)
+	}
+}
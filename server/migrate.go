@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type migratedTable struct {
+	name string
+	copy func(src, dst *gorm.DB, batch int) (int64, error)
+}
+
+// migratedTables are what migrate-data copies, in an order that keeps rows
+// pointing at rows already copied. Custom deck card images are written out
+// to blobDir on the way, unless it is empty.
+func migratedTables(blobDir string) []migratedTable {
+	customCards := copyTable[customDeck]
+	if blobDir != "" {
+		customCards = func(src, dst *gorm.DB, batch int) (int64, error) {
+			return copyCustomCards(src, dst, batch, blobDir)
+		}
+	}
+	return []migratedTable{
+		{"users", copyTable[User]},
+		{"room settings", copyTable[RoomSettings]},
+		{"rooms", copyTable[Room]},
+		{"situations", copyTable[Situation]},
+		{"cards", copyTable[Card]},
+		{"deck previews", copyTable[DeckPreview]},
+		{"custom deck cards", customCards},
+		{"custom situation decks", copyTable[customSituationDeck]},
+	}
+}
+
+// databaseDialector picks the driver for a database named by dsn: a
+// postgres:// URL, or otherwise a SQLite file.
+func databaseDialector(dsn string) gorm.Dialector {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return postgres.Open(dsn)
+	}
+	return sqlite.Open(dsn)
+}
+
+// openDatabase connects to the database dsn names, as the server's primary
+// or one of its replicas.
+func openDatabase(dsn string) (*gorm.DB, error) {
+	return gorm.Open(databaseDialector(dsn), &gorm.Config{})
+}
+
+// runMigrateData is the migrate-data command. It copies an existing SQLite
+// database's users, rooms, situations, cards and custom decks into another
+// database, keeping their IDs. Rows already there are left alone, so an
+// interrupted copy can simply be run again. Card image files stay where
+// they are. Custom deck card images, kept in the database until now, are
+// moved out to files in -blob-dir, which the servers using the new database
+// must be able to read.
+func runMigrateData(args []string) {
+	fs := flag.NewFlagSet("migrate-data", flag.ExitOnError)
+	from := fs.String("from", config.DatabaseURI, "SQLite database to copy from")
+	to := fs.String("to", os.Getenv("DATABASE_URL"), "database to copy into (default $DATABASE_URL)")
+	batch := fs.Int("batch", 500, "rows copied per insert")
+	blobDir := fs.String("blob-dir", filepath.Join(config.UploadFolder, "decks"), "folder custom deck card images are moved to; empty keeps them in the database")
+	fs.Parse(args)
+
+	if *to == "" {
+		log.Fatalf("migrate-data: -to is required")
+	}
+	if *batch < 1 {
+		log.Fatalf("migrate-data: batch must be at least 1")
+	}
+
+	src, err := gorm.Open(sqlite.Open(*from), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("migrate-data: opening %s: %v", *from, err)
+	}
+	if *blobDir != "" {
+		if err := os.MkdirAll(*blobDir, os.ModePerm); err != nil {
+			log.Fatalf("migrate-data: %v", err)
+		}
+	}
+	dst, err := gorm.Open(databaseDialector(*to), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("migrate-data: opening destination: %v", err)
+	}
+	if err := dst.AutoMigrate(&User{}, &RoomSettings{}, &Room{}, &Situation{}, &Card{}, &DeckPreview{}, &customDeck{}, &customSituationDeck{}); err != nil {
+		log.Fatalf("migrate-data: creating tables: %v", err)
+	}
+
+	for _, table := range migratedTables(*blobDir) {
+		copied, err := table.copy(src, dst, *batch)
+		if err != nil {
+			log.Fatalf("migrate-data: copying %s after %d rows: %v", table.name, copied, err)
+		}
+		log.Printf("migrate-data: copied %d %s", copied, table.name)
+	}
+}
+
+// copyTable copies every row of T from src to dst in batches, skipping rows
+// whose primary key dst already has, and returns how many it inserted. A
+// database from before the table existed has nothing to copy.
+func copyTable[T any](src, dst *gorm.DB, batch int) (int64, error) {
+	if !src.Migrator().HasTable(new(T)) {
+		return 0, nil
+	}
+	var rows []T
+	var copied int64
+	err := src.FindInBatches(&rows, batch, func(tx *gorm.DB, _ int) error {
+		result := dst.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows)
+		copied += result.RowsAffected
+		return result.Error
+	}).Error
+	if err != nil {
+		return copied, err
+	}
+	return copied, resetSequence(dst, new(T))
+}
+
+// copyCustomCards is copyTable for custom deck cards, writing each image
+// still held in the database to a file in dir and copying the card with the
+// file's path in its place.
+func copyCustomCards(src, dst *gorm.DB, batch int, dir string) (int64, error) {
+	if !src.Migrator().HasTable(&customDeck{}) {
+		return 0, nil
+	}
+	var rows []customDeck
+	var copied int64
+	err := src.FindInBatches(&rows, batch, func(tx *gorm.DB, _ int) error {
+		for i := range rows {
+			if rows[i].ImgPath != "" {
+				continue
+			}
+			path := filepath.Join(dir, fmt.Sprintf("%d-%d", rows[i].DeckId, rows[i].ID))
+			if err := os.WriteFile(path, rows[i].CardImg, 0o644); err != nil {
+				return err
+			}
+			rows[i].ImgPath = path
+			rows[i].CardImg = []byte{}
+		}
+		result := dst.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows)
+		copied += result.RowsAffected
+		return result.Error
+	}).Error
+	if err != nil {
+		return copied, err
+	}
+	return copied, resetSequence(dst, &customDeck{})
+}
+
+// resetSequence moves a Postgres ID sequence past the IDs copied into the
+// model's table, since inserting explicit IDs doesn't advance it. Other
+// databases pick the next ID from the table itself.
+func resetSequence(db *gorm.DB, model interface{}) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	table := stmt.Schema.Table
+	return db.Exec(fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 1)) FROM %q`, table, table)).Error
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestDatabaseDialector(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"users10.db", "sqlite"},
+		{"file:meme-battle?mode=memory&cache=shared", "sqlite"},
+		{"postgres://meme@db:5432/meme", "postgres"},
+		{"postgresql://meme@db/meme?sslmode=disable", "postgres"},
+	}
+	for _, tt := range tests {
+		if got := databaseDialector(tt.dsn).Name(); got != tt.want {
+			t.Errorf("databaseDialector(%q) = %s, want %s", tt.dsn, got, tt.want)
+		}
+	}
+}
+
+// fakePostgres accepts connections speaking just enough of the Postgres
+// protocol for a client to log in without a password and run empty
+// queries. The channel gets a value each time a client logs in.
+func fakePostgres(t *testing.T) (string, <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	started := make(chan struct{}, 8)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go servePostgres(conn, started)
+		}
+	}()
+	return ln.Addr().String(), started
+}
+
+func servePostgres(conn net.Conn, started chan<- struct{}) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	// The startup message has no type byte.
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(length)-4); err != nil {
+		return
+	}
+	ready := []byte{'Z', 0, 0, 0, 5, 'I'}
+	conn.Write(append([]byte{
+		'R', 0, 0, 0, 8, 0, 0, 0, 0, // AuthenticationOk
+		'K', 0, 0, 0, 12, 0, 0, 0, 1, 0, 0, 0, 2, // BackendKeyData
+	}, ready...))
+	started <- struct{}{}
+
+	for {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(length)-4); err != nil {
+			return
+		}
+		switch kind {
+		case 'Q':
+			conn.Write(append([]byte{'I', 0, 0, 0, 4}, ready...)) // EmptyQueryResponse
+		case 'X':
+			return
+		}
+	}
+}
+
+func TestOpenDatabasePostgres(t *testing.T) {
+	addr, started := fakePostgres(t)
+
+	db, err := openDatabase("postgres://meme@" + addr + "/meme?sslmode=disable")
+	if err != nil {
+		t.Fatalf("openDatabase: %v", err)
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+	if name := db.Dialector.Name(); name != "postgres" {
+		t.Errorf("dialector %s, want postgres", name)
+	}
+	select {
+	case <-started:
+	default:
+		t.Fatal("openDatabase never connected to the Postgres server")
+	}
+}
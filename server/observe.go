@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadObserveModerated sets from OBSERVE_MODERATED whether players are told
+// when an admin watches their game, unless the admin says otherwise.
+func loadObserveModerated() {
+	if moderated, err := strconv.ParseBool(os.Getenv("OBSERVE_MODERATED")); err == nil {
+		config.ObserveModerated = moderated
+	}
+}
+
+// observeRoom gets an admin a ticket to watch a reported game live on the
+// WebSocket server, read-only and chat included. The ticket is good for one
+// connection to the returned URL within a minute; every one handed out is
+// audited.
+func observeRoom(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_observe_room")
+
+	var json struct {
+		Reason    string `json:"reason"`
+		Moderated *bool  `json:"moderated"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&json); err != nil {
+			respondError(c, "invalid_request")
+			return
+		}
+	}
+	moderated := config.ObserveModerated
+	if json.Moderated != nil {
+		moderated = *json.Moderated
+	}
+
+	gameID := c.Param("game_id")
+	var rooms int64
+	db.Model(&Room{}).Where("game_id = ?", gameID).Count(&rooms)
+	if rooms == 0 {
+		respondError(c, "game_not_found")
+		return
+	}
+
+	var result struct {
+		Ticket    string    `json:"ticket"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := postWS("/games/"+url.PathEscape(gameID)+"/observers", gin.H{"moderated": moderated}, &result); err != nil {
+		errorf("Failed to get an observe ticket for game_id %s: %v", gameID, err)
+		respondError(c, "observe_failed")
+		return
+	}
+
+	reason := strings.TrimSpace(json.Reason)
+	recordAudit(db, c, "observe_room", gameID, fmt.Sprintf("reason=%q moderated=%t", reason, moderated))
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_id":    gameID,
+		"url":        observeURL(gameID, result.Ticket),
+		"moderated":  moderated,
+		"expires_at": result.ExpiresAt,
+	})
+}
+
+// observeURL is where the WebSocket server takes the observer connection
+// for ticket.
+func observeURL(gameID, ticket string) string {
+	base := config.WSURL
+	if strings.HasPrefix(base, "https://") {
+		base = "wss://" + strings.TrimPrefix(base, "https://")
+	} else {
+		base = "ws://" + strings.TrimPrefix(base, "http://")
+	}
+	return base + "/games/" + url.PathEscape(gameID) + "/observe?ticket=" + url.QueryEscape(ticket)
+}
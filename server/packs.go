@@ -0,0 +1,237 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Pack describes a card pack. Cards of a locked pack are only dealt to games
+// where every player has unlocked it.
+type Pack struct {
+	ID     uint   `gorm:"primaryKey"`
+	Name   string `gorm:"unique;not null"`
+	Locked bool   `gorm:"not null;default:false"`
+}
+
+type PackEntitlement struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_user_pack"`
+	Pack      string `gorm:"not null;uniqueIndex:idx_user_pack"`
+	Source    string `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+type PackCode struct {
+	ID      uint   `gorm:"primaryKey"`
+	Code    string `gorm:"unique;not null"`
+	Pack    string `gorm:"not null"`
+	MaxUses int    `gorm:"not null;default:1"`
+	Uses    int    `gorm:"not null;default:0"`
+}
+
+// grantPack unlocks a pack for a user. Granting an already unlocked pack is a
+// no-op, so achievement hooks can call it unconditionally.
+func grantPack(db *gorm.DB, userID uint, pack, source string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&PackEntitlement{
+		UserID: userID,
+		Pack:   pack,
+		Source: source,
+	}).Error
+}
+
+// lockedPacksForGame lists the locked packs that at least one member of the
+// game hasn't unlocked. With no game every locked pack is returned.
+func lockedPacksForGame(db *gorm.DB, gameID string) []string {
+	var locked []string
+	db.Model(&Pack{}).Where("locked = ?", true).Pluck("name", &locked)
+	if len(locked) == 0 || gameID == "" {
+		return locked
+	}
+
+	var userIDs []uint
+	db.Model(&User{}).
+		Joins("JOIN rooms ON rooms.session_id = users.session_id").
+		Where("rooms.game_id = ?", gameID).
+		Pluck("users.id", &userIDs)
+	if len(userIDs) == 0 {
+		return locked
+	}
+
+	var unlocked []struct {
+		Pack    string
+		Players int
+	}
+	db.Model(&PackEntitlement{}).
+		Select("pack, COUNT(*) AS players").
+		Where("user_id IN ? AND pack IN ?", userIDs, locked).
+		Group("pack").
+		Scan(&unlocked)
+
+	everyone := map[string]bool{}
+	for _, u := range unlocked {
+		if u.Players == len(userIDs) {
+			everyone[u.Pack] = true
+		}
+	}
+
+	var excluded []string
+	for _, pack := range locked {
+		if !everyone[pack] {
+			excluded = append(excluded, pack)
+		}
+	}
+	return excluded
+}
+
+func listPacks(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "packs")
+
+	sessionID := c.Query("session_id")
+	if sessionID == "" {
+		respondError(c, "missing_session_id")
+		return
+	}
+
+	var user User
+	if err := db.Where("session_id = ?", sessionID).First(&user).Error; err != nil {
+		respondError(c, "user_not_found")
+		return
+	}
+
+	var packs []Pack
+	db.Order("name").Find(&packs)
+
+	var owned []string
+	db.Model(&PackEntitlement{}).Where("user_id = ?", user.ID).Pluck("pack", &owned)
+	ownedSet := map[string]bool{}
+	for _, pack := range owned {
+		ownedSet[pack] = true
+	}
+
+	result := make([]gin.H, 0, len(packs))
+	for _, pack := range packs {
+		result = append(result, gin.H{
+			"name":     pack.Name,
+			"locked":   pack.Locked,
+			"unlocked": !pack.Locked || ownedSet[pack.Name],
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func unlockPack(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "pack_unlock")
+
+	var json struct {
+		SessionID string `json:"session_id"`
+		Code      string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" || json.Code == "" {
+		respondError(c, "session_and_code_required")
+		return
+	}
+
+	var user User
+	if err := db.Where("session_id = ?", json.SessionID).First(&user).Error; err != nil {
+		respondError(c, "user_not_found")
+		return
+	}
+
+	var pack string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var code PackCode
+		if err := tx.Where("code = ?", strings.ToUpper(strings.TrimSpace(json.Code))).First(&code).Error; err != nil {
+			return err
+		}
+		if code.Uses >= code.MaxUses {
+			return gorm.ErrRecordNotFound
+		}
+
+		result := tx.Model(&PackCode{}).
+			Where("id = ? AND uses < max_uses", code.ID).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		pack = code.Pack
+		return grantPack(tx, user.ID, code.Pack, "code")
+	})
+	if err == gorm.ErrRecordNotFound {
+		respondError(c, "invalid_code")
+		return
+	}
+	if err != nil {
+		respondError(c, "pack_unlock_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pack": pack, "unlocked": true})
+}
+
+func updatePack(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_packs")
+
+	var json struct {
+		Locked bool `json:"locked"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+
+	pack := Pack{Name: strings.ToLower(c.Param("name")), Locked: json.Locked}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"locked"}),
+	}).Create(&pack).Error; err != nil {
+		respondError(c, "pack_update_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"name": pack.Name, "locked": pack.Locked})
+}
+
+func createPackCode(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_packs")
+
+	var json struct {
+		Pack    string `json:"pack"`
+		MaxUses int    `json:"max_uses"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.Pack == "" {
+		respondError(c, "pack_required")
+		return
+	}
+	if json.MaxUses < 1 {
+		json.MaxUses = 1
+	}
+
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		respondError(c, "code_generate_failed")
+		return
+	}
+
+	code := PackCode{
+		Code:    strings.ToUpper(hex.EncodeToString(buf)),
+		Pack:    strings.ToLower(json.Pack),
+		MaxUses: json.MaxUses,
+	}
+	if err := db.Create(&code).Error; err != nil {
+		respondError(c, "code_create_failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": code.Code, "pack": code.Pack, "max_uses": code.MaxUses})
+}
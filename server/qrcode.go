@@ -0,0 +1,461 @@
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// A QR code encoder for invite links: byte mode at error correction level
+// M, versions 1 to 10, which holds links of up to 213 bytes.
+
+// qrBlocks describes how a version's codewords split into error correction
+// blocks at level M.
+type qrBlocks struct {
+	ecPerBlock int
+	// Blocks of each data length, shortest first.
+	groups [][2]int // {count, data codewords}
+}
+
+var qrVersions = []qrBlocks{
+	1:  {10, [][2]int{{1, 16}}},
+	2:  {16, [][2]int{{1, 28}}},
+	3:  {26, [][2]int{{1, 44}}},
+	4:  {18, [][2]int{{2, 32}}},
+	5:  {24, [][2]int{{2, 43}}},
+	6:  {16, [][2]int{{4, 27}}},
+	7:  {18, [][2]int{{4, 31}}},
+	8:  {22, [][2]int{{2, 38}, {2, 39}}},
+	9:  {22, [][2]int{{3, 36}, {2, 37}}},
+	10: {26, [][2]int{{4, 43}, {1, 44}}},
+}
+
+// qrAlignment lists the alignment pattern centres of each version.
+var qrAlignment = [][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+var errQRTooLong = errors.New("qr: data too long")
+
+func (b qrBlocks) dataCodewords() int {
+	n := 0
+	for _, group := range b.groups {
+		n += group[0] * group[1]
+	}
+	return n
+}
+
+// qrCode is an encoded symbol; dark modules are true.
+type qrCode struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// encodeQR encodes data in the smallest version it fits.
+func encodeQR(data []byte) (*qrCode, error) {
+	for version := 1; version < len(qrVersions); version++ {
+		blocks := qrVersions[version]
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > 8*blocks.dataCodewords() {
+			continue
+		}
+
+		var bits qrBitBuffer
+		bits.append(0b0100, 4)
+		bits.append(len(data), countBits)
+		for _, b := range data {
+			bits.append(int(b), 8)
+		}
+		capacity := 8 * blocks.dataCodewords()
+		bits.append(0, min(4, capacity-len(bits)))
+		bits.append(0, (8-len(bits)%8)%8)
+		codewords := bits.bytes()
+		for pad := byte(0xec); len(codewords) < blocks.dataCodewords(); pad ^= 0xec ^ 0x11 {
+			codewords = append(codewords, pad)
+		}
+
+		qr := newQRCode(version)
+		qr.drawCodewords(qrInterleave(codewords, blocks))
+		qr.applyBestMask()
+		return qr, nil
+	}
+	return nil, errQRTooLong
+}
+
+type qrBitBuffer []bool
+
+func (b *qrBitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b qrBitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// qrInterleave splits data into blocks, adds each block's error correction
+// and interleaves the lot as the symbol stores it.
+func qrInterleave(data []byte, blocks qrBlocks) []byte {
+	var dataBlocks, ecBlocks [][]byte
+	generator := qrGenerator(blocks.ecPerBlock)
+	for _, group := range blocks.groups {
+		for i := 0; i < group[0]; i++ {
+			block := data[:group[1]]
+			data = data[group[1]:]
+			dataBlocks = append(dataBlocks, block)
+			ecBlocks = append(ecBlocks, qrRemainder(block, generator))
+		}
+	}
+
+	var out []byte
+	longest := blocks.groups[len(blocks.groups)-1][1]
+	for i := 0; i < longest; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < blocks.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			out = append(out, block[i])
+		}
+	}
+	return out
+}
+
+// qrMultiply multiplies in GF(2^8) modulo the QR polynomial 0x11d.
+func qrMultiply(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z >> 7
+		z <<= 1
+		z ^= carry * 0x1d
+		z ^= (y >> i & 1) * x
+	}
+	return z
+}
+
+// qrGenerator returns the Reed-Solomon generator polynomial of the given
+// degree, highest coefficient first and the leading 1 left out.
+func qrGenerator(degree int) []byte {
+	poly := make([]byte, degree)
+	poly[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range poly {
+			poly[j] = qrMultiply(poly[j], root)
+			if j+1 < len(poly) {
+				poly[j] ^= poly[j+1]
+			}
+		}
+		root = qrMultiply(root, 0x02)
+	}
+	return poly
+}
+
+func qrRemainder(data, generator []byte) []byte {
+	rem := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i, coef := range generator {
+			rem[i] ^= qrMultiply(coef, factor)
+		}
+	}
+	return rem
+}
+
+// newQRCode lays out the function patterns of a version.
+func newQRCode(version int) *qrCode {
+	size := 17 + 4*version
+	qr := &qrCode{size: size, modules: make([][]bool, size), isFunction: make([][]bool, size)}
+	for y := range qr.modules {
+		qr.modules[y] = make([]bool, size)
+		qr.isFunction[y] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+	for _, centre := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := centre[0]+dx, centre[1]+dy
+				if x < 0 || x >= size || y < 0 || y >= size {
+					continue
+				}
+				dist := max(abs(dx), abs(dy))
+				qr.setFunction(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+	if version < len(qrAlignment) {
+		positions := qrAlignment[version]
+		last := len(positions) - 1
+		for i, cy := range positions {
+			for j, cx := range positions {
+				if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+					continue
+				}
+				for dy := -2; dy <= 2; dy++ {
+					for dx := -2; dx <= 2; dx++ {
+						qr.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+					}
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas; the real bits go in with the mask.
+	qr.drawFormat(0)
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1f25
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := size-11+i%3, i/3
+			qr.setFunction(a, b, dark)
+			qr.setFunction(b, a, dark)
+		}
+	}
+	return qr
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (qr *qrCode) setFunction(x, y int, dark bool) {
+	qr.modules[y][x] = dark
+	qr.isFunction[y][x] = true
+}
+
+// drawFormat writes the error correction level (M) and mask into both
+// copies of the format information.
+func (qr *qrCode) drawFormat(mask int) {
+	data := 0b00<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(8, i, bit(i))
+	}
+	qr.setFunction(8, 7, bit(6))
+	qr.setFunction(8, 8, bit(7))
+	qr.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(14-i, 8, bit(i))
+	}
+
+	size := qr.size
+	for i := 0; i < 8; i++ {
+		qr.setFunction(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(8, size-15+i, bit(i))
+	}
+	qr.setFunction(8, size-8, true)
+}
+
+// drawCodewords fills the data area in the symbol's zigzag order, two
+// columns at a time from the bottom right.
+func (qr *qrCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if upward {
+					y = qr.size - 1 - vert
+				}
+				if qr.isFunction[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				qr.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func qrMasked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	}
+	return ((x+y)%2+x*y%3)%2 == 0
+}
+
+// toggleMask XORs mask over the data modules; applying it twice undoes it.
+func (qr *qrCode) toggleMask(mask int) {
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if !qr.isFunction[y][x] && qrMasked(mask, x, y) {
+				qr.modules[y][x] = !qr.modules[y][x]
+			}
+		}
+	}
+}
+
+// applyBestMask applies whichever of the eight masks scores the lowest
+// penalty.
+func (qr *qrCode) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.toggleMask(mask)
+		qr.drawFormat(mask)
+		if penalty := qr.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		qr.toggleMask(mask)
+	}
+	qr.toggleMask(best)
+	qr.drawFormat(best)
+}
+
+// penalty scores how hard the symbol is to read: long runs, 2x2 blocks,
+// finder-like patterns and an unbalanced dark ratio all count against it.
+func (qr *qrCode) penalty() int {
+	size := qr.size
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return qr.modules[x][y]
+		}
+		return qr.modules[y][x]
+	}
+
+	penalty := 0
+	finder := []bool{true, false, true, true, true, false, true}
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < size; y++ {
+			run := 1
+			for x := 1; x <= size; x++ {
+				if x < size && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+
+			for x := 0; x+7 <= size; x++ {
+				match := true
+				for i, dark := range finder {
+					if at(x+i, y, transpose) != dark {
+						match = false
+						break
+					}
+				}
+				if match && (qr.lightRun(x-4, x, y, transpose) || qr.lightRun(x+7, x+11, y, transpose)) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if qr.modules[y][x] {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				c := qr.modules[y][x]
+				if qr.modules[y][x+1] == c && qr.modules[y+1][x] == c && qr.modules[y+1][x+1] == c {
+					penalty += 3
+				}
+			}
+		}
+	}
+	percent := dark * 100 / (size * size)
+	penalty += abs(percent-50) / 5 * 10
+	return penalty
+}
+
+// lightRun reports whether modules from..to-1 of a row (or column) are all
+// light, counting those outside the symbol as light.
+func (qr *qrCode) lightRun(from, to, line int, transpose bool) bool {
+	for i := from; i < to; i++ {
+		if i < 0 || i >= qr.size {
+			continue
+		}
+		if transpose && qr.modules[i][line] || !transpose && qr.modules[line][i] {
+			return false
+		}
+	}
+	return true
+}
+
+// image renders the symbol with scale pixels per module and the standard
+// four-module quiet zone.
+func (qr *qrCode) image(scale int) image.Image {
+	const quiet = 4
+	side := (qr.size + 2*quiet) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if !qr.modules[y][x] {
+				continue
+			}
+			for py := 0; py < scale; py++ {
+				for px := 0; px < scale; px++ {
+					img.SetGray((x+quiet)*scale+px, (y+quiet)*scale+py, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func hexBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var out []byte
+	for _, field := range strings.Fields(s) {
+		var b byte
+		for _, c := range field {
+			b <<= 4
+			switch {
+			case c >= '0' && c <= '9':
+				b |= byte(c - '0')
+			case c >= 'A' && c <= 'F':
+				b |= byte(c - 'A' + 10)
+			default:
+				t.Fatalf("bad hex %q", field)
+			}
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func TestQRGenerator(t *testing.T) {
+	// The degree 7 generator polynomial, x^7 + 127x^6 + ... + 117.
+	want := []byte{127, 122, 154, 164, 11, 68, 117}
+	if got := qrGenerator(7); !bytes.Equal(got, want) {
+		t.Errorf("qrGenerator(7) = %v, want %v", got, want)
+	}
+}
+
+func TestQRRemainder(t *testing.T) {
+	// Version 1-M symbols: ISO/IEC 18004 annex I's "01234567" and the
+	// widely reproduced "HELLO WORLD".
+	tests := []struct {
+		name, data, ec string
+	}{
+		{"01234567", "10 20 0C 56 61 80 EC 11 EC 11 EC 11 EC 11 EC 11", "A5 24 D4 C1 ED 36 C7 87 2C 55"},
+		{"HELLO WORLD", "20 5B 0B 78 D1 72 DC 4D 43 40 EC 11 EC 11 EC 11", "C4 23 27 77 EB D7 E7 E2 5D 17"},
+	}
+	for _, tt := range tests {
+		got := qrRemainder(hexBytes(t, tt.data), qrGenerator(10))
+		if want := hexBytes(t, tt.ec); !bytes.Equal(got, want) {
+			t.Errorf("%s: error correction = % X, want % X", tt.name, got, want)
+		}
+	}
+}
+
+// qrFormatBits reads both copies of the format information, most
+// significant bit first.
+func qrFormatBits(qr *qrCode) (first, second int) {
+	var bits [15]bool
+	for i := 0; i <= 5; i++ {
+		bits[i] = qr.modules[i][8]
+	}
+	bits[6] = qr.modules[7][8]
+	bits[7] = qr.modules[8][8]
+	bits[8] = qr.modules[8][7]
+	for i := 9; i < 15; i++ {
+		bits[i] = qr.modules[8][14-i]
+	}
+	for i := 14; i >= 0; i-- {
+		first <<= 1
+		if bits[i] {
+			first |= 1
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		bits[i] = qr.modules[8][qr.size-1-i]
+	}
+	for i := 8; i < 15; i++ {
+		bits[i] = qr.modules[qr.size-15+i][8]
+	}
+	for i := 14; i >= 0; i-- {
+		second <<= 1
+		if bits[i] {
+			second |= 1
+		}
+	}
+	return first, second
+}
+
+func TestQRFormat(t *testing.T) {
+	// Format information for level M with each mask, from the standard's
+	// table.
+	want := []int{
+		0b101010000010010,
+		0b101000100100101,
+		0b101111001111100,
+		0b101101101001011,
+		0b100010111111001,
+		0b100000011001110,
+		0b100111110010111,
+		0b100101010100000,
+	}
+	for mask, bits := range want {
+		qr := newQRCode(1)
+		qr.drawFormat(mask)
+		first, second := qrFormatBits(qr)
+		if first != bits || second != bits {
+			t.Errorf("mask %d: format %015b and %015b, want %015b", mask, first, second, bits)
+		}
+		if !qr.modules[qr.size-8][8] {
+			t.Errorf("mask %d: dark module missing", mask)
+		}
+	}
+}
+
+func TestQRVersionInformation(t *testing.T) {
+	// Version information blocks from the standard's table.
+	want := map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3}
+	for version, bits := range want {
+		qr := newQRCode(version)
+		var below, right int
+		for i := 17; i >= 0; i-- {
+			a, b := qr.size-11+i%3, i/3
+			below <<= 1
+			if qr.modules[b][a] {
+				below |= 1
+			}
+			right <<= 1
+			if qr.modules[a][b] {
+				right |= 1
+			}
+		}
+		if below != bits || right != bits {
+			t.Errorf("version %d: version information %05X and %05X, want %05X", version, below, right, bits)
+		}
+	}
+}
+
+func TestQRCapacity(t *testing.T) {
+	// The most bytes each version holds at level M.
+	capacity := []int{1: 14, 2: 26, 3: 42, 4: 62, 5: 84, 6: 106, 7: 122, 8: 152, 9: 180, 10: 213}
+	for version := 1; version < len(capacity); version++ {
+		qr, err := encodeQR(bytes.Repeat([]byte("a"), capacity[version]))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", capacity[version], err)
+		}
+		if want := 17 + 4*version; qr.size != want {
+			t.Errorf("%d bytes: size %d, want %d", capacity[version], qr.size, want)
+		}
+		if version+1 < len(capacity) {
+			qr, err := encodeQR(bytes.Repeat([]byte("a"), capacity[version]+1))
+			if err != nil {
+				t.Fatalf("%d bytes: %v", capacity[version]+1, err)
+			}
+			if want := 17 + 4*(version+1); qr.size != want {
+				t.Errorf("%d bytes: size %d, want %d", capacity[version]+1, qr.size, want)
+			}
+		}
+	}
+	if _, err := encodeQR(bytes.Repeat([]byte("a"), 214)); !errors.Is(err, errQRTooLong) {
+		t.Errorf("214 bytes: err = %v, want errQRTooLong", err)
+	}
+}
+
+// readQR reads back what a symbol holds: it finds the mask from the format
+// information, reads the codewords in the standard's placement order,
+// checks every block's error correction and decodes the byte mode segment.
+func readQR(t *testing.T, qr *qrCode) []byte {
+	t.Helper()
+	version := (qr.size - 17) / 4
+	format, _ := qrFormatBits(qr)
+	mask := -1
+	for m := 0; m < 8; m++ {
+		probe := newQRCode(version)
+		probe.drawFormat(m)
+		if bits, _ := qrFormatBits(probe); bits == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format %015b isn't level M", format)
+	}
+
+	var bits []bool
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = qr.size - 1 - vert
+			}
+			for x := right; x >= right-1; x-- {
+				if !qr.isFunction[y][x] {
+					bits = append(bits, qr.modules[y][x] != qrMasked(mask, x, y))
+				}
+			}
+		}
+	}
+	raw := make([]byte, len(bits)/8)
+	for i := range raw {
+		for _, bit := range bits[8*i : 8*i+8] {
+			raw[i] <<= 1
+			if bit {
+				raw[i] |= 1
+			}
+		}
+	}
+
+	blocks := qrVersions[version]
+	var lengths []int
+	for _, group := range blocks.groups {
+		for i := 0; i < group[0]; i++ {
+			lengths = append(lengths, group[1])
+		}
+	}
+	data := make([][]byte, len(lengths))
+	ec := make([][]byte, len(lengths))
+	at := 0
+	for i := 0; i < lengths[len(lengths)-1]; i++ {
+		for b, n := range lengths {
+			if i < n {
+				data[b] = append(data[b], raw[at])
+				at++
+			}
+		}
+	}
+	for i := 0; i < blocks.ecPerBlock; i++ {
+		for b := range lengths {
+			ec[b] = append(ec[b], raw[at])
+			at++
+		}
+	}
+	var codewords []byte
+	for b := range lengths {
+		if want := qrRemainder(data[b], qrGenerator(blocks.ecPerBlock)); !bytes.Equal(ec[b], want) {
+			t.Fatalf("block %d: error correction % X, want % X", b, ec[b], want)
+		}
+		codewords = append(codewords, data[b]...)
+	}
+
+	pos := 0
+	read := func(n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v = v<<1 | int(codewords[pos/8]>>(7-pos%8)&1)
+			pos++
+		}
+		return v
+	}
+	if mode := read(4); mode != 0b0100 {
+		t.Fatalf("mode %04b, want byte mode", mode)
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	out := make([]byte, read(countBits))
+	for i := range out {
+		out[i] = byte(read(8))
+	}
+	for pos%8 != 0 && pos < 8*len(codewords) {
+		if read(1) != 0 {
+			t.Fatalf("terminator or padding bit set at %d", pos-1)
+		}
+	}
+	for i, pad := pos/8, byte(0xec); i < len(codewords); i, pad = i+1, pad^0xec^0x11 {
+		if codewords[i] != pad {
+			t.Fatalf("pad codeword %d is %02X, want %02X", i, codewords[i], pad)
+		}
+	}
+	return out
+}
+
+func TestQRRoundTrip(t *testing.T) {
+	for _, link := range []string{
+		"",
+		"https://example.com/join/ABC123",
+		strings.Repeat("https://example.com/join/", 6) + "XYZ",
+		strings.Repeat("x", 213),
+	} {
+		qr, err := encodeQR([]byte(link))
+		if err != nil {
+			t.Fatalf("%q: %v", link, err)
+		}
+		if got := string(readQR(t, qr)); got != link {
+			t.Errorf("read back %q, want %q", got, link)
+		}
+	}
+}
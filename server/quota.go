@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadDeckQuota overrides the per-creator deck storage quota from the
+// environment. Zero turns the quota off.
+func loadDeckQuota() {
+	if n, err := strconv.ParseInt(os.Getenv("DECK_QUOTA_BYTES"), 10, 64); err == nil && n >= 0 {
+		config.DeckQuotaBytes = n
+	}
+}
+
+// deckUsage is how many bytes of card images the creator's decks hold.
+// Decks from before creators were recorded belong to nobody and don't count.
+func deckUsage(db *gorm.DB, creator string) int64 {
+	var used int64
+	db.Model(&DeckPreview{}).
+		Select("COALESCE(SUM(bytes), 0)").
+		Where("creator = ?", creator).
+		Scan(&used)
+	return used
+}
+
+func deckBytes(cardImgs [][]byte) int64 {
+	var total int64
+	for _, cardImg := range cardImgs {
+		total += int64(len(cardImg))
+	}
+	return total
+}
+
+// checkDeckQuota answers 413 and returns false when adding size bytes of
+// cards would take the creator over their quota.
+func checkDeckQuota(db *gorm.DB, c *gin.Context, creator string, size int64) bool {
+	if config.DeckQuotaBytes <= 0 {
+		return true
+	}
+	if used := deckUsage(db, creator); used+size > config.DeckQuotaBytes {
+		respondError(c, "deck_quota_exceeded", used, config.DeckQuotaBytes)
+		return false
+	}
+	return true
+}
+
+// sessionUser resolves the session a deck request was made with.
+func sessionUser(db *gorm.DB, c *gin.Context, sessionID string) (User, bool) {
+	var user User
+	if sessionID == "" {
+		respondError(c, "session_id_required")
+		return user, false
+	}
+	if err := db.Where("session_id = ?", sessionID).First(&user).Error; err != nil {
+		respondError(c, "user_not_found")
+		return user, false
+	}
+	return user, true
+}
+
+// deckStorage reports how much of their quota the player's decks use, deck
+// by deck, so they can pick which ones to clear.
+func deckStorage(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "deck_usage")
+
+	user, ok := sessionUser(db, c, c.Query("session_id"))
+	if !ok {
+		return
+	}
+
+	var previews []DeckPreview
+	if err := db.Where("creator = ?", user.Login).Order("created_at").Find(&previews).Error; err != nil {
+		respondError(c, "decks_failed")
+		return
+	}
+
+	var used int64
+	decks := make([]gin.H, 0, len(previews))
+	for _, preview := range previews {
+		used += preview.Bytes
+		decks = append(decks, gin.H{
+			"deckId":    preview.DeckId,
+			"gameId":    preview.GameId,
+			"cardCount": preview.CardCount,
+			"bytes":     preview.Bytes,
+			"createdAt": preview.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"login":      user.Login,
+		"usedBytes":  used,
+		"quotaBytes": config.DeckQuotaBytes,
+		"decks":      decks,
+	})
+}
+
+// clearDecks deletes the player's decks created before the given time, or
+// all of them without one, to free quota. Decks a running game is playing
+// with are kept.
+func clearDecks(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "deck_clear")
+
+	var request struct {
+		SessionID string    `json:"sessionId"`
+		Before    time.Time `json:"before"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+	user, ok := sessionUser(db, c, request.SessionID)
+	if !ok {
+		return
+	}
+
+	query := db.Where("creator = ?", user.Login).
+		Where("deck_id NOT IN (?)", db.Model(&RoomSettings{}).Select("custom_deck"))
+	if !request.Before.IsZero() {
+		query = query.Where("created_at < ?", request.Before)
+	}
+	var previews []DeckPreview
+	if err := query.Find(&previews).Error; err != nil {
+		respondError(c, "decks_failed")
+		return
+	}
+
+	var freed int64
+	deckIDs := make([]uint, 0, len(previews))
+	for _, preview := range previews {
+		deckIDs = append(deckIDs, preview.DeckId)
+		freed += preview.Bytes
+	}
+	if len(deckIDs) > 0 {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("deck_id IN ?", deckIDs).Delete(&customDeck{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("deck_id IN ?", deckIDs).Delete(&DeckPreview{}).Error
+		})
+		if err != nil {
+			respondError(c, "deck_clear_failed")
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deckIds":    deckIDs,
+		"freedBytes": freed,
+		"usedBytes":  deckUsage(db, user.Login),
+	})
+}
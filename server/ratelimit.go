@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadUsage counts one uploader's images in the current minute and day.
+type uploadUsage struct {
+	minuteStart time.Time
+	minute      int
+	dayStart    time.Time
+	day         int
+}
+
+// uploadLimiter caps how many images each uploader can send per minute and
+// per day. Counts live in memory, so they reset when the server restarts.
+type uploadLimiter struct {
+	mu        sync.Mutex
+	usage     map[string]*uploadUsage
+	nextPrune time.Time
+}
+
+var uploads = &uploadLimiter{usage: map[string]*uploadUsage{}}
+
+// loadUploadRates overrides the default upload rate limits from the
+// environment. Zero turns a limit off.
+func loadUploadRates() {
+	if n, err := strconv.Atoi(os.Getenv("UPLOAD_RATE_MINUTE")); err == nil && n >= 0 {
+		config.UploadsPerMinute = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("UPLOAD_RATE_DAY")); err == nil && n >= 0 {
+		config.UploadsPerDay = n
+	}
+}
+
+// allow charges count images to key. When that would go over a limit nothing
+// is charged and it returns how long until the limit resets.
+func (l *uploadLimiter) allow(key string, count int) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.After(l.nextPrune) {
+		for k, u := range l.usage {
+			if now.Sub(u.dayStart) >= 24*time.Hour {
+				delete(l.usage, k)
+			}
+		}
+		l.nextPrune = now.Add(time.Hour)
+	}
+
+	u := l.usage[key]
+	if u == nil {
+		u = &uploadUsage{minuteStart: now, dayStart: now}
+		l.usage[key] = u
+	}
+	if now.Sub(u.minuteStart) >= time.Minute {
+		u.minuteStart, u.minute = now, 0
+	}
+	if now.Sub(u.dayStart) >= 24*time.Hour {
+		u.dayStart, u.day = now, 0
+	}
+
+	if config.UploadsPerDay > 0 && u.day+count > config.UploadsPerDay {
+		return u.dayStart.Add(24 * time.Hour).Sub(now), false
+	}
+	if config.UploadsPerMinute > 0 && u.minute+count > config.UploadsPerMinute {
+		return u.minuteStart.Add(time.Minute).Sub(now), false
+	}
+	u.minute += count
+	u.day += count
+	return 0, true
+}
+
+// uploaderKey identifies who is uploading: the session when the request has
+// one, otherwise the client address, which is all register has to go on.
+func uploaderKey(c *gin.Context, sessionID string) string {
+	if sessionID != "" {
+		return "session:" + sessionID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// allowUpload charges count images to the uploader, answering 429 with a
+// Retry-After hint and returning false when they are over their limit.
+func allowUpload(c *gin.Context, sessionID string, count int) bool {
+	wait, ok := uploads.allow(uploaderKey(c, sessionID), count)
+	if ok {
+		return true
+	}
+	seconds := int((wait + time.Second - 1) / time.Second)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	body := errorBody(c, "upload_rate_limited", seconds)
+	body["retry_after"] = seconds
+	c.JSON(errorStatus("upload_rate_limited"), body)
+	return false
+}
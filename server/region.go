@@ -0,0 +1,155 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// regionNetwork maps the clients in a network to a region.
+type regionNetwork struct {
+	Region  string
+	Network *net.IPNet
+}
+
+// regionPattern is what a region name looks like, such as "eu" or
+// "us-east".
+var regionPattern = regexp.MustCompile(`^[a-z]{2,8}(-[a-z0-9]{1,8}){0,2}$`)
+
+// loadRegions reads REGION_NETWORKS, a comma-separated list of
+// region=CIDR entries used to place clients that don't say where they are.
+func loadRegions() {
+	for _, entry := range strings.Split(os.Getenv("REGION_NETWORKS"), ",") {
+		region, cidr, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil || !regionPattern.MatchString(region) {
+			warnf("Ignoring region network %q", entry)
+			continue
+		}
+		config.RegionNetworks = append(config.RegionNetworks, regionNetwork{Region: region, Network: network})
+	}
+}
+
+// clientRegion places the caller in a region: the one they hinted, if it
+// looks like a region name, else the one their address falls in, else none.
+// ok is false for a malformed hint.
+func clientRegion(c *gin.Context, hint string) (region string, ok bool) {
+	if hint != "" {
+		hint = strings.ToLower(strings.TrimSpace(hint))
+		return hint, regionPattern.MatchString(hint)
+	}
+	ip := net.ParseIP(c.ClientIP())
+	if ip == nil {
+		return "", true
+	}
+	for _, rn := range config.RegionNetworks {
+		if rn.Network.Contains(ip) {
+			return rn.Region, true
+		}
+	}
+	return "", true
+}
+
+// updateRegion records region against the user's session when it is known
+// and has changed.
+func updateRegion(db *gorm.DB, user *User, region string) {
+	if region == "" || region == user.Region {
+		return
+	}
+	if err := db.Model(user).Update("region", region).Error; err != nil {
+		errorf("Failed to record region for %s: %v", user.Login, err)
+	}
+}
+
+// quickMatch finds a room for the session to join: one that hasn't started,
+// has a free seat and that they aren't already in. Rooms hosted from the
+// session's region come first to keep WebSocket latency low, then fuller
+// rooms, so games fill up, then older ones; with none in the region any open
+// room will do.
+func quickMatch(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "quick_match")
+
+	var json struct {
+		SessionID string `json:"session_id"`
+		Region    string `json:"region"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" {
+		respondError(c, "session_id_required")
+		return
+	}
+	region, ok := clientRegion(c, json.Region)
+	if !ok {
+		respondError(c, "invalid_region")
+		return
+	}
+
+	var user User
+	if err := db.Where("session_id = ?", json.SessionID).First(&user).Error; err != nil {
+		respondError(c, "user_not_found")
+		return
+	}
+	updateRegion(db, &user, region)
+	if region == "" {
+		region = user.Region
+	}
+
+	var candidates []struct {
+		GameID      string
+		Name        string
+		Region      string
+		Capacity    int
+		PlayerCount int
+		CreatedAt   time.Time
+	}
+	err := db.Raw(`SELECT s.game_id, s.name, s.region, s.capacity, COUNT(r.id) AS player_count, s.created_at
+		FROM room_settings s
+		JOIN rooms r ON r.game_id = s.game_id
+		WHERE NOT EXISTS (SELECT 1 FROM ws_members m WHERE m.game_id = s.game_id AND m.started)
+			AND NOT EXISTS (SELECT 1 FROM rooms own WHERE own.game_id = s.game_id AND own.session_id = ?)
+		GROUP BY s.id`, json.SessionID).Scan(&candidates).Error
+	if err != nil {
+		respondError(c, "quick_match_failed")
+		return
+	}
+
+	open := candidates[:0]
+	for _, room := range candidates {
+		if room.PlayerCount < (RoomSettings{Capacity: room.Capacity}).capacity() {
+			open = append(open, room)
+		}
+	}
+	if len(open) == 0 {
+		respondError(c, "no_open_rooms")
+		return
+	}
+	sort.SliceStable(open, func(i, j int) bool {
+		a, b := open[i], open[j]
+		if region != "" && (a.Region == region) != (b.Region == region) {
+			return a.Region == region
+		}
+		if a.PlayerCount != b.PlayerCount {
+			return a.PlayerCount > b.PlayerCount
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	match := open[0]
+	c.JSON(http.StatusOK, gin.H{
+		"game_id":      match.GameID,
+		"name":         match.Name,
+		"region":       match.Region,
+		"same_region":  region != "" && match.Region == region,
+		"player_count": match.PlayerCount,
+		"capacity":     RoomSettings{Capacity: match.Capacity}.capacity(),
+	})
+}
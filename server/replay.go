@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxReplayGap caps how long a timed replay waits between two events so
+// idle stretches of a game don't stall playback.
+const maxReplayGap = 30 * time.Second
+
+// encodeBaseMessage wraps an event's payload in the wire format of the
+// WebSocket protocol's BaseMessage (classId = 1, data = 2), so replay clients
+// can feed frames straight into their normal message handling.
+func encodeBaseMessage(classID int32, data []byte) []byte {
+	buf := make([]byte, 0, len(data)+12)
+	if classID != 0 {
+		buf = append(buf, 0x08)
+		buf = binary.AppendUvarint(buf, uint64(classID))
+	}
+	if len(data) > 0 {
+		buf = append(buf, 0x12)
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		buf = append(buf, data...)
+	}
+	return buf
+}
+
+// replayGame streams a finished game's event log as server-sent events. In
+// the default timed mode events keep their original spacing, scaled by
+// speed; in step mode they are sent at once and the client advances through
+// them itself using offset_ms. Playback resumes after Last-Event-ID or the
+// from parameter. Anyone may watch a replay, so players are known by login:
+// session IDs are credentials and stay out of it.
+func replayGame(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "replay")
+
+	gameID := c.Param("game_id")
+	mode := c.DefaultQuery("mode", "timed")
+	if mode != "timed" && mode != "step" {
+		respondError(c, "invalid_replay_mode")
+		return
+	}
+	speed, err := strconv.ParseFloat(c.DefaultQuery("speed", "1"), 64)
+	if err != nil || speed <= 0 {
+		respondError(c, "invalid_speed")
+		return
+	}
+	from := c.GetHeader("Last-Event-ID")
+	if from == "" {
+		from = c.Query("from")
+	}
+	var after uint64
+	if from != "" {
+		if after, err = strconv.ParseUint(from, 10, 64); err != nil {
+			respondError(c, "invalid_event_id")
+			return
+		}
+	}
+
+	var running int64
+	db.Model(&RoomSettings{}).Where("game_id = ?", gameID).Count(&running)
+	if running > 0 {
+		respondError(c, "game_running")
+		return
+	}
+
+	var events []GameEvent
+	if err := db.Where("game_id = ?", gameID).Order("occurred_at, id").Find(&events).Error; err != nil {
+		respondError(c, "events_failed")
+		return
+	}
+	if len(events) == 0 {
+		respondError(c, "no_events")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	ctx := c.Request.Context()
+	first := events[0].OccurredAt
+	var previous time.Time
+	for _, event := range events {
+		if uint64(event.ID) <= after {
+			continue
+		}
+
+		if mode == "timed" && !previous.IsZero() {
+			gap := event.OccurredAt.Sub(previous)
+			if gap > maxReplayGap {
+				gap = maxReplayGap
+			}
+			select {
+			case <-time.After(time.Duration(float64(gap) / speed)):
+			case <-ctx.Done():
+				return
+			}
+		}
+		previous = event.OccurredAt
+
+		payload, err := json.Marshal(gin.H{
+			"login":     event.Login,
+			"offset_ms": event.OccurredAt.Sub(first).Milliseconds(),
+			"message":   encodeBaseMessage(event.ClassID, event.Data),
+		})
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+		c.Writer.Flush()
+	}
+
+	fmt.Fprint(c.Writer, "event: end\ndata: {}\n\n")
+	c.Writer.Flush()
+}
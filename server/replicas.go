@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// replicas are read-only copies of the database, named in DATABASE_REPLICAS.
+// Reads that can stand to lag the primary a little, like the lobby listing,
+// the situation catalogue and stats, are spread over them so they don't
+// contend with game writes; without any, everything goes to the primary.
+var (
+	replicas    []*gorm.DB
+	nextReplica atomic.Uint64
+)
+
+// openReplicas opens the databases in DATABASE_REPLICAS, each with the
+// driver its DSN calls for.
+func openReplicas() error {
+	for _, dsn := range splitAddrs(os.Getenv("DATABASE_REPLICAS")) {
+		replica, err := openDatabase(dsn)
+		if err != nil {
+			return err
+		}
+		if err := replica.Use(dbMetricsPlugin{}); err != nil {
+			return err
+		}
+		replicas = append(replicas, replica)
+	}
+	return nil
+}
+
+// readReplica returns a handle on the next replica for read-only queries,
+// carrying db's context and operation, or db itself when there are no
+// replicas. Nothing should be written through it.
+func readReplica(db *gorm.DB) *gorm.DB {
+	if len(replicas) == 0 {
+		return db
+	}
+	replica := replicas[nextReplica.Add(1)%uint64(len(replicas))]
+	if op, ok := db.Get(metricsOpKey); ok {
+		replica = replica.Set(metricsOpKey, op)
+	}
+	return replica.WithContext(db.Statement.Context)
+}
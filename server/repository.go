@@ -0,0 +1,426 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var errNotEnoughRows = errors.New("not enough rows to pick from")
+
+// repository wraps the queries whose SQL depends on the database dialect so
+// handlers don't have to care which backend they run against. Writes, and
+// reads that have to see them, go to db; reads that can lag a little go to
+// read, which is a replica when there are any.
+type repository struct {
+	db   *gorm.DB
+	read *gorm.DB
+}
+
+func newRepository(db *gorm.DB) *repository {
+	return &repository{db: db, read: readReplica(db)}
+}
+
+// pickRandom loads one random row matched by query into dest. It counts the
+// candidates and reads a single row at a random offset instead of sorting the
+// whole table with ORDER BY RANDOM().
+func pickRandom(query *gorm.DB, dest interface{}) error {
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	offset := rand.Intn(int(count))
+	return query.Session(&gorm.Session{}).Offset(offset).Limit(1).Take(dest).Error
+}
+
+// pickRandomN loads n distinct random rows matched by query, reading each at
+// its own random offset.
+func pickRandomN[T any](query *gorm.DB, n int) ([]T, error) {
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if int(count) < n {
+		return nil, errNotEnoughRows
+	}
+
+	picked := make(map[int]bool, n)
+	rows := make([]T, 0, n)
+	for len(rows) < n {
+		offset := rand.Intn(int(count))
+		if picked[offset] {
+			continue
+		}
+		picked[offset] = true
+
+		var row T
+		if err := query.Session(&gorm.Session{}).Offset(offset).Limit(1).Take(&row).Error; err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// situationCandidates is how many random situations SituationForGame draws
+// before serving the one served longest ago, across all games. More
+// candidates make a recently served prompt less likely to come up again,
+// which matters most when the pool is small and games run back to back.
+const situationCandidates = 4
+
+// pickLeastRecent draws up to situationCandidates random situations matched
+// by query and returns the one served longest ago.
+func pickLeastRecent(query *gorm.DB) (Situation, error) {
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return Situation{}, err
+	}
+	if count == 0 {
+		return Situation{}, gorm.ErrRecordNotFound
+	}
+
+	candidates, err := pickRandomN[Situation](query, min(situationCandidates, int(count)))
+	if err != nil {
+		return Situation{}, err
+	}
+	picked := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if candidate.LastServedAt.Before(picked.LastServedAt) {
+			picked = candidate
+		}
+	}
+	return picked, nil
+}
+
+type situationFilter struct {
+	Language string
+	Pack     string
+}
+
+// SituationForGame picks a situation matching filter that the game hasn't
+// been given yet and records it against the game. Once every matching
+// situation has been used the game's history is cleared and the pool starts
+// over. An empty gameID gives an anonymous pick with no history of its own.
+// Every pick leans away from situations other games were served recently,
+// see situationCandidates. The pick comes from the catalogue on a replica,
+// the history from the primary.
+func (r *repository) SituationForGame(gameID string, filter situationFilter) (Situation, error) {
+	query := r.read.Model(&Situation{})
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
+	}
+	if filter.Pack != "" {
+		query = query.Where("pack = ?", filter.Pack)
+	}
+	query = query.Session(&gorm.Session{})
+
+	if gameID == "" {
+		situation, err := pickLeastRecent(query)
+		if err == nil {
+			r.markServed(situation)
+		}
+		return situation, err
+	}
+
+	served, err := r.servedSituations(gameID, false)
+	if err != nil {
+		return Situation{}, err
+	}
+	situation, err := pickLeastRecent(excludeIDs(query, served))
+	if err == gorm.ErrRecordNotFound {
+		r.db.Where("game_id = ? AND custom = ?", gameID, false).Delete(&GameSituation{})
+		situation, err = pickLeastRecent(query)
+	}
+	if err != nil {
+		return situation, err
+	}
+
+	r.db.Create(&GameSituation{GameID: gameID, SituationID: situation.ID})
+	r.markServed(situation)
+	return situation, nil
+}
+
+// markServed records that a situation has just been served.
+func (r *repository) markServed(situation Situation) {
+	if err := r.db.Model(&Situation{}).Where("id = ?", situation.ID).Update("last_served_at", time.Now()).Error; err != nil {
+		warnf("Failed to mark situation %d served: %v", situation.ID, err)
+	}
+}
+
+// CustomSituationForGame is SituationForGame for a host-uploaded prompt
+// deck, with the same no-repeat history.
+func (r *repository) CustomSituationForGame(gameID string, deckID uint) (string, error) {
+	query := r.read.Model(&customSituationDeck{}).Where("deck_id = ?", deckID).Session(&gorm.Session{})
+
+	var situation customSituationDeck
+	served, err := r.servedSituations(gameID, true)
+	if err != nil {
+		return "", err
+	}
+	err = pickRandom(excludeIDs(query, served), &situation)
+	if err == gorm.ErrRecordNotFound {
+		r.db.Where("game_id = ? AND custom = ?", gameID, true).Delete(&GameSituation{})
+		err = pickRandom(query, &situation)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	r.db.Create(&GameSituation{GameID: gameID, SituationID: situation.ID, Custom: true})
+	return situation.Text, nil
+}
+
+// servedSituations lists the situations the game has been given from the
+// catalogue, or from its custom deck.
+func (r *repository) servedSituations(gameID string, custom bool) ([]uint, error) {
+	var served []uint
+	err := r.db.Model(&GameSituation{}).Where("game_id = ? AND custom = ?", gameID, custom).Pluck("situation_id", &served).Error
+	return served, err
+}
+
+// excludeIDs narrows query to rows whose id isn't in ids.
+func excludeIDs(query *gorm.DB, ids []uint) *gorm.DB {
+	if len(ids) == 0 {
+		return query
+	}
+	return query.Where("id NOT IN ?", ids)
+}
+
+// openRoom is a room as the lobby browser lists it.
+type openRoom struct {
+	GameID      string
+	Name        string
+	PlayerCount int
+	Capacity    int
+	HostLogin   string
+	Started     bool
+	CreatedAt   *time.Time
+}
+
+// OpenRooms lists the rooms with their player counts and hosts for the
+// lobby browser, which doesn't mind being a moment behind.
+func (r *repository) OpenRooms() ([]openRoom, error) {
+	var rooms []openRoom
+	err := r.read.Raw(`SELECT r.game_id, COALESCE(s.name, '') AS name, COUNT(u.id) AS player_count,
+			COALESCE(s.capacity, 0) AS capacity, COALESCE(h.login, '') AS host_login,
+			EXISTS (SELECT 1 FROM ws_members m WHERE m.game_id = r.game_id AND m.started) AS started,
+			s.created_at
+		FROM rooms r
+		LEFT JOIN users u ON r.session_id = u.session_id
+		LEFT JOIN room_settings s ON s.game_id = r.game_id
+		LEFT JOIN users h ON h.session_id = s.host_session
+		GROUP BY r.game_id, s.id, h.id`).Scan(&rooms).Error
+	return rooms, err
+}
+
+type cardFilter struct {
+	Tags          []string
+	Pack          string
+	ExcludedPacks []string
+}
+
+// likeEscaper escapes a value to be matched literally in a LIKE pattern
+// with ESCAPE '\', so a tag like "50%" or "a_b" means just that.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// apply narrows a Card query to cards carrying every tag in the filter,
+// from the requested pack and outside the excluded ones.
+func (f cardFilter) apply(query *gorm.DB) *gorm.DB {
+	for _, tag := range f.Tags {
+		query = query.Where(`tags LIKE ? ESCAPE '\'`, "%,"+likeEscaper.Replace(tag)+",%")
+	}
+	if f.Pack != "" {
+		query = query.Where("pack = ?", f.Pack)
+	}
+	if len(f.ExcludedPacks) > 0 {
+		query = query.Where("pack NOT IN ?", f.ExcludedPacks)
+	}
+	return query
+}
+
+// DealCards picks n distinct cards that haven't been dealt in the game yet
+// and records them as dealt. When fewer than n undealt cards remain the
+// game's dealt pile is shuffled back in. An empty gameID deals without
+// tracking anything.
+func (r *repository) DealCards(gameID string, n int, filter cardFilter) ([]Card, error) {
+	pool := filter.apply(r.db.Model(&Card{})).Session(&gorm.Session{})
+	if gameID == "" {
+		return pickRandomN[Card](pool, n)
+	}
+
+	unlock := lockGame(gameID)
+	defer unlock()
+
+	return r.dealFrom(gameID, pool, n)
+}
+
+// DealFavoredCards is DealCards with up to favored of the n cards drawn from
+// those the game's members have favorited. When they haven't favorited
+// enough undealt cards the rest come from the whole pool.
+func (r *repository) DealFavoredCards(gameID string, n, favored int, filter cardFilter) ([]Card, error) {
+	pool := filter.apply(r.db.Model(&Card{})).Session(&gorm.Session{})
+
+	unlock := lockGame(gameID)
+	defer unlock()
+
+	dealt := r.db.Model(&DealtCard{}).Select("card_id").Where("game_id = ?", gameID)
+	favorites := pool.Where("id IN (?) AND id NOT IN (?)", roomFavorites(r.db, gameID), dealt)
+	var available int64
+	if err := favorites.Session(&gorm.Session{}).Count(&available).Error; err != nil {
+		return nil, err
+	}
+	if int(available) < favored {
+		favored = int(available)
+	}
+	cards, err := pickRandomN[Card](favorites, favored)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(cards))
+	for _, card := range cards {
+		if err := r.db.Create(&DealtCard{GameID: gameID, CardID: card.ID}).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, card.ID)
+	}
+
+	rest, err := r.dealFrom(gameID, excludeIDs(pool, ids).Session(&gorm.Session{}), n-favored)
+	if err != nil {
+		return nil, err
+	}
+	return append(cards, rest...), nil
+}
+
+// dealFrom deals n cards from pool that haven't been dealt in the game and
+// records them as dealt, shuffling the dealt pile back in when too few are
+// left. The caller holds the game's lock.
+func (r *repository) dealFrom(gameID string, pool *gorm.DB, n int) ([]Card, error) {
+	dealt := r.db.Model(&DealtCard{}).Select("card_id").Where("game_id = ?", gameID)
+	cards, err := pickRandomN[Card](pool.Where("id NOT IN (?)", dealt), n)
+	if err == errNotEnoughRows {
+		warnf("Card pool ran dry for game_id %s, reshuffling dealt cards", gameID)
+		r.db.Where("game_id = ?", gameID).Delete(&DealtCard{})
+		cards, err = pickRandomN[Card](pool, n)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, card := range cards {
+		if err := r.db.Create(&DealtCard{GameID: gameID, CardID: card.ID}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return cards, nil
+}
+
+func (r *repository) RandomCustomCards(deckID uint, n int) ([]customDeck, error) {
+	return pickRandomN[customDeck](r.db.Model(&customDeck{}).Where("deck_id = ?", deckID), n)
+}
+
+// DealCustomHand deals n cards from a custom deck to a session so that no
+// two sessions in the same game hold the same card. A session asking again
+// in the same round gets the hand it was dealt back, so it can't re-roll;
+// in a later round its previous hand goes back to the deck and a new one is
+// dealt. It reports whether the hand was newly dealt. Without a game the
+// hand is drawn without tracking.
+func (r *repository) DealCustomHand(gameID, sessionID string, round int, deckID uint, n int) ([]customDeck, bool, error) {
+	deck := r.db.Model(&customDeck{}).Where("deck_id = ?", deckID)
+	if gameID == "" {
+		cards, err := pickRandomN[customDeck](deck, n)
+		return cards, true, err
+	}
+
+	unlock := lockGame(gameID)
+	defer unlock()
+
+	var hand []customDeck
+	dealt := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var held []DealtCustomCard
+		if err := tx.Where("game_id = ? AND session_id = ? AND deck_id = ? AND round = ?", gameID, sessionID, deckID, round).Order("id").Find(&held).Error; err != nil {
+			return err
+		}
+		if len(held) > 0 {
+			for _, row := range held {
+				var card customDeck
+				if err := tx.First(&card, row.CardID).Error; err != nil {
+					return err
+				}
+				hand = append(hand, card)
+			}
+			return nil
+		}
+
+		if err := tx.Where("game_id = ? AND session_id = ?", gameID, sessionID).Delete(&DealtCustomCard{}).Error; err != nil {
+			return err
+		}
+
+		assigned := tx.Model(&DealtCustomCard{}).Select("card_id").Where("game_id = ? AND deck_id = ?", gameID, deckID)
+		cards, err := pickRandomN[customDeck](tx.Model(&customDeck{}).Where("deck_id = ? AND id NOT IN (?)", deckID, assigned), n)
+		if err != nil {
+			return err
+		}
+
+		for _, card := range cards {
+			if err := tx.Create(&DealtCustomCard{
+				GameID:    gameID,
+				SessionID: sessionID,
+				DeckID:    deckID,
+				CardID:    card.ID,
+				Round:     round,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		hand = cards
+		dealt = true
+		return nil
+	})
+	return hand, dealt, err
+}
+
+// gameLock is a game's mutex, counting the callers holding or waiting for
+// it so it can be dropped once nobody is.
+type gameLock struct {
+	sync.Mutex
+	refs int
+}
+
+var (
+	gameLocksMu sync.Mutex
+	gameLocks   = make(map[string]*gameLock)
+)
+
+// lockGame serializes work on a single game's state, such as dealing from
+// its pile, without blocking other games. A game's lock only exists while
+// somebody holds or waits for it, so closed games leave nothing behind.
+func lockGame(gameID string) func() {
+	gameLocksMu.Lock()
+	lock, ok := gameLocks[gameID]
+	if !ok {
+		lock = &gameLock{}
+		gameLocks[gameID] = lock
+	}
+	lock.refs++
+	gameLocksMu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		gameLocksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(gameLocks, gameID)
+		}
+		gameLocksMu.Unlock()
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// tusVersion is the version of the tus resumable upload protocol spoken by
+// the /uploads endpoints.
+const tusVersion = "1.0.0"
+
+// resumableUploadTTL is how long an unfinished or unclaimed resumable upload
+// is kept.
+const resumableUploadTTL = 24 * time.Hour
+
+const (
+	uploadKindAvatar = "avatar"
+	uploadKindCard   = "card"
+)
+
+// ResumableUpload is an image sent in pieces over the tus protocol so an
+// interrupted upload can carry on from where it stopped. The bytes
+// received so far sit in a file under the upload folder, whose size is the
+// upload's offset. A finished card goes straight into its chunked deck
+// upload; a finished avatar waits for register to claim it by ID.
+type ResumableUpload struct {
+	ID           string `gorm:"primaryKey"`
+	Kind         string `gorm:"not null"`
+	Filename     string `gorm:"not null"`
+	Length       int64  `gorm:"not null"`
+	SessionID    string
+	DeckUploadID uint
+	Position     int
+	CreatedAt    time.Time
+}
+
+func (u ResumableUpload) path() string {
+	return filepath.Join(config.UploadFolder, "partial", u.ID)
+}
+
+// offset is how many bytes of the upload have been received.
+func (u ResumableUpload) offset() int64 {
+	info, err := os.Stat(u.path())
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+var resumableLocks sync.Map
+
+// lockResumable serializes writes to a single upload.
+func lockResumable(id string) func() {
+	value, _ := resumableLocks.LoadOrStore(id, &sync.Mutex{})
+	m := value.(*sync.Mutex)
+	m.Lock()
+	return m.Unlock
+}
+
+// parseUploadMetadata decodes a tus Upload-Metadata header: comma-separated
+// pairs of a key and a base64 value.
+func parseUploadMetadata(header string) map[string]string {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 {
+			continue
+		}
+		var value []byte
+		if len(fields) > 1 {
+			value, _ = base64.StdEncoding.DecodeString(fields[1])
+		}
+		metadata[fields[0]] = string(value)
+	}
+	return metadata
+}
+
+func findResumable(db *gorm.DB, c *gin.Context) (ResumableUpload, bool) {
+	var upload ResumableUpload
+	if err := db.Where("id = ?", c.Param("id")).First(&upload).Error; err != nil {
+		respondError(c, "upload_not_found")
+		return upload, false
+	}
+	return upload, true
+}
+
+func deleteResumable(db *gorm.DB, upload ResumableUpload) {
+	os.Remove(upload.path())
+	db.Where("id = ?", upload.ID).Delete(&ResumableUpload{})
+	resumableLocks.Delete(upload.ID)
+}
+
+// tusOptions advertises what the upload endpoints support.
+func tusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", "creation,termination")
+	c.Header("Tus-Max-Size", strconv.FormatInt(config.MaxUploadBytes, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// createResumable opens a resumable upload of Upload-Length bytes. The
+// metadata names the file and what it is for: an avatar, or card "card" of
+// the chunked deck upload "deck_upload" owned by "session_id".
+func createResumable(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "resumable_create")
+	c.Header("Tus-Resumable", tusVersion)
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		respondError(c, "invalid_upload_length")
+		return
+	}
+	if length > config.MaxUploadBytes {
+		uerr := &uploadError{Code: uploadErrFileSize, Args: []interface{}{config.MaxUploadBytes}}
+		uerr.respond(c)
+		return
+	}
+	metadata := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if !allowedFile(metadata["filename"]) {
+		uerr := &uploadError{Code: uploadErrExtension}
+		uerr.respond(c)
+		return
+	}
+
+	upload := ResumableUpload{
+		ID:        uuid.New().String(),
+		Kind:      metadata["kind"],
+		Filename:  secureFilename(metadata["filename"]),
+		Length:    length,
+		SessionID: metadata["session_id"],
+	}
+	switch upload.Kind {
+	case uploadKindAvatar:
+	case uploadKindCard:
+		var deckUpload DeckUpload
+		if err := db.Where("id = ?", metadata["deck_upload"]).First(&deckUpload).Error; err != nil || deckUpload.SessionID != upload.SessionID {
+			respondError(c, "deck_upload_not_found")
+			return
+		}
+		position, err := strconv.Atoi(metadata["card"])
+		if err != nil || position < 1 || position > deckUpload.CardCount {
+			respondError(c, "invalid_card_position", deckUpload.CardCount)
+			return
+		}
+		upload.DeckUploadID = deckUpload.ID
+		upload.Position = position
+	default:
+		respondError(c, "invalid_upload_kind")
+		return
+	}
+	if !allowUpload(c, upload.SessionID, 1) {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(upload.path()), os.ModePerm); err != nil {
+		respondError(c, "file_save_failed")
+		return
+	}
+	if err := os.WriteFile(upload.path(), nil, 0o644); err != nil {
+		respondError(c, "file_save_failed")
+		return
+	}
+	if err := db.Create(&upload).Error; err != nil {
+		os.Remove(upload.path())
+		respondError(c, "file_save_failed")
+		return
+	}
+
+	c.Header("Location", "/uploads/"+upload.ID)
+	c.Header("Upload-Offset", "0")
+	c.Status(http.StatusCreated)
+}
+
+// resumableOffset tells a client where to carry on from.
+func resumableOffset(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "resumable_head")
+	c.Header("Tus-Resumable", tusVersion)
+
+	upload, ok := findResumable(db, c)
+	if !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(upload.offset(), 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Status(http.StatusOK)
+}
+
+// appendResumable writes the next piece of an upload at Upload-Offset. Bytes
+// that arrive before a connection drops are kept, so the client resumes from
+// whatever offset HEAD reports afterwards.
+func appendResumable(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "resumable_patch")
+	c.Header("Tus-Resumable", tusVersion)
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		respondError(c, "invalid_content_type")
+		return
+	}
+	unlock := lockResumable(c.Param("id"))
+	defer unlock()
+
+	upload, ok := findResumable(db, c)
+	if !ok {
+		return
+	}
+	offset := upload.offset()
+	if claimed, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64); err != nil || claimed != offset {
+		c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+		respondError(c, "upload_offset_mismatch", offset)
+		return
+	}
+
+	f, err := os.OpenFile(upload.path(), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		respondError(c, "file_save_failed")
+		return
+	}
+	n, err := io.Copy(f, contextReader{c.Request.Context(), io.LimitReader(c.Request.Body, upload.Length-offset)})
+	f.Close()
+	offset += n
+	c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+	if err != nil {
+		respondError(c, "file_save_failed")
+		return
+	}
+
+	if offset == upload.Length && !finishResumable(db, c, upload) {
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// finishResumable checks a complete upload against the upload policy and
+// hands a card to its deck upload. Uploads that fail are discarded; the
+// client has to start over with a different image.
+func finishResumable(db *gorm.DB, c *gin.Context, upload ResumableUpload) bool {
+	data, err := readFileContext(c.Request.Context(), upload.path())
+	if err != nil {
+		respondError(c, "image_read_failed")
+		return false
+	}
+	if uerr := checkImage(data); uerr != nil {
+		deleteResumable(db, upload)
+		uerr.Card = upload.Position
+		uerr.respond(c)
+		return false
+	}
+	if upload.Kind != uploadKindCard {
+		return true
+	}
+
+	var deckUpload DeckUpload
+	if err := db.First(&deckUpload, upload.DeckUploadID).Error; err != nil {
+		deleteResumable(db, upload)
+		respondError(c, "deck_upload_not_found")
+		return false
+	}
+	if !storeDeckCard(db, c, deckUpload, upload.Position, data) {
+		return false
+	}
+	deleteResumable(db, upload)
+	return true
+}
+
+// terminateResumable abandons an upload and frees what it stored.
+func terminateResumable(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "resumable_delete")
+	c.Header("Tus-Resumable", tusVersion)
+
+	unlock := lockResumable(c.Param("id"))
+	defer unlock()
+
+	upload, ok := findResumable(db, c)
+	if !ok {
+		return
+	}
+	deleteResumable(db, upload)
+	c.Status(http.StatusNoContent)
+}
+
+// claimAvatar takes a finished avatar upload for register and returns its
+// file name and content.
+func claimAvatar(db *gorm.DB, c *gin.Context, id string) (string, []byte, bool) {
+	unlock := lockResumable(id)
+	defer unlock()
+
+	var upload ResumableUpload
+	if err := db.Where("id = ? AND kind = ?", id, uploadKindAvatar).First(&upload).Error; err != nil {
+		respondError(c, "upload_not_found")
+		return "", nil, false
+	}
+	if upload.offset() != upload.Length {
+		respondError(c, "upload_incomplete")
+		return "", nil, false
+	}
+	data, err := readFileContext(c.Request.Context(), upload.path())
+	if err != nil {
+		respondError(c, "image_read_failed")
+		return "", nil, false
+	}
+	deleteResumable(db, upload)
+	return upload.Filename, data, true
+}
+
+// sweepResumableUploads drops uploads that were never finished or claimed.
+func sweepResumableUploads(db *gorm.DB) {
+	var uploads []ResumableUpload
+	db.Where("created_at < ?", time.Now().Add(-resumableUploadTTL)).Find(&uploads)
+	for _, upload := range uploads {
+		deleteResumable(db, upload)
+	}
+}
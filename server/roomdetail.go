@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// gameStarted reports whether the WebSocket server has started a round of
+// the game, going by the membership it keeps in the room store.
+func gameStarted(db *gorm.DB, gameID string) bool {
+	var started int64
+	db.Model(&WSMember{}).Where("game_id = ? AND started = ?", gameID, true).Count(&started)
+	return started > 0
+}
+
+// roomDetail describes a room for a preview before joining: who is in it,
+// who hosts it, how full it is, whether it has started and how it is set
+// up. Members are listed by login and avatar thumbnail only, leaving out
+// the thumbnails of avatar_revisions the client already has. Thumbnails are
+// only for callers whose session is in the room, as avatars are.
+func roomDetail(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "room_detail")
+
+	var settings RoomSettings
+	if err := db.Where("game_id = ?", c.Param("game_id")).First(&settings).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
+	}
+
+	var rooms []Room
+	db.Where("game_id = ?", settings.GameID).Order("id").Find(&rooms)
+	sessionIDs := make([]string, 0, len(rooms))
+	member := false
+	caller, authenticated := optionalSessionUserFrom(c)
+	for _, room := range rooms {
+		sessionIDs = append(sessionIDs, room.SessionID)
+		if authenticated && room.SessionID == caller.SessionID {
+			member = true
+		}
+	}
+	members := make([]gin.H, 0, len(sessionIDs))
+	var host gin.H
+	for _, profile := range userProfiles(db, sessionIDs, profileThumbnails, parseAvatarRevisions(c.Query("avatar_revisions"))) {
+		entry := gin.H{"login": profile["login"], "avatar_revision": profile["avatar_revision"]}
+		if url, ok := profile["image_url"]; ok && member {
+			entry["image_url"] = url
+		}
+		if profile["session_id"] == settings.HostSession {
+			host = entry
+		}
+		members = append(members, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_id":    settings.GameID,
+		"name":       settings.Name,
+		"host":       host,
+		"members":    members,
+		"capacity":   settings.capacity(),
+		"started":    gameStarted(db, settings.GameID),
+		"created_at": settings.CreatedAt,
+		"rules": gin.H{
+			"language":        settings.Language,
+			"pack":            settings.Pack,
+			"card_pack":       settings.CardPack,
+			"card_tags":       splitList(settings.CardTags),
+			"exclude_packs":   splitList(settings.ExcludedPacks),
+			"custom_deck":     settings.CustomDeck,
+			"custom_ratio":    settings.CustomRatio,
+			"favorites_ratio": settings.FavoritesRatio,
+		},
+	})
+}
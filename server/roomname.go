@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxRoomNameLength caps a room's display name, in characters.
+const maxRoomNameLength = 40
+
+// cleanRoomName trims a display name and folds control characters and runs
+// of white space into single spaces. It returns false when the name is too
+// long to show in a lobby list.
+func cleanRoomName(name string) (string, bool) {
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+	return name, utf8.RuneCountInString(name) <= maxRoomNameLength
+}
+
+// setRoomName lets the host rename the room. The name is only for display;
+// players still join by game ID. An empty name clears it.
+func setRoomName(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "room_name")
+
+	var json struct {
+		SessionID string `json:"session_id"`
+		GameID    string `json:"game_id"`
+		Name      string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" || json.GameID == "" {
+		respondError(c, "game_and_session_required")
+		return
+	}
+	name, ok := cleanRoomName(json.Name)
+	if !ok {
+		respondError(c, "room_name_too_long", maxRoomNameLength)
+		return
+	}
+
+	var settings RoomSettings
+	if err := db.Where("game_id = ?", json.GameID).First(&settings).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
+	}
+	if settings.HostSession != json.SessionID {
+		respondError(c, "not_host")
+		return
+	}
+
+	db.Model(&settings).Update("name", name)
+
+	c.JSON(http.StatusOK, gin.H{"game_id": json.GameID, "name": name})
+}
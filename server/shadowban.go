@@ -0,0 +1,103 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ShadowBan marks a player whose chat only they can see and whose votes
+// don't count, without anything telling them so. Players are known by
+// login, as in the game history, so the ban outlives the player exiting and
+// registering again under the same name.
+type ShadowBan struct {
+	Login     string `gorm:"primaryKey"`
+	Reason    string
+	CreatedAt time.Time
+}
+
+// shadowBanned reports whether the session belongs to a shadow-banned
+// player.
+func shadowBanned(db *gorm.DB, sessionID string) bool {
+	var count int64
+	db.Model(&ShadowBan{}).
+		Where("login IN (?)", db.Model(&User{}).Select("login").Where("session_id = ?", sessionID)).
+		Count(&count)
+	return count > 0
+}
+
+func shadowBanInfo(ban ShadowBan) gin.H {
+	return gin.H{"login": ban.Login, "reason": ban.Reason, "created_at": ban.CreatedAt}
+}
+
+func listShadowBans(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_shadow_bans")
+
+	var bans []ShadowBan
+	if err := db.Order("created_at DESC").Find(&bans).Error; err != nil {
+		respondError(c, "shadow_ban_failed")
+		return
+	}
+	result := make([]gin.H, 0, len(bans))
+	for _, ban := range bans {
+		result = append(result, shadowBanInfo(ban))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func setShadowBan(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_shadow_bans")
+
+	var json struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil && err != io.EOF {
+		respondError(c, "invalid_request")
+		return
+	}
+
+	ban := ShadowBan{Login: c.Param("login"), Reason: json.Reason}
+	err := db.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"reason"})}).Create(&ban).Error
+	if err != nil {
+		respondError(c, "shadow_ban_failed")
+		return
+	}
+
+	recordAudit(db, c, "shadow_ban", ban.Login, ban.Reason)
+	c.JSON(http.StatusOK, shadowBanInfo(ban))
+}
+
+func liftShadowBan(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "admin_shadow_bans")
+
+	login := c.Param("login")
+	if err := db.Where("login = ?", login).Delete(&ShadowBan{}).Error; err != nil {
+		respondError(c, "shadow_ban_failed")
+		return
+	}
+
+	recordAudit(db, c, "lift_shadow_ban", login, "")
+	c.Status(http.StatusNoContent)
+}
+
+// wsShadowBans lists the sessions of shadow-banned players for the
+// WebSocket server, which keeps their chat to themselves. It takes the
+// service token: the sessions are credentials, and a banned player mustn't be able
+// to find themselves on the list.
+func wsShadowBans(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "ws_shadow_bans")
+
+	sessions := []string{}
+	err := db.Model(&User{}).
+		Where("login IN (?)", db.Model(&ShadowBan{}).Select("login")).
+		Pluck("session_id", &sessions).Error
+	if err != nil {
+		respondError(c, "shadow_ban_failed")
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// imageStream writes JSON responses that carry images base64-encoded, as
+// the older endpoints do, without holding the encoded images in memory:
+// each image in the response is a placeholder until respond copies it out
+// of its file, or its bytes, through a base64 encoder.
+type imageStream struct {
+	// marker prefixes the placeholders, random so no other string in the
+	// response can pass for one.
+	marker []byte
+	images []streamedImage
+}
+
+// streamedImage is an image in an imageStream response: a file, or bytes
+// already in memory.
+type streamedImage struct {
+	stream *imageStream
+	index  int
+	path   string
+	data   []byte
+}
+
+func newImageStream() *imageStream {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic("failed to generate image stream marker")
+	}
+	return &imageStream{marker: []byte(`"` + hex.EncodeToString(buf) + `:`)}
+}
+
+// file returns a placeholder for the image in the file at path.
+func (s *imageStream) file(path string) json.Marshaler {
+	return s.add(streamedImage{path: path})
+}
+
+// bytes returns a placeholder for an image already in memory, which is
+// still spared being copied out in base64.
+func (s *imageStream) bytes(data []byte) json.Marshaler {
+	return s.add(streamedImage{data: data})
+}
+
+func (s *imageStream) add(image streamedImage) json.Marshaler {
+	image.stream, image.index = s, len(s.images)
+	s.images = append(s.images, image)
+	return image
+}
+
+func (i streamedImage) MarshalJSON() ([]byte, error) {
+	return append(append([]byte{}, i.stream.marker...), strconv.Itoa(i.index)+`"`...), nil
+}
+
+// respond answers with obj as JSON, its placeholders filled in with the
+// images they stand for. A file that can't be read once the response is
+// under way cuts the response short, so callers check the files are there
+// first.
+func (s *imageStream) respond(c *gin.Context, status int, obj interface{}) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		errorf("Error encoding response: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(status)
+	w := c.Writer
+	for {
+		at := bytes.Index(body, s.marker)
+		if at < 0 {
+			w.Write(body)
+			return
+		}
+		w.Write(body[:at+1])
+		body = body[at+len(s.marker):]
+		end := bytes.IndexByte(body, '"')
+		index, _ := strconv.Atoi(string(body[:end]))
+		body = body[end:]
+
+		if err := s.images[index].writeTo(c.Request.Context(), w); err != nil {
+			errorf("Error streaming image: %v", err)
+			c.Abort()
+			return
+		}
+	}
+}
+
+// writeTo writes the image base64-encoded, giving up on a file when ctx is
+// done.
+func (i streamedImage) writeTo(ctx context.Context, w io.Writer) error {
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	if i.path == "" {
+		if _, err := encoder.Write(i.data); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	file, err := os.Open(i.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := io.Copy(encoder, contextReader{ctx, file}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
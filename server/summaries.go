@@ -0,0 +1,248 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GamePlayer remembers who took part in a game. User rows are deleted when a
+// player exits, so the login is copied here for the summary.
+type GamePlayer struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameID    string `gorm:"not null;uniqueIndex:idx_game_player"`
+	SessionID string `gorm:"not null;uniqueIndex:idx_game_player"`
+	Login     string `gorm:"not null;index"`
+	Score     int    `gorm:"not null;default:0"`
+	JoinedAt  time.Time
+}
+
+type Vote struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameID    string `gorm:"not null;index"`
+	Round     int    `gorm:"not null"`
+	VoterID   string `gorm:"not null"`
+	ChosenID  string `gorm:"not null"`
+	CreatedAt time.Time
+	// Ignored votes came from a shadow-banned player. They are kept so
+	// the player sees nothing amiss, but never counted.
+	Ignored bool `gorm:"not null;default:false"`
+}
+
+type GameSummary struct {
+	ID              uint   `gorm:"primaryKey"`
+	GameID          string `gorm:"unique;not null"`
+	Players         int    `gorm:"not null"`
+	Rounds          int    `gorm:"not null"`
+	DurationSeconds int    `gorm:"not null"`
+	CardPack        string
+	CustomDeck      uint
+	Winner          string
+	EndedAt         time.Time `gorm:"index"`
+}
+
+func recordPlayer(db *gorm.DB, gameID string, user User) {
+	db.Clauses(clause.OnConflict{DoNothing: true}).Create(&GamePlayer{
+		GameID:    gameID,
+		SessionID: user.SessionID,
+		Login:     user.Login,
+		JoinedAt:  time.Now(),
+	})
+}
+
+// voteCounts returns how many votes each session's cards have received in the
+// game so far, which is its score. Ignored votes don't count.
+func voteCounts(db *gorm.DB, gameID string) map[string]int {
+	var scores []struct {
+		ChosenID string
+		Votes    int
+	}
+	db.Model(&Vote{}).
+		Select("chosen_id, COUNT(*) AS votes").
+		Where("game_id = ? AND ignored = ?", gameID, false).
+		Group("chosen_id").
+		Scan(&scores)
+	votes := map[string]int{}
+	for _, s := range scores {
+		votes[s.ChosenID] = s.Votes
+	}
+	return votes
+}
+
+// writeSummary scores a finished game from its votes and stores the summary.
+// It has to run before the game's settings are deleted. Games that never
+// got to a first round aren't summarised.
+func writeSummary(db *gorm.DB, gameID string) {
+	settings := loadRoomSettings(db, gameID)
+	if settings.Round == 0 {
+		return
+	}
+
+	var players []GamePlayer
+	db.Where("game_id = ?", gameID).Order("joined_at").Find(&players)
+	if len(players) == 0 {
+		return
+	}
+
+	votes := voteCounts(db, gameID)
+
+	winner, best := "", 0
+	for _, player := range players {
+		player.Score = votes[player.SessionID]
+		db.Model(&GamePlayer{}).Where("id = ?", player.ID).Update("score", player.Score)
+		if player.Score > best {
+			winner, best = player.Login, player.Score
+		}
+	}
+
+	db.Clauses(clause.OnConflict{DoNothing: true}).Create(&GameSummary{
+		GameID:          gameID,
+		Players:         len(players),
+		Rounds:          settings.Round,
+		DurationSeconds: int(time.Since(players[0].JoinedAt).Seconds()),
+		CardPack:        settings.CardPack,
+		CustomDeck:      settings.CustomDeck,
+		Winner:          winner,
+		EndedAt:         time.Now(),
+	})
+}
+
+// recordVote stores a vote the WebSocket server took. It takes the service
+// token, so players can't post votes of their own.
+func recordVote(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "vote")
+
+	var json struct {
+		GameID    string `json:"game_id"`
+		SessionID string `json:"session_id"`
+		ChosenID  string `json:"chosen_id"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.GameID == "" || json.SessionID == "" || json.ChosenID == "" {
+		respondError(c, "vote_fields_required")
+		return
+	}
+
+	settings := loadRoomSettings(db, json.GameID)
+	if err := db.Create(&Vote{
+		GameID:   json.GameID,
+		Round:    settings.Round,
+		VoterID:  json.SessionID,
+		ChosenID: json.ChosenID,
+		Ignored:  shadowBanned(db, json.SessionID),
+	}).Error; err != nil {
+		respondError(c, "vote_failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"game_id": json.GameID, "round": settings.Round})
+}
+
+// roomScores reports the running scores of a game in progress, for clients
+// catching up after a reconnect.
+func roomScores(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "room_scores")
+
+	gameID := c.Param("game_id")
+	var settings RoomSettings
+	if err := db.Where("game_id = ?", gameID).First(&settings).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
+	}
+
+	var players []GamePlayer
+	db.Where("game_id = ?", gameID).Order("joined_at").Find(&players)
+	votes := voteCounts(db, gameID)
+
+	scores := make([]gin.H, 0, len(players))
+	for _, player := range players {
+		scores = append(scores, gin.H{
+			"login": player.Login,
+			"score": votes[player.SessionID],
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"game_id": gameID, "round": settings.Round, "scores": scores})
+}
+
+func summaryInfo(summary GameSummary, players []GamePlayer) gin.H {
+	result := make([]gin.H, 0, len(players))
+	for _, player := range players {
+		result = append(result, gin.H{"login": player.Login, "score": player.Score})
+	}
+	return gin.H{
+		"game_id":          summary.GameID,
+		"players":          result,
+		"rounds":           summary.Rounds,
+		"duration_seconds": summary.DurationSeconds,
+		"card_pack":        summary.CardPack,
+		"custom_deck":      summary.CustomDeck,
+		"winner":           summary.Winner,
+		"ended_at":         summary.EndedAt,
+	}
+}
+
+func recentGames(db *gorm.DB, c *gin.Context) {
+	db = readReplica(withRequest(db, c, "recent_games"))
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		respondError(c, "invalid_limit")
+		return
+	}
+
+	var summaries []GameSummary
+	if err := db.Order("ended_at DESC").Limit(limit).Find(&summaries).Error; err != nil {
+		respondError(c, "games_failed")
+		return
+	}
+
+	gameIDs := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		gameIDs = append(gameIDs, summary.GameID)
+	}
+	var players []GamePlayer
+	db.Where("game_id IN ?", gameIDs).Order("score DESC").Find(&players)
+	byGame := map[string][]GamePlayer{}
+	for _, player := range players {
+		byGame[player.GameID] = append(byGame[player.GameID], player)
+	}
+
+	result := make([]gin.H, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, summaryInfo(summary, byGame[summary.GameID]))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func userStats(db *gorm.DB, c *gin.Context) {
+	db = readReplica(withRequest(db, c, "user_stats"))
+
+	login := c.Param("login")
+
+	var stats struct {
+		Games      int
+		TotalScore int
+	}
+	db.Model(&GamePlayer{}).
+		Joins("JOIN game_summaries ON game_summaries.game_id = game_players.game_id").
+		Select("COUNT(*) AS games, COALESCE(SUM(game_players.score), 0) AS total_score").
+		Where("game_players.login = ?", login).
+		Scan(&stats)
+	if stats.Games == 0 {
+		respondError(c, "no_finished_games")
+		return
+	}
+
+	var wins int64
+	db.Model(&GameSummary{}).Where("winner = ?", login).Count(&wins)
+
+	c.JSON(http.StatusOK, gin.H{
+		"login":       login,
+		"games":       stats.Games,
+		"wins":        wins,
+		"total_score": stats.TotalScore,
+	})
+}
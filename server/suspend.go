@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultSuspendTTL is how long a suspended game can be resumed when
+// SUSPEND_DAYS isn't set.
+const defaultSuspendTTL = 7 * 24 * time.Hour
+
+// SuspendedGame is a game its host put away to finish later. State holds
+// the game's rows as they were, which are removed from their tables until
+// the game is resumed; the hands dealt stay in HandCard all along.
+type SuspendedGame struct {
+	GameID      string `gorm:"primaryKey"`
+	HostSession string `gorm:"not null"`
+	State       []byte `gorm:"not null"`
+	SuspendedAt time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+}
+
+// gameSnapshot is what a suspended game's State decodes to: the settings,
+// with the round, the players' seats, the votes the scores come from and the
+// cards dealt so far, which is where each deck was up to.
+type gameSnapshot struct {
+	Settings         RoomSettings      `json:"settings"`
+	Rooms            []Room            `json:"rooms"`
+	Votes            []Vote            `json:"votes"`
+	DealtCards       []DealtCard       `json:"dealt_cards"`
+	DealtCustomCards []DealtCustomCard `json:"dealt_custom_cards"`
+}
+
+func loadSuspendTTL() {
+	if days, err := strconv.Atoi(os.Getenv("SUSPEND_DAYS")); err == nil && days > 0 {
+		config.SuspendTTL = time.Duration(days) * 24 * time.Hour
+	}
+}
+
+// suspendGame saves the host's game and closes it, dropping everyone from
+// the WebSocket server. Its players can pick it up again with resumeGame
+// until it expires.
+func suspendGame(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "suspend")
+
+	var json struct {
+		SessionID string `json:"session_id"`
+		GameID    string `json:"game_id"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" || json.GameID == "" {
+		respondError(c, "game_and_session_required")
+		return
+	}
+
+	unlock := lockGame(json.GameID)
+	defer unlock()
+
+	var snapshot gameSnapshot
+	if err := db.Where("game_id = ?", json.GameID).First(&snapshot.Settings).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
+	}
+	if snapshot.Settings.HostSession != json.SessionID {
+		respondError(c, "not_host")
+		return
+	}
+
+	now := time.Now()
+	suspended := SuspendedGame{
+		GameID:      json.GameID,
+		HostSession: json.SessionID,
+		SuspendedAt: now,
+		ExpiresAt:   now.Add(config.SuspendTTL),
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, rows := range []interface{}{&snapshot.Rooms, &snapshot.Votes, &snapshot.DealtCards, &snapshot.DealtCustomCards} {
+			if err := tx.Where("game_id = ?", json.GameID).Order("id").Find(rows).Error; err != nil {
+				return err
+			}
+		}
+		state, err := encodeSnapshot(snapshot)
+		if err != nil {
+			return err
+		}
+		suspended.State = state
+		if err := tx.Save(&suspended).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&Room{}, &Vote{}, &RoomSettings{}, &DealtCard{}, &DealtCustomCard{}, &WSMember{}} {
+			if err := tx.Where("game_id = ?", json.GameID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, "suspend_failed")
+		return
+	}
+	publishLobbyEvent(db, lobbyRoomClosed, json.GameID)
+
+	clients, err := closeWSRoom(json.GameID, "The host suspended the game")
+	if err != nil {
+		errorf("Failed to close suspended game_id %s on the WebSocket server: %v", json.GameID, err)
+	}
+	infof("Host %s suspended game_id %s with %d players until %v", json.SessionID, json.GameID, len(snapshot.Rooms), suspended.ExpiresAt)
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_id":    json.GameID,
+		"round":      snapshot.Settings.Round,
+		"players":    len(snapshot.Rooms),
+		"clients":    clients,
+		"expires_at": suspended.ExpiresAt,
+	})
+}
+
+// resumeGame puts a player of a suspended game back in it. The first player
+// back restores the game as it was; once every player is back the saved
+// copy is dropped.
+func resumeGame(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "resume")
+
+	var json struct {
+		SessionID       string          `json:"session_id"`
+		GameID          string          `json:"game_id"`
+		AvatarRevisions map[string]uint `json:"avatar_revisions"`
+	}
+	if err := c.ShouldBindJSON(&json); err != nil || json.SessionID == "" || json.GameID == "" {
+		respondError(c, "game_and_session_required")
+		return
+	}
+
+	unlock := lockGame(json.GameID)
+	defer unlock()
+
+	var suspended SuspendedGame
+	if err := db.Where("game_id = ?", json.GameID).First(&suspended).Error; err != nil {
+		respondError(c, "game_not_found")
+		return
+	}
+	if time.Now().After(suspended.ExpiresAt) {
+		respondError(c, "suspension_expired")
+		return
+	}
+	var snapshot gameSnapshot
+	if err := decodeSnapshot(suspended.State, &snapshot); err != nil {
+		errorf("Error decoding suspended game_id %s: %v", json.GameID, err)
+		respondError(c, "resume_failed")
+		return
+	}
+
+	var seat *Room
+	for i := range snapshot.Rooms {
+		if snapshot.Rooms[i].SessionID == json.SessionID {
+			seat = &snapshot.Rooms[i]
+		}
+	}
+	if seat == nil {
+		respondError(c, "not_room_member")
+		return
+	}
+
+	var restored bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var settings int64
+		if err := tx.Model(&RoomSettings{}).Where("game_id = ?", json.GameID).Count(&settings).Error; err != nil {
+			return err
+		}
+		if settings == 0 {
+			if err := restoreSnapshot(tx, snapshot); err != nil {
+				return err
+			}
+			restored = true
+		}
+
+		var seated int64
+		if err := tx.Model(&Room{}).Where("game_id = ? AND session_id = ?", json.GameID, json.SessionID).Count(&seated).Error; err != nil {
+			return err
+		}
+		if seated == 0 {
+			room := *seat
+			room.ID = 0
+			if err := tx.Create(&room).Error; err != nil {
+				return err
+			}
+		}
+
+		var back int64
+		if err := tx.Model(&Room{}).Where("game_id = ?", json.GameID).Count(&back).Error; err != nil {
+			return err
+		}
+		if int(back) >= len(snapshot.Rooms) {
+			return tx.Delete(&suspended).Error
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, "resume_failed")
+		return
+	}
+	if restored {
+		publishLobbyEvent(db, lobbyRoomCreated, json.GameID)
+		infof("Restored suspended game_id %s at round %d", json.GameID, snapshot.Settings.Round)
+	}
+
+	var rooms []Room
+	db.Where("game_id = ? AND session_id <> ?", json.GameID, json.SessionID).Find(&rooms)
+	sessionIDs := make([]string, 0, len(rooms))
+	for _, room := range rooms {
+		sessionIDs = append(sessionIDs, room.SessionID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_id":  json.GameID,
+		"round":    snapshot.Settings.Round,
+		"sessions": userProfiles(db, sessionIDs, profileImageData, json.AvatarRevisions),
+	})
+}
+
+// restoreSnapshot puts a suspended game's settings, votes and dealt cards
+// back. The rows get new IDs, since the old ones may have been reused.
+func restoreSnapshot(tx *gorm.DB, snapshot gameSnapshot) error {
+	settings := snapshot.Settings
+	settings.ID = 0
+	if err := tx.Create(&settings).Error; err != nil {
+		return err
+	}
+	for _, vote := range snapshot.Votes {
+		vote.ID = 0
+		if err := tx.Create(&vote).Error; err != nil {
+			return err
+		}
+	}
+	for _, card := range snapshot.DealtCards {
+		card.ID = 0
+		if err := tx.Create(&card).Error; err != nil {
+			return err
+		}
+	}
+	for _, card := range snapshot.DealtCustomCards {
+		card.ID = 0
+		if err := tx.Create(&card).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeSnapshot(snapshot gameSnapshot) ([]byte, error) {
+	return json.Marshal(snapshot)
+}
+
+func decodeSnapshot(state []byte, snapshot *gameSnapshot) error {
+	return json.Unmarshal(state, snapshot)
+}
+
+// sweepSuspendedGames drops suspended games nobody resumed in time.
+func sweepSuspendedGames(db *gorm.DB) {
+	result := db.Where("expires_at < ?", time.Now()).Delete(&SuspendedGame{})
+	if result.Error != nil {
+		errorf("Error sweeping suspended games: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		infof("Dropped %d expired suspended games", result.RowsAffected)
+	}
+}
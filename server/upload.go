@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned alongside the message when an upload is rejected.
+const (
+	uploadErrExtension  = "unsupported_extension"
+	uploadErrFormat     = "unsupported_format"
+	uploadErrFileSize   = "file_too_large"
+	uploadErrDimensions = "image_too_large"
+	uploadErrDeckSize   = "too_many_cards"
+	uploadErrInvalid    = "invalid_image"
+)
+
+// uploadError is a rejected upload. Args fill in the catalog message; Card
+// is the 1-based position of the offending card in a deck upload.
+type uploadError struct {
+	Code string
+	Args []interface{}
+	Card int
+}
+
+func (e *uploadError) respond(c *gin.Context) {
+	if _, ok := bodyTooLarge(c); ok {
+		respondError(c, e.Code, e.Args...)
+		return
+	}
+	body := errorBody(c, e.Code, e.Args...)
+	if e.Card > 0 {
+		body["card"] = e.Card
+	}
+	c.JSON(errorStatus(e.Code), body)
+}
+
+// loadUploadPolicy overrides the default upload limits from the environment.
+func loadUploadPolicy() {
+	if exts := splitList(os.Getenv("UPLOAD_EXTENSIONS")); len(exts) > 0 {
+		config.AllowedExtensions = map[string]bool{}
+		for _, ext := range exts {
+			config.AllowedExtensions[strings.TrimPrefix(ext, ".")] = true
+		}
+	}
+	if n, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		config.MaxUploadBytes = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_WIDTH")); err == nil && n > 0 {
+		config.MaxImageWidth = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_HEIGHT")); err == nil && n > 0 {
+		config.MaxImageHeight = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("DECK_MAX_CARDS")); err == nil && n > 0 {
+		config.MaxDeckCards = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("CARD_MAX_WIDTH")); err == nil && n > 0 {
+		config.CardMaxWidth = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("CARD_MAX_HEIGHT")); err == nil && n > 0 {
+		config.CardMaxHeight = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("CARD_QUALITY")); err == nil && n >= 1 && n <= 100 {
+		config.CardQuality = n
+	}
+}
+
+func allowedFile(filename string) bool {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	return config.AllowedExtensions[ext]
+}
+
+// allowedFormat reports whether an image format, as named by image.Decode,
+// matches one of the allowed extensions.
+func allowedFormat(format string) bool {
+	if format == "jpeg" {
+		return config.AllowedExtensions["jpeg"] || config.AllowedExtensions["jpg"]
+	}
+	return config.AllowedExtensions[format]
+}
+
+// checkImage applies the upload policy to an image's content.
+func checkImage(data []byte) *uploadError {
+	if int64(len(data)) > config.MaxUploadBytes {
+		return &uploadError{Code: uploadErrFileSize, Args: []interface{}{config.MaxUploadBytes}}
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return &uploadError{Code: uploadErrInvalid}
+	}
+	if !allowedFormat(format) {
+		return &uploadError{Code: uploadErrFormat, Args: []interface{}{format}}
+	}
+	if cfg.Width > config.MaxImageWidth || cfg.Height > config.MaxImageHeight {
+		return &uploadError{Code: uploadErrDimensions, Args: []interface{}{config.MaxImageWidth, config.MaxImageHeight}}
+	}
+	return nil
+}
+
+// readUpload applies the upload policy to a multipart file and returns its
+// content.
+func readUpload(file *multipart.FileHeader) ([]byte, *uploadError) {
+	if !allowedFile(file.Filename) {
+		return nil, &uploadError{Code: uploadErrExtension}
+	}
+	if file.Size > config.MaxUploadBytes {
+		return nil, &uploadError{Code: uploadErrFileSize, Args: []interface{}{config.MaxUploadBytes}}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, &uploadError{Code: uploadErrInvalid}
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, config.MaxUploadBytes+1))
+	if err != nil {
+		return nil, &uploadError{Code: uploadErrInvalid}
+	}
+	if uerr := checkImage(data); uerr != nil {
+		return nil, uerr
+	}
+	return data, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBatchUsers caps how many profiles one /users call can ask for.
+const maxBatchUsers = 100
+
+// profileImages is how userProfiles gives each avatar.
+type profileImages int
+
+const (
+	// profileImageURL gives the avatar by URL.
+	profileImageURL profileImages = iota
+	// profileImageData gives it by URL and inline as well.
+	profileImageData
+	// profileThumbnails gives only the URL of a thumbnail, for listings
+	// that would otherwise weigh as much as every avatar in them.
+	profileThumbnails
+)
+
+// userProfiles loads the profiles of the given sessions in one query, in the
+// order asked for. Unknown sessions and users whose avatar can't be read are
+// left out. Every profile carries its avatar_revision; those whose revision
+// matches the one in known get no image, the client having it already.
+func userProfiles(db *gorm.DB, sessionIDs []string, images profileImages, known map[string]uint) []gin.H {
+	profiles := make([]gin.H, 0, len(sessionIDs))
+	if len(sessionIDs) == 0 {
+		return profiles
+	}
+
+	var users []User
+	db.Where("session_id IN ?", sessionIDs).Find(&users)
+	bySession := make(map[string]User, len(users))
+	for _, user := range users {
+		bySession[user.SessionID] = user
+	}
+
+	for _, sessionID := range sessionIDs {
+		user, ok := bySession[sessionID]
+		if !ok {
+			continue
+		}
+		profile := gin.H{
+			"session_id":      user.SessionID,
+			"login":           user.Login,
+			"avatar_revision": user.AvatarRevision,
+		}
+		if revision, ok := known[sessionID]; ok && revision == user.AvatarRevision {
+			profiles = append(profiles, profile)
+			continue
+		}
+		profile["image_url"] = signedImageURL(imageKindAvatar, user.ID)
+		switch images {
+		case profileThumbnails:
+			profile["image_url"] = signedThumbnailURL(imageKindAvatar, user.ID)
+		case profileImageData:
+			imageBytes, err := readFileContext(db.Statement.Context, user.ImagePath)
+			if err != nil {
+				continue
+			}
+			profile["image_data"] = base64.StdEncoding.EncodeToString(imageBytes)
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+// listUsers returns the profiles of several sessions at once, with avatar
+// URLs rather than inline images. Clients list the avatars they have cached
+// as avatar_revisions=session:revision,... to be left their URLs.
+func listUsers(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "users")
+
+	var sessionIDs []string
+	seen := map[string]bool{}
+	for _, sessionID := range strings.Split(c.Query("session_ids"), ",") {
+		sessionID = strings.TrimSpace(sessionID)
+		if sessionID != "" && !seen[sessionID] {
+			seen[sessionID] = true
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	if len(sessionIDs) == 0 {
+		respondError(c, "session_id_required")
+		return
+	}
+	if len(sessionIDs) > maxBatchUsers {
+		respondError(c, "too_many_sessions", maxBatchUsers)
+		return
+	}
+
+	known := parseAvatarRevisions(c.Query("avatar_revisions"))
+	c.JSON(http.StatusOK, gin.H{"users": userProfiles(db, sessionIDs, profileImageURL, known)})
+}
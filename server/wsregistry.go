@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WSMember is the WebSocket server's view of one session in a game, kept
+// here so a restarted WebSocket process can pick up where it left off
+// instead of orphaning the rooms. Sessions are credentials, so only the
+// WebSocket server, holding the service token, reads or writes these.
+type WSMember struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameID    string `gorm:"not null;index"`
+	SessionID string `gorm:"not null;index"`
+	Login     string
+	Spectator bool
+	Ready     bool
+	Turn      bool
+	Voted     bool
+	InGame    bool
+	Started   bool
+	UpdatedAt time.Time
+}
+
+type wsMemberJSON struct {
+	GameID    string `json:"game_id"`
+	SessionID string `json:"session_id"`
+	Login     string `json:"login"`
+	Spectator bool   `json:"spectator"`
+	Ready     bool   `json:"ready"`
+	Turn      bool   `json:"turn"`
+	Voted     bool   `json:"voted"`
+	InGame    bool   `json:"in_game"`
+	Started   bool   `json:"started"`
+}
+
+// replaceWSMembers stores the WebSocket server's current membership of a
+// game, replacing what was there. An empty list forgets the game.
+func replaceWSMembers(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "ws_members_replace")
+
+	gameID := c.Param("game_id")
+	var json []wsMemberJSON
+	if err := c.ShouldBindJSON(&json); err != nil {
+		respondError(c, "invalid_request")
+		return
+	}
+
+	members := make([]WSMember, 0, len(json))
+	for _, m := range json {
+		if m.SessionID == "" {
+			continue
+		}
+		members = append(members, WSMember{
+			GameID:    gameID,
+			SessionID: m.SessionID,
+			Login:     m.Login,
+			Spectator: m.Spectator,
+			Ready:     m.Ready,
+			Turn:      m.Turn,
+			Voted:     m.Voted,
+			InGame:    m.InGame,
+			Started:   m.Started,
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("game_id = ?", gameID).Delete(&WSMember{}).Error; err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		return tx.Create(&members).Error
+	})
+	if err != nil {
+		respondError(c, "ws_members_failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"game_id": gameID, "members": len(members)})
+}
+
+// listWSMembers returns every stored membership, which the WebSocket server
+// reloads on startup.
+func listWSMembers(db *gorm.DB, c *gin.Context) {
+	db = withRequest(db, c, "ws_members")
+
+	var members []WSMember
+	if err := db.Order("game_id, id").Find(&members).Error; err != nil {
+		respondError(c, "ws_members_failed")
+		return
+	}
+
+	result := make([]wsMemberJSON, 0, len(members))
+	for _, m := range members {
+		result = append(result, wsMemberJSON{
+			GameID:    m.GameID,
+			SessionID: m.SessionID,
+			Login:     m.Login,
+			Spectator: m.Spectator,
+			Ready:     m.Ready,
+			Turn:      m.Turn,
+			Voted:     m.Voted,
+			InGame:    m.InGame,
+			Started:   m.Started,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
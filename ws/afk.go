@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	game "ws_server/proto"
+)
+
+// nextTurnDeadline is when a play or vote phase starting now runs out.
+func nextTurnDeadline(gameID string) time.Time {
+	mu.Lock()
+	defer mu.Unlock()
+	return time.Now().Add(getGameStateLocked(gameID).turnTimeout())
+}
+
+// armTurnTimer (re)starts the game's turn timer for the current play or vote
+// phase, which runs out at deadline. It has to be armed before the phase is
+// announced so plays and votes aren't checked against the last deadline.
+func armTurnTimer(gameID string, deadline time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	armTurnTimerLocked(gameID, deadline)
+}
+
+// armTurnTimerLocked is armTurnTimer with mu held. While the game is paused
+// the timer is only set up to start on resume.
+func armTurnTimerLocked(gameID string, deadline time.Time) {
+	state := getGameStateLocked(gameID)
+	if state.turnTimer != nil {
+		state.turnTimer.Stop()
+		state.turnTimer = nil
+	}
+	state.turnDeadline = deadline
+	if state.paused {
+		state.turnFrozen = true
+		return
+	}
+	state.turnTimer = time.AfterFunc(time.Until(deadline)+deadlineGrace, func() { turnTimeoutExpired(gameID) })
+}
+
+// turnDeadlinePassedLocked reports whether the game's current phase ran out,
+// grace included, so plays and votes are no longer taken. mu must be held.
+func turnDeadlinePassedLocked(gameID string) bool {
+	deadline := getGameStateLocked(gameID).turnDeadline
+	return !deadline.IsZero() && time.Now().After(deadline.Add(deadlineGrace))
+}
+
+// unixMilli is t in Unix milliseconds, or 0 for the zero time.
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// turnTimeoutExpired counts a missed turn against every player who hasn't
+// played (or voted, once the table is cleared) yet. Players who have missed
+// afkMissedTurns in a row are skipped so the rest of the game can go on.
+func turnTimeoutExpired(gameID string) {
+	var skipped []*game.AfkNotice
+	pending := false
+
+	mu.Lock()
+	state := getGameStateLocked(gameID)
+	state.turnTimer = nil
+	if state.paused {
+		// The game was paused as the timer fired; resuming restarts it.
+		mu.Unlock()
+		return
+	}
+	playing := roundStartedLocked(gameID)
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			if room.GameID != gameID {
+				continue
+			}
+			for _, user := range room.Users {
+				if !user.InGame || user.Spectator {
+					continue
+				}
+				if (playing && user.Turn) || (!playing && user.Voted) {
+					continue
+				}
+
+				user.missedTurns++
+				if user.missedTurns < afkMissedTurns {
+					pending = true
+					continue
+				}
+
+				if playing {
+					user.Turn = true
+				} else {
+					user.setVoted(true)
+				}
+				warnf("Skipping AFK user %s in game_id %s, last active %v", user.Login, gameID, user.LastActive)
+				skipped = append(skipped, &game.AfkNotice{
+					ClassId: game.ClassTypes_PROTO_TYPE_AFK,
+					User: &game.User{
+						Login:     []byte(user.Login),
+						SessionId: []byte(user.SessionID),
+						GameId:    []byte(gameID),
+					},
+					MissedTurns: uint32(user.missedTurns),
+				})
+			}
+		}
+	}
+	mu.Unlock()
+
+	for _, notice := range skipped {
+		sendAfkNotice(notice)
+	}
+
+	shareGame(gameID)
+	tally := tallyRound(gameID)
+	if playing && tally.moved == tally.inGame {
+		finishPlayPhase(gameID)
+		return
+	}
+	if !pending {
+		return
+	}
+	// Every instance's timer runs out together; one extends the phase for
+	// all of them.
+	mu.Lock()
+	expired := unixMilli(getGameStateLocked(gameID).turnDeadline)
+	mu.Unlock()
+	if !claimRound(gameID, fmt.Sprintf("%s:%d", roundDeadline, expired), phaseClaimTTL) {
+		return
+	}
+	deadline := nextTurnDeadline(gameID)
+	announceRound(roundEvent{GameID: gameID, Kind: roundDeadline, Deadline: unixMilli(deadline)})
+	sendTurnDeadline(gameID, deadline, !playing)
+}
+
+// sendTurnDeadline tells the game the phase was extended to deadline for
+// players who haven't moved yet.
+func sendTurnDeadline(gameID string, deadline time.Time, voting bool) {
+	data, err := SerializeToString(&game.TurnDeadline{
+		ClassId:  game.ClassTypes_PROTO_TYPE_DEADLINE,
+		GameId:   []byte(gameID),
+		Deadline: unixMilli(deadline),
+		Voting:   voting,
+	})
+	if err != nil {
+		errorf("Error serializing TurnDeadline: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_DEADLINE,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	if err := SendMessageToGameClients(gameID, serializedBaseMessage, nil); err != nil {
+		errorf("Failed to send turn deadline: %v", err)
+	}
+}
+
+func sendAfkNotice(notice *game.AfkNotice) {
+	data, err := SerializeToString(notice)
+	if err != nil {
+		errorf("Error serializing AfkNotice: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_AFK,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	if err := SendMessageToGameClients(string(notice.User.GameId), serializedBaseMessage, nil); err != nil {
+		errorf("Failed to send AFK notice: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	game "ws_server/proto"
+)
+
+func registerAnnouncements(mux *http.ServeMux) {
+	mux.HandleFunc("POST /announcements", announceHandler)
+}
+
+// announceHandler pushes an admin's announcement, such as a maintenance
+// warning, to every connected client or only to those in game_id.
+// shutdown_at, in Unix seconds, tells clients when a planned shutdown is.
+func announceHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	var body struct {
+		Message    string `json:"message"`
+		Level      string `json:"level"`
+		GameID     string `json:"game_id"`
+		ShutdownAt int64  `json:"shutdown_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Message) == "" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	sent, err := announce(&game.Announcement{
+		Message:    []byte(body.Message),
+		Level:      []byte(body.Level),
+		GameId:     []byte(body.GameID),
+		ShutdownAt: body.ShutdownAt,
+	})
+	if err != nil {
+		http.Error(w, "failed to send announcement", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"game_id": body.GameID, "clients": sent})
+}
+
+// announce sends an Announcement to its game's connections, or to every
+// connection when it has no game, on this instance and the others. It
+// returns how many local connections it went to.
+func announce(announcement *game.Announcement) (int, error) {
+	gameID := string(announcement.GameId)
+	infof("Announcing to game_id %q: %s", gameID, announcement.Message)
+
+	announcement.ClassId = game.ClassTypes_PROTO_TYPE_ANNOUNCEMENT
+	data, err := SerializeToString(announcement)
+	if err != nil {
+		errorf("Error serializing Announcement: %v", err)
+		return 0, err
+	}
+	frame, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_ANNOUNCEMENT,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return 0, err
+	}
+
+	if gameID == "" {
+		mu.Lock()
+		sent := len(clients)
+		mu.Unlock()
+		sendToAllLocalClients(frame)
+		publishFrame("", frame)
+		return sent, nil
+	}
+
+	sent := 0
+	mu.Lock()
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			if room.GameID == gameID {
+				sent++
+				break
+			}
+		}
+	}
+	mu.Unlock()
+	SendMessageToGameClients(gameID, frame, nil)
+	return sent, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// classCapabilities names the capability a client must have agreed to for
+// the server to send it frames of a class. Classes missing here go to
+// everyone. A new message type gets a capability of its own, outside
+// legacyCapabilities, so clients that would not know what to do with it
+// never see it.
+var classCapabilities = map[game.ClassTypes]game.Capability{
+	game.ClassTypes_PROTO_TYPE_REJOIN:       game.Capability_CAPABILITY_REJOIN,
+	game.ClassTypes_PROTO_TYPE_TIMESYNC:     game.Capability_CAPABILITY_TIMERS,
+	game.ClassTypes_PROTO_TYPE_DEADLINE:     game.Capability_CAPABILITY_TIMERS,
+	game.ClassTypes_PROTO_TYPE_COUNTDOWN:    game.Capability_CAPABILITY_TIMERS,
+	game.ClassTypes_PROTO_TYPE_PAUSE:        game.Capability_CAPABILITY_PAUSE,
+	game.ClassTypes_PROTO_TYPE_AFK:          game.Capability_CAPABILITY_NOTICES,
+	game.ClassTypes_PROTO_TYPE_ANNOUNCEMENT: game.Capability_CAPABILITY_NOTICES,
+	game.ClassTypes_PROTO_TYPE_ROOMCLOSED:   game.Capability_CAPABILITY_NOTICES,
+	game.ClassTypes_PROTO_TYPE_MODERATED:    game.Capability_CAPABILITY_NOTICES,
+}
+
+// legacyCapabilities is what a client that never sends Hello is assumed to
+// handle: everything it was sent before capabilities were negotiated.
+const legacyCapabilities = uint64(game.Capability_CAPABILITY_REJOIN |
+	game.Capability_CAPABILITY_SPECTATOR |
+	game.Capability_CAPABILITY_TIMERS |
+	game.Capability_CAPABILITY_PAUSE |
+	game.Capability_CAPABILITY_NOTICES)
+
+var (
+	capabilitiesMu sync.Mutex
+	// connCapabilities holds what each connection agreed to in its Hello.
+	connCapabilities = make(map[*websocket.Conn]uint64)
+)
+
+// serverCapabilities is what the server offers conn. Reactions aren't
+// offered yet.
+func serverCapabilities(conn *websocket.Conn) uint64 {
+	capabilities := legacyCapabilities
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		host = conn.RemoteAddr().String()
+	}
+	if featureEnabled(flagWSMsgpack, host) {
+		capabilities |= uint64(game.Capability_CAPABILITY_MSGPACK)
+	}
+	return capabilities
+}
+
+// handleHello answers a client's Hello with the capabilities the server
+// offers and the ones both sides share, which from then on decide what the
+// connection is sent.
+func handleHello(conn *websocket.Conn, data []byte) {
+	var hello game.Hello
+	if err := proto.Unmarshal(data, &hello); err != nil {
+		errorf("Error unmarshaling Hello: %v", err)
+		return
+	}
+
+	offered := serverCapabilities(conn)
+	agreed := hello.Capabilities & offered
+	capabilitiesMu.Lock()
+	connCapabilities[conn] = agreed
+	capabilitiesMu.Unlock()
+	debugf("Client %q agreed capabilities %#x", hello.Client, agreed)
+
+	serializedData, err := SerializeToString(&game.Hello{
+		ClassId:      game.ClassTypes_PROTO_TYPE_HELLO,
+		Capabilities: offered,
+		Agreed:       agreed,
+	})
+	if err != nil {
+		errorf("Error serializing Hello: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_HELLO,
+		Data:    serializedData,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+	if err := SendMessageToClient(conn, serializedBaseMessage); err != nil {
+		errorf("Failed to send hello: %v", err)
+	}
+}
+
+// forgetCapabilities drops what conn agreed to once it is gone.
+func forgetCapabilities(conn *websocket.Conn) {
+	capabilitiesMu.Lock()
+	defer capabilitiesMu.Unlock()
+	delete(connCapabilities, conn)
+}
+
+// frameAllowed reports whether conn agreed to the class of frame.
+func frameAllowed(conn *websocket.Conn, frame []byte) bool {
+	capability, ok := classCapabilities[frameClass(frame)]
+	if !ok {
+		return true
+	}
+	capabilitiesMu.Lock()
+	agreed, negotiated := connCapabilities[conn]
+	capabilitiesMu.Unlock()
+	if !negotiated {
+		agreed = legacyCapabilities
+	}
+	return agreed&uint64(capability) != 0
+}
+
+// frameClass reads the class of a serialized frame from its leading classId
+// field without decoding the rest.
+func frameClass(frame []byte) game.ClassTypes {
+	num, typ, n := protowire.ConsumeTag(frame)
+	if n < 0 || num != 1 || typ != protowire.VarintType {
+		return game.ClassTypes(0)
+	}
+	class, m := protowire.ConsumeVarint(frame[n:])
+	if m < 0 {
+		return game.ClassTypes(0)
+	}
+	return game.ClassTypes(class)
+}
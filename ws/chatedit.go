@@ -0,0 +1,117 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// trackChat remembers a chat message so it can be edited, deleted or
+// receipted later, forgetting the oldest once maxTrackedChats is reached.
+func trackChat(gameID string, conn *websocket.Conn, msg *game.ChatMessage, spectatorsOnly, shadow bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	state := getGameStateLocked(gameID)
+	if state.chats == nil {
+		state.chats = make(map[uint64]*chatRecord)
+	}
+	state.chats[msg.Id] = &chatRecord{conn: conn, msg: msg, spectatorsOnly: spectatorsOnly, shadow: shadow}
+	state.chatOrder = append(state.chatOrder, msg.Id)
+	if len(state.chatOrder) > maxTrackedChats {
+		delete(state.chats, state.chatOrder[0])
+		state.chatOrder = state.chatOrder[1:]
+	}
+}
+
+// authoredChatLocked returns the record of chat message id if user wrote it
+// and it is still within chatEditWindow, or an error code explaining why not.
+// mu must be held.
+func authoredChatLocked(gameID string, id uint64, user *game.User) (*chatRecord, string) {
+	record := getGameStateLocked(gameID).chats[id]
+	if record == nil || record.deleted {
+		return nil, errCodeUnknownMessage
+	}
+	if string(record.msg.User.SessionId) != string(user.SessionId) {
+		return nil, errCodeNotAuthor
+	}
+	if time.Since(time.UnixMilli(record.msg.SentAt)) > chatEditWindow {
+		return nil, errCodeEditWindow
+	}
+	return record, ""
+}
+
+func handleChatEdit(conn *websocket.Conn, data []byte) {
+	var edit game.ChatEdit
+	if err := proto.Unmarshal(data, &edit); err != nil {
+		errorf("Error unmarshaling ChatEdit: %v", err)
+		return
+	}
+
+	gameID := string(edit.User.GameId)
+	mu.Lock()
+	record, code := authoredChatLocked(gameID, edit.MessageId, edit.User)
+	var updated *game.ChatMessage
+	if record != nil {
+		updated = proto.Clone(record.msg).(*game.ChatMessage)
+		updated.Message = edit.Message
+		updated.EditedAt = time.Now().UnixMilli()
+		record.msg = updated
+	}
+	mu.Unlock()
+	if record == nil {
+		sendErrorMessage(conn, code, "Chat message can't be edited")
+		return
+	}
+
+	edit.ClassId = game.ClassTypes_PROTO_TYPE_CHATEDIT
+	edit.EditedAt = updated.EditedAt
+	logChatEvent("chat_edit", edit.MessageId, updated.User, game.ClassTypes_PROTO_TYPE_CHATMESSAGE, updated)
+	sendChatUpdate(conn, gameID, game.ClassTypes_PROTO_TYPE_CHATEDIT, &edit, record)
+}
+
+func handleChatDelete(conn *websocket.Conn, data []byte) {
+	var del game.ChatDelete
+	if err := proto.Unmarshal(data, &del); err != nil {
+		errorf("Error unmarshaling ChatDelete: %v", err)
+		return
+	}
+
+	gameID := string(del.User.GameId)
+	mu.Lock()
+	record, code := authoredChatLocked(gameID, del.MessageId, del.User)
+	if record != nil {
+		record.deleted = true
+	}
+	mu.Unlock()
+	if record == nil {
+		sendErrorMessage(conn, code, "Chat message can't be deleted")
+		return
+	}
+
+	del.ClassId = game.ClassTypes_PROTO_TYPE_CHATDELETE
+	logChatEvent("chat_delete", del.MessageId, del.User, game.ClassTypes_PROTO_TYPE_CHATDELETE, &del)
+	sendChatUpdate(conn, gameID, game.ClassTypes_PROTO_TYPE_CHATDELETE, &del, record)
+}
+
+// sendChatUpdate broadcasts an edit or delete to the same audience the
+// original message went to.
+func sendChatUpdate(conn *websocket.Conn, gameID string, classID game.ClassTypes, msg proto.Message, record *chatRecord) {
+	data, err := SerializeToString(msg)
+	if err != nil {
+		errorf("Error serializing %v: %v", classID, err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: classID,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+	broadcastChat(conn, gameID, serializedBaseMessage, record.spectatorsOnly, record.shadow)
+}
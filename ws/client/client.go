@@ -0,0 +1,156 @@
+// Package client is a Go client for the meme battle servers. It wraps the
+// REST API and the WebSocket game protocol in typed calls and reconnects the
+// WebSocket when it drops, so it can drive bots and integration tests.
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	game "ws_server/proto"
+)
+
+var (
+	// ErrClosed is returned once Close has been called or the connection
+	// could not be re-established.
+	ErrClosed = errors.New("client: connection closed")
+	// ErrNotConnected is returned by WebSocket calls made before Connect.
+	ErrNotConnected = errors.New("client: not connected")
+)
+
+// Subprotocol is the WebSocket subprotocol the client asks for: version 1
+// of the game protocol in protobuf frames.
+const Subprotocol = "game.v1.proto"
+
+type Config struct {
+	RestURL string
+	WSURL   string
+	// Timeout bounds each REST call and each WebSocket dial.
+	Timeout time.Duration
+	// MaxReconnects is how many times in a row a dropped WebSocket is
+	// redialled before the client gives up. Zero disables reconnecting.
+	MaxReconnects int
+	HTTPClient    *http.Client
+	// Capabilities are the game.Capability bits announced in a Hello on
+	// each connect. Zero sends no Hello, and the server treats the client
+	// as one from before capabilities were negotiated.
+	Capabilities uint64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		RestURL:       "http://localhost:8080",
+		WSURL:         "ws://localhost:8765",
+		Timeout:       10 * time.Second,
+		MaxReconnects: 5,
+		Capabilities: uint64(game.Capability_CAPABILITY_REJOIN |
+			game.Capability_CAPABILITY_SPECTATOR |
+			game.Capability_CAPABILITY_TIMERS |
+			game.Capability_CAPABILITY_PAUSE |
+			game.Capability_CAPABILITY_NOTICES),
+	}
+}
+
+// Client is one player. REST calls fill in the session and game it acts as;
+// the WebSocket methods then speak for that player.
+type Client struct {
+	cfg Config
+
+	Login     string
+	SessionID string
+	GameID    string
+
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	closed bool
+	// rejoinToken comes with each round's Start; reconnects use it to take
+	// the player's seat back instead of joining afresh.
+	rejoinToken []byte
+	// roomClosed is set once the server closes the game, after which there
+	// is nothing to reconnect to.
+	roomClosed bool
+	// pingSeq numbers Pings so each is matched to its own Pong.
+	pingSeq uint32
+	// seq numbers every frame sent; the server's Errors carry the number
+	// of the frame they answer.
+	seq uint32
+	// agreed holds the capabilities the server's last Hello agreed to.
+	agreed uint64
+	// closeReason is why the server last closed the connection, if it said.
+	closeReason *CloseReason
+	messages    chan *game.BaseMessage
+	done        chan struct{}
+}
+
+func New(cfg Config) *Client {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: cfg.Timeout}
+	}
+	return &Client{
+		cfg:      cfg,
+		messages: make(chan *game.BaseMessage, 64),
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *Client) user() *game.User {
+	return &game.User{
+		Login:     []byte(c.Login),
+		SessionId: []byte(c.SessionID),
+		GameId:    []byte(c.GameID),
+	}
+}
+
+// Capabilities returns the capabilities the server agreed to on the
+// current connection, or 0 before its Hello arrives.
+func (c *Client) Capabilities() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.agreed
+}
+
+// CloseReason is what the server gave as the reason for closing the
+// connection. Reconnect is "now", "backoff", to wait RetryAfter seconds
+// first, or "never", in which case Message is for the player.
+type CloseReason struct {
+	Code       int    `json:"-"`
+	Reconnect  string `json:"reconnect"`
+	RetryAfter int    `json:"retry_after"`
+	Message    string `json:"message"`
+}
+
+// CloseReason returns why the server last closed the connection, or nil if
+// it hasn't or didn't say.
+func (c *Client) CloseReason() *CloseReason {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeReason
+}
+
+// Messages delivers every frame the server sends. It survives reconnects
+// and is closed when the client is closed for good.
+func (c *Client) Messages() <-chan *game.BaseMessage {
+	return c.messages
+}
+
+// WaitFor drops frames until one of the given class arrives.
+func (c *Client) WaitFor(ctx context.Context, classID game.ClassTypes) (*game.BaseMessage, error) {
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				return nil, ErrClosed
+			}
+			if msg.ClassId == classID {
+				return msg, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
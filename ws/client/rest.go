@@ -0,0 +1,238 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+)
+
+// APIError is a non-2xx response from the REST API.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("client: status %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, out interface{}) error {
+	if c.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+	}
+
+	resp, err := c.cfg.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &body) != nil || body.Error == "" {
+			body.Error = string(data)
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: body.Error}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (c *Client) postJSON(ctx context.Context, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", c.cfg.RestURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(ctx, req, out)
+}
+
+func (c *Client) get(ctx context.Context, path string, query neturl.Values, out interface{}) error {
+	url := c.cfg.RestURL + path
+	if len(query) > 0 {
+		url += "?" + query.Encode()
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, out)
+}
+
+// Register creates the player with an avatar and keeps the session it gets.
+func (c *Client) Register(ctx context.Context, login, filename string, image []byte) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("login", login)
+	part, err := form.CreateFormFile("image", filename)
+	if err != nil {
+		return err
+	}
+	part.Write(image)
+	form.Close()
+
+	req, err := http.NewRequest("POST", c.cfg.RestURL+"/register", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var data struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := c.do(ctx, req, &data); err != nil {
+		return err
+	}
+	c.Login = login
+	c.SessionID = data.SessionID
+	return nil
+}
+
+// UploadChatImage uploads an image to attach to chat in the current game and
+// returns its ID for ChatImage.
+func (c *Client) UploadChatImage(ctx context.Context, filename string, image []byte) (uint64, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("session_id", c.SessionID)
+	form.WriteField("game_id", c.GameID)
+	part, err := form.CreateFormFile("image", filename)
+	if err != nil {
+		return 0, err
+	}
+	part.Write(image)
+	form.Close()
+
+	req, err := http.NewRequest("POST", c.cfg.RestURL+"/chat/images", &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	var data struct {
+		ImageID uint64 `json:"image_id"`
+	}
+	if err := c.do(ctx, req, &data); err != nil {
+		return 0, err
+	}
+	return data.ImageID, nil
+}
+
+type HostOptions struct {
+	Language      string   `json:"language,omitempty"`
+	Pack          string   `json:"pack,omitempty"`
+	CardPack      string   `json:"card_pack,omitempty"`
+	CardTags      []string `json:"card_tags,omitempty"`
+	ExcludedPacks []string `json:"exclude_packs,omitempty"`
+	Capacity      int      `json:"capacity,omitempty"`
+	Name          string   `json:"name,omitempty"`
+}
+
+// Host opens a new game with the player as host and joins it.
+func (c *Client) Host(ctx context.Context, opts HostOptions) (string, error) {
+	body := struct {
+		SessionID string `json:"session_id"`
+		HostOptions
+	}{c.SessionID, opts}
+
+	var data struct {
+		GameID string `json:"game_id"`
+	}
+	if err := c.postJSON(ctx, "/host", body, &data); err != nil {
+		return "", err
+	}
+	c.GameID = data.GameID
+	return data.GameID, nil
+}
+
+type Player struct {
+	SessionID string `json:"session_id"`
+	Login     string `json:"login"`
+	ImageData string `json:"image_data"`
+	// ImageURL is a signed link to the avatar that expires after a while.
+	ImageURL string `json:"image_url"`
+}
+
+// Join adds the player to an existing game and returns who is already in it.
+func (c *Client) Join(ctx context.Context, gameID string) ([]Player, error) {
+	var data struct {
+		Sessions []Player `json:"sessions"`
+	}
+	if err := c.postJSON(ctx, "/connect", map[string]string{
+		"session_id": c.SessionID,
+		"game_id":    gameID,
+	}, &data); err != nil {
+		return nil, err
+	}
+	c.GameID = gameID
+	return data.Sessions, nil
+}
+
+// Me fetches the player's own profile.
+func (c *Client) Me(ctx context.Context) (Player, error) {
+	var player Player
+	req, err := http.NewRequest("GET", c.cfg.RestURL+"/me", nil)
+	if err != nil {
+		return player, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.SessionID)
+	err = c.do(ctx, req, &player)
+	return player, err
+}
+
+// Exit deletes the player on the server.
+func (c *Client) Exit(ctx context.Context) error {
+	return c.postJSON(ctx, "/exit", map[string]string{"session_id": c.SessionID}, nil)
+}
+
+// Card is a dealt card. Cards from a custom deck have CustomCardID and DeckID
+// set instead of CardID.
+type Card struct {
+	CardID       uint   `json:"card_id"`
+	CustomCardID uint   `json:"custom_card_id"`
+	DeckID       uint   `json:"deck_id"`
+	Image        []byte `json:"card_img"`
+	// URL is a signed, expiring link to a custom card's image.
+	URL string `json:"card_url"`
+}
+
+// Hand deals count cards to the player for the current round.
+func (c *Client) Hand(ctx context.Context, count int) ([]Card, error) {
+	var data struct {
+		Cards []Card `json:"cards"`
+	}
+	err := c.get(ctx, "/cards", neturl.Values{
+		"game_id":    {c.GameID},
+		"session_id": {c.SessionID},
+		"count":      {strconv.Itoa(count)},
+	}, &data)
+	return data.Cards, err
+}
+
+// Situation fetches the prompt for the next round of the player's game.
+func (c *Client) Situation(ctx context.Context) (string, error) {
+	var data struct {
+		Text string `json:"text"`
+	}
+	err := c.get(ctx, "/text", neturl.Values{"game_id": {c.GameID}}, &data)
+	return data.Text, err
+}
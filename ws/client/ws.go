@@ -0,0 +1,419 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// Connect opens the game WebSocket and announces the player to the room.
+func (c *Client) Connect(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	go c.readLoop(conn)
+	return nil
+}
+
+// dial connects, says Hello and sends UserInfo, which is how the server
+// learns which game the connection belongs to. Mid-round it sends the rejoin token from
+// the last Start instead, so the player keeps their seat.
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	if c.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+	}
+
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = []string{Subprotocol}
+	conn, _, err := dialer.DialContext(ctx, c.cfg.WSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		conn.Close()
+		return nil, ErrClosed
+	}
+	c.conn = conn
+	c.agreed = 0
+	token := c.rejoinToken
+	c.mu.Unlock()
+
+	if c.cfg.Capabilities != 0 {
+		if err := c.send(game.ClassTypes_PROTO_TYPE_HELLO, &game.Hello{
+			ClassId:      game.ClassTypes_PROTO_TYPE_HELLO,
+			Capabilities: c.cfg.Capabilities,
+			Client:       []byte("ws_server/client"),
+		}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if len(token) > 0 {
+		err = c.send(game.ClassTypes_PROTO_TYPE_REJOIN, &game.Rejoin{
+			ClassId: game.ClassTypes_PROTO_TYPE_REJOIN,
+			Token:   token,
+		})
+	} else {
+		err = c.send(game.ClassTypes_PROTO_TYPE_USERINFO, &game.UserInfo{
+			ClassId:   game.ClassTypes_PROTO_TYPE_USERINFO,
+			User:      c.user(),
+			Connected: true,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *Client) readLoop(conn *websocket.Conn) {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			conn.Close()
+			c.noteClose(err)
+			if conn = c.reconnect(); conn == nil {
+				close(c.messages)
+				return
+			}
+			continue
+		}
+
+		var baseMsg game.BaseMessage
+		if err := proto.Unmarshal(message, &baseMsg); err != nil {
+			continue
+		}
+		if baseMsg.ClassId == game.ClassTypes_PROTO_TYPE_START {
+			var start game.Start
+			if proto.Unmarshal(baseMsg.Data, &start) == nil && len(start.RejoinToken) > 0 {
+				c.mu.Lock()
+				c.rejoinToken = start.RejoinToken
+				c.mu.Unlock()
+			}
+		}
+		if baseMsg.ClassId == game.ClassTypes_PROTO_TYPE_ERROR {
+			// An expired rejoin token falls back to joining the normal way.
+			var e game.Error
+			if proto.Unmarshal(baseMsg.Data, &e) == nil && string(e.Code) == "invalid_rejoin_token" {
+				c.mu.Lock()
+				c.rejoinToken = nil
+				c.mu.Unlock()
+				c.send(game.ClassTypes_PROTO_TYPE_USERINFO, &game.UserInfo{
+					ClassId:   game.ClassTypes_PROTO_TYPE_USERINFO,
+					User:      c.user(),
+					Connected: true,
+				})
+			}
+		}
+		if baseMsg.ClassId == game.ClassTypes_PROTO_TYPE_HELLO {
+			var hello game.Hello
+			if proto.Unmarshal(baseMsg.Data, &hello) == nil {
+				c.mu.Lock()
+				c.agreed = hello.Agreed
+				c.mu.Unlock()
+			}
+		}
+		if baseMsg.ClassId == game.ClassTypes_PROTO_TYPE_ROOMCLOSED {
+			c.mu.Lock()
+			c.roomClosed = true
+			c.rejoinToken = nil
+			c.mu.Unlock()
+			conn.Close()
+		}
+		select {
+		case c.messages <- &baseMsg:
+		case <-c.done:
+			close(c.messages)
+			return
+		}
+	}
+}
+
+// noteClose keeps the reason the server gave for closing the connection, if
+// err carries one.
+func (c *Client) noteClose(err error) {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return
+	}
+	reason := &CloseReason{}
+	if json.Unmarshal([]byte(closeErr.Text), reason) != nil {
+		reason = &CloseReason{Message: closeErr.Text}
+	}
+	reason.Code = closeErr.Code
+	c.mu.Lock()
+	c.closeReason = reason
+	c.mu.Unlock()
+}
+
+// reconnect redials with exponential backoff after the connection drops,
+// starting when the server's close reason said to. It returns nil when the
+// client or the room was closed, the server said not to come back, or every
+// attempt failed.
+func (c *Client) reconnect() *websocket.Conn {
+	c.mu.Lock()
+	roomClosed := c.roomClosed
+	reason := c.closeReason
+	c.mu.Unlock()
+	if roomClosed {
+		return nil
+	}
+
+	backoff := 250 * time.Millisecond
+	if reason != nil {
+		switch reason.Reconnect {
+		case "never":
+			return nil
+		case "now":
+			backoff = 0
+		case "backoff":
+			backoff = max(backoff, time.Duration(reason.RetryAfter)*time.Second)
+		}
+	}
+	for attempt := 0; attempt < c.cfg.MaxReconnects; attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-c.done:
+			return nil
+		}
+		if conn, err := c.dial(context.Background()); err == nil {
+			return conn
+		}
+		if backoff == 0 {
+			backoff = 250 * time.Millisecond
+		} else if backoff < 8*time.Second {
+			backoff *= 2
+		}
+	}
+	return nil
+}
+
+func (c *Client) send(classID game.ClassTypes, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+	c.seq++
+	serialized, err := proto.Marshal(&game.BaseMessage{ClassId: classID, Data: data, Seq: c.seq})
+	if err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(websocket.BinaryMessage, serialized)
+}
+
+// Ready toggles the player's ready flag.
+func (c *Client) Ready() error {
+	return c.send(game.ClassTypes_PROTO_TYPE_STATUS, &game.Ready{
+		ClassId: game.ClassTypes_PROTO_TYPE_STATUS,
+		User:    c.user(),
+	})
+}
+
+// Start asks the server to start the round. Only the host may.
+func (c *Client) Start() error {
+	return c.send(game.ClassTypes_PROTO_TYPE_STARTREQUEST, &game.StartRequest{
+		ClassId: game.ClassTypes_PROTO_TYPE_STARTREQUEST,
+		User:    c.user(),
+	})
+}
+
+// SetCapacity changes how many players the room takes. Only the host may.
+func (c *Client) SetCapacity(capacity int) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_ROOMCAPACITY, &game.RoomCapacity{
+		ClassId:  game.ClassTypes_PROTO_TYPE_ROOMCAPACITY,
+		User:     c.user(),
+		Capacity: uint32(capacity),
+	})
+}
+
+// Pause pauses the game, or resumes it when paused is false. Only the host
+// may.
+func (c *Client) Pause(paused bool) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_PAUSE, &game.Pause{
+		ClassId: game.ClassTypes_PROTO_TYPE_PAUSE,
+		User:    c.user(),
+		Paused:  paused,
+	})
+}
+
+// Play puts a card from the player's hand on the table.
+func (c *Client) Play(card Card) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_ACTION, &game.Action{
+		ClassId:      game.ClassTypes_PROTO_TYPE_ACTION,
+		User:         c.user(),
+		Turn:         true,
+		Image:        card.Image,
+		CardId:       uint64(card.CardID),
+		CustomCardId: uint64(card.CustomCardID),
+	})
+}
+
+// Vote picks the card another player played.
+func (c *Client) Vote(sessionID string, card Card) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_CHOOSE, &game.Choose{
+		ClassId:      game.ClassTypes_PROTO_TYPE_CHOOSE,
+		User:         c.user(),
+		ChosenId:     []byte(sessionID),
+		CardId:       uint64(card.CardID),
+		CustomCardId: uint64(card.CustomCardID),
+	})
+}
+
+func (c *Client) Chat(message string) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_CHATMESSAGE, &game.ChatMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_CHATMESSAGE,
+		User:    c.user(),
+		Message: []byte(message),
+	})
+}
+
+// ChatImage sends a chat line with an image uploaded by UploadChatImage.
+func (c *Client) ChatImage(message string, imageID uint64) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_CHATMESSAGE, &game.ChatMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_CHATMESSAGE,
+		User:    c.user(),
+		Message: []byte(message),
+		ImageId: imageID,
+	})
+}
+
+// ChatWithReceipts sends a chat line and asks for a delivered receipt, plus
+// read receipts from members that call MarkRead on it.
+func (c *Client) ChatWithReceipts(message string) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_CHATMESSAGE, &game.ChatMessage{
+		ClassId:  game.ClassTypes_PROTO_TYPE_CHATMESSAGE,
+		User:     c.user(),
+		Message:  []byte(message),
+		Receipts: true,
+	})
+}
+
+// MarkRead tells the author of a chat message that this client has read it.
+func (c *Client) MarkRead(messageID uint64) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_CHATRECEIPT, &game.ChatReceipt{
+		ClassId:   game.ClassTypes_PROTO_TYPE_CHATRECEIPT,
+		User:      c.user(),
+		MessageId: messageID,
+		Status:    game.ReceiptStatus_RECEIPT_READ,
+	})
+}
+
+// EditChat replaces the text of a chat message this client sent.
+func (c *Client) EditChat(messageID uint64, message string) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_CHATEDIT, &game.ChatEdit{
+		ClassId:   game.ClassTypes_PROTO_TYPE_CHATEDIT,
+		User:      c.user(),
+		MessageId: messageID,
+		Message:   []byte(message),
+	})
+}
+
+// DeleteChat removes a chat message this client sent.
+func (c *Client) DeleteChat(messageID uint64) error {
+	return c.send(game.ClassTypes_PROTO_TYPE_CHATDELETE, &game.ChatDelete{
+		ClassId:   game.ClassTypes_PROTO_TYPE_CHATDELETE,
+		User:      c.user(),
+		MessageId: messageID,
+	})
+}
+
+// Ping measures the round trip to the game server with a Ping message,
+// dropping frames until the matching Pong arrives like WaitFor does.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	seq := atomic.AddUint32(&c.pingSeq, 1)
+	sent := time.Now()
+	err := c.send(game.ClassTypes_PROTO_TYPE_PING, &game.Ping{
+		ClassId:    game.ClassTypes_PROTO_TYPE_PING,
+		Seq:        seq,
+		ClientTime: sent.UnixMilli(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	for {
+		msg, err := c.WaitFor(ctx, game.ClassTypes_PROTO_TYPE_PONG)
+		if err != nil {
+			return 0, err
+		}
+		var pong game.Pong
+		if err := proto.Unmarshal(msg.Data, &pong); err != nil {
+			return 0, err
+		}
+		if pong.Seq == seq {
+			return time.Since(sent), nil
+		}
+	}
+}
+
+// ClockOffset asks the server for its time and returns how far ahead of
+// the local clock it is, allowing half the round trip for the reply to
+// arrive. Like WaitFor it drops other frames while it waits.
+func (c *Client) ClockOffset(ctx context.Context) (time.Duration, error) {
+	sent := time.Now()
+	err := c.send(game.ClassTypes_PROTO_TYPE_TIMESYNC, &game.TimeSync{
+		ClassId:    game.ClassTypes_PROTO_TYPE_TIMESYNC,
+		ClientTime: sent.UnixMilli(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	for {
+		msg, err := c.WaitFor(ctx, game.ClassTypes_PROTO_TYPE_TIMESYNC)
+		if err != nil {
+			return 0, err
+		}
+		var timeSync game.TimeSync
+		if err := proto.Unmarshal(msg.Data, &timeSync); err != nil {
+			return 0, err
+		}
+		if timeSync.ClientTime != sent.UnixMilli() {
+			continue
+		}
+		received := time.Now()
+		midpoint := sent.Add(received.Sub(sent) / 2)
+		return time.UnixMilli(timeSync.ServerTime).Sub(midpoint), nil
+	}
+}
+
+// Close leaves the game and closes the WebSocket without reconnecting.
+func (c *Client) Close() error {
+	c.send(game.ClassTypes_PROTO_TYPE_DISCONNECT, &game.Disconnect{
+		ClassId: game.ClassTypes_PROTO_TYPE_DISCONNECT,
+		User:    c.user(),
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
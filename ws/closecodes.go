@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// What a client should do after the server closes its connection, as given
+// in the close frame's reason.
+const (
+	// reconnectNow: the connection can be made again straight away.
+	reconnectNow = "now"
+	// reconnectBackoff: the server is briefly unavailable; reconnect after
+	// retry_after seconds.
+	reconnectBackoff = "backoff"
+	// reconnectNever: reconnecting won't help; show the message instead.
+	reconnectNever = "never"
+)
+
+// maxCloseReason is the most a close frame's reason can hold.
+const maxCloseReason = 123
+
+// closeKind is why the server closes a connection: a close code of its own,
+// from the range the WebSocket spec leaves to applications, or a standard
+// one where it fits, and what the client should do about it.
+type closeKind struct {
+	code      int
+	reconnect string
+}
+
+var (
+	closeRestarting = closeKind{websocket.CloseServiceRestart, reconnectBackoff}
+	closeMalformed  = closeKind{websocket.CloseUnsupportedData, reconnectNever}
+	closeLeft       = closeKind{websocket.CloseNormalClosure, reconnectNever}
+	closeRoomClosed = closeKind{4001, reconnectNever}
+	closeKicked     = closeKind{4002, reconnectNever}
+	// closeReplaced: the player connected again elsewhere.
+	closeReplaced = closeKind{4003, reconnectNever}
+	// closeTooSlow: the client fell too far behind on what it was sent.
+	closeTooSlow = closeKind{4004, reconnectNow}
+	// closeTimedOut: nothing was heard from the client for too long.
+	closeTimedOut = closeKind{4005, reconnectNow}
+)
+
+// closeReason is the JSON a close frame's reason carries.
+type closeReason struct {
+	Reconnect  string `json:"reconnect"`
+	RetryAfter int    `json:"retry_after,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// encode renders the reason, cutting the message short to fit a close
+// frame.
+func (r closeReason) encode() string {
+	for {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return ""
+		}
+		if len(data) <= maxCloseReason || r.Message == "" {
+			return string(data)
+		}
+		r.Message = r.Message[:len(r.Message)-1]
+	}
+}
+
+func (k closeKind) frame(message string, retryAfter time.Duration) []byte {
+	reason := closeReason{Reconnect: k.reconnect, RetryAfter: int(retryAfter.Seconds()), Message: message}
+	return websocket.FormatCloseMessage(k.code, reason.encode())
+}
+
+// closeConn sends conn a close frame of kind after what is already queued
+// for it, then closes it.
+func closeConn(conn *websocket.Conn, kind closeKind, message string, retryAfter time.Duration) {
+	if err := queueMessage(conn, websocket.CloseMessage, kind.frame(message, retryAfter)); err != nil {
+		errorf("Error queueing close frame: %v", err)
+	}
+	closeWhenFlushed(conn)
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	game "ws_server/proto"
+)
+
+// frameCodec translates between a connection's wire encoding and the
+// serialized BaseMessage frames handlers build and parse. Only the edges of
+// a connection see the wire encoding; everything in between, including
+// frames relayed through Redis, stays protobuf.
+type frameCodec interface {
+	decode(wire []byte) (*game.BaseMessage, error)
+	encode(frame []byte) ([]byte, error)
+}
+
+// codecFor picks a connection's codec from the subprotocol it agreed to.
+func codecFor(conn *websocket.Conn) frameCodec {
+	if connSubprotocol(conn) == subprotocolMsgpack {
+		return msgpackCodec{}
+	}
+	return protoCodec{}
+}
+
+// readFrame decodes a message read from conn.
+func readFrame(conn *websocket.Conn, wire []byte) (*game.BaseMessage, error) {
+	return codecFor(conn).decode(wire)
+}
+
+// decodePayload checks the message in a frame's Data decodes as its class
+// and passes validatePayload, so handlers, which decode it again, only ever
+// see well-formed messages. Classes without a message are left for the
+// dispatcher to turn away.
+func decodePayload(baseMsg *game.BaseMessage) error {
+	newMessage, ok := classMessages[baseMsg.ClassId]
+	if !ok {
+		return nil
+	}
+	msg := newMessage()
+	if err := proto.Unmarshal(baseMsg.Data, msg); err != nil {
+		return fmt.Errorf("%v: %w", baseMsg.ClassId, err)
+	}
+	if err := validatePayload(msg); err != nil {
+		return fmt.Errorf("%v: %w", baseMsg.ClassId, err)
+	}
+	return nil
+}
+
+// writeFrame queues a serialized BaseMessage for conn in its encoding,
+// unless conn didn't agree to its class.
+func writeFrame(conn *websocket.Conn, frame []byte) error {
+	if !frameAllowed(conn, frame) {
+		return nil
+	}
+	wire, err := codecFor(conn).encode(frame)
+	if err != nil {
+		errorf("Error encoding frame for %s: %v", connSubprotocol(conn), err)
+		return err
+	}
+	return queueMessage(conn, websocket.BinaryMessage, wire)
+}
+
+// protoCodec is the original encoding: frames go over the wire as is.
+type protoCodec struct{}
+
+func (protoCodec) decode(wire []byte) (*game.BaseMessage, error) {
+	var baseMsg game.BaseMessage
+	if err := proto.Unmarshal(wire, &baseMsg); err != nil {
+		return nil, err
+	}
+	return &baseMsg, nil
+}
+
+func (protoCodec) encode(frame []byte) ([]byte, error) {
+	return frame, nil
+}
+
+// classMessages gives the message carried in Data for each class. Classes
+// missing here travel with their Data as raw bytes.
+var classMessages = map[game.ClassTypes]func() proto.Message{
+	game.ClassTypes_PROTO_TYPE_USERINFO:      func() proto.Message { return &game.UserInfo{} },
+	game.ClassTypes_PROTO_TYPE_ACTION:        func() proto.Message { return &game.Action{} },
+	game.ClassTypes_PROTO_TYPE_DELETE:        func() proto.Message { return &game.DeleteCards{} },
+	game.ClassTypes_PROTO_TYPE_STATUS:        func() proto.Message { return &game.Ready{} },
+	game.ClassTypes_PROTO_TYPE_START:         func() proto.Message { return &game.Start{} },
+	game.ClassTypes_PROTO_TYPE_CHOOSE:        func() proto.Message { return &game.Choose{} },
+	game.ClassTypes_PROTO_TYPE_UPDATE:        func() proto.Message { return &game.UpdateInfo{} },
+	game.ClassTypes_PROTO_TYPE_GAMEINFO:      func() proto.Message { return &game.GameInfo{} },
+	game.ClassTypes_PROTO_TYPE_DISCONNECT:    func() proto.Message { return &game.Disconnect{} },
+	game.ClassTypes_PROTO_TYPE_CHATMESSAGE:   func() proto.Message { return &game.ChatMessage{} },
+	game.ClassTypes_PROTO_TYPE_ERROR:         func() proto.Message { return &game.Error{} },
+	game.ClassTypes_PROTO_TYPE_CHATSETTINGS:  func() proto.Message { return &game.ChatSettings{} },
+	game.ClassTypes_PROTO_TYPE_LOBBYSETTINGS: func() proto.Message { return &game.LobbySettings{} },
+	game.ClassTypes_PROTO_TYPE_AFK:           func() proto.Message { return &game.AfkNotice{} },
+	game.ClassTypes_PROTO_TYPE_STARTREQUEST:  func() proto.Message { return &game.StartRequest{} },
+	game.ClassTypes_PROTO_TYPE_CHATRECEIPT:   func() proto.Message { return &game.ChatReceipt{} },
+	game.ClassTypes_PROTO_TYPE_CHATEDIT:      func() proto.Message { return &game.ChatEdit{} },
+	game.ClassTypes_PROTO_TYPE_CHATDELETE:    func() proto.Message { return &game.ChatDelete{} },
+	game.ClassTypes_PROTO_TYPE_ROOMCAPACITY:  func() proto.Message { return &game.RoomCapacity{} },
+	game.ClassTypes_PROTO_TYPE_REJOIN:        func() proto.Message { return &game.Rejoin{} },
+	game.ClassTypes_PROTO_TYPE_STATESYNC:     func() proto.Message { return &game.StateSync{} },
+	game.ClassTypes_PROTO_TYPE_ROOMCLOSED:    func() proto.Message { return &game.RoomClosed{} },
+	game.ClassTypes_PROTO_TYPE_ANNOUNCEMENT:  func() proto.Message { return &game.Announcement{} },
+	game.ClassTypes_PROTO_TYPE_PING:          func() proto.Message { return &game.Ping{} },
+	game.ClassTypes_PROTO_TYPE_PONG:          func() proto.Message { return &game.Pong{} },
+	game.ClassTypes_PROTO_TYPE_TIMESYNC:      func() proto.Message { return &game.TimeSync{} },
+	game.ClassTypes_PROTO_TYPE_DEADLINE:      func() proto.Message { return &game.TurnDeadline{} },
+	game.ClassTypes_PROTO_TYPE_COUNTDOWN:     func() proto.Message { return &game.Countdown{} },
+	game.ClassTypes_PROTO_TYPE_PAUSE:         func() proto.Message { return &game.Pause{} },
+	game.ClassTypes_PROTO_TYPE_MODERATED:     func() proto.Message { return &game.Moderated{} },
+	game.ClassTypes_PROTO_TYPE_HELLO:         func() proto.Message { return &game.Hello{} },
+}
+
+// msgpackCodec sends each frame as a MessagePack map {classId, data}, where
+// data is the class's message as a map keyed by the proto JSON field names.
+// Enums are numbers. Bytes fields are sent as strings when they hold valid
+// UTF-8 and as binary otherwise, and either is accepted from clients. A
+// frame's seq, if it has one, rides along as a third key.
+type msgpackCodec struct{}
+
+func (msgpackCodec) decode(wire []byte) (*game.BaseMessage, error) {
+	value, err := decodeMsgpack(wire)
+	if err != nil {
+		return nil, err
+	}
+	envelope, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("msgpack frame is not a map")
+	}
+	classID, err := toInt64(envelope["classId"])
+	if err != nil {
+		return nil, fmt.Errorf("classId: %w", err)
+	}
+	baseMsg := &game.BaseMessage{ClassId: game.ClassTypes(classID)}
+	if seq, ok := envelope["seq"]; ok {
+		n, err := toInt64(seq)
+		if err != nil {
+			return nil, fmt.Errorf("seq: %w", err)
+		}
+		baseMsg.Seq = uint32(n)
+	}
+
+	switch data := envelope["data"].(type) {
+	case nil:
+	case []byte:
+		baseMsg.Data = data
+	case map[string]interface{}:
+		newMessage, ok := classMessages[baseMsg.ClassId]
+		if !ok {
+			return nil, fmt.Errorf("no message for class %v", baseMsg.ClassId)
+		}
+		msg := newMessage()
+		if err := mapToMessage(msg.ProtoReflect(), data); err != nil {
+			return nil, err
+		}
+		if baseMsg.Data, err = proto.Marshal(msg); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("data is %T, not a map", data)
+	}
+	return baseMsg, nil
+}
+
+func (msgpackCodec) encode(frame []byte) ([]byte, error) {
+	var baseMsg game.BaseMessage
+	if err := proto.Unmarshal(frame, &baseMsg); err != nil {
+		return nil, err
+	}
+	envelope := map[string]interface{}{"classId": int64(baseMsg.ClassId)}
+	if baseMsg.Seq != 0 {
+		envelope["seq"] = int64(baseMsg.Seq)
+	}
+
+	newMessage, ok := classMessages[baseMsg.ClassId]
+	switch {
+	case !ok && len(baseMsg.Data) > 0:
+		envelope["data"] = baseMsg.Data
+	case ok:
+		msg := newMessage()
+		if err := proto.Unmarshal(baseMsg.Data, msg); err != nil {
+			return nil, err
+		}
+		envelope["data"] = messageToMap(msg.ProtoReflect())
+	}
+	return appendMsgpack(nil, envelope)
+}
+
+// messageToMap turns a message into plain values for MessagePack, leaving
+// out unset fields.
+func messageToMap(m protoreflect.Message) map[string]interface{} {
+	out := map[string]interface{}{}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList():
+			list := v.List()
+			items := make([]interface{}, list.Len())
+			for i := range items {
+				items[i] = scalarToPlain(fd, list.Get(i))
+			}
+			out[fd.JSONName()] = items
+		case fd.IsMap():
+			entries := map[string]interface{}{}
+			v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				entries[k.String()] = scalarToPlain(fd.MapValue(), v)
+				return true
+			})
+			out[fd.JSONName()] = entries
+		default:
+			out[fd.JSONName()] = scalarToPlain(fd, v)
+		}
+		return true
+	})
+	return out
+}
+
+func scalarToPlain(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.EnumKind:
+		return int64(v.Enum())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.BytesKind:
+		if b := v.Bytes(); utf8.Valid(b) {
+			return string(b)
+		}
+		return v.Bytes()
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageToMap(v.Message())
+	}
+	return nil
+}
+
+// mapToMessage fills m from a decoded MessagePack map. Keys may be JSON or
+// proto field names; unknown keys are ignored.
+func mapToMessage(m protoreflect.Message, in map[string]interface{}) error {
+	fields := m.Descriptor().Fields()
+	for key, value := range in {
+		fd := fields.ByJSONName(key)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(key))
+		}
+		if fd == nil || value == nil {
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			return fmt.Errorf("%s: map fields aren't supported", key)
+		case fd.IsList():
+			items, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s: expected an array, got %T", key, value)
+			}
+			list := m.Mutable(fd).List()
+			for _, item := range items {
+				if fd.Message() != nil {
+					elem := list.NewElement()
+					if err := fillMessage(elem.Message(), key, item); err != nil {
+						return err
+					}
+					list.Append(elem)
+					continue
+				}
+				v, err := plainToScalar(fd, item)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				list.Append(v)
+			}
+		case fd.Message() != nil:
+			if err := fillMessage(m.Mutable(fd).Message(), key, value); err != nil {
+				return err
+			}
+		default:
+			v, err := plainToScalar(fd, value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			m.Set(fd, v)
+		}
+	}
+	return nil
+}
+
+func fillMessage(m protoreflect.Message, key string, value interface{}) error {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: expected a map, got %T", key, value)
+	}
+	return mapToMessage(m, fields)
+}
+
+func plainToScalar(fd protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, ok := value.(bool)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected a bool, got %T", value)
+		}
+		return protoreflect.ValueOfBool(b), nil
+	case protoreflect.EnumKind:
+		n, err := toInt64(value)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := toInt64(value)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := toInt64(value)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := toUint64(value)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := toUint64(value)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := toFloat64(value)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := toFloat64(value)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		switch s := value.(type) {
+		case string:
+			return protoreflect.ValueOfString(s), nil
+		case []byte:
+			return protoreflect.ValueOfString(string(s)), nil
+		}
+	case protoreflect.BytesKind:
+		switch b := value.(type) {
+		case string:
+			return protoreflect.ValueOfBytes([]byte(b)), nil
+		case []byte:
+			return protoreflect.ValueOfBytes(b), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("can't use %T as %v", value, fd.Kind())
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch n := value.(type) {
+	case int64:
+		return n, nil
+	case uint64:
+		return int64(n), nil
+	case float64:
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", value)
+}
+
+func toUint64(value interface{}) (uint64, error) {
+	switch n := value.(type) {
+	case int64:
+		if n < 0 {
+			return 0, fmt.Errorf("expected an unsigned number, got %d", n)
+		}
+		return uint64(n), nil
+	case uint64:
+		return n, nil
+	case float64:
+		if n < 0 || n > math.MaxUint64 {
+			return 0, fmt.Errorf("number %v out of range", n)
+		}
+		return uint64(n), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", value)
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	switch n := value.(type) {
+	case int64:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", value)
+}
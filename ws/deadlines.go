@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	game "ws_server/proto"
+)
+
+// Every frame a client sends is handled within messageTimeout, and each call
+// the handling makes to the REST service within restTimeout of that, so a
+// stalled database behind the REST service holds a client's reader up for a
+// while rather than for good.
+const (
+	messageTimeout = 15 * time.Second
+	restTimeout    = 5 * time.Second
+)
+
+// messageContext bounds the handling of one frame.
+func messageContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), messageTimeout)
+}
+
+// restContext bounds one call to the REST service made on behalf of ctx.
+// Work that doesn't answer a frame, like timers and background syncs, passes
+// context.Background().
+func restContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, restTimeout)
+}
+
+// noteSlowMessage logs a frame whose handling ran out of time, so the calls
+// it made were cut short.
+func noteSlowMessage(ctx context.Context, classID game.ClassTypes) {
+	if ctx.Err() == context.DeadlineExceeded {
+		warnf("Handling %v took longer than %v", classID, messageTimeout)
+	}
+}
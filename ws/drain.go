@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultRestartDowntime is how long clients are told a restart takes
+	// when WS_RESTART_DOWNTIME doesn't say.
+	defaultRestartDowntime = 30 * time.Second
+	// drainTimeout bounds how long shutting down waits for close frames and
+	// in-flight requests.
+	drainTimeout = 10 * time.Second
+)
+
+var (
+	// draining is set once shutdown has begun: upgrades are refused and
+	// membership is no longer synced, so the snapshot taken for the restart
+	// isn't overwritten as connections close.
+	draining atomic.Bool
+
+	serversMu sync.Mutex
+	servers   []*http.Server
+)
+
+// handleShutdownSignals drains the server on SIGTERM or an interrupt and
+// exits.
+func handleShutdownSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	sig := <-signals
+	infof("Received %v, draining", sig)
+	drain(restartDowntime())
+	infof("Drained, exiting")
+	os.Exit(0)
+}
+
+func restartDowntime() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("WS_RESTART_DOWNTIME")); err == nil && d > 0 {
+		return d
+	}
+	return defaultRestartDowntime
+}
+
+// drain stops taking connections, saves every game's membership to the room
+// store for the next run to pick up, and closes each connection with a
+// close frame saying the server is restarting and to retry after downtime.
+func drain(downtime time.Duration) {
+	draining.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	serversMu.Lock()
+	for _, srv := range servers {
+		go srv.Shutdown(ctx)
+	}
+	serversMu.Unlock()
+
+	shareMu.Lock()
+	mu.Lock()
+	games := snapshotMembersLocked()
+	mu.Unlock()
+	for gameID, members := range games {
+		if err := roomStore.SaveGame(gameID, members); err != nil {
+			errorf("Failed to save sessions for game_id %s: %v", gameID, err)
+		}
+	}
+	shareMu.Unlock()
+	infof("Saved %d games before restart", len(games))
+
+	mu.Lock()
+	for conn := range clients {
+		closeConn(conn, closeRestarting, "Server restarting", downtime)
+	}
+	for conn := range observers {
+		closeConn(conn, closeRestarting, "Server restarting", downtime)
+	}
+	mu.Unlock()
+
+	for {
+		pumpsMu.Lock()
+		pending := len(pumps)
+		pumpsMu.Unlock()
+		if pending == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			warnf("Gave up waiting on %d connections to close", pending)
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// refuseWhileDraining turns away an upgrade once shutdown has begun, telling
+// the client when to try again, and reports whether it did.
+func refuseWhileDraining(w http.ResponseWriter) bool {
+	if !draining.Load() {
+		return false
+	}
+	w.Header().Set("Retry-After", fmt.Sprint(int(restartDowntime().Seconds())))
+	http.Error(w, "server restarting", http.StatusServiceUnavailable)
+	return true
+}
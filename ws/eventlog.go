@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	game "ws_server/proto"
+)
+
+const (
+	eventQueueSize = 1024
+	eventBatchSize = 50
+	// eventBatchBytes keeps each batch well under the REST service's limit
+	// on /events; a longer batch is sent in parts.
+	eventBatchBytes = 4 << 20
+)
+
+// gameEvent is one entry of a game's event log. Data holds the proto message
+// the event was about so replays can be rebuilt from the existing types.
+type gameEvent struct {
+	GameID     string    `json:"game_id"`
+	Type       string    `json:"type"`
+	SessionID  string    `json:"session_id"`
+	Login      string    `json:"login"`
+	ClassID    int32     `json:"class_id"`
+	MessageID  uint64    `json:"message_id,omitempty"`
+	Data       []byte    `json:"data"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+var eventQueue = make(chan gameEvent, eventQueueSize)
+
+// logEvent queues an event for the game's log. It never blocks the caller: if
+// the REST service falls behind far enough to fill the queue, events are
+// dropped.
+func logEvent(eventType string, user *game.User, classID game.ClassTypes, msg proto.Message) {
+	logChatEvent(eventType, 0, user, classID, msg)
+}
+
+// logChatEvent is logEvent for events about a chat message, tagging them with
+// its ID so the REST service can edit or remove the stored message later.
+func logChatEvent(eventType string, messageID uint64, user *game.User, classID game.ClassTypes, msg proto.Message) {
+	event := gameEvent{
+		Type:       eventType,
+		ClassID:    int32(classID),
+		MessageID:  messageID,
+		OccurredAt: time.Now(),
+	}
+	if user != nil {
+		event.GameID = string(user.GameId)
+		event.SessionID = string(user.SessionId)
+		event.Login = string(user.Login)
+	}
+	if msg != nil {
+		msg = proto.Clone(msg)
+		scrubSessions(msg.ProtoReflect())
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			errorf("Error serializing %s event: %v", eventType, err)
+			return
+		}
+		event.Data = data
+	}
+
+	select {
+	case eventQueue <- event:
+	default:
+		warnf("Event queue full, dropping %s event for game_id %s", eventType, event.GameID)
+	}
+}
+
+// scrubSessions clears the session IDs and rejoin tokens in a logged
+// message. They are credentials, and the log is replayed to anyone, so
+// players are known there by login.
+func scrubSessions(m protoreflect.Message) {
+	var secrets []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Name() == "session_id" || fd.Name() == "rejoin_token":
+			secrets = append(secrets, fd)
+		case fd.Kind() != protoreflect.MessageKind || fd.IsMap():
+		case fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				scrubSessions(v.List().Get(i).Message())
+			}
+		default:
+			scrubSessions(v.Message())
+		}
+		return true
+	})
+	for _, fd := range secrets {
+		m.Clear(fd)
+	}
+}
+
+// runEventLogger ships queued events to the REST service in batches, in the
+// order they were logged.
+func runEventLogger() {
+	for event := range eventQueue {
+		batch := []gameEvent{event}
+	drain:
+		for len(batch) < eventBatchSize {
+			select {
+			case event := <-eventQueue:
+				batch = append(batch, event)
+			default:
+				break drain
+			}
+		}
+
+		if err := postEvents(batch); err != nil {
+			errorf("Failed to store %d game events: %v", len(batch), err)
+		}
+	}
+}
+
+// postEvents stores a batch of events, splitting it in halves until each
+// part fits in eventBatchBytes.
+func postEvents(events []gameEvent) error {
+	url := restURL("/events")
+
+	jsonData, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	if len(jsonData) > eventBatchBytes && len(events) > 1 {
+		half := len(events) / 2
+		err := postEvents(events[:half])
+		if rest := postEvents(events[half:]); err == nil {
+			err = rest
+		}
+		return err
+	}
+
+	ctx, cancel := restContext(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// featureRefreshInterval is how often flags are fetched from the REST
+// service.
+const featureRefreshInterval = 30 * time.Second
+
+// flagWSMsgpack gates the MessagePack subprotocol.
+const flagWSMsgpack = "ws_msgpack"
+
+// featureFlag mirrors the REST service's flags.
+type featureFlag struct {
+	Enabled bool `json:"enabled"`
+	Percent int  `json:"percent"`
+}
+
+var (
+	featuresMu sync.RWMutex
+	// features is nil until the first fetch succeeds, and every feature is
+	// on until then so an unreachable REST service doesn't take them away.
+	features map[string]featureFlag
+)
+
+func fetchFeatures() (map[string]featureFlag, error) {
+	ctx, cancel := restContext(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", restURL("/ws/features"), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	var flags map[string]featureFlag
+	if err := json.NewDecoder(resp.Body).Decode(&flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// runFeatureRefresh keeps features current, so flags changed through the
+// REST service's admin API apply here without a restart.
+func runFeatureRefresh() {
+	for {
+		if flags, err := fetchFeatures(); err != nil {
+			errorf("Failed to fetch feature flags: %v", err)
+		} else {
+			featuresMu.Lock()
+			features = flags
+			featuresMu.Unlock()
+		}
+		time.Sleep(featureRefreshInterval)
+	}
+}
+
+// featureEnabled reports whether the feature is on for key, bucketing keys
+// the same way the REST service does.
+func featureEnabled(name, key string) bool {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	if features == nil {
+		return true
+	}
+	flag, ok := features[name]
+	if !ok || !flag.Enabled {
+		return false
+	}
+	if flag.Percent >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + key))
+	return int(h.Sum32()%100) < flag.Percent
+}
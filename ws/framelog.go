@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	game "ws_server/proto"
+)
+
+// The frame log records the metadata of every frame clients send, so a
+// report like "my vote disappeared" can be checked against what actually
+// arrived. It is off unless FRAME_LOG names a file. Entries are JSON lines;
+// once the file reaches FRAME_LOG_MAX_BYTES, or has been written to for
+// LOG_MAX_AGE, it is rotated to FRAME_LOG.1 and so on, keeping
+// FRAME_LOG_FILES old files.
+const (
+	frameLogQueueSize       = 4096
+	defaultFrameLogMaxBytes = 10 << 20
+	defaultFrameLogFiles    = 5
+)
+
+// frameLogEntry describes one inbound frame. Decode is "ok" or why the
+// frame, or the message in its Data, couldn't be decoded.
+type frameLogEntry struct {
+	At          time.Time `json:"at"`
+	Conn        string    `json:"conn"`
+	Subprotocol string    `json:"subprotocol"`
+	ClassID     int32     `json:"class_id"`
+	Class       string    `json:"class,omitempty"`
+	Size        int       `json:"size"`
+	Decode      string    `json:"decode"`
+}
+
+var frameLogQueue chan frameLogEntry
+
+// startFrameLog opens the frame log if FRAME_LOG is set and starts writing
+// queued entries to it.
+func startFrameLog() {
+	path := os.Getenv("FRAME_LOG")
+	if path == "" {
+		return
+	}
+	maxBytes := int64(defaultFrameLogMaxBytes)
+	if n, err := strconv.ParseInt(os.Getenv("FRAME_LOG_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		maxBytes = n
+	}
+	files := defaultFrameLogFiles
+	if n, err := strconv.Atoi(os.Getenv("FRAME_LOG_FILES")); err == nil && n >= 0 {
+		files = n
+	}
+	f, err := openRotatingFile(path, maxBytes, logMaxAge(), files)
+	if err != nil {
+		warnf("Frame log disabled: %v", err)
+		return
+	}
+
+	infof("Logging inbound frames to %s", path)
+	frameLogQueue = make(chan frameLogEntry, frameLogQueueSize)
+	go writeFrameLog(f)
+}
+
+// logFrame queues the metadata of a frame read from conn. baseMsg and err
+// are what decoding it gave, baseMsg being nil if even its envelope
+// couldn't be. It never blocks: entries are dropped when the writer falls
+// behind.
+func logFrame(conn *websocket.Conn, wire []byte, baseMsg *game.BaseMessage, err error) {
+	if frameLogQueue == nil {
+		return
+	}
+
+	entry := frameLogEntry{
+		At:          time.Now(),
+		Conn:        conn.RemoteAddr().String(),
+		Subprotocol: connSubprotocol(conn),
+		Size:        len(wire),
+		Decode:      "ok",
+	}
+	if baseMsg != nil {
+		entry.ClassID = int32(baseMsg.ClassId)
+		entry.Class = baseMsg.ClassId.String()
+		if _, ok := classMessages[baseMsg.ClassId]; !ok {
+			entry.Decode = "unknown class"
+		}
+	}
+	if err != nil {
+		entry.Decode = err.Error()
+	}
+
+	select {
+	case frameLogQueue <- entry:
+	default:
+	}
+}
+
+func writeFrameLog(f *rotatingFile) {
+	for entry := range frameLogQueue {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			errorf("Error writing frame log: %v", err)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"google.golang.org/protobuf/proto"
@@ -12,90 +14,325 @@ import (
 )
 
 func handleClient(conn *websocket.Conn) {
-	log.Println("Client connected")
+	infof("Client connected using %s", connSubprotocol(conn))
+	stopHeartbeat := startHeartbeat(conn)
+	// silent is set when the client went quiet rather than closing, in
+	// which case its players are disconnected for good.
+	silent := false
 	defer func() {
+		stopHeartbeat()
+		if silent {
+			dropSilentClient(conn)
+		}
+		setRequestSeq(conn, 0)
+		forgetCapabilities(conn)
+		pauseForDroppedPlayers(conn)
 		mu.Lock()
 		delete(clients, conn)
 		mu.Unlock()
-		conn.Close()
-		log.Println("Client disconnected")
+		closeWhenFlushed(conn)
+		infof("Client disconnected")
 	}()
 
+	// malformed counts the frames that couldn't be decoded or failed
+	// validation; each is answered with an Error, and the client is dropped
+	// once there are maxMalformedFrames of them.
+	malformed := 0
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("Error reading message: %v", err)
+			if heartbeatMissed(err) {
+				warnf("Client missed its heartbeat")
+				silent = true
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				errorf("Error reading message: %v", err)
 			}
 			break
 		}
-
-		var baseMsg game.BaseMessage
-		if err := proto.Unmarshal(message, &baseMsg); err != nil {
-			log.Printf("Error unmarshaling message: %v", err)
+		extendHeartbeat(conn)
+
+		baseMsg, err := readFrame(conn, message)
+		if err == nil {
+			setRequestSeq(conn, baseMsg.Seq)
+			err = decodePayload(baseMsg)
+		} else {
+			setRequestSeq(conn, 0)
+		}
+		logFrame(conn, message, baseMsg, err)
+		if err != nil {
+			errorf("Error unmarshaling message: %v", err)
+			malformed++
+			if malformed >= maxMalformedFrames {
+				warnf("Dropping client after %d malformed frames", malformed)
+				sendErrorMessage(conn, errCodeMalformedFrame, "Too many messages could not be decoded")
+				closeConn(conn, closeMalformed, "Too many malformed frames", 0)
+				break
+			}
+			if errors.Is(err, errInvalidPayload) {
+				sendErrorMessage(conn, errCodeInvalidMessage, err.Error())
+			} else {
+				sendErrorMessage(conn, errCodeMalformedFrame, "The message could not be decoded")
+			}
 			continue
 		}
 
+		ctx, cancel := messageContext()
 		switch baseMsg.ClassId {
 		case game.ClassTypes_PROTO_TYPE_USERINFO:
-			handleUserInfo(conn, baseMsg.Data)
+			handleUserInfo(ctx, conn, baseMsg.Data)
 		case game.ClassTypes_PROTO_TYPE_ACTION:
-			handleAction(conn, baseMsg.Data)
+			handleAction(ctx, conn, baseMsg.Data)
 		case game.ClassTypes_PROTO_TYPE_STATUS:
 			handleStatus(conn, baseMsg.Data)
 		case game.ClassTypes_PROTO_TYPE_CHOOSE:
-			handleChoose(conn, baseMsg.Data)
+			handleChoose(ctx, conn, baseMsg.Data)
 		case game.ClassTypes_PROTO_TYPE_GAMEINFO:
 			handleGameInfo(baseMsg.Data)
 		case game.ClassTypes_PROTO_TYPE_DISCONNECT:
-			handleDisconnect(conn, baseMsg.Data)
+			handleDisconnect(ctx, conn, baseMsg.Data)
 		case game.ClassTypes_PROTO_TYPE_CHATMESSAGE:
-			handleChatMessage(conn, baseMsg.Data)
+			handleChatMessage(ctx, conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_CHATRECEIPT:
+			handleChatReceipt(conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_CHATEDIT:
+			handleChatEdit(conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_CHATDELETE:
+			handleChatDelete(conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_CHATSETTINGS:
+			handleChatSettings(ctx, conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_LOBBYSETTINGS:
+			handleLobbySettings(ctx, conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_ROOMCAPACITY:
+			handleRoomCapacity(ctx, conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_REJOIN:
+			handleRejoin(ctx, conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_STARTREQUEST:
+			handleStartRequest(ctx, conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_PING:
+			handlePing(conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_TIMESYNC:
+			handleTimeSync(conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_PAUSE:
+			handlePause(ctx, conn, baseMsg.Data)
+		case game.ClassTypes_PROTO_TYPE_HELLO:
+			handleHello(conn, baseMsg.Data)
 		default:
-			log.Printf("Unknown message type: %v", baseMsg.ClassId)
+			warnf("Unknown message type: %v", baseMsg.ClassId)
+			sendErrorMessage(conn, errCodeUnknownMessage, fmt.Sprintf("Unknown message type %v", baseMsg.ClassId))
 		}
+		noteSlowMessage(ctx, baseMsg.ClassId)
+		cancel()
 	}
 }
 
-func handleChatMessage(conn *websocket.Conn, data []byte) {
+func handleChatMessage(ctx context.Context, conn *websocket.Conn, data []byte) {
 	var chatMsg game.ChatMessage
 	if err := proto.Unmarshal(data, &chatMsg); err != nil {
-		log.Printf("Error unmarshaling chat message: %v", err)
+		errorf("Error unmarshaling chat message: %v", err)
 		return
 	}
 
-	log.Printf("Received chat message from %s: %s", string(chatMsg.User.Login), string(chatMsg.Message))
-	sendChatMessage(conn, string(chatMsg.User.GameId), string(chatMsg.User.Login), string(chatMsg.Message))
+	gameID := string(chatMsg.User.GameId)
+	debugf("Received chat message from %s: %s", string(chatMsg.User.Login), string(chatMsg.Message))
+
+	mu.Lock()
+	sender := findUserLocked(gameID, string(chatMsg.User.SessionId))
+	crossTalk := getGameStateLocked(gameID).CrossTalk
+	mu.Unlock()
+
+	// Spectators only reach other spectators unless the host opened
+	// cross-talk; players always reach the whole room.
+	spectatorsOnly := false
+	if sender != nil && sender.Spectator {
+		spectatorsOnly = chatMsg.Scope == game.ChatScope_CHAT_SCOPE_SPECTATORS || !crossTalk
+	}
+
+	// Attachments are relayed as a URL the REST service signed for this
+	// sender and game, never as a URL or bytes the client supplied.
+	chatMsg.ImageUrl = nil
+	if chatMsg.ImageId != 0 {
+		url, err := fetchChatImageURL(ctx, gameID, string(chatMsg.User.SessionId), chatMsg.ImageId)
+		if err != nil {
+			warnf("Rejected chat image %d for game_id %s: %v", chatMsg.ImageId, gameID, err)
+			sendErrorMessage(conn, errCodeInvalidImage, "Chat image not found")
+			return
+		}
+		chatMsg.ImageUrl = []byte(url)
+	}
+
+	// IDs and timestamps are always the server's; whatever the client sent
+	// is overwritten so they can be trusted for ordering and references.
+	chatMsg.Id = nextChatID()
+	chatMsg.SentAt = time.Now().UnixMilli()
+
+	shadow := shadowBanned(string(chatMsg.User.SessionId))
+	trackChat(gameID, conn, &chatMsg, spectatorsOnly, shadow)
+	logChatEvent("chat", chatMsg.Id, chatMsg.User, game.ClassTypes_PROTO_TYPE_CHATMESSAGE, &chatMsg)
+	delivered := sendChatMessage(conn, gameID, &chatMsg, spectatorsOnly, shadow)
+	if chatMsg.Receipts {
+		sendChatReceipt(conn, &game.ChatReceipt{
+			User:      chatMsg.User,
+			MessageId: chatMsg.Id,
+			Status:    game.ReceiptStatus_RECEIPT_DELIVERED,
+			Delivered: uint32(delivered),
+		})
+	}
 }
 
-func handleUserInfo(conn *websocket.Conn, data []byte) {
+func handleChatSettings(ctx context.Context, conn *websocket.Conn, data []byte) {
+	var settings game.ChatSettings
+	if err := proto.Unmarshal(data, &settings); err != nil {
+		errorf("Error unmarshaling ChatSettings: %v", err)
+		return
+	}
+
+	gameID := string(settings.User.GameId)
+	host, err := fetchRoomHost(ctx, gameID)
+	if err != nil {
+		errorf("Error fetching host for game_id %s: %v", gameID, err)
+		sendErrorMessage(conn, errCodeValidationFailed, "Could not verify the room host")
+		return
+	}
+	if host != string(settings.User.SessionId) {
+		sendErrorMessage(conn, errCodeNotHost, "Only the host can change chat settings")
+		return
+	}
+
+	mu.Lock()
+	getGameStateLocked(gameID).CrossTalk = settings.CrossTalk
+	mu.Unlock()
+	infof("Cross-talk for game_id %s set to %v", gameID, settings.CrossTalk)
+
+	settings.ClassId = game.ClassTypes_PROTO_TYPE_CHATSETTINGS
+	serializedData, err := SerializeToString(&settings)
+	if err != nil {
+		errorf("Error serializing ChatSettings: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_CHATSETTINGS,
+		Data:    serializedData,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	if err := SendMessageToGameClients(gameID, serializedBaseMessage, conn); err != nil {
+		errorf("Failed to send chat settings to game clients: %v", err)
+	}
+}
+
+func handleUserInfo(ctx context.Context, conn *websocket.Conn, data []byte) {
 	var userInfo game.UserInfo
 	if err := proto.Unmarshal(data, &userInfo); err != nil {
-		log.Printf("Error unmarshaling UserInfo: %v", err)
+		errorf("Error unmarshaling UserInfo: %v", err)
 		return
 	}
 
-	log.Printf("Received user info: %v", userInfo)
+	debugf("Received user info: %v", &userInfo)
+
+	gameID := string(userInfo.User.GameId)
+
+	// A seat held for a player who dropped mid-round only goes back to
+	// whoever presents its Rejoin token; knowing the session isn't enough.
+	if userInfo.Connected {
+		mu.Lock()
+		held := rejoinTokenLocked(gameID, string(userInfo.User.SessionId)) != ""
+		mu.Unlock()
+		if held {
+			warnf("Refused UserInfo for a seat held for rejoin in game_id %s", gameID)
+			return
+		}
+	}
+
+	var capacity int
+	if userInfo.Connected {
+		if info, err := fetchRoomInfo(ctx, gameID); err != nil {
+			errorf("Error fetching room info for game_id %s: %v", gameID, err)
+		} else {
+			capacity = info.Capacity
+		}
+	}
+
+	// Spectators of a game under way, and members picking up after a
+	// restart, get a snapshot once they're in rather than a blank table until
+	// someone acts. Registered before locking so it runs after the unlock.
+	syncState := false
+	syncSessionID := ""
+	defer func() {
+		if syncState {
+			sendStateSync(ctx, conn, gameID, syncSessionID)
+		}
+	}()
+	// A player leaving is dropped from the REST service once mu is released,
+	// not while this handler holds it.
+	exited := false
+	defer func() {
+		if exited {
+			deleteUser(ctx, string(userInfo.User.SessionId))
+		}
+	}()
+	// Seats and rounds other instances hold count too, and this instance's
+	// new member counts for them.
+	tallyRound(gameID)
+	defer shareGame(gameID)
 
 	mu.Lock()
-	defer mu.Unlock()
 
 	if _, ok := clients[conn]; !ok {
 		clients[conn] = []*Room{}
 	}
+	if capacity > 0 {
+		getGameStateLocked(gameID).Capacity = capacity
+	}
+
+	// Anyone joining a game that is already under way, or a room whose
+	// player seats are taken, watches instead of playing.
+	started := gameStartedLocked(gameID)
+	spectator := userInfo.Connected && (started || roomFullLocked(gameID))
+	syncState = spectator && started
+
+	sessionID := string(userInfo.User.SessionId)
+	newUser := &User{
+		Login:     string(userInfo.User.Login),
+		SessionID: sessionID,
+		GameID:    gameID,
+		Spectator: spectator,
+	}
+	roundStarted := false
+	if userInfo.Connected {
+		// A member reloaded from the registry after a restart picks up where
+		// they were instead of joining afresh.
+		if member, ok := takeRestoredMemberLocked(gameID, sessionID); ok {
+			newUser.Spectator = member.Spectator
+			newUser.Ready = member.Ready
+			newUser.Turn = member.Turn
+			newUser.Voted = member.Voted
+			newUser.InGame = member.InGame
+			roundStarted = member.Started
+			syncState = member.InGame || member.Started
+			if !member.Spectator {
+				syncSessionID = sessionID
+			}
+		}
+	}
+	userInfo.Spectator = newUser.Spectator
 
 	roomExists := false
 	for _, room := range clients[conn] {
-		if room.GameID == string(userInfo.User.GameId) {
+		if room.GameID == gameID {
 			roomExists = true
 			if userInfo.Connected {
-				addUserToRoom(room, &User{
-					Login:     string(userInfo.User.Login),
-					SessionID: string(userInfo.User.SessionId),
-				})
+				addUserToRoom(room, newUser)
+				room.started = room.started || roundStarted
 			} else {
-				removeUserFromRoom(room, string(userInfo.User.SessionId))
-				deleteUser(string(userInfo.User.SessionId))
+				for _, rooms := range clients {
+					for _, r := range rooms {
+						removeUserFromRoom(r, sessionID)
+					}
+				}
+				exited = true
 			}
 			break
 		}
@@ -103,31 +340,69 @@ func handleUserInfo(conn *websocket.Conn, data []byte) {
 
 	if !roomExists {
 		newRoom := &Room{
-			GameID:  string(userInfo.User.GameId),
-			started: false,
+			GameID:  gameID,
+			started: roundStarted,
 			Users:   []*User{},
 		}
 		if userInfo.Connected {
-			addUserToRoom(newRoom, &User{
-				Login:     string(userInfo.User.Login),
-				SessionID: string(userInfo.User.SessionId),
-			})
+			addUserToRoom(newRoom, newUser)
 		}
 		clients[conn] = append(clients[conn], newRoom)
 	}
 
-	log.Printf("Current clients: %+v", clients)
+	debugf("Current clients: %+v", clients)
+	if userInfo.Connected {
+		logEvent("join", userInfo.User, game.ClassTypes_PROTO_TYPE_USERINFO, &userInfo)
+	} else {
+		logEvent("leave", userInfo.User, game.ClassTypes_PROTO_TYPE_USERINFO, &userInfo)
+	}
+	mu.Unlock()
+
 	SendUserInfoToGameClients(&userInfo, conn)
 	SendUpdateMessage(string(userInfo.User.Login), string(userInfo.User.SessionId), string(userInfo.User.GameId), conn)
+	if userInfo.Connected {
+		sendTimeSync(conn, 0)
+	}
 }
 
-func handleAction(conn *websocket.Conn, data []byte) {
+func handleAction(ctx context.Context, conn *websocket.Conn, data []byte) {
 	var action game.Action
 	if err := proto.Unmarshal(data, &action); err != nil {
-		log.Printf("Error unmarshaling Action: %v", err)
+		errorf("Error unmarshaling Action: %v", err)
+		return
+	}
+	debugf("Received action from: %s and game_id: %s, card_id: %d, custom_card_id: %d", action.User.SessionId, action.User.GameId, action.CardId, action.CustomCardId)
+
+	if userHasTurned(string(action.User.GameId), string(action.User.SessionId)) {
+		warnf("User already turned")
+		sendErrorMessage(conn, errCodeAlreadyPlayed, "You already played a card this round")
+		return
+	}
+	mu.Lock()
+	paused := getGameStateLocked(string(action.User.GameId)).paused
+	late := turnDeadlinePassedLocked(string(action.User.GameId))
+	mu.Unlock()
+	if paused {
+		sendErrorMessage(conn, errCodePaused, "The game is paused")
+		return
+	}
+	if late {
+		warnf("Rejected late action from %s", action.User.SessionId)
+		sendErrorMessage(conn, errCodeDeadlinePassed, "The time to play this round is up")
+		return
+	}
+
+	code, message, err := validatePlay(ctx, &action)
+	if err != nil {
+		errorf("Error validating action from %s: %v", action.User.SessionId, err)
+		sendErrorMessage(conn, errCodeValidationFailed, "Could not validate the played card")
+		return
+	}
+	if code != "" {
+		warnf("Rejected action from %s: %s", action.User.SessionId, code)
+		sendErrorMessage(conn, code, message)
 		return
 	}
-	log.Printf("Received action from: %s and game_id: %s", action.User.SessionId, action.User.GameId)
 
 	mu.Lock()
 	userTurned := false
@@ -140,6 +415,7 @@ func handleAction(conn *websocket.Conn, data []byte) {
 							userTurned = true
 						} else {
 							user.Turn = true
+							user.markActive()
 						}
 						break
 					}
@@ -149,55 +425,92 @@ func handleAction(conn *websocket.Conn, data []byte) {
 	}
 	mu.Unlock()
 
-	log.Printf("Current clients: %+v", clients)
+	debugf("Current clients: %+v", clients)
 
 	if userTurned {
-		log.Printf("User already turned")
+		warnf("User already turned")
+		sendErrorMessage(conn, errCodeAlreadyPlayed, "You already played a card this round")
 		return
 	}
 
+	logEvent("action", action.User, game.ClassTypes_PROTO_TYPE_ACTION, &action)
 	if err := SendActionToGameClients(&action, conn); err != nil {
-		log.Printf("Failed to send action to game clients: %v", err)
+		errorf("Failed to send action to game clients: %v", err)
 	}
 
-	usersMoved := clientsMoved(string(action.User.GameId))
-	users := ClientsInGame(string(action.User.GameId))
-	log.Printf("users_moved: %d", usersMoved)
-	log.Printf("users: %d", users)
-	if usersMoved == users {
-		SendDeleteMessage(string(action.User.GameId))
-		mu.Lock()
-		for _, rooms := range clients {
-			for _, room := range rooms {
-				if room.GameID == string(action.User.GameId) {
-					for _, user := range room.Users {
-						room.started = false
-						user.Voted = false
-					}
+	// Shared first, so whichever instance takes the last card sees every
+	// player has played.
+	shareGame(string(action.User.GameId))
+	tally := tallyRound(string(action.User.GameId))
+	debugf("users_moved: %d", tally.moved)
+	debugf("users: %d", tally.inGame)
+	if tally.moved == tally.inGame {
+		finishPlayPhase(string(action.User.GameId))
+	}
+}
+
+// finishPlayPhase clears the table once every player has played and opens
+// voting, on every instance but only once.
+func finishPlayPhase(gameID string) {
+	mu.Lock()
+	started := roundStartedLocked(gameID)
+	mu.Unlock()
+	if !started || !claimRound(gameID, roundVote, phaseClaimTTL) {
+		return
+	}
+
+	deadline := nextTurnDeadline(gameID)
+	announceRound(roundEvent{GameID: gameID, Kind: roundVote, Deadline: unixMilli(deadline)})
+}
+
+// openVotingHere clears the table for this instance's players and opens
+// voting. Votes are reset before the table is cleared so nobody can vote
+// against the previous round's flags.
+func openVotingHere(gameID string, deadline time.Time) {
+	mu.Lock()
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			if room.GameID == gameID {
+				room.started = false
+				for _, user := range room.Users {
+					user.Voted = false
 				}
 			}
 		}
-		mu.Unlock()
 	}
+	mu.Unlock()
+
+	armTurnTimer(gameID, deadline)
+	SendDeleteMessage(gameID, deadline)
+	shareGame(gameID)
 }
 
 func handleStatus(conn *websocket.Conn, data []byte) {
 	var status game.Ready
 	if err := proto.Unmarshal(data, &status); err != nil {
-		log.Printf("Error unmarshaling Ready: %v", err)
+		errorf("Error unmarshaling Ready: %v", err)
 		return
 	}
-	log.Printf("Received status: %+v", status)
+	debugf("Received status: %+v", &status)
 
+	// Counting the game refreshes how many seats other instances hold.
+	tallyRound(string(status.User.GameId))
+	roomFull := false
 	mu.Lock()
 	for _, rooms := range clients {
 		for _, room := range rooms {
 			if room.GameID == string(status.User.GameId) && !room.started {
 				for _, user := range room.Users {
 					if user.SessionID == string(status.User.SessionId) {
+						if user.Spectator && roomFullLocked(user.GameID) {
+							roomFull = true
+							break
+						}
+						// A spectator pressing ready joins from the next round.
+						user.Spectator = false
 						user.Ready = !user.Ready
 						status.Status = user.Ready
-						log.Printf("Updated user ready status: %+v", user)
+						debugf("Updated user ready status: %+v", user)
 						break
 					}
 				}
@@ -205,58 +518,81 @@ func handleStatus(conn *websocket.Conn, data []byte) {
 		}
 	}
 	mu.Unlock()
+	if roomFull {
+		sendErrorMessage(conn, errCodeRoomFull, "All player seats are taken")
+		return
+	}
 
-	users := ClientsInRoom(string(status.User.GameId))
-	readyUsers := clientsReady(string(status.User.GameId))
-	log.Printf("Clients ready in room %s: %d", status.User.GameId, readyUsers)
-	log.Printf("Clients in room %s: %d", status.User.GameId, users)
+	shareGame(string(status.User.GameId))
+	tally := tallyRound(string(status.User.GameId))
+	readyUsers := tally.ready
+	debugf("Clients ready in room %s: %d", status.User.GameId, readyUsers)
+	debugf("Clients in room %s: %d", status.User.GameId, tally.players)
 
+	logEvent("ready", status.User, game.ClassTypes_PROTO_TYPE_STATUS, &status)
 	if err := SendStatusToGameClients(&status, conn); err != nil {
-		log.Printf("Failed to send status to game clients: %v", err)
+		errorf("Failed to send status to game clients: %v", err)
 	}
 
-	if readyUsers == users {
-		text, err := GetText(string(status.User.GameId))
-		if err != nil {
-			log.Printf("Error fetching text for game_id %s: %v", status.User.GameId, err)
-			return
-		}
-		SendStartGameMessage(string(status.User.GameId), text)
-		mu.Lock()
-		for _, rooms := range clients {
-			for _, room := range rooms {
-				if room.GameID == string(status.User.GameId) {
-					for _, user := range room.Users {
-						user.Ready = false
-						user.Turn = false
-						room.started = true
-						status.Status = false
-					}
-				}
-			}
-		}
-		mu.Unlock()
-
-		if err := SendStatusToGameClients(&status, conn); err != nil {
-			log.Printf("Failed to send status to game clients: %v", err)
-		}
+	// The round itself only starts on the host's StartRequest (or when the
+	// ready timeout runs out), so toggling ready can't start and stop it.
+	if readyUsers > 0 {
+		armReadyTimer(string(status.User.GameId))
+	} else {
+		stopReadyTimer(string(status.User.GameId))
 	}
 }
 
-func handleChoose(conn *websocket.Conn, data []byte) {
+func handleChoose(ctx context.Context, conn *websocket.Conn, data []byte) {
 	var choose game.Choose
 	if err := proto.Unmarshal(data, &choose); err != nil {
-		log.Printf("Error unmarshaling Choose: %v", err)
+		errorf("Error unmarshaling Choose: %v", err)
 		return
 	}
-	//log.Printf("Received choose: %+v", choose)
-	log.Printf("Received session_id: %s", string(choose.User.SessionId))
-	log.Printf("Received game_id: %s", string(choose.User.GameId))
-	log.Printf("Received chosen_id: %s", string(choose.ChosenId))
+	//debugf("Received choose: %+v", choose)
+	debugf("Received session_id: %s", string(choose.User.SessionId))
+	debugf("Received game_id: %s", string(choose.User.GameId))
+	debugf("Received chosen_id: %s", string(choose.ChosenId))
+	debugf("Received card_id: %d, custom_card_id: %d", choose.CardId, choose.CustomCardId)
 
+	ownCard, err := choosesOwnCard(ctx, &choose)
+	if err != nil {
+		errorf("Error checking chosen card owner: %v", err)
+		sendErrorMessage(conn, errCodeValidationFailed, "Could not validate the chosen card")
+		return
+	}
+	if ownCard {
+		warnf("User voted for their own card, not sending chosen_id")
+		sendErrorMessage(conn, errCodeOwnCard, "You can't vote for your own card")
+		return
+	}
+
+	// Registered before locking so they run after the unlock.
+	defer shareGame(string(choose.User.GameId))
+	accepted := false
+	defer func() {
+		if !accepted {
+			return
+		}
+		if err := sendChosenID(&choose, conn); err != nil {
+			errorf("Error sending chosen_id: %v", err)
+		} else {
+			debugf("Sending chosen_id to clients")
+		}
+	}()
 	mu.Lock()
 	defer mu.Unlock()
 
+	if getGameStateLocked(string(choose.User.GameId)).paused {
+		sendErrorMessage(conn, errCodePaused, "The game is paused")
+		return
+	}
+	if turnDeadlinePassedLocked(string(choose.User.GameId)) {
+		warnf("Rejected late vote from %s", choose.User.SessionId)
+		sendErrorMessage(conn, errCodeDeadlinePassed, "The time to vote this round is up")
+		return
+	}
+
 	userVoted := false
 	for _, rooms := range clients {
 		for _, room := range rooms {
@@ -267,6 +603,7 @@ func handleChoose(conn *websocket.Conn, data []byte) {
 							userVoted = true
 						} else {
 							user.setVoted(true)
+							user.markActive()
 						}
 						break
 					}
@@ -279,80 +616,149 @@ func handleChoose(conn *websocket.Conn, data []byte) {
 	}
 
 	if userVoted {
-		log.Println("User already voted, not sending chosen_id")
+		warnf("User already voted, not sending chosen_id")
+		sendErrorMessage(conn, errCodeAlreadyVoted, "You already voted this round")
 		return
 	}
 
-	if err := sendChosenID(&choose, conn); err != nil {
-		log.Printf("Error sending chosen_id: %v", err)
-	} else {
-		log.Println("Sending chosen_id to clients")
+	accepted = true
+	// The log names the chosen player by login, as it keeps no sessions.
+	logged := proto.Clone(&choose).(*game.Choose)
+	logged.ChosenId = nil
+	if chosen := findUserLocked(string(choose.User.GameId), string(choose.ChosenId)); chosen != nil {
+		logged.ChosenId = []byte(chosen.Login)
 	}
+	logEvent("vote", choose.User, game.ClassTypes_PROTO_TYPE_CHOOSE, logged)
+	go reportVote(&choose)
 }
 
 func handleGameInfo(data []byte) {
 	var gameInfo game.GameInfo
 	if err := proto.Unmarshal(data, &gameInfo); err != nil {
-		log.Printf("Error unmarshaling GameInfo: %v", err)
+		errorf("Error unmarshaling GameInfo: %v", err)
 		return
 	}
-	log.Println("Received game info: ")
-	log.Printf("Received session_id: %s", gameInfo.User.SessionId)
-	log.Printf("Received destinationId: %s", gameInfo.DestinationId)
-	log.Printf("Received login: %s", gameInfo.User.Login)
+	debugf("Received game info")
+	debugf("Received session_id: %s", gameInfo.User.SessionId)
+	debugf("Received destinationId: %s", gameInfo.DestinationId)
+	debugf("Received login: %s", gameInfo.User.Login)
 
 	if err := sendUpdateInfoToClient(&gameInfo); err != nil {
-		log.Printf("Error sending update info to client: %v", err)
+		errorf("Error sending update info to client: %v", err)
 	}
 }
 
-func handleDisconnect(conn *websocket.Conn, data []byte) {
+func handleDisconnect(ctx context.Context, conn *websocket.Conn, data []byte) {
 	var disconnect game.Disconnect
 	if err := proto.Unmarshal(data, &disconnect); err != nil {
-		log.Printf("Error unmarshaling Disconnect: %v", err)
+		errorf("Error unmarshaling Disconnect: %v", err)
 		return
 	}
-	log.Println("Received disconnect")
+	debugf("Received disconnect")
+
+	debugf("Disconnect user %s", disconnect.User.Login)
+	debugf("session id to disconnect: %s", disconnect.User.SessionId)
 
-	login := string(disconnect.User.Login)
-	sessionID := string(disconnect.User.SessionId)
-	gameID := string(disconnect.User.GameId)
+	logEvent("disconnect", disconnect.User, game.ClassTypes_PROTO_TYPE_DISCONNECT, &disconnect)
+	disconnectPlayer(ctx, conn, disconnect.User, closeLeft, "Left the game")
+}
 
-	log.Printf("Disconnect user %s", login)
-	log.Printf("session id to disconnect: %s", sessionID)
+// disconnectPlayer takes a player out of their game for good: out of the
+// room, with the connection closed with a close frame of kind, and out of
+// the REST service's, then tells the others and moves the round on if it
+// was only waiting for them.
+func disconnectPlayer(ctx context.Context, conn *websocket.Conn, user *game.User, kind closeKind, message string) {
+	login := string(user.Login)
+	sessionID := string(user.SessionId)
+	gameID := string(user.GameId)
 
-	disconnectUser(sessionID)
+	disconnectUser(sessionID, kind, message)
 
-	if err := disconnectUserFromDB(sessionID); err != nil {
-		log.Printf("Error disconnecting user from DB: %v", err)
+	if err := disconnectUserFromDB(ctx, sessionID); err != nil {
+		errorf("Error disconnecting user from DB: %v", err)
 	}
 
 	if err := sendUserDisconnectMessage(login, sessionID, gameID); err != nil {
-		log.Printf("Error sending user disconnect message: %v", err)
+		errorf("Error sending user disconnect message: %v", err)
 	}
 
-	log.Printf("Game id to update: %s", gameID)
+	debugf("Game id to update: %s", gameID)
 	updateGame(gameID, conn)
 }
 
+var (
+	seqMu sync.Mutex
+	// requestSeqs holds the sequence number of the frame each connection
+	// is being handled for, so errors can name the request they answer.
+	requestSeqs = make(map[*websocket.Conn]uint32)
+)
+
+// setRequestSeq records the sequence number of the frame being handled for
+// conn; 0, which clients that don't number their frames send, forgets it.
+func setRequestSeq(conn *websocket.Conn, seq uint32) {
+	seqMu.Lock()
+	defer seqMu.Unlock()
+	if seq == 0 {
+		delete(requestSeqs, conn)
+		return
+	}
+	requestSeqs[conn] = seq
+}
+
+func requestSeq(conn *websocket.Conn) uint32 {
+	seqMu.Lock()
+	defer seqMu.Unlock()
+	return requestSeqs[conn]
+}
+
+// sendErrorMessage tells conn its request failed, with a code clients can
+// act on, text to show and the sequence number of the request.
+func sendErrorMessage(conn *websocket.Conn, code, message string) error {
+	errorMsg := &game.Error{
+		ClassId: game.ClassTypes_PROTO_TYPE_ERROR,
+		Code:    []byte(code),
+		Message: []byte(message),
+		Seq:     requestSeq(conn),
+	}
+
+	data, err := SerializeToString(errorMsg)
+	if err != nil {
+		errorf("Error serializing Error message: %v", err)
+		return err
+	}
+
+	baseMessage := &game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_ERROR,
+		Data:    data,
+	}
+
+	serializedBaseMessage, err := SerializeToString(baseMessage)
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return err
+	}
+
+	return SendMessageToClient(conn, serializedBaseMessage)
+}
+
 func SendMessageToClient(client *websocket.Conn, serializedMessage []byte) error {
 	if client == nil {
 		return fmt.Errorf("client is nil")
 	}
 
-	err := client.WriteMessage(websocket.BinaryMessage, serializedMessage)
+	err := writeFrame(client, serializedMessage)
 	if err != nil {
-		log.Printf("Error sending message to client: %v", err)
+		errorf("Error sending message to client: %v", err)
 		return err
 	}
 
-	log.Println("Message sent to client")
+	debugf("Message sent to client")
 	return nil
 }
 
 func SendStatusToGameClients(status *game.Ready, senderWebSocket *websocket.Conn) error {
 	gameID := string(status.User.GameId)
-	log.Printf("Sending status to game clients for game_id %s", gameID)
+	debugf("Sending status to game clients for game_id %s", gameID)
 
 	baseMessage := &game.BaseMessage{
 		ClassId: game.ClassTypes_PROTO_TYPE_STATUS,
@@ -360,131 +766,132 @@ func SendStatusToGameClients(status *game.Ready, senderWebSocket *websocket.Conn
 
 	statusData, err := SerializeToString(status)
 	if err != nil {
-		log.Printf("Error serializing status: %v", err)
+		errorf("Error serializing status: %v", err)
 		return err
 	}
 	baseMessage.Data = statusData
 
 	serializedBaseMessage, err := SerializeToString(baseMessage)
 	if err != nil {
-		log.Printf("Error serializing BaseMessage: %v", err)
+		errorf("Error serializing BaseMessage: %v", err)
 		return err
 	}
 
 	if err := SendMessageToGameClients(gameID, serializedBaseMessage, senderWebSocket); err != nil {
-		log.Printf("Failed to send message to game clients: %v", err)
+		errorf("Failed to send message to game clients: %v", err)
 		return err
 	}
 
 	return nil
 }
-func SendStartGameMessage(gameID string, text string) error {
-	log.Printf("Sending start game message for game_id %s", gameID)
+func SendStartGameMessage(gameID string, text string, deadline time.Time) error {
+	debugf("Sending start game message for game_id %s", gameID)
 
 	startMessage := &game.Start{
-		GameId: []byte(gameID),
-		Start:  true,
-		Text:   []byte(text),
-	}
-
-	serializedStartMessage, err := SerializeToString(startMessage)
-	if err != nil {
-		log.Printf("Error serializing Start message: %v", err)
-		return err
-	}
-
-	baseMessage := &game.BaseMessage{
-		ClassId: game.ClassTypes_PROTO_TYPE_START,
-		Data:    serializedStartMessage,
+		GameId:   []byte(gameID),
+		Start:    true,
+		Text:     []byte(text),
+		Deadline: unixMilli(deadline),
 	}
-
-	serializedBaseMessage, err := SerializeToString(baseMessage)
-	if err != nil {
-		log.Printf("Error serializing BaseMessage: %v", err)
-		return err
+	if info, err := fetchRoomInfo(context.Background(), gameID); err == nil {
+		startMessage.RoomName = []byte(info.Name)
+	} else {
+		errorf("Error fetching room name for game_id %s: %v", gameID, err)
 	}
 
-	if err := SendStartGameMessageAndMark(gameID, serializedBaseMessage, nil); err != nil {
-		log.Printf("Failed to send start game message: %v", err)
+	if err := SendStartGameMessageAndMark(gameID, startMessage, nil); err != nil {
+		errorf("Failed to send start game message: %v", err)
 		return err
 	}
 
 	return nil
 }
 
-func SendStartGameMessageAndMark(gameID string, serializedMessage []byte, senderWebSocket *websocket.Conn) error {
-	log.Printf("Sending message to game clients for game_id %s", gameID)
-
-	var wg sync.WaitGroup
+// SendStartGameMessageAndMark marks the game's players as in game and sends
+// each connection the Start message, carrying a rejoin token for its player.
+func SendStartGameMessageAndMark(gameID string, startMessage *game.Start, senderWebSocket *websocket.Conn) error {
+	debugf("Sending message to game clients for game_id %s", gameID)
 
 	mu.Lock()
 	defer mu.Unlock()
 
+	// Observers get the Start without anyone's rejoin token.
+	observed := proto.Clone(startMessage).(*game.Start)
+	observed.ServerTime = time.Now().UnixMilli()
+	if data, err := SerializeToString(observed); err == nil {
+		if frame, err := SerializeToString(&game.BaseMessage{ClassId: game.ClassTypes_PROTO_TYPE_START, Data: data}); err == nil {
+			sendToObserversLocked(gameID, frame)
+		}
+	}
+
 	for clientConn, rooms := range clients {
 		for _, room := range rooms {
 			if room.GameID == gameID {
+				message := proto.Clone(startMessage).(*game.Start)
+				message.ServerTime = time.Now().UnixMilli()
 				for _, user := range room.Users {
-					log.Printf("Preparing to send message to client: session_id=%s, login=%s", user.SessionID, user.Login)
-					user.setInGame(true)
+					debugf("Preparing to send message to client: session_id=%s, login=%s", user.SessionID, user.Login)
+					if !user.Spectator {
+						user.setInGame(true)
+						message.RejoinToken = []byte(issueRejoinTokenLocked(room, user))
+					}
 				}
 
-				wg.Add(1)
-				go func(client *websocket.Conn) {
-					defer wg.Done()
-					if err := SendMessageToClient(client, serializedMessage); err != nil {
-						log.Printf("Error sending message to client: %v", err)
-					}
-				}(clientConn)
+				serializedStartMessage, err := SerializeToString(message)
+				if err != nil {
+					errorf("Error serializing Start message: %v", err)
+					return err
+				}
+				serializedMessage, err := SerializeToString(&game.BaseMessage{
+					ClassId: game.ClassTypes_PROTO_TYPE_START,
+					Data:    serializedStartMessage,
+				})
+				if err != nil {
+					errorf("Error serializing BaseMessage: %v", err)
+					return err
+				}
+
+				if err := SendMessageToClient(clientConn, serializedMessage); err != nil {
+					errorf("Error sending message to client: %v", err)
+				}
 			}
 		}
 	}
 
-	wg.Wait()
-
 	return nil
 }
 
-func SendDeleteMessage(gameID string) error {
-	log.Printf("Sending delete message for game_id %s", gameID)
-	var wg sync.WaitGroup
+// SendDeleteMessage clears the table and opens voting, which runs out at
+// deadline; a zero deadline sends none.
+func SendDeleteMessage(gameID string, deadline time.Time) error {
+	debugf("Sending delete message for game_id %s", gameID)
+
+	serializedMessage, err := SerializeToString(&game.DeleteCards{
+		ClassId:  game.ClassTypes_PROTO_TYPE_DELETE,
+		Deadline: unixMilli(deadline),
+	})
+	if err != nil {
+		errorf("Failed to serialize DeleteCards message: %v", err)
+		return err
+	}
 
+	mu.Lock()
 	for client, rooms := range clients {
 		for _, room := range rooms {
 			if room.GameID == gameID {
-				wg.Add(1)
-				go func(client *websocket.Conn) {
-					defer wg.Done()
-					message := &game.DeleteCards{
-						ClassId: game.ClassTypes_PROTO_TYPE_DELETE,
-					}
-
-					serializedMessage, err := SerializeToString(message)
-					if err != nil {
-						log.Printf("Failed to serialize DeleteCards message: %v", err)
-						return
-					}
-
-					err = client.WriteMessage(websocket.BinaryMessage, serializedMessage)
-					if err != nil {
-						if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-							log.Printf("Connection closed while sending message: %v", err)
-						} else {
-							log.Printf("Error sending message to client: %v", err)
-						}
-						return
-					}
-					log.Println("Delete message sent to client")
-				}(client)
+				if err := writeFrame(client, serializedMessage); err != nil {
+					errorf("Error sending message to client: %v", err)
+				}
 			}
 		}
 	}
-
-	wg.Wait()
+	sendToObserversLocked(gameID, serializedMessage)
+	mu.Unlock()
 	return nil
 }
 
 func SendUpdateMessage(login, sessionID, gameID string, websocket *websocket.Conn) error {
-	log.Printf("Sending update message for game_id %s", gameID)
+	debugf("Sending update message for game_id %s", gameID)
 
 	message := &game.UpdateInfo{
 		User: &game.User{
@@ -513,7 +920,7 @@ func SendUpdateMessage(login, sessionID, gameID string, websocket *websocket.Con
 }
 
 func SendActionToGameClients(action *game.Action, senderWebSocket *websocket.Conn) error {
-	log.Printf("Sending action to game clients for game_id %s", action.User.GameId)
+	debugf("Sending action to game clients for game_id %s", action.User.GameId)
 
 	baseMessage := &game.BaseMessage{
 		ClassId: game.ClassTypes_PROTO_TYPE_ACTION,
@@ -534,21 +941,32 @@ func SendActionToGameClients(action *game.Action, senderWebSocket *websocket.Con
 	return SendMessageToGameClients(string(action.User.GameId), serializedMessage, senderWebSocket)
 }
 
-func sendChatMessage(conn *websocket.Conn, gameID, login, message string) {
+// sendChatMessage broadcasts a chat line and returns how many other
+// connections it was written to.
+func sendChatMessage(conn *websocket.Conn, gameID string, msg *game.ChatMessage, spectatorsOnly, shadow bool) int {
 	chatMsg := &game.ChatMessage{
 		ClassId: game.ClassTypes_PROTO_TYPE_CHATMESSAGE,
 		User: &game.User{
-			Login:     []byte(login),
+			Login:     msg.User.Login,
 			SessionId: []byte(conn.RemoteAddr().String()),
 			GameId:    []byte(gameID),
 		},
-		Message: []byte(message),
+		Message:  msg.Message,
+		Id:       msg.Id,
+		SentAt:   msg.SentAt,
+		Receipts: msg.Receipts,
+		EditedAt: msg.EditedAt,
+		ImageId:  msg.ImageId,
+		ImageUrl: msg.ImageUrl,
+	}
+	if spectatorsOnly {
+		chatMsg.Scope = game.ChatScope_CHAT_SCOPE_SPECTATORS
 	}
 
 	data, err := proto.Marshal(chatMsg)
 	if err != nil {
-		log.Printf("Error marshaling chat message: %v", err)
-		return
+		errorf("Error marshaling chat message: %v", err)
+		return 0
 	}
 
 	baseMsg := &game.BaseMessage{
@@ -558,25 +976,62 @@ func sendChatMessage(conn *websocket.Conn, gameID, login, message string) {
 
 	msgData, err := proto.Marshal(baseMsg)
 	if err != nil {
-		log.Printf("Error marshaling base message: %v", err)
-		return
+		errorf("Error marshaling base message: %v", err)
+		return 0
 	}
 
+	return broadcastChat(conn, gameID, msgData, spectatorsOnly, shadow)
+}
+
+// broadcastChat writes a chat frame to the game and returns how many other
+// connections it reached. Chat goes to every connection with a member in the
+// game whether or not a round has started, and always back to the sender, so
+// lobby chat works no matter when each client's UserInfo was handled. Shadow
+// chat goes back to the sender and to observing admins only, but is counted
+// as if it had reached everyone else, so receipts give nothing away.
+func broadcastChat(conn *websocket.Conn, gameID string, msgData []byte, spectatorsOnly, shadow bool) int {
+	delivered := 0
 	mu.Lock()
+	sendToObserversLocked(gameID, msgData)
 	for clientConn, rooms := range clients {
 		for _, room := range rooms {
-			if room.GameID == gameID {
-				if err := clientConn.WriteMessage(websocket.BinaryMessage, msgData); err != nil {
-					log.Printf("Error writing message to client: %v", err)
-				}
+			if room.GameID != gameID {
+				continue
 			}
+			if clientConn != conn && len(room.Users) == 0 {
+				continue
+			}
+			if spectatorsOnly && clientConn != conn && !roomHasSpectator(room) {
+				continue
+			}
+			if shadow && clientConn != conn {
+				delivered++
+				break
+			}
+			if err := writeFrame(clientConn, msgData); err != nil {
+				errorf("Error writing message to client: %v", err)
+			} else if clientConn != conn {
+				delivered++
+			}
+			break
+		}
+	}
+	if _, ok := clients[conn]; !ok {
+		if err := writeFrame(conn, msgData); err != nil {
+			errorf("Error writing message to client: %v", err)
 		}
 	}
 	mu.Unlock()
+	// Other instances can't tell spectators apart by frame alone, so
+	// spectator-only chat stays on this one.
+	if !spectatorsOnly && !shadow {
+		publishFrame(gameID, msgData)
+	}
+	return delivered
 }
 
 func sendUserDisconnectMessage(login, sessionID, gameID string) error {
-	log.Printf("Sending user disconnect message for game_id %s and session_id %s", gameID, sessionID)
+	debugf("Sending user disconnect message for game_id %s and session_id %s", gameID, sessionID)
 
 	userExitMessage := &game.UserInfo{
 		User: &game.User{
@@ -593,20 +1048,20 @@ func sendUserDisconnectMessage(login, sessionID, gameID string) error {
 
 	serializedData, err := SerializeToString(userExitMessage)
 	if err != nil {
-		log.Printf("Error serializing UserInfo message: %v", err)
+		errorf("Error serializing UserInfo message: %v", err)
 		return err
 	}
 	baseMessage.Data = serializedData
 
 	serializedBaseMessage, err := SerializeToString(baseMessage)
 	if err != nil {
-		log.Printf("Error serializing BaseMessage: %v", err)
+		errorf("Error serializing BaseMessage: %v", err)
 		return err
 	}
 
 	err = SendMessageToGameClients(gameID, serializedBaseMessage, nil)
 	if err != nil {
-		log.Printf("Error sending message to game clients: %v", err)
+		errorf("Error sending message to game clients: %v", err)
 		return err
 	}
 
@@ -614,7 +1069,7 @@ func sendUserDisconnectMessage(login, sessionID, gameID string) error {
 }
 
 func sendUserStatus(sessionID, gameID string, senderWebSocket *websocket.Conn) error {
-	log.Printf("Sending user status message for game_id %s and session_id %s", gameID, sessionID)
+	debugf("Sending user status message for game_id %s and session_id %s", gameID, sessionID)
 	userStatus := &game.Ready{
 		ClassId: game.ClassTypes_PROTO_TYPE_STATUS,
 		User: &game.User{
@@ -629,7 +1084,7 @@ func sendUserStatus(sessionID, gameID string, senderWebSocket *websocket.Conn) e
 }
 
 func sendChosenID(chosenMsg *game.Choose, senderWebSocket *websocket.Conn) error {
-	log.Printf("Sending chosen_id to game clients for game_id %s", string(chosenMsg.User.GameId))
+	debugf("Sending chosen_id to game clients for game_id %s", string(chosenMsg.User.GameId))
 
 	baseMessage := &game.BaseMessage{
 		ClassId: game.ClassTypes_PROTO_TYPE_CHOOSE,
@@ -637,19 +1092,26 @@ func sendChosenID(chosenMsg *game.Choose, senderWebSocket *websocket.Conn) error
 
 	data, err := SerializeToString(chosenMsg)
 	if err != nil {
-		log.Printf("Error serializing chosenMsg: %v", err)
+		errorf("Error serializing chosenMsg: %v", err)
 		return err
 	}
 	baseMessage.Data = data
 
 	serializedBaseMessage, err := SerializeToString(baseMessage)
 	if err != nil {
-		log.Printf("Error serializing BaseMessage: %v", err)
+		errorf("Error serializing BaseMessage: %v", err)
 		return err
 	}
 
+	// A shadow-banned player's vote goes back to them alone, so their client
+	// shows it as usual while nobody else's tally counts it. The REST
+	// service leaves it out of the scores.
+	if shadowBanned(string(chosenMsg.User.SessionId)) {
+		return SendMessageToClient(senderWebSocket, serializedBaseMessage)
+	}
+
 	if err := SendMessageToGameClients(string(chosenMsg.User.GameId), serializedBaseMessage, senderWebSocket); err != nil {
-		log.Printf("Failed to send message to game clients: %v", err)
+		errorf("Failed to send message to game clients: %v", err)
 		return err
 	}
 
@@ -657,7 +1119,7 @@ func sendChosenID(chosenMsg *game.Choose, senderWebSocket *websocket.Conn) error
 }
 
 func sendUpdateInfoToClient(gameInfo *game.GameInfo) error {
-	log.Printf("Sending update info to client for destinationId %s", string(gameInfo.DestinationId))
+	debugf("Sending update info to client for destinationId %s", string(gameInfo.DestinationId))
 
 	// Lock the mutex to safely access the shared clients map
 	mu.Lock()
@@ -674,21 +1136,21 @@ func sendUpdateInfoToClient(gameInfo *game.GameInfo) error {
 					// Serialize gameInfo to bytes
 					data, err := SerializeToString(gameInfo)
 					if err != nil {
-						log.Printf("Error serializing gameInfo: %v", err)
+						errorf("Error serializing gameInfo: %v", err)
 						return err
 					}
 					baseMessage.Data = data
 
 					serializedBaseMessage, err := SerializeToString(baseMessage)
 					if err != nil {
-						log.Printf("Error serializing BaseMessage: %v", err)
+						errorf("Error serializing BaseMessage: %v", err)
 						return err
 					}
 
 					if err := SendMessageToClient(clientConn, serializedBaseMessage); err != nil {
-						log.Printf("Error sending update to client %s: %v", gameInfo.DestinationId, err)
+						errorf("Error sending update to client %s: %v", gameInfo.DestinationId, err)
 					} else {
-						log.Printf("Sent update to client: %s", gameInfo.DestinationId)
+						debugf("Sent update to client: %s", gameInfo.DestinationId)
 					}
 					return nil
 				}
@@ -696,34 +1158,67 @@ func sendUpdateInfoToClient(gameInfo *game.GameInfo) error {
 		}
 	}
 
-	log.Printf("Client with destinationId %s not found", gameInfo.DestinationId)
+	warnf("Client with destinationId %s not found", gameInfo.DestinationId)
 	return nil
 }
+
+// SendMessageToGameClients sends a frame to the game's connections on this
+// instance and publishes it for connections on the others.
 func SendMessageToGameClients(gameID string, serializedMessage []byte, senderWebSocket *websocket.Conn) error {
-	log.Printf("Sending message to game clients for game_id %s", gameID)
-	var wg sync.WaitGroup
+	debugf("Sending message to game clients for game_id %s", gameID)
+	sendToLocalGameClients(gameID, serializedMessage)
+	publishFrame(gameID, serializedMessage)
+	return nil
+}
 
+// sendToLocalGameClients sends a frame to the game's connections on this
+// instance, its observers included. It takes mu only to see who they are, so
+// it must be called without it.
+func sendToLocalGameClients(gameID string, serializedMessage []byte) {
+	mu.Lock()
+	var targets []*websocket.Conn
+	for conn, o := range observers {
+		if o.gameID == gameID {
+			targets = append(targets, conn)
+		}
+	}
 	for client, clientRooms := range clients {
 		for _, room := range clientRooms {
 			if room.GameID == gameID {
-				log.Printf("Preparing to send message to client: game_id=%s", room.GameID)
-				wg.Add(1)
-				go func(client *websocket.Conn, message []byte) {
-					defer wg.Done()
-					if err := SendMessageToClient(client, message); err != nil {
-						log.Printf("Error sending message to client: %v", err)
-					}
-				}(client, serializedMessage)
+				targets = append(targets, client)
 			}
 		}
 	}
+	mu.Unlock()
 
-	wg.Wait()
-	return nil
+	for _, client := range targets {
+		debugf("Preparing to send message to client: game_id=%s", gameID)
+		if err := SendMessageToClient(client, serializedMessage); err != nil {
+			errorf("Error sending message to client: %v", err)
+		}
+	}
+}
+
+// sendToAllLocalClients sends a frame to every connection on this instance
+// that is in a game. It takes mu only to see who they are, so it must be
+// called without it.
+func sendToAllLocalClients(serializedMessage []byte) {
+	mu.Lock()
+	targets := make([]*websocket.Conn, 0, len(clients))
+	for client := range clients {
+		targets = append(targets, client)
+	}
+	mu.Unlock()
+
+	for _, client := range targets {
+		if err := SendMessageToClient(client, serializedMessage); err != nil {
+			errorf("Error sending message to client: %v", err)
+		}
+	}
 }
 
 func SendUserInfoToGameClients(userInfo *game.UserInfo, senderWebSocket interface{}) error {
-	log.Printf("Sending user info to game clients for game_id %s", userInfo.User.GameId)
+	debugf("Sending user info to game clients for game_id %s", userInfo.User.GameId)
 
 	baseMessage := &game.BaseMessage{
 		ClassId: game.ClassTypes_PROTO_TYPE_USERINFO,
@@ -731,7 +1226,7 @@ func SendUserInfoToGameClients(userInfo *game.UserInfo, senderWebSocket interfac
 
 	userInfoData, err := SerializeToString(userInfo)
 	if err != nil {
-		log.Printf("Failed to serialize UserInfo: %v", err)
+		errorf("Failed to serialize UserInfo: %v", err)
 		return err
 	}
 
@@ -744,12 +1239,12 @@ func SendUserInfoToGameClients(userInfo *game.UserInfo, senderWebSocket interfac
 
 	serializedMessage, err := SerializeToString(baseMessage)
 	if err != nil {
-		log.Printf("Failed to serialize BaseMessage: %v", err)
+		errorf("Failed to serialize BaseMessage: %v", err)
 		return err
 	}
 
 	if err := SendMessageToGameClients(string(userInfo.User.GameId), serializedMessage, wsConn); err != nil {
-		log.Printf("Failed to send message to game clients: %v", err)
+		errorf("Failed to send message to game clients: %v", err)
 		return err
 	}
 
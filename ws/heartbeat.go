@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	game "ws_server/proto"
+)
+
+// The server pings every connection at pingInterval, and one that sends
+// nothing, not even the pong, for pongWait is taken to have vanished. Without
+// this a half-open connection never errors, and its players would hold up
+// every check that waits on all of them.
+const (
+	pingInterval = 20 * time.Second
+	pongWait     = 45 * time.Second
+)
+
+// startHeartbeat arms conn's read deadline, pushes it back whenever a pong
+// arrives and starts pinging. The returned function stops the pings.
+func startHeartbeat(conn *websocket.Conn) func() {
+	extendHeartbeat(conn)
+	conn.SetPongHandler(func(string) error {
+		extendHeartbeat(conn)
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// extendHeartbeat gives conn another pongWait to be heard from.
+func extendHeartbeat(conn *websocket.Conn) {
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+}
+
+// heartbeatMissed reports whether a read failed because the connection went
+// quiet for longer than pongWait.
+func heartbeatMissed(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// dropSilentClient disconnects every player on a connection that missed its
+// heartbeat exactly as if each had sent Disconnect, so the rest of the game
+// is told and can go on without them.
+func dropSilentClient(conn *websocket.Conn) {
+	var users []*game.User
+	mu.Lock()
+	for _, room := range clients[conn] {
+		for _, user := range room.Users {
+			users = append(users, &game.User{
+				Login:     []byte(user.Login),
+				SessionId: []byte(user.SessionID),
+				GameId:    []byte(room.GameID),
+			})
+		}
+	}
+	mu.Unlock()
+
+	for _, user := range users {
+		warnf("Session %s missed its heartbeat, disconnecting it from game_id %s", user.SessionId, user.GameId)
+		logEvent("heartbeat_timeout", user, game.ClassTypes_PROTO_TYPE_DISCONNECT, &game.Disconnect{User: user})
+		disconnectPlayer(context.Background(), conn, user, closeTimedOut, "No heartbeat")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// IP_ALLOW and IP_DENY list networks, comma separated, in CIDR form or as
+// single addresses; IP_ALLOW_FILE and IP_DENY_FILE name files listing more,
+// one per line, with # comments. A client on a denied network can't connect,
+// and neither can one outside every allowed network when any are listed.
+// The REST service reads the same variables; an admin reload there reloads
+// both.
+type ipLists struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+var currentIPLists atomic.Pointer[ipLists]
+
+func init() {
+	currentIPLists.Store(&ipLists{})
+}
+
+// permits reports whether ip may connect.
+func (l *ipLists) permits(ip net.IP) bool {
+	for _, network := range l.deny {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	if len(l.allow) == 0 {
+		return true
+	}
+	for _, network := range l.allow {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadIPLists reads the lists from the environment and their files and, if
+// they all parse, puts them in place of the current ones.
+func loadIPLists() (*ipLists, error) {
+	allow, err := readIPList("IP_ALLOW", "IP_ALLOW_FILE")
+	if err != nil {
+		return nil, err
+	}
+	deny, err := readIPList("IP_DENY", "IP_DENY_FILE")
+	if err != nil {
+		return nil, err
+	}
+	lists := &ipLists{allow: allow, deny: deny}
+	currentIPLists.Store(lists)
+	return lists, nil
+}
+
+func readIPList(env, fileEnv string) ([]*net.IPNet, error) {
+	entries := strings.Split(os.Getenv(env), ",")
+	if path := os.Getenv(fileEnv); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			entries = append(entries, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		network, err := parseNetwork(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", env, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// parseNetwork reads a CIDR network, or a single address as a network of
+// one.
+func parseNetwork(entry string) (*net.IPNet, error) {
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		return network, err
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q", entry)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// refuseBlockedIP answers a request from a network the lists don't permit,
+// reporting whether it did.
+func refuseBlockedIP(w http.ResponseWriter, r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || currentIPLists.Load().permits(ip) {
+		return false
+	}
+	debugf("Refused connection from %s", host)
+	http.Error(w, "forbidden", http.StatusForbidden)
+	return true
+}
+
+func registerIPLists(mux *http.ServeMux) {
+	mux.HandleFunc("POST /ip-lists/reload", reloadIPListsHandler)
+}
+
+// reloadIPListsHandler rereads the lists. Lists that don't parse leave the
+// current ones in place. Connections already open are left alone.
+func reloadIPListsHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	lists, err := loadIPLists()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	infof("Reloaded IP lists: %d allowed, %d denied networks", len(lists.allow), len(lists.deny))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"allow": len(lists.allow), "deny": len(lists.deny)})
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"ws_server/client"
+	game "ws_server/proto"
+)
+
+// MaxRoomCapacity is the largest room the REST service allows unless
+// ROOM_CAPACITY_MAX overrides it, which the load test honours too.
+const MaxRoomCapacity = 8
+
+type loadTestConfig struct {
+	Clients  int
+	RoomSize int
+	Rounds   int
+	RestURL  string
+	WSURL    string
+	Timeout  time.Duration
+}
+
+type loadTestStats struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	messages  int
+}
+
+func newLoadTestStats() *loadTestStats {
+	return &loadTestStats{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+	}
+}
+
+func (s *loadTestStats) record(op string, d time.Duration) {
+	s.mu.Lock()
+	s.latencies[op] = append(s.latencies[op], d)
+	s.mu.Unlock()
+}
+
+func (s *loadTestStats) fail(op string, err error) {
+	log.Printf("loadtest: %s failed: %v", op, err)
+	s.mu.Lock()
+	s.errors[op]++
+	s.mu.Unlock()
+}
+
+func (s *loadTestStats) received() {
+	s.mu.Lock()
+	s.messages++
+	s.mu.Unlock()
+}
+
+type loadTestClient struct {
+	*client.Client
+	cfg   *loadTestConfig
+	stats *loadTestStats
+	login string
+}
+
+// timed runs one scripted call and records how long it took under op.
+func (c *loadTestClient) timed(op string, call func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := call(ctx); err != nil {
+		return err
+	}
+	c.stats.record(op, time.Since(start))
+	return nil
+}
+
+// runLoadTest is the loadtest command: it plays whole games against running
+// REST and WebSocket servers and reports latencies. Start the REST server
+// with DATABASE_MODE=memory to keep its players out of the real database.
+func runLoadTest(args []string) {
+	cfg := &loadTestConfig{}
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	fs.IntVar(&cfg.Clients, "clients", 8, "number of simulated players")
+	fs.IntVar(&cfg.RoomSize, "room-size", 4, "players per game")
+	fs.IntVar(&cfg.Rounds, "rounds", 3, "rounds each game plays")
+	fs.StringVar(&cfg.RestURL, "rest", "http://localhost:8080", "REST server base URL")
+	fs.StringVar(&cfg.WSURL, "ws", "ws://localhost:8765", "WebSocket server URL")
+	fs.DurationVar(&cfg.Timeout, "timeout", 30*time.Second, "max wait for any single server response")
+	fs.Parse(args)
+
+	maxRoomSize := MaxRoomCapacity
+	if n, err := strconv.Atoi(os.Getenv("ROOM_CAPACITY_MAX")); err == nil && n >= 2 {
+		maxRoomSize = n
+	}
+	if cfg.RoomSize < 2 || cfg.RoomSize > maxRoomSize {
+		log.Fatalf("room-size must be between 2 and %d", maxRoomSize)
+	}
+
+	stats := newLoadTestStats()
+	started := time.Now()
+
+	var wg sync.WaitGroup
+	for first := 0; first < cfg.Clients; first += cfg.RoomSize {
+		size := cfg.RoomSize
+		if first+size > cfg.Clients {
+			size = cfg.Clients - first
+		}
+		if size < 2 {
+			break
+		}
+		wg.Add(1)
+		go func(first, size int) {
+			defer wg.Done()
+			runLoadTestGame(cfg, stats, first, size)
+		}(first, size)
+	}
+	wg.Wait()
+
+	printLoadTestReport(stats, time.Since(started))
+}
+
+func runLoadTestGame(cfg *loadTestConfig, stats *loadTestStats, first, size int) {
+	players := make([]*loadTestClient, size)
+	for i := range players {
+		players[i] = &loadTestClient{
+			Client: client.New(client.Config{
+				RestURL: cfg.RestURL,
+				WSURL:   cfg.WSURL,
+				Timeout: cfg.Timeout,
+			}),
+			cfg:   cfg,
+			stats: stats,
+			login: fmt.Sprintf("loadtest-%d-%d", time.Now().UnixNano(), first+i),
+		}
+	}
+	defer func() {
+		for _, p := range players {
+			p.close()
+		}
+	}()
+
+	for _, p := range players {
+		if err := p.timed("register", func(ctx context.Context) error {
+			return p.Register(ctx, p.login, p.login+".png", loadTestImage)
+		}); err != nil {
+			stats.fail("register", err)
+			return
+		}
+	}
+
+	var gameID string
+	if err := players[0].timed("host", func(ctx context.Context) (err error) {
+		gameID, err = players[0].Host(ctx, client.HostOptions{Capacity: size})
+		return err
+	}); err != nil {
+		stats.fail("host", err)
+		return
+	}
+	for _, p := range players[1:] {
+		if err := p.timed("connect", func(ctx context.Context) error {
+			_, err := p.Join(ctx, gameID)
+			return err
+		}); err != nil {
+			stats.fail("connect", err)
+			return
+		}
+	}
+
+	for _, p := range players {
+		if err := p.timed("ws_connect", func(ctx context.Context) error {
+			if err := p.Connect(ctx); err != nil {
+				return err
+			}
+			return p.waitFor(game.ClassTypes_PROTO_TYPE_UPDATE)
+		}); err != nil {
+			stats.fail("ws_connect", err)
+			return
+		}
+	}
+
+	for round := 0; round < cfg.Rounds; round++ {
+		if err := playLoadTestRound(players); err != nil {
+			return
+		}
+	}
+}
+
+func playLoadTestRound(players []*loadTestClient) error {
+	stats := players[0].stats
+
+	start := time.Now()
+	for _, p := range players {
+		if err := p.Ready(); err != nil {
+			stats.fail("ready", err)
+			return err
+		}
+	}
+	host := players[0]
+	for range players {
+		if err := host.waitFor(game.ClassTypes_PROTO_TYPE_STATUS); err != nil {
+			stats.fail("ready", err)
+			return err
+		}
+	}
+	if err := host.Start(); err != nil {
+		stats.fail("start", err)
+		return err
+	}
+	for _, p := range players {
+		if err := p.waitFor(game.ClassTypes_PROTO_TYPE_START); err != nil {
+			stats.fail("start", err)
+			return err
+		}
+	}
+	stats.record("ready_to_start", time.Since(start))
+
+	start = time.Now()
+	for _, p := range players {
+		var hand []client.Card
+		if err := p.timed("hand", func(ctx context.Context) (err error) {
+			hand, err = p.Hand(ctx, 1)
+			return err
+		}); err != nil {
+			stats.fail("hand", err)
+			return err
+		}
+		if len(hand) == 0 {
+			err := fmt.Errorf("no cards dealt")
+			stats.fail("hand", err)
+			return err
+		}
+		if err := p.Play(hand[0]); err != nil {
+			stats.fail("action", err)
+			return err
+		}
+	}
+	for _, p := range players {
+		if err := p.waitFor(game.ClassTypes_PROTO_TYPE_DELETE); err != nil {
+			stats.fail("action", err)
+			return err
+		}
+	}
+	stats.record("play", time.Since(start))
+
+	start = time.Now()
+	for i, p := range players {
+		target := players[(i+1)%len(players)]
+		if err := p.Vote(target.SessionID, client.Card{}); err != nil {
+			stats.fail("vote", err)
+			return err
+		}
+	}
+	for _, p := range players {
+		for range players {
+			if err := p.waitFor(game.ClassTypes_PROTO_TYPE_CHOOSE); err != nil {
+				stats.fail("vote", err)
+				return err
+			}
+		}
+	}
+	stats.record("vote", time.Since(start))
+
+	return nil
+}
+
+// waitFor drops frames until one of the given class arrives, so each client
+// only has to care about the broadcasts that mark progress in the round.
+func (c *loadTestClient) waitFor(classID game.ClassTypes) error {
+	timeout := time.After(c.cfg.Timeout)
+	for {
+		select {
+		case msg, ok := <-c.Messages():
+			if !ok {
+				return client.ErrClosed
+			}
+			c.stats.received()
+			if msg.ClassId == classID {
+				return nil
+			}
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for %v", classID)
+		}
+	}
+}
+
+func (c *loadTestClient) close() {
+	c.Close()
+	if c.SessionID != "" {
+		c.timed("exit", c.Exit)
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
+
+func printLoadTestReport(stats *loadTestStats, elapsed time.Duration) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	ops := make([]string, 0, len(stats.latencies))
+	for op := range stats.latencies {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	fmt.Printf("Load test finished in %s, %d frames received (%.1f/s)\n",
+		elapsed.Round(time.Millisecond), stats.messages, float64(stats.messages)/elapsed.Seconds())
+	fmt.Printf("%-16s %8s %8s %10s %10s %10s %10s\n", "operation", "count", "errors", "p50", "p90", "p99", "max")
+	for _, op := range ops {
+		samples := stats.latencies[op]
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		fmt.Printf("%-16s %8d %8d %10s %10s %10s %10s\n", op, len(samples), stats.errors[op],
+			percentile(samples, 0.50).Round(time.Microsecond),
+			percentile(samples, 0.90).Round(time.Microsecond),
+			percentile(samples, 0.99).Round(time.Microsecond),
+			samples[len(samples)-1].Round(time.Microsecond))
+	}
+	for op, n := range stats.errors {
+		if _, ok := stats.latencies[op]; !ok {
+			fmt.Printf("%-16s %8d %8d\n", op, 0, n)
+		}
+	}
+}
+
+var loadTestImage = func() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			img.Set(x, y, color.RGBA{uint8(x * 32), uint8(y * 32), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}()
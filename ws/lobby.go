@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+func handleLobbySettings(ctx context.Context, conn *websocket.Conn, data []byte) {
+	var settings game.LobbySettings
+	if err := proto.Unmarshal(data, &settings); err != nil {
+		errorf("Error unmarshaling LobbySettings: %v", err)
+		return
+	}
+
+	gameID := string(settings.User.GameId)
+	host, err := fetchRoomHost(ctx, gameID)
+	if err != nil {
+		errorf("Error fetching host for game_id %s: %v", gameID, err)
+		sendErrorMessage(conn, errCodeValidationFailed, "Could not verify the room host")
+		return
+	}
+	if host != string(settings.User.SessionId) {
+		sendErrorMessage(conn, errCodeNotHost, "Only the host can change lobby settings")
+		return
+	}
+
+	announceRound(roundEvent{
+		GameID: gameID,
+		Kind:   roundSettings,
+		Settings: &lobbySettings{
+			ReadyTimeout: time.Duration(settings.ReadyTimeout) * time.Second,
+			KickUnready:  settings.KickUnready,
+			MinPlayers:   int(settings.MinPlayers),
+			TurnTimeout:  time.Duration(settings.TurnTimeout) * time.Second,
+		},
+	})
+	debugf("Lobby settings for game_id %s: %+v", gameID, &settings)
+
+	settings.ClassId = game.ClassTypes_PROTO_TYPE_LOBBYSETTINGS
+	serializedData, err := SerializeToString(&settings)
+	if err != nil {
+		errorf("Error serializing LobbySettings: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_LOBBYSETTINGS,
+		Data:    serializedData,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	if err := SendMessageToGameClients(gameID, serializedBaseMessage, conn); err != nil {
+		errorf("Failed to send lobby settings to game clients: %v", err)
+	}
+}
+
+// applyLobbySettings puts the host's lobby settings into effect here.
+func applyLobbySettings(gameID string, settings lobbySettings) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	state := getGameStateLocked(gameID)
+	state.ReadyTimeout = settings.ReadyTimeout
+	state.KickUnready = settings.KickUnready
+	state.MinPlayers = settings.MinPlayers
+	state.TurnTimeout = settings.TurnTimeout
+	if state.ReadyTimeout == 0 && state.readyTimer != nil {
+		state.readyTimer.Stop()
+		state.readyTimer = nil
+	}
+}
+
+// handleRoomCapacity changes the room's player cap. The REST service owns the
+// value and checks the host and bounds; the cached copy here is only updated
+// once it has accepted.
+func handleRoomCapacity(ctx context.Context, conn *websocket.Conn, data []byte) {
+	var capacity game.RoomCapacity
+	if err := proto.Unmarshal(data, &capacity); err != nil {
+		errorf("Error unmarshaling RoomCapacity: %v", err)
+		return
+	}
+
+	gameID := string(capacity.User.GameId)
+	code, message, err := updateRoomCapacity(ctx, gameID, string(capacity.User.SessionId), capacity.Capacity)
+	if err != nil {
+		errorf("Error updating capacity for game_id %s: %v", gameID, err)
+		sendErrorMessage(conn, errCodeValidationFailed, "Could not update the room capacity")
+		return
+	}
+	if code != "" {
+		sendErrorMessage(conn, code, message)
+		return
+	}
+	infof("Capacity for game_id %s set to %d", gameID, capacity.Capacity)
+
+	capacity.ClassId = game.ClassTypes_PROTO_TYPE_ROOMCAPACITY
+	serializedData, err := SerializeToString(&capacity)
+	if err != nil {
+		errorf("Error serializing RoomCapacity: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_ROOMCAPACITY,
+		Data:    serializedData,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	mu.Lock()
+	getGameStateLocked(gameID).Capacity = int(capacity.Capacity)
+	mu.Unlock()
+	if err := SendMessageToGameClients(gameID, serializedBaseMessage, conn); err != nil {
+		errorf("Failed to send room capacity to game clients: %v", err)
+	}
+}
+
+func handleStartRequest(ctx context.Context, conn *websocket.Conn, data []byte) {
+	var request game.StartRequest
+	if err := proto.Unmarshal(data, &request); err != nil {
+		errorf("Error unmarshaling StartRequest: %v", err)
+		return
+	}
+
+	gameID := string(request.User.GameId)
+	host, err := fetchRoomHost(ctx, gameID)
+	if err != nil {
+		errorf("Error fetching host for game_id %s: %v", gameID, err)
+		sendErrorMessage(conn, errCodeValidationFailed, "Could not verify the room host")
+		return
+	}
+	if host != string(request.User.SessionId) {
+		sendErrorMessage(conn, errCodeNotHost, "Only the host can start the game")
+		return
+	}
+
+	mu.Lock()
+	started := roundStartedLocked(gameID) || getGameStateLocked(gameID).countingDown
+	paused := getGameStateLocked(gameID).paused
+	minPlayers := getGameStateLocked(gameID).minPlayers()
+	mu.Unlock()
+	if paused {
+		sendErrorMessage(conn, errCodePaused, "The game is paused")
+		return
+	}
+	if started {
+		sendErrorMessage(conn, errCodeAlreadyStarted, "The round has already started")
+		return
+	}
+
+	readyUsers := tallyRound(gameID).ready
+	if readyUsers < minPlayers {
+		sendErrorMessage(conn, errCodeNotEnoughReady, fmt.Sprintf("%d of %d required players are ready", readyUsers, minPlayers))
+		return
+	}
+
+	// Whoever isn't ready sits this round out.
+	announceRound(roundEvent{GameID: gameID, Kind: roundBench})
+
+	infof("Host %s started game_id %s with %d players", host, gameID, readyUsers)
+	startRound(ctx, gameID, &game.Ready{User: request.User}, conn)
+}
+
+// benchUnready moves this instance's players who aren't ready out of the
+// way, as spectators or by kicking them, and tells the game.
+func benchUnready(gameID string, kick bool) {
+	type unready struct{ login, sessionID string }
+	var idle []unready
+
+	mu.Lock()
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			if room.GameID != gameID {
+				continue
+			}
+			for _, user := range room.Users {
+				if !user.Ready && !user.Spectator {
+					idle = append(idle, unready{user.Login, user.SessionID})
+					if !kick {
+						user.Spectator = true
+					}
+				}
+			}
+		}
+	}
+	mu.Unlock()
+
+	for _, user := range idle {
+		if kick {
+			kickUser(user.login, user.sessionID, gameID)
+			continue
+		}
+		sendSpectatorNotice(user.login, user.sessionID, gameID)
+	}
+	shareGame(gameID)
+}
+
+// armReadyTimer starts the game's ready countdown once somebody is ready,
+// unless the host left the timeout off or it is already running.
+func armReadyTimer(gameID string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	state := getGameStateLocked(gameID)
+	if state.ReadyTimeout == 0 || state.readyTimer != nil {
+		return
+	}
+	infof("Ready timeout for game_id %s started: %v", gameID, state.ReadyTimeout)
+	state.readyTimer = time.AfterFunc(state.ReadyTimeout, func() { readyTimeoutExpired(gameID) })
+}
+
+func stopReadyTimer(gameID string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	state := getGameStateLocked(gameID)
+	if state.readyTimer != nil {
+		state.readyTimer.Stop()
+		state.readyTimer = nil
+	}
+}
+
+// readyTimeoutExpired moves players who still aren't ready out of the way,
+// as spectators or by kicking them, and starts the round if enough players
+// remain.
+func readyTimeoutExpired(gameID string) {
+	mu.Lock()
+	state := getGameStateLocked(gameID)
+	state.readyTimer = nil
+	kick := state.KickUnready
+	minPlayers := state.minPlayers()
+	started := roundStartedLocked(gameID)
+	mu.Unlock()
+	if started {
+		return
+	}
+
+	tally := tallyRound(gameID)
+	infof("Ready timeout for game_id %s expired, %d players not ready", gameID, tally.players-tally.ready)
+	announceRound(roundEvent{GameID: gameID, Kind: roundBench, Kick: kick})
+
+	if tally.ready < minPlayers {
+		infof("Not starting game_id %s: %d ready, %d needed", gameID, tally.ready, minPlayers)
+		return
+	}
+	startRound(context.Background(), gameID, &game.Ready{User: &game.User{GameId: []byte(gameID)}}, nil)
+}
+
+// startRound counts the next round with the REST service and fetches its
+// situation, counts down to it so every client reveals it together, then
+// tells everyone the round has started and resets their ready flags. The
+// countdown runs in the background; a round already counting down, here or
+// on another instance, isn't started again.
+func startRound(ctx context.Context, gameID string, status *game.Ready, conn *websocket.Conn) {
+	mu.Lock()
+	state := getGameStateLocked(gameID)
+	if state.countingDown {
+		mu.Unlock()
+		return
+	}
+	state.countingDown = true
+	mu.Unlock()
+
+	if !claimRound(gameID, roundStart, startClaimTTL) {
+		debugf("Round for game_id %s is being started by another instance", gameID)
+		mu.Lock()
+		getGameStateLocked(gameID).countingDown = false
+		mu.Unlock()
+		return
+	}
+
+	stopReadyTimer(gameID)
+
+	if err := postRoundStart(ctx, gameID); err != nil {
+		errorf("Error starting round for game_id %s: %v", gameID, err)
+	}
+	text, err := GetText(ctx, gameID)
+	if err != nil {
+		errorf("Error fetching text for game_id %s: %v", gameID, err)
+		mu.Lock()
+		getGameStateLocked(gameID).countingDown = false
+		mu.Unlock()
+		return
+	}
+	go func() {
+		countDown(gameID)
+		beginRound(gameID, text, status, conn)
+	}()
+}
+
+// countDown sends a Countdown each second from roundCountdown down to 1,
+// each carrying when the round starts in server time.
+func countDown(gameID string) {
+	startAt := time.Now().Add(roundCountdown * time.Second)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for remaining := roundCountdown; remaining > 0; remaining-- {
+		sendCountdown(gameID, remaining, startAt)
+		<-ticker.C
+	}
+}
+
+func sendCountdown(gameID string, remaining int, startAt time.Time) {
+	data, err := SerializeToString(&game.Countdown{
+		ClassId:   game.ClassTypes_PROTO_TYPE_COUNTDOWN,
+		GameId:    []byte(gameID),
+		Remaining: uint32(remaining),
+		StartAt:   startAt.UnixMilli(),
+	})
+	if err != nil {
+		errorf("Error serializing Countdown: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_COUNTDOWN,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	if err := SendMessageToGameClients(gameID, serializedBaseMessage, nil); err != nil {
+		errorf("Failed to send countdown: %v", err)
+	}
+}
+
+// beginRound is where the countdown ends: the round's Start goes out with
+// the situation text on every instance.
+func beginRound(gameID, text string, status *game.Ready, conn *websocket.Conn) {
+	deadline := nextTurnDeadline(gameID)
+	logEvent("start", &game.User{GameId: []byte(gameID)}, game.ClassTypes_PROTO_TYPE_START, &game.Start{
+		GameId:   []byte(gameID),
+		Start:    true,
+		Text:     []byte(text),
+		Deadline: unixMilli(deadline),
+	})
+	announceRound(roundEvent{GameID: gameID, Kind: roundStart, Text: text, Deadline: unixMilli(deadline)})
+
+	status.Status = false
+	if err := SendStatusToGameClients(status, conn); err != nil {
+		errorf("Failed to send status to game clients: %v", err)
+	}
+}
+
+// startRoundHere starts the round for this instance's players: each is sent
+// the Start, with a rejoin token for players, and has their ready flag reset.
+func startRoundHere(gameID, text string, deadline time.Time) {
+	mu.Lock()
+	state := getGameStateLocked(gameID)
+	state.countingDown = false
+	state.Situation = text
+	state.round = unixMilli(deadline)
+	if state.readyTimer != nil {
+		state.readyTimer.Stop()
+		state.readyTimer = nil
+	}
+	mu.Unlock()
+	armTurnTimer(gameID, deadline)
+	SendStartGameMessage(gameID, text, deadline)
+	mu.Lock()
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			if room.GameID == gameID {
+				room.started = true
+				for _, user := range room.Users {
+					user.Ready = false
+					user.Turn = false
+				}
+			}
+		}
+	}
+	mu.Unlock()
+	shareGame(gameID)
+}
+
+// kickUser drops a player from the game the same way an explicit Disconnect
+// would.
+func kickUser(login, sessionID, gameID string) {
+	infof("Kicking unready user %s from game_id %s", login, gameID)
+
+	disconnectUser(sessionID, closeKicked, "Removed from the game for not getting ready")
+	if err := disconnectUserFromDB(context.Background(), sessionID); err != nil {
+		errorf("Error disconnecting user from DB: %v", err)
+	}
+	if err := sendUserDisconnectMessage(login, sessionID, gameID); err != nil {
+		errorf("Error sending user disconnect message: %v", err)
+	}
+}
+
+func sendSpectatorNotice(login, sessionID, gameID string) {
+	userInfo := &game.UserInfo{
+		ClassId: game.ClassTypes_PROTO_TYPE_USERINFO,
+		User: &game.User{
+			Login:     []byte(login),
+			SessionId: []byte(sessionID),
+			GameId:    []byte(gameID),
+		},
+		Connected: true,
+		Spectator: true,
+	}
+
+	data, err := SerializeToString(userInfo)
+	if err != nil {
+		errorf("Error serializing UserInfo: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_USERINFO,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	if err := SendMessageToGameClients(gameID, serializedBaseMessage, nil); err != nil {
+		errorf("Failed to send spectator notice: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The server logs to stdout unless LOG_FILE names a file, which is rotated
+// to name.1, name.2 and so on once it reaches LOG_MAX_BYTES or has been
+// written to for LOG_MAX_AGE, keeping LOG_FILES old files. The frame log is
+// rotated the same way.
+const (
+	defaultLogMaxBytes = 50 << 20
+	defaultLogMaxAge   = 24 * time.Hour
+	defaultLogFiles    = 7
+)
+
+// rotatingFile is a log file that rotates itself as it is written.
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	maxAge   time.Duration
+	files    int
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// loadLogFiles sends the log to LOG_FILE if it is set.
+func loadLogFiles() {
+	path := os.Getenv("LOG_FILE")
+	if path == "" {
+		return
+	}
+	f, err := openRotatingFile(path, logMaxBytes(), logMaxAge(), logFiles())
+	if err != nil {
+		log.Printf("Ignoring LOG_FILE: %v", err)
+		return
+	}
+	log.SetOutput(f)
+}
+
+func logMaxBytes() int64 {
+	if n, err := strconv.ParseInt(os.Getenv("LOG_MAX_BYTES"), 10, 64); err == nil && n > 0 {
+		return n
+	}
+	return defaultLogMaxBytes
+}
+
+func logMaxAge() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("LOG_MAX_AGE")); err == nil && d >= 0 {
+		return d
+	}
+	return defaultLogMaxAge
+}
+
+func logFiles() int {
+	if n, err := strconv.Atoi(os.Getenv("LOG_FILES")); err == nil && n >= 0 {
+		return n
+	}
+	return defaultLogFiles
+}
+
+// openRotatingFile opens path for appending. maxAge counts from when it was
+// opened, so a file carried over from a previous run gets a full period.
+// A zero maxAge rotates on size alone.
+func openRotatingFile(path string, maxBytes int64, maxAge time.Duration, files int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxBytes: maxBytes, maxAge: maxAge, files: files}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	full := f.size+int64(len(p)) > f.maxBytes
+	old := f.maxAge > 0 && time.Since(f.opened) >= f.maxAge
+	if f.size > 0 && (full || old) {
+		if err := f.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rotating %s: %v\n", f.path, err)
+		}
+	}
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), time.Now()
+	return nil
+}
+
+// rotate shifts the old files up by one, dropping the oldest past the
+// retention limit, and starts a new file.
+func (f *rotatingFile) rotate() error {
+	f.file.Close()
+	f.file = nil
+	os.Remove(fmt.Sprintf("%s.%d", f.path, f.files))
+	for i := f.files - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if f.files > 0 {
+		os.Rename(f.path, f.path+".1")
+	} else {
+		os.Remove(f.path)
+	}
+	return f.open()
+}
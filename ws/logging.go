@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// logLevel is how severe a log message is. Messages below the current level
+// are dropped; LOG_LEVEL sets it at startup and admins can change it while
+// the server runs, to turn on debug output while looking into a problem.
+type logLevel int32
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+var currentLogLevel atomic.Int32
+
+func init() {
+	currentLogLevel.Store(int32(levelInfo))
+}
+
+func (l logLevel) String() string {
+	return logLevelNames[l]
+}
+
+func parseLogLevel(name string) (logLevel, bool) {
+	for i, n := range logLevelNames {
+		if strings.EqualFold(name, n) {
+			return logLevel(i), true
+		}
+	}
+	return 0, false
+}
+
+// loadLogLevel sets the starting level from LOG_LEVEL.
+func loadLogLevel() {
+	name := os.Getenv("LOG_LEVEL")
+	if name == "" {
+		return
+	}
+	level, ok := parseLogLevel(name)
+	if !ok {
+		log.Printf("Ignoring unknown LOG_LEVEL %q", name)
+		return
+	}
+	currentLogLevel.Store(int32(level))
+}
+
+func logAt(level logLevel, format string, args ...interface{}) {
+	if int32(level) < currentLogLevel.Load() {
+		return
+	}
+	log.Output(3, strings.ToUpper(level.String())+" "+fmt.Sprintf(format, args...))
+}
+
+func debugf(format string, args ...interface{}) { logAt(levelDebug, format, args...) }
+func infof(format string, args ...interface{})  { logAt(levelInfo, format, args...) }
+func warnf(format string, args ...interface{})  { logAt(levelWarn, format, args...) }
+func errorf(format string, args ...interface{}) { logAt(levelError, format, args...) }
+
+func registerLogLevel(mux *http.ServeMux) {
+	mux.HandleFunc("GET /log-level", logLevelHandler)
+	mux.HandleFunc("POST /log-level", logLevelHandler)
+}
+
+// logLevelHandler reports the log level, or changes it when a level is
+// posted.
+func logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		var body struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		level, ok := parseLogLevel(body.Level)
+		if !ok {
+			http.Error(w, "unknown log level", http.StatusBadRequest)
+			return
+		}
+		previous := logLevel(currentLogLevel.Swap(int32(level)))
+		log.Printf("Log level changed from %s to %s", previous, level)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"level": logLevel(currentLogLevel.Load()).String()})
+}
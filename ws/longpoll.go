@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The long-poll transport is for clients behind proxies that won't pass
+// WebSockets. Each poll session is tunnelled through an in-process WebSocket
+// connection to the normal handler, so room membership, dispatch and
+// broadcasts treat it like any other client. Frames are the same serialized
+// BaseMessages as on the WebSocket, base64-encoded in JSON:
+//
+//	POST   /poll       opens a session and returns {"session": id}
+//	GET    /poll/{id}  waits up to ?wait= seconds for {"frames": [...]}
+//	POST   /poll/{id}  sends {"frames": [...]}
+//	DELETE /poll/{id}  closes the session
+const (
+	// pollWait is how long a GET waits for frames by default, and
+	// maxPollWait the most a client can ask for.
+	pollWait    = 25 * time.Second
+	maxPollWait = 55 * time.Second
+	// pollIdleTimeout closes a session nobody has polled for this long.
+	pollIdleTimeout = 60 * time.Second
+	// maxPollQueue caps the frames held for a session between polls; a
+	// client that falls further behind is disconnected as a slow WebSocket
+	// reader would be.
+	maxPollQueue = 1000
+)
+
+// pollSession is one long-poll client and the tunnel standing in for its
+// WebSocket.
+type pollSession struct {
+	id      string
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	queue    [][]byte
+	ready    chan struct{}
+	closed   bool
+	lastSeen time.Time
+}
+
+var (
+	pollMu       sync.Mutex
+	pollSessions = map[string]*pollSession{}
+)
+
+// pipeListener hands the server ends of in-process tunnels to an
+// http.Server, so they go through the same handler as real connections.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+var tunnels = &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return tunnelAddr("tunnels")
+}
+
+// tunnelAddr names a tunnel after its poll session, so anything keyed on a
+// connection's remote address tells sessions apart.
+type tunnelAddr string
+
+func (tunnelAddr) Network() string  { return "pipe" }
+func (a tunnelAddr) String() string { return "poll:" + string(a) }
+
+// tunnelConn is the server end of a tunnel.
+type tunnelConn struct {
+	net.Conn
+	id string
+}
+
+func (c tunnelConn) RemoteAddr() net.Addr {
+	return tunnelAddr(c.id)
+}
+
+// dial opens a tunnel for session id and returns its client end.
+func (l *pipeListener) dial(ctx context.Context, id string) (net.Conn, error) {
+	server, client := net.Pipe()
+	select {
+	case l.conns <- tunnelConn{Conn: server, id: id}:
+		return client, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// serveTunnels runs handler for long-poll tunnels.
+func serveTunnels(handler http.Handler) {
+	if err := http.Serve(tunnels, handler); err != nil && !errors.Is(err, net.ErrClosed) {
+		warnf("Tunnel server stopped: %v", err)
+	}
+}
+
+func registerLongPoll(mux *http.ServeMux) {
+	mux.HandleFunc("OPTIONS /poll", pollPreflight)
+	mux.HandleFunc("OPTIONS /poll/{id}", pollPreflight)
+	mux.HandleFunc("POST /poll", openPollSession)
+	mux.HandleFunc("GET /poll/{id}", receivePollFrames)
+	mux.HandleFunc("POST /poll/{id}", sendPollFrames)
+	mux.HandleFunc("DELETE /poll/{id}", closePollSession)
+}
+
+// pollPreflight lets browsers on other origins use the transport, as they
+// can the WebSocket.
+func pollPreflight(w http.ResponseWriter, r *http.Request) {
+	allowPollOrigin(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func allowPollOrigin(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+func writePollJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func pollError(w http.ResponseWriter, status int, message string) {
+	writePollJSON(w, status, map[string]string{"error": message})
+}
+
+func newPollID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func openPollSession(w http.ResponseWriter, r *http.Request) {
+	allowPollOrigin(w)
+	if refuseBlockedIP(w, r) {
+		return
+	}
+
+	id := newPollID()
+	dialer := websocket.Dialer{
+		NetDialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return tunnels.dial(ctx, id)
+		},
+		HandshakeTimeout: 5 * time.Second,
+		Subprotocols:     []string{subprotocolProto},
+	}
+	conn, _, err := dialer.DialContext(r.Context(), "ws://tunnel/", nil)
+	if err != nil {
+		errorf("Error opening poll tunnel: %v", err)
+		pollError(w, http.StatusServiceUnavailable, "tunnel unavailable")
+		return
+	}
+
+	session := &pollSession{
+		id:       id,
+		conn:     conn,
+		ready:    make(chan struct{}),
+		lastSeen: time.Now(),
+	}
+	pollMu.Lock()
+	pollSessions[session.id] = session
+	pollMu.Unlock()
+	go session.readTunnel()
+
+	infof("Poll session %s opened", session.id)
+	writePollJSON(w, http.StatusCreated, map[string]string{"session": session.id})
+}
+
+// findPollSession looks up the session in the path and marks it as seen.
+func findPollSession(w http.ResponseWriter, r *http.Request) *pollSession {
+	pollMu.Lock()
+	session := pollSessions[r.PathValue("id")]
+	pollMu.Unlock()
+	if session == nil {
+		pollError(w, http.StatusNotFound, "unknown session")
+		return nil
+	}
+	session.mu.Lock()
+	session.lastSeen = time.Now()
+	session.mu.Unlock()
+	return session
+}
+
+// readTunnel queues what the server sends the session until the tunnel
+// closes.
+func (s *pollSession) readTunnel() {
+	defer s.close()
+	for {
+		_, frame, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if len(s.queue) >= maxPollQueue {
+			s.mu.Unlock()
+			warnf("Poll session %s fell %d frames behind, closing", s.id, maxPollQueue)
+			return
+		}
+		s.queue = append(s.queue, frame)
+		close(s.ready)
+		s.ready = make(chan struct{})
+		s.mu.Unlock()
+	}
+}
+
+// close ends the session and its tunnel, which the handler sees as the
+// client disconnecting. Waiting polls return what is left.
+func (s *pollSession) close() {
+	pollMu.Lock()
+	delete(pollSessions, s.id)
+	pollMu.Unlock()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.ready)
+	s.mu.Unlock()
+	s.conn.Close()
+	infof("Poll session %s closed", s.id)
+}
+
+// take returns the queued frames, waiting up to wait for the first one.
+func (s *pollSession) take(ctx context.Context, wait time.Duration) ([][]byte, bool) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		if len(s.queue) > 0 || s.closed {
+			frames, open := s.queue, !s.closed
+			s.queue = nil
+			s.lastSeen = time.Now()
+			s.mu.Unlock()
+			return frames, open
+		}
+		ready := s.ready
+		s.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-timer.C:
+			return nil, true
+		case <-ctx.Done():
+			return nil, true
+		}
+	}
+}
+
+func receivePollFrames(w http.ResponseWriter, r *http.Request) {
+	allowPollOrigin(w)
+	session := findPollSession(w, r)
+	if session == nil {
+		return
+	}
+
+	wait := pollWait
+	if value := r.URL.Query().Get("wait"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			pollError(w, http.StatusBadRequest, "invalid wait")
+			return
+		}
+		wait = min(time.Duration(seconds)*time.Second, maxPollWait)
+	}
+
+	frames, open := session.take(r.Context(), wait)
+	if frames == nil {
+		frames = [][]byte{}
+	}
+	writePollJSON(w, http.StatusOK, map[string]interface{}{"frames": frames, "open": open})
+}
+
+func sendPollFrames(w http.ResponseWriter, r *http.Request) {
+	allowPollOrigin(w)
+	session := findPollSession(w, r)
+	if session == nil {
+		return
+	}
+
+	var body struct {
+		Frames [][]byte `json:"frames"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		pollError(w, http.StatusBadRequest, "invalid frames")
+		return
+	}
+	session.writeMu.Lock()
+	defer session.writeMu.Unlock()
+	for _, frame := range body.Frames {
+		if err := session.conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+			pollError(w, http.StatusGone, "session closed")
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func closePollSession(w http.ResponseWriter, r *http.Request) {
+	allowPollOrigin(w)
+	session := findPollSession(w, r)
+	if session == nil {
+		return
+	}
+	session.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// expirePollSessions closes sessions whose client stopped polling.
+func expirePollSessions() {
+	ticker := time.NewTicker(pollIdleTimeout / 4)
+	defer ticker.Stop()
+	for range ticker.C {
+		var idle []*pollSession
+		pollMu.Lock()
+		for _, session := range pollSessions {
+			session.mu.Lock()
+			if time.Since(session.lastSeen) > pollIdleTimeout {
+				idle = append(idle, session)
+			}
+			session.mu.Unlock()
+		}
+		pollMu.Unlock()
+		for _, session := range idle {
+			session.close()
+		}
+	}
+}
@@ -3,27 +3,106 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gorilla/websocket"
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
+	CheckOrigin:  func(r *http.Request) bool { return true },
+	Subprotocols: supportedSubprotocols,
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		runLoadTest(os.Args[2:])
+		return
+	}
+
+	if serviceToken == "" {
+		log.Fatalf("SERVICE_TOKEN must be set to the token the REST service expects")
+	}
+	loadLogLevel()
+	loadLogFiles()
+	if _, err := loadIPLists(); err != nil {
+		log.Fatalf("Failed to load the IP lists: %v", err)
+	}
+	go runEventLogger()
+	startFrameLog()
+	startWriteWorkers()
+	go handleShutdownSignals()
+	roomStore = newRoomStore()
+	go roomStore.Subscribe(deliverRemoteFrame)
+	go roomStore.SubscribeRounds(applyRemoteRound)
+	go runPublisher()
+	loadRegistry()
+	go runRegistrySync()
+	go runFeatureRefresh()
+	go runShadowBanRefresh()
+
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
-		if err != nil {
-			log.Printf("Error while upgrading connection: %v", err)
+		conn, ok := upgradeClient(w, r)
+		if !ok {
 			return
 		}
 		handleClient(conn)
 	})
+	registerLongPoll(http.DefaultServeMux)
+
+	// With WS_ADMIN_LISTEN set, the admin endpoints are only served there,
+	// typically on loopback, and not on the public addresses.
+	adminMux := http.DefaultServeMux
+	adminAddr := os.Getenv("WS_ADMIN_LISTEN")
+	if adminAddr != "" {
+		adminMux = http.NewServeMux()
+	}
+	registerRoomClose(adminMux)
+	registerAnnouncements(adminMux)
+	registerObservers(adminMux)
+	registerLogLevel(adminMux)
+	registerIPLists(adminMux)
+	go serveTunnels(http.DefaultServeMux)
+	go expirePollSessions()
 
-	serverAddr := "localhost:8765"
-	log.Printf("WebSocket server started on ws://%s", serverAddr)
-	if err := http.ListenAndServe(serverAddr, nil); err != nil {
+	addrs := []string{"localhost:8765"}
+	if listen := splitAddrs(os.Getenv("WS_LISTEN")); len(listen) > 0 {
+		addrs = listen
+	}
+	if adminMux != http.DefaultServeMux {
+		infof("Admin endpoints listening on %s", adminAddr)
+		go func() {
+			if err := serve(adminMux, []string{adminAddr}); err != nil {
+				log.Fatalf("Error starting admin server: %v", err)
+			}
+		}()
+	}
+	infof("WebSocket server listening on %s", strings.Join(addrs, ", "))
+	if err := serve(http.DefaultServeMux, addrs); err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
 }
+
+// upgradeClient upgrades r to a WebSocket speaking one of the subprotocols
+// the client offered, with a write pump, answering the request itself when
+// that fails, the server is shutting down or the client's network is
+// blocked.
+func upgradeClient(w http.ResponseWriter, r *http.Request) (*websocket.Conn, bool) {
+	if refuseWhileDraining(w) || refuseBlockedIP(w, r) {
+		return nil, false
+	}
+	offered := offeredSubprotocols(r)
+	if !acceptableSubprotocols(r, offered) {
+		rejectSubprotocols(w, offered)
+		return nil, false
+	}
+	upgrader := upgrader
+	upgrader.Subprotocols = offered
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		errorf("Error while upgrading connection: %v", err)
+		return nil, false
+	}
+	attachWritePump(conn)
+	return conn, true
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
+
+	game "ws_server/proto"
 )
 
 type User struct {
@@ -14,6 +18,10 @@ type User struct {
 	Turn      bool
 	Voted     bool
 	InGame    bool
+	Spectator bool
+
+	LastActive  time.Time
+	missedTurns int
 }
 
 type Room struct {
@@ -22,10 +30,160 @@ type Room struct {
 	Users   []*User
 }
 
+const (
+	errCodeValidationFailed = "validation_failed"
+	errCodeOwnCard          = "own_card"
+	errCodeNotHost          = "not_host"
+	errCodeAlreadyStarted   = "already_started"
+	errCodeNotEnoughReady   = "not_enough_ready"
+	errCodeUnknownMessage   = "unknown_message"
+	errCodeNotAuthor        = "not_author"
+	errCodeEditWindow       = "edit_window_closed"
+	errCodeInvalidImage     = "invalid_image"
+	errCodeRoomFull         = "room_full"
+	errCodeInvalidRejoin    = "invalid_rejoin_token"
+	errCodeDeadlinePassed   = "deadline_passed"
+	errCodePaused           = "paused"
+	errCodeAlreadyPlayed    = "already_played"
+	errCodeAlreadyVoted     = "already_voted"
+	errCodeMalformedFrame   = "malformed_frame"
+	errCodeInvalidMessage   = "invalid_message"
+)
+
+const (
+	// maxTrackedChats bounds how many recent chat messages per game can
+	// still be edited, deleted or have read receipts routed back.
+	maxTrackedChats = 200
+	// chatEditWindow is how long after sending a message its author can
+	// edit or delete it.
+	chatEditWindow = 5 * time.Minute
+)
+
+// maxMalformedFrames is how many frames that can't be decoded a connection
+// may send before it is dropped.
+const maxMalformedFrames = 5
+
+// defaultMinPlayers is how many ready players a round needs when the host
+// hasn't set a minimum.
+const defaultMinPlayers = 2
+
+const (
+	// defaultTurnTimeout is how long a play or vote phase waits for
+	// stragglers when the host hasn't set a turn timer.
+	defaultTurnTimeout = 90 * time.Second
+	// afkMissedTurns is how many turn timers in a row a player can let run
+	// out before they are skipped.
+	afkMissedTurns = 2
+	// deadlineGrace is how late after a phase's deadline a play or vote is
+	// still taken, allowing for the trip from a client that beat it.
+	deadlineGrace = 500 * time.Millisecond
+	// roundCountdown is how many one-second ticks are counted down before a
+	// round's Start goes out.
+	roundCountdown = 3
+	// reconnectPauseLimit is how long a game stays paused for players who
+	// dropped mid-round before it goes on without them.
+	reconnectPauseLimit = 2 * time.Minute
+)
+
+// GameState holds per-game settings that don't belong to any single
+// connection's Room.
+type GameState struct {
+	CrossTalk    bool
+	ReadyTimeout time.Duration
+	KickUnready  bool
+	MinPlayers   int
+	TurnTimeout  time.Duration
+	// Capacity is the room's player cap as last read from the REST service,
+	// or 0 if it hasn't been read yet.
+	Capacity int
+	// Situation is the current round's prompt, kept for state syncs.
+	Situation string
+
+	readyTimer *time.Timer
+	turnTimer  *time.Timer
+	// turnDeadline is when the current play or vote phase runs out, as
+	// broadcast to clients.
+	turnDeadline time.Time
+	// countingDown is set while a round's countdown runs, so it can't be
+	// started twice.
+	countingDown bool
+	// round identifies the current round on every instance: its Start's
+	// deadline in Unix milliseconds, or 0 before the first.
+	round int64
+	// otherPlayers and otherInGame are how many players the other instances
+	// held in the game, and how many of them were dealt into a round, when
+	// it was last counted.
+	otherPlayers int
+	otherInGame  int
+
+	// paused is set while the host or a reconnecting player holds the game.
+	// Its timers are stopped then; turnFrozen and readyFrozen record which
+	// ones to restart on resume, with the turn deadline pushed back by how
+	// long the game was paused since pausedAt.
+	paused      bool
+	hostPaused  bool
+	pausedAt    time.Time
+	turnFrozen  bool
+	readyFrozen bool
+	// reconnecting holds the sessions the game is paused for, until they
+	// rejoin or pauseTimer gives up on them.
+	reconnecting map[string]bool
+	pauseTimer   *time.Timer
+
+	// chats holds recent chat messages by ID, oldest first in chatOrder.
+	chats     map[uint64]*chatRecord
+	chatOrder []uint64
+}
+
+func (s *GameState) minPlayers() int {
+	if s.MinPlayers > 0 {
+		return s.MinPlayers
+	}
+	return defaultMinPlayers
+}
+
+func (s *GameState) turnTimeout() time.Duration {
+	if s.TurnTimeout > 0 {
+		return s.TurnTimeout
+	}
+	return defaultTurnTimeout
+}
+
+// chatRecord is a chat message the server still remembers, with the
+// connection that sent it and the audience it went to.
+type chatRecord struct {
+	conn           *websocket.Conn
+	msg            *game.ChatMessage
+	spectatorsOnly bool
+	// shadow is set when the author was shadow-banned, so the message
+	// and any change to it only went back to them.
+	shadow  bool
+	deleted bool
+}
+
+// rejoinTokenTTL is how long the rejoin token handed out with a round's Start
+// message stays valid.
+const rejoinTokenTTL = 10 * time.Minute
+
+// rejoinGrant binds a rejoin token to the player and the room they were in,
+// so a client that lost its connection can take both back as they were.
+type rejoinGrant struct {
+	room    *Room
+	user    *User
+	expires time.Time
+}
+
 type TextResponse struct {
 	Text string `json:"text"`
 }
 
+// markActive records that the user took their turn, clearing any missed
+// turns counted against them.
+func (u *User) markActive() {
+	u.LastActive = time.Now()
+	u.missedTurns = 0
+}
+
 func (u *User) setVoted(status bool) {
 	u.Voted = status
 }
@@ -35,6 +193,17 @@ func (u *User) setInGame(status bool) {
 }
 
 var (
-	mu      sync.Mutex
-	clients = make(map[*websocket.Conn][]*Room)
+	mu         sync.Mutex
+	clients    = make(map[*websocket.Conn][]*Room)
+	gameStates = make(map[string]*GameState)
+	// rejoinGrants maps rejoin tokens to what they restore.
+	rejoinGrants = make(map[string]*rejoinGrant)
 )
+
+// chatSeq hands out chat message IDs. It is seeded from the start time so
+// IDs stay unique across restarts for history that outlives the process.
+var chatSeq = uint64(time.Now().UnixMicro())
+
+func nextChatID() uint64 {
+	return atomic.AddUint64(&chatSeq, 1)
+}
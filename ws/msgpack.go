@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// A minimal MessagePack encoder and decoder covering the types frames are
+// built from: nil, bool, int64, uint64, float64, string, []byte,
+// []interface{} and map[string]interface{}. Decoded integers come back as
+// int64, or uint64 when they don't fit.
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+func appendMsgpack(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case int:
+		return appendMsgpackInt(buf, int64(v)), nil
+	case int64:
+		return appendMsgpackInt(buf, v), nil
+	case uint64:
+		return appendMsgpackUint(buf, v), nil
+	case float64:
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(v)), nil
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			buf = append(buf, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			buf = append(buf, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+		default:
+			buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+		}
+		return append(buf, v...), nil
+	case []byte:
+		n := len(v)
+		switch {
+		case n <= math.MaxUint8:
+			buf = append(buf, 0xc4, byte(n))
+		case n <= math.MaxUint16:
+			buf = binary.BigEndian.AppendUint16(append(buf, 0xc5), uint16(n))
+		default:
+			buf = binary.BigEndian.AppendUint32(append(buf, 0xc6), uint32(n))
+		}
+		return append(buf, v...), nil
+	case []interface{}:
+		buf = appendMsgpackHeader(buf, len(v), 0x90, 0xdc, 0xdd)
+		var err error
+		for _, item := range v {
+			if buf, err = appendMsgpack(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf = appendMsgpackHeader(buf, len(v), 0x80, 0xde, 0xdf)
+		var err error
+		for _, key := range keys {
+			if buf, err = appendMsgpack(buf, key); err != nil {
+				return nil, err
+			}
+			if buf, err = appendMsgpack(buf, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("msgpack: can't encode %T", v)
+}
+
+func appendMsgpackHeader(buf []byte, n int, fix, b16, b32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, b16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, b32), uint32(n))
+	}
+}
+
+func appendMsgpackInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(buf, uint64(v))
+	case v >= -32:
+		return append(buf, byte(v))
+	case v >= math.MinInt8:
+		return append(buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+}
+
+func appendMsgpackUint(buf []byte, v uint64) []byte {
+	switch {
+	case v < 128:
+		return append(buf, byte(v))
+	case v <= math.MaxUint8:
+		return append(buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xcf), v)
+}
+
+// msgpackReader decodes one value at a time from data.
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func decodeMsgpack(data []byte) (interface{}, error) {
+	r := &msgpackReader{data: data}
+	v, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(data) {
+		return nil, errors.New("msgpack: trailing data")
+	}
+	return v, nil
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, errMsgpackShort
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgpackReader) uint(size int) (uint64, error) {
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (r *msgpackReader) value() (interface{}, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	t := b[0]
+	switch {
+	case t < 0x80:
+		return int64(t), nil
+	case t >= 0xe0:
+		return int64(int8(t)), nil
+	case t&0xf0 == 0x80:
+		return r.mapOf(int(t & 0x0f))
+	case t&0xf0 == 0x90:
+		return r.arrayOf(int(t & 0x0f))
+	case t&0xe0 == 0xa0:
+		return r.str(int(t & 0x1f))
+	}
+
+	switch t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (t - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		raw, err := r.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), raw...), nil
+	case 0xca:
+		n, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := r.uint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := r.uint(1 << (t - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 0xd0:
+		n, err := r.uint(1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := r.uint(2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := r.uint(4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := r.uint(8)
+		return int64(n), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (t - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (t - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.arrayOf(int(n))
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (t - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.mapOf(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", t)
+}
+
+func (r *msgpackReader) str(n int) (string, error) {
+	b, err := r.next(n)
+	return string(b), err
+}
+
+func (r *msgpackReader) arrayOf(n int) ([]interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, errMsgpackShort
+	}
+	items := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		item, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *msgpackReader) mapOf(n int) (map[string]interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, errMsgpackShort
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key is %T, not a string", key)
+		}
+		if m[name], err = r.value(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
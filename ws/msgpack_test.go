@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// Encodings from the MessagePack specification's format table.
+var msgpackVectors = []struct {
+	value interface{}
+	wire  []byte
+}{
+	{nil, []byte{0xc0}},
+	{false, []byte{0xc2}},
+	{true, []byte{0xc3}},
+	{int64(0), []byte{0x00}},
+	{int64(127), []byte{0x7f}},
+	{int64(128), []byte{0xcc, 0x80}},
+	{int64(255), []byte{0xcc, 0xff}},
+	{int64(256), []byte{0xcd, 0x01, 0x00}},
+	{int64(65536), []byte{0xce, 0x00, 0x01, 0x00, 0x00}},
+	{int64(1 << 32), []byte{0xcf, 0, 0, 0, 0x01, 0, 0, 0, 0}},
+	{uint64(math.MaxUint64), []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	{int64(-1), []byte{0xff}},
+	{int64(-32), []byte{0xe0}},
+	{int64(-33), []byte{0xd0, 0xdf}},
+	{int64(-129), []byte{0xd1, 0xff, 0x7f}},
+	{int64(-32769), []byte{0xd2, 0xff, 0xff, 0x7f, 0xff}},
+	{int64(math.MinInt64), []byte{0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+	{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+	{"", []byte{0xa0}},
+	{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+	{strings.Repeat("x", 32), append([]byte{0xd9, 32}, strings.Repeat("x", 32)...)},
+	{strings.Repeat("x", 256), append([]byte{0xda, 0x01, 0x00}, strings.Repeat("x", 256)...)},
+	{[]byte{1, 2}, []byte{0xc4, 0x02, 1, 2}},
+	{[]interface{}{}, []byte{0x90}},
+	{[]interface{}{int64(1), "a"}, []byte{0x92, 0x01, 0xa1, 'a'}},
+	{make([]interface{}, 16), append([]byte{0xdc, 0x00, 0x10}, bytes.Repeat([]byte{0xc0}, 16)...)},
+	{map[string]interface{}{}, []byte{0x80}},
+	{map[string]interface{}{"b": int64(2), "a": int64(1)}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+}
+
+func TestMsgpackEncode(t *testing.T) {
+	for _, tt := range msgpackVectors {
+		got, err := appendMsgpack(nil, tt.value)
+		if err != nil {
+			t.Errorf("appendMsgpack(%#v): %v", tt.value, err)
+			continue
+		}
+		if !bytes.Equal(got, tt.wire) {
+			t.Errorf("appendMsgpack(%#v) = % x, want % x", tt.value, got, tt.wire)
+		}
+	}
+	if _, err := appendMsgpack(nil, struct{}{}); err == nil {
+		t.Error("appendMsgpack(struct{}{}) succeeded")
+	}
+}
+
+func TestMsgpackDecode(t *testing.T) {
+	for _, tt := range msgpackVectors {
+		got, err := decodeMsgpack(tt.wire)
+		if err != nil {
+			t.Errorf("decodeMsgpack(% x): %v", tt.wire, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.value) {
+			t.Errorf("decodeMsgpack(% x) = %#v, want %#v", tt.wire, got, tt.value)
+		}
+	}
+
+	// Forms the encoder never writes but clients may.
+	others := []struct {
+		wire  []byte
+		value interface{}
+	}{
+		{[]byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, 1.5},
+		{[]byte{0xdb, 0, 0, 0, 0x01, 'a'}, "a"},
+		{[]byte{0xc5, 0x00, 0x01, 0x07}, []byte{7}},
+		{[]byte{0xd0, 0x05}, int64(5)},
+		{[]byte{0xdd, 0, 0, 0, 0x01, 0xc3}, []interface{}{true}},
+		{[]byte{0xde, 0x00, 0x01, 0xa1, 'k', 0xc0}, map[string]interface{}{"k": nil}},
+	}
+	for _, tt := range others {
+		got, err := decodeMsgpack(tt.wire)
+		if err != nil {
+			t.Errorf("decodeMsgpack(% x): %v", tt.wire, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.value) {
+			t.Errorf("decodeMsgpack(% x) = %#v, want %#v", tt.wire, got, tt.value)
+		}
+	}
+}
+
+func TestMsgpackDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		wire []byte
+	}{
+		{"empty", nil},
+		{"short uint16", []byte{0xcd, 0x01}},
+		{"short string", []byte{0xa3, 'a'}},
+		{"short array", []byte{0x92, 0x01}},
+		{"huge array", []byte{0xdd, 0xff, 0xff, 0xff, 0xff}},
+		{"trailing data", []byte{0xc0, 0xc0}},
+		{"integer key", []byte{0x81, 0x01, 0x02}},
+		{"extension", []byte{0xd4, 0x01, 0x00}},
+	}
+	for _, tt := range tests {
+		if v, err := decodeMsgpack(tt.wire); err == nil {
+			t.Errorf("%s: decodeMsgpack(% x) = %#v, want an error", tt.name, tt.wire, v)
+		}
+	}
+	if _, err := decodeMsgpack([]byte{0xa3, 'a'}); !errors.Is(err, errMsgpackShort) {
+		t.Errorf("short string: err = %v, want errMsgpackShort", err)
+	}
+}
+
+func TestMsgpackCodec(t *testing.T) {
+	data, err := proto.Marshal(&game.Pause{
+		ClassId: game.ClassTypes_PROTO_TYPE_PAUSE,
+		Paused:  true,
+		Reason:  []byte("afk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame, err := proto.Marshal(&game.BaseMessage{ClassId: game.ClassTypes_PROTO_TYPE_PAUSE, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wire, err := msgpackCodec{}.encode(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x82, 0xa7}
+	want = append(want, "classId"...)
+	want = append(want, 0x1e, 0xa4)
+	want = append(want, "data"...)
+	want = append(want, 0x83, 0xa7)
+	want = append(want, "classId"...)
+	want = append(want, 0x1e, 0xa6)
+	want = append(want, "paused"...)
+	want = append(want, 0xc3, 0xa6)
+	want = append(want, "reason"...)
+	want = append(want, 0xa3)
+	want = append(want, "afk"...)
+	if !bytes.Equal(wire, want) {
+		t.Fatalf("encode = % x, want % x", wire, want)
+	}
+
+	baseMsg, err := msgpackCodec{}.decode(wire)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseMsg.ClassId != game.ClassTypes_PROTO_TYPE_PAUSE {
+		t.Errorf("decoded class %v, want PROTO_TYPE_PAUSE", baseMsg.ClassId)
+	}
+	var pause game.Pause
+	if err := proto.Unmarshal(baseMsg.Data, &pause); err != nil {
+		t.Fatal(err)
+	}
+	if !pause.Paused || string(pause.Reason) != "afk" || pause.ClassId != game.ClassTypes_PROTO_TYPE_PAUSE {
+		t.Errorf("decoded %v", &pause)
+	}
+}
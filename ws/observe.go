@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	game "ws_server/proto"
+)
+
+// observeTicketTTL is how long an admin has to open the observer connection
+// a ticket was issued for.
+const observeTicketTTL = time.Minute
+
+// observeTicket lets one admin connection watch a game. Tickets are issued
+// through the REST service's admin API, which audits them, so nobody can
+// watch a game without it being on record.
+type observeTicket struct {
+	gameID    string
+	moderated bool
+	expires   time.Time
+}
+
+// observer is an admin connection receiving a game's broadcasts, chat
+// included. Anything it sends is ignored.
+type observer struct {
+	gameID    string
+	moderated bool
+}
+
+var (
+	observeTickets = make(map[string]*observeTicket)
+	observers      = make(map[*websocket.Conn]*observer)
+)
+
+func registerObservers(mux *http.ServeMux) {
+	mux.HandleFunc("POST /games/{id}/observers", issueObserveTicketHandler)
+	mux.HandleFunc("GET /games/{id}/observe", observeHandler)
+}
+
+// issueObserveTicketHandler hands out a one-time ticket for watching a game.
+// With moderated set, the game's players are told they are being watched
+// while the observer is connected.
+func issueObserveTicketHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	var body struct {
+		Moderated bool `json:"moderated"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		errorf("Error generating observe ticket: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(buf)
+	gameID := r.PathValue("id")
+	expires := time.Now().Add(observeTicketTTL)
+
+	mu.Lock()
+	for t, ticket := range observeTickets {
+		if time.Now().After(ticket.expires) {
+			delete(observeTickets, t)
+		}
+	}
+	observeTickets[token] = &observeTicket{gameID: gameID, moderated: body.Moderated, expires: expires}
+	mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"game_id":    gameID,
+		"ticket":     token,
+		"expires_at": expires,
+	})
+}
+
+// observeHandler upgrades an admin holding a ticket for the game to a
+// read-only connection that gets everything broadcast in it.
+func observeHandler(w http.ResponseWriter, r *http.Request) {
+	gameID := r.PathValue("id")
+	token := r.URL.Query().Get("ticket")
+
+	mu.Lock()
+	ticket := observeTickets[token]
+	delete(observeTickets, token)
+	mu.Unlock()
+	if ticket == nil || ticket.gameID != gameID || time.Now().After(ticket.expires) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	conn, ok := upgradeClient(w, r)
+	if !ok {
+		return
+	}
+	infof("Admin observing game_id %s, moderated %v", gameID, ticket.moderated)
+
+	mu.Lock()
+	observers[conn] = &observer{gameID: gameID, moderated: ticket.moderated}
+	notify := ticket.moderated && moderatedLocked(gameID) == 1
+	mu.Unlock()
+	if notify {
+		sendModerated(gameID, true)
+	}
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	mu.Lock()
+	delete(observers, conn)
+	notify = ticket.moderated && moderatedLocked(gameID) == 0
+	mu.Unlock()
+	closeWhenFlushed(conn)
+	infof("Admin stopped observing game_id %s", gameID)
+	if notify {
+		sendModerated(gameID, false)
+	}
+}
+
+// moderatedLocked counts the observers watching the game openly. mu must
+// be held.
+func moderatedLocked(gameID string) int {
+	count := 0
+	for _, o := range observers {
+		if o.gameID == gameID && o.moderated {
+			count++
+		}
+	}
+	return count
+}
+
+// sendToObserversLocked copies a frame broadcast in the game to its
+// observers. mu must be held.
+func sendToObserversLocked(gameID string, frame []byte) {
+	for conn, o := range observers {
+		if o.gameID != gameID {
+			continue
+		}
+		if err := writeFrame(conn, frame); err != nil {
+			errorf("Error sending frame to observer: %v", err)
+		}
+	}
+}
+
+// closeObserversLocked disconnects the game's observers once it is closed.
+// mu must be held.
+func closeObserversLocked(gameID string) {
+	for conn, o := range observers {
+		if o.gameID == gameID {
+			closeConn(conn, closeRoomClosed, "The game was closed", 0)
+		}
+	}
+}
+
+// sendModerated tells the game's players whether an admin is watching.
+func sendModerated(gameID string, moderated bool) {
+	data, err := SerializeToString(&game.Moderated{
+		ClassId:   game.ClassTypes_PROTO_TYPE_MODERATED,
+		GameId:    []byte(gameID),
+		Moderated: moderated,
+	})
+	if err != nil {
+		errorf("Error serializing Moderated: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_MODERATED,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	if err := SendMessageToGameClients(gameID, serializedBaseMessage, nil); err != nil {
+		errorf("Failed to send moderated notice: %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+const (
+	pauseReasonHost         = "host"
+	pauseReasonReconnecting = "reconnecting"
+)
+
+// handlePause lets the host pause the game or resume it. Resuming also ends
+// any pause held for players who are reconnecting.
+func handlePause(ctx context.Context, conn *websocket.Conn, data []byte) {
+	var pause game.Pause
+	if err := proto.Unmarshal(data, &pause); err != nil {
+		errorf("Error unmarshaling Pause: %v", err)
+		return
+	}
+
+	gameID := string(pause.User.GameId)
+	host, err := fetchRoomHost(ctx, gameID)
+	if err != nil {
+		errorf("Error fetching host for game_id %s: %v", gameID, err)
+		sendErrorMessage(conn, errCodeValidationFailed, "Could not verify the room host")
+		return
+	}
+	if host != string(pause.User.SessionId) {
+		sendErrorMessage(conn, errCodeNotHost, "Only the host can pause the game")
+		return
+	}
+
+	mu.Lock()
+	changed, deadline := setHostPauseLocked(gameID, pause.Paused)
+	mu.Unlock()
+	if !changed {
+		return
+	}
+	publishRound(roundEvent{GameID: gameID, Kind: roundPause, Paused: pause.Paused})
+
+	infof("Host %s set paused=%v for game_id %s", host, pause.Paused, gameID)
+	sendPause(gameID, pause.User, pause.Paused, pauseReasonHost, deadline)
+}
+
+// setHostPauseLocked pauses or resumes the game for the host. It reports
+// whether that changed anything and, on resume, the current phase's new
+// deadline. mu must be held.
+func setHostPauseLocked(gameID string, paused bool) (bool, time.Time) {
+	state := getGameStateLocked(gameID)
+	if paused == state.hostPaused {
+		return false, time.Time{}
+	}
+	state.hostPaused = paused
+	if paused {
+		pauseGameLocked(gameID)
+		return true, time.Time{}
+	}
+	state.reconnecting = nil
+	return true, resumeGameLocked(gameID)
+}
+
+// applyPause follows a pause or resume announced by another instance: the
+// host's, or with sessionID one held for a reconnecting player. The Pause
+// frame itself reaches this instance's players as a game broadcast.
+func applyPause(gameID string, paused bool, sessionID string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch {
+	case sessionID == "":
+		setHostPauseLocked(gameID, paused)
+	case paused:
+		pauseForReconnectLocked(gameID, sessionID)
+	default:
+		reconnectedLocked(gameID, sessionID)
+	}
+}
+
+// pauseGameLocked freezes the game's timers. A play or vote phase that was
+// running picks up where it left off on resume; a ready countdown starts
+// over. mu must be held.
+func pauseGameLocked(gameID string) {
+	state := getGameStateLocked(gameID)
+	if state.paused {
+		return
+	}
+	state.paused = true
+	state.pausedAt = time.Now()
+	if state.turnTimer != nil {
+		state.turnTimer.Stop()
+		state.turnTimer = nil
+		state.turnFrozen = true
+	}
+	if state.readyTimer != nil {
+		state.readyTimer.Stop()
+		state.readyTimer = nil
+		state.readyFrozen = true
+	}
+}
+
+// resumeGameLocked restarts the timers pauseGameLocked froze, unless the
+// host or a reconnecting player still holds the game, and returns the
+// current phase's new deadline, if one is running. mu must be held.
+func resumeGameLocked(gameID string) time.Time {
+	state := getGameStateLocked(gameID)
+	if !state.paused || state.hostPaused || len(state.reconnecting) > 0 {
+		return time.Time{}
+	}
+	state.paused = false
+	if state.pauseTimer != nil {
+		state.pauseTimer.Stop()
+		state.pauseTimer = nil
+	}
+
+	var deadline time.Time
+	if state.turnFrozen {
+		state.turnFrozen = false
+		deadline = state.turnDeadline.Add(time.Since(state.pausedAt))
+		armTurnTimerLocked(gameID, deadline)
+	}
+	if state.readyFrozen && state.ReadyTimeout > 0 {
+		state.readyFrozen = false
+		state.readyTimer = time.AfterFunc(state.ReadyTimeout, func() { readyTimeoutExpired(gameID) })
+	}
+	return deadline
+}
+
+// pauseForReconnectLocked pauses the game while a player who dropped in the
+// middle of a round can still rejoin, for up to reconnectPauseLimit. mu
+// must be held.
+func pauseForReconnectLocked(gameID, sessionID string) {
+	state := getGameStateLocked(gameID)
+	if state.reconnecting == nil {
+		state.reconnecting = make(map[string]bool)
+	}
+	state.reconnecting[sessionID] = true
+	pauseGameLocked(gameID)
+	if state.pauseTimer == nil {
+		state.pauseTimer = time.AfterFunc(reconnectPauseLimit, func() { reconnectPauseExpired(gameID) })
+	}
+}
+
+// reconnectedLocked ends the reconnect pause the session held, if any. It
+// reports whether the game resumed and the phase's new deadline. mu must be
+// held.
+func reconnectedLocked(gameID, sessionID string) (bool, time.Time) {
+	state := getGameStateLocked(gameID)
+	if !state.reconnecting[sessionID] {
+		return false, time.Time{}
+	}
+	delete(state.reconnecting, sessionID)
+	deadline := resumeGameLocked(gameID)
+	return !state.paused, deadline
+}
+
+// reconnectPauseExpired stops waiting for players who haven't come back, so
+// the rest of the game can go on without them.
+func reconnectPauseExpired(gameID string) {
+	mu.Lock()
+	state := getGameStateLocked(gameID)
+	state.pauseTimer = nil
+	if len(state.reconnecting) == 0 {
+		mu.Unlock()
+		return
+	}
+	warnf("Gave up waiting for %d players to reconnect to game_id %s", len(state.reconnecting), gameID)
+	state.reconnecting = nil
+	deadline := resumeGameLocked(gameID)
+	resumed := !state.paused
+	mu.Unlock()
+
+	// Every instance holding the game gives up at the same time, so each
+	// only tells its own connections.
+	if resumed {
+		frame, err := pauseFrame(&game.User{GameId: []byte(gameID)}, false, pauseReasonReconnecting, deadline)
+		if err != nil {
+			errorf("Error serializing Pause: %v", err)
+		} else {
+			sendToLocalGameClients(gameID, frame)
+		}
+	}
+}
+
+// pauseForDroppedPlayers pauses every game conn was playing a round in.
+// Players who can't rejoin, having left for good, don't hold the game up.
+// It is called as the connection goes away.
+func pauseForDroppedPlayers(conn *websocket.Conn) {
+	var dropped []*game.User
+
+	mu.Lock()
+	for _, room := range clients[conn] {
+		for _, user := range room.Users {
+			if !user.InGame || user.Spectator || rejoinTokenLocked(room.GameID, user.SessionID) == "" {
+				continue
+			}
+			pauseForReconnectLocked(room.GameID, user.SessionID)
+			dropped = append(dropped, &game.User{
+				Login:     []byte(user.Login),
+				SessionId: []byte(user.SessionID),
+				GameId:    []byte(room.GameID),
+			})
+		}
+	}
+	mu.Unlock()
+
+	for _, user := range dropped {
+		infof("Pausing game_id %s while %s reconnects", user.GameId, user.SessionId)
+		publishRound(roundEvent{GameID: string(user.GameId), Kind: roundPause, Paused: true, SessionID: string(user.SessionId)})
+		sendPause(string(user.GameId), user, true, pauseReasonReconnecting, time.Time{})
+	}
+}
+
+// sendPause tells the game it was paused or resumed, and by whom. A resume
+// carries the running phase's new deadline, if there is one.
+func sendPause(gameID string, user *game.User, paused bool, reason string, deadline time.Time) {
+	serializedBaseMessage, err := pauseFrame(user, paused, reason, deadline)
+	if err != nil {
+		errorf("Error serializing Pause: %v", err)
+		return
+	}
+
+	if err := SendMessageToGameClients(gameID, serializedBaseMessage, nil); err != nil {
+		errorf("Failed to send pause: %v", err)
+	}
+}
+
+func pauseFrame(user *game.User, paused bool, reason string, deadline time.Time) ([]byte, error) {
+	data, err := SerializeToString(&game.Pause{
+		ClassId:  game.ClassTypes_PROTO_TYPE_PAUSE,
+		User:     user,
+		Paused:   paused,
+		Reason:   []byte(reason),
+		Deadline: unixMilli(deadline),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_PAUSE,
+		Data:    data,
+	})
+}
+
+// resumeAfterRejoin ends the reconnect pause a rejoining player held. If the
+// game stays paused for someone else, the player is told so.
+func resumeAfterRejoin(conn *websocket.Conn, user *game.User) {
+	gameID := string(user.GameId)
+
+	mu.Lock()
+	state := getGameStateLocked(gameID)
+	held := state.reconnecting[string(user.SessionId)]
+	resumed, deadline := reconnectedLocked(gameID, string(user.SessionId))
+	paused := state.paused
+	reason := pauseReasonReconnecting
+	if state.hostPaused {
+		reason = pauseReasonHost
+	}
+	mu.Unlock()
+
+	if held {
+		publishRound(roundEvent{GameID: gameID, Kind: roundPause, SessionID: string(user.SessionId)})
+	}
+	if resumed {
+		sendPause(gameID, user, false, pauseReasonReconnecting, deadline)
+		return
+	}
+	if !paused {
+		return
+	}
+	serializedBaseMessage, err := pauseFrame(&game.User{GameId: user.GameId}, true, reason, time.Time{})
+	if err != nil {
+		errorf("Error serializing Pause: %v", err)
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := SendMessageToClient(conn, serializedBaseMessage); err != nil {
+		errorf("Failed to send pause: %v", err)
+	}
+}
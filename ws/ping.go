@@ -0,0 +1,44 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// handlePing answers a Ping straight away with a Pong that echoes it and
+// adds the server's clock, so the client can time the round trip through
+// the game protocol rather than the transport's own keepalives. Times are
+// Unix milliseconds.
+func handlePing(conn *websocket.Conn, data []byte) {
+	var ping game.Ping
+	if err := proto.Unmarshal(data, &ping); err != nil {
+		errorf("Error unmarshaling Ping: %v", err)
+		return
+	}
+
+	serializedData, err := SerializeToString(&game.Pong{
+		ClassId:    game.ClassTypes_PROTO_TYPE_PONG,
+		Seq:        ping.Seq,
+		ClientTime: ping.ClientTime,
+		ServerTime: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		errorf("Error serializing Pong: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_PONG,
+		Data:    serializedData,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+	if err := SendMessageToClient(conn, serializedBaseMessage); err != nil {
+		errorf("Failed to send pong: %v", err)
+	}
+}
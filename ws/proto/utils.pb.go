@@ -23,18 +23,39 @@ const (
 type ClassTypes int32
 
 const (
-	ClassTypes_PROTO_TYPE_INVALID     ClassTypes = 0
-	ClassTypes_PROTO_TYPE_USERINFO    ClassTypes = 1
-	ClassTypes_PROTO_TYPE_ACTION      ClassTypes = 2
-	ClassTypes_PROTO_TYPE_DELETE      ClassTypes = 3
-	ClassTypes_PROTO_TYPE_STATUS      ClassTypes = 4
-	ClassTypes_PROTO_TYPE_START       ClassTypes = 5
-	ClassTypes_PROTO_TYPE_CHOOSE      ClassTypes = 6
-	ClassTypes_PROTO_TYPE_CARD        ClassTypes = 7
-	ClassTypes_PROTO_TYPE_UPDATE      ClassTypes = 8
-	ClassTypes_PROTO_TYPE_GAMEINFO    ClassTypes = 9
-	ClassTypes_PROTO_TYPE_DISCONNECT  ClassTypes = 10
-	ClassTypes_PROTO_TYPE_CHATMESSAGE ClassTypes = 11
+	ClassTypes_PROTO_TYPE_INVALID       ClassTypes = 0
+	ClassTypes_PROTO_TYPE_USERINFO      ClassTypes = 1
+	ClassTypes_PROTO_TYPE_ACTION        ClassTypes = 2
+	ClassTypes_PROTO_TYPE_DELETE        ClassTypes = 3
+	ClassTypes_PROTO_TYPE_STATUS        ClassTypes = 4
+	ClassTypes_PROTO_TYPE_START         ClassTypes = 5
+	ClassTypes_PROTO_TYPE_CHOOSE        ClassTypes = 6
+	ClassTypes_PROTO_TYPE_CARD          ClassTypes = 7
+	ClassTypes_PROTO_TYPE_UPDATE        ClassTypes = 8
+	ClassTypes_PROTO_TYPE_GAMEINFO      ClassTypes = 9
+	ClassTypes_PROTO_TYPE_DISCONNECT    ClassTypes = 10
+	ClassTypes_PROTO_TYPE_CHATMESSAGE   ClassTypes = 11
+	ClassTypes_PROTO_TYPE_ERROR         ClassTypes = 12
+	ClassTypes_PROTO_TYPE_CHATSETTINGS  ClassTypes = 13
+	ClassTypes_PROTO_TYPE_LOBBYSETTINGS ClassTypes = 14
+	ClassTypes_PROTO_TYPE_AFK           ClassTypes = 15
+	ClassTypes_PROTO_TYPE_STARTREQUEST  ClassTypes = 16
+	ClassTypes_PROTO_TYPE_CHATRECEIPT   ClassTypes = 17
+	ClassTypes_PROTO_TYPE_CHATEDIT      ClassTypes = 18
+	ClassTypes_PROTO_TYPE_CHATDELETE    ClassTypes = 19
+	ClassTypes_PROTO_TYPE_ROOMCAPACITY  ClassTypes = 20
+	ClassTypes_PROTO_TYPE_REJOIN        ClassTypes = 21
+	ClassTypes_PROTO_TYPE_STATESYNC     ClassTypes = 22
+	ClassTypes_PROTO_TYPE_ROOMCLOSED    ClassTypes = 23
+	ClassTypes_PROTO_TYPE_ANNOUNCEMENT  ClassTypes = 24
+	ClassTypes_PROTO_TYPE_PING          ClassTypes = 25
+	ClassTypes_PROTO_TYPE_PONG          ClassTypes = 26
+	ClassTypes_PROTO_TYPE_TIMESYNC      ClassTypes = 27
+	ClassTypes_PROTO_TYPE_DEADLINE      ClassTypes = 28
+	ClassTypes_PROTO_TYPE_COUNTDOWN     ClassTypes = 29
+	ClassTypes_PROTO_TYPE_PAUSE         ClassTypes = 30
+	ClassTypes_PROTO_TYPE_MODERATED     ClassTypes = 31
+	ClassTypes_PROTO_TYPE_HELLO         ClassTypes = 32
 )
 
 // Enum value maps for ClassTypes.
@@ -52,20 +73,62 @@ var (
 		9:  "PROTO_TYPE_GAMEINFO",
 		10: "PROTO_TYPE_DISCONNECT",
 		11: "PROTO_TYPE_CHATMESSAGE",
+		12: "PROTO_TYPE_ERROR",
+		13: "PROTO_TYPE_CHATSETTINGS",
+		14: "PROTO_TYPE_LOBBYSETTINGS",
+		15: "PROTO_TYPE_AFK",
+		16: "PROTO_TYPE_STARTREQUEST",
+		17: "PROTO_TYPE_CHATRECEIPT",
+		18: "PROTO_TYPE_CHATEDIT",
+		19: "PROTO_TYPE_CHATDELETE",
+		20: "PROTO_TYPE_ROOMCAPACITY",
+		21: "PROTO_TYPE_REJOIN",
+		22: "PROTO_TYPE_STATESYNC",
+		23: "PROTO_TYPE_ROOMCLOSED",
+		24: "PROTO_TYPE_ANNOUNCEMENT",
+		25: "PROTO_TYPE_PING",
+		26: "PROTO_TYPE_PONG",
+		27: "PROTO_TYPE_TIMESYNC",
+		28: "PROTO_TYPE_DEADLINE",
+		29: "PROTO_TYPE_COUNTDOWN",
+		30: "PROTO_TYPE_PAUSE",
+		31: "PROTO_TYPE_MODERATED",
+		32: "PROTO_TYPE_HELLO",
 	}
 	ClassTypes_value = map[string]int32{
-		"PROTO_TYPE_INVALID":     0,
-		"PROTO_TYPE_USERINFO":    1,
-		"PROTO_TYPE_ACTION":      2,
-		"PROTO_TYPE_DELETE":      3,
-		"PROTO_TYPE_STATUS":      4,
-		"PROTO_TYPE_START":       5,
-		"PROTO_TYPE_CHOOSE":      6,
-		"PROTO_TYPE_CARD":        7,
-		"PROTO_TYPE_UPDATE":      8,
-		"PROTO_TYPE_GAMEINFO":    9,
-		"PROTO_TYPE_DISCONNECT":  10,
-		"PROTO_TYPE_CHATMESSAGE": 11,
+		"PROTO_TYPE_INVALID":       0,
+		"PROTO_TYPE_USERINFO":      1,
+		"PROTO_TYPE_ACTION":        2,
+		"PROTO_TYPE_DELETE":        3,
+		"PROTO_TYPE_STATUS":        4,
+		"PROTO_TYPE_START":         5,
+		"PROTO_TYPE_CHOOSE":        6,
+		"PROTO_TYPE_CARD":          7,
+		"PROTO_TYPE_UPDATE":        8,
+		"PROTO_TYPE_GAMEINFO":      9,
+		"PROTO_TYPE_DISCONNECT":    10,
+		"PROTO_TYPE_CHATMESSAGE":   11,
+		"PROTO_TYPE_ERROR":         12,
+		"PROTO_TYPE_CHATSETTINGS":  13,
+		"PROTO_TYPE_LOBBYSETTINGS": 14,
+		"PROTO_TYPE_AFK":           15,
+		"PROTO_TYPE_STARTREQUEST":  16,
+		"PROTO_TYPE_CHATRECEIPT":   17,
+		"PROTO_TYPE_CHATEDIT":      18,
+		"PROTO_TYPE_CHATDELETE":    19,
+		"PROTO_TYPE_ROOMCAPACITY":  20,
+		"PROTO_TYPE_REJOIN":        21,
+		"PROTO_TYPE_STATESYNC":     22,
+		"PROTO_TYPE_ROOMCLOSED":    23,
+		"PROTO_TYPE_ANNOUNCEMENT":  24,
+		"PROTO_TYPE_PING":          25,
+		"PROTO_TYPE_PONG":          26,
+		"PROTO_TYPE_TIMESYNC":      27,
+		"PROTO_TYPE_DEADLINE":      28,
+		"PROTO_TYPE_COUNTDOWN":     29,
+		"PROTO_TYPE_PAUSE":         30,
+		"PROTO_TYPE_MODERATED":     31,
+		"PROTO_TYPE_HELLO":         32,
 	}
 )
 
@@ -96,6 +159,162 @@ func (ClassTypes) EnumDescriptor() ([]byte, []int) {
 	return file_utils_proto_rawDescGZIP(), []int{0}
 }
 
+type ChatScope int32
+
+const (
+	ChatScope_CHAT_SCOPE_ALL        ChatScope = 0
+	ChatScope_CHAT_SCOPE_SPECTATORS ChatScope = 1
+)
+
+// Enum value maps for ChatScope.
+var (
+	ChatScope_name = map[int32]string{
+		0: "CHAT_SCOPE_ALL",
+		1: "CHAT_SCOPE_SPECTATORS",
+	}
+	ChatScope_value = map[string]int32{
+		"CHAT_SCOPE_ALL":        0,
+		"CHAT_SCOPE_SPECTATORS": 1,
+	}
+)
+
+func (x ChatScope) Enum() *ChatScope {
+	p := new(ChatScope)
+	*p = x
+	return p
+}
+
+func (x ChatScope) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChatScope) Descriptor() protoreflect.EnumDescriptor {
+	return file_utils_proto_enumTypes[1].Descriptor()
+}
+
+func (ChatScope) Type() protoreflect.EnumType {
+	return &file_utils_proto_enumTypes[1]
+}
+
+func (x ChatScope) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChatScope.Descriptor instead.
+func (ChatScope) EnumDescriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{1}
+}
+
+type ReceiptStatus int32
+
+const (
+	ReceiptStatus_RECEIPT_DELIVERED ReceiptStatus = 0
+	ReceiptStatus_RECEIPT_READ      ReceiptStatus = 1
+)
+
+// Enum value maps for ReceiptStatus.
+var (
+	ReceiptStatus_name = map[int32]string{
+		0: "RECEIPT_DELIVERED",
+		1: "RECEIPT_READ",
+	}
+	ReceiptStatus_value = map[string]int32{
+		"RECEIPT_DELIVERED": 0,
+		"RECEIPT_READ":      1,
+	}
+)
+
+func (x ReceiptStatus) Enum() *ReceiptStatus {
+	p := new(ReceiptStatus)
+	*p = x
+	return p
+}
+
+func (x ReceiptStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReceiptStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_utils_proto_enumTypes[2].Descriptor()
+}
+
+func (ReceiptStatus) Type() protoreflect.EnumType {
+	return &file_utils_proto_enumTypes[2]
+}
+
+func (x ReceiptStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReceiptStatus.Descriptor instead.
+func (ReceiptStatus) EnumDescriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{2}
+}
+
+type Capability int32
+
+const (
+	Capability_CAPABILITY_NONE      Capability = 0
+	Capability_CAPABILITY_REJOIN    Capability = 1
+	Capability_CAPABILITY_SPECTATOR Capability = 2
+	Capability_CAPABILITY_MSGPACK   Capability = 4
+	Capability_CAPABILITY_REACTIONS Capability = 8
+	Capability_CAPABILITY_TIMERS    Capability = 16
+	Capability_CAPABILITY_PAUSE     Capability = 32
+	Capability_CAPABILITY_NOTICES   Capability = 64
+)
+
+// Enum value maps for Capability.
+var (
+	Capability_name = map[int32]string{
+		0:  "CAPABILITY_NONE",
+		1:  "CAPABILITY_REJOIN",
+		2:  "CAPABILITY_SPECTATOR",
+		4:  "CAPABILITY_MSGPACK",
+		8:  "CAPABILITY_REACTIONS",
+		16: "CAPABILITY_TIMERS",
+		32: "CAPABILITY_PAUSE",
+		64: "CAPABILITY_NOTICES",
+	}
+	Capability_value = map[string]int32{
+		"CAPABILITY_NONE":      0,
+		"CAPABILITY_REJOIN":    1,
+		"CAPABILITY_SPECTATOR": 2,
+		"CAPABILITY_MSGPACK":   4,
+		"CAPABILITY_REACTIONS": 8,
+		"CAPABILITY_TIMERS":    16,
+		"CAPABILITY_PAUSE":     32,
+		"CAPABILITY_NOTICES":   64,
+	}
+)
+
+func (x Capability) Enum() *Capability {
+	p := new(Capability)
+	*p = x
+	return p
+}
+
+func (x Capability) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Capability) Descriptor() protoreflect.EnumDescriptor {
+	return file_utils_proto_enumTypes[3].Descriptor()
+}
+
+func (Capability) Type() protoreflect.EnumType {
+	return &file_utils_proto_enumTypes[3]
+}
+
+func (x Capability) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Capability.Descriptor instead.
+func (Capability) EnumDescriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{3}
+}
+
 type User struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -348,6 +567,7 @@ type UserInfo struct {
 	ClassId   ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
 	User      *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
 	Connected bool       `protobuf:"varint,3,opt,name=connected,proto3" json:"connected,omitempty"`
+	Spectator bool       `protobuf:"varint,4,opt,name=spectator,proto3" json:"spectator,omitempty"`
 }
 
 func (x *UserInfo) Reset() {
@@ -403,6 +623,13 @@ func (x *UserInfo) GetConnected() bool {
 	return false
 }
 
+func (x *UserInfo) GetSpectator() bool {
+	if x != nil {
+		return x.Spectator
+	}
+	return false
+}
+
 type Ready struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -471,10 +698,14 @@ type Start struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ClassId ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
-	GameId  []byte     `protobuf:"bytes,2,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
-	Start   bool       `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
-	Text    []byte     `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	ClassId     ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	GameId      []byte     `protobuf:"bytes,2,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Start       bool       `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	Text        []byte     `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	RejoinToken []byte     `protobuf:"bytes,5,opt,name=rejoin_token,json=rejoinToken,proto3" json:"rejoin_token,omitempty"`
+	RoomName    []byte     `protobuf:"bytes,6,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`
+	ServerTime  int64      `protobuf:"varint,7,opt,name=server_time,json=serverTime,proto3" json:"server_time,omitempty"`
+	Deadline    int64      `protobuf:"varint,8,opt,name=deadline,proto3" json:"deadline,omitempty"`
 }
 
 func (x *Start) Reset() {
@@ -537,20 +768,121 @@ func (x *Start) GetText() []byte {
 	return nil
 }
 
+func (x *Start) GetRejoinToken() []byte {
+	if x != nil {
+		return x.RejoinToken
+	}
+	return nil
+}
+
+func (x *Start) GetRoomName() []byte {
+	if x != nil {
+		return x.RoomName
+	}
+	return nil
+}
+
+func (x *Start) GetServerTime() int64 {
+	if x != nil {
+		return x.ServerTime
+	}
+	return 0
+}
+
+func (x *Start) GetDeadline() int64 {
+	if x != nil {
+		return x.Deadline
+	}
+	return 0
+}
+
+type Rejoin struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId   ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	Token     []byte     `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	User      *User      `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	Spectator bool       `protobuf:"varint,4,opt,name=spectator,proto3" json:"spectator,omitempty"`
+}
+
+func (x *Rejoin) Reset() {
+	*x = Rejoin{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rejoin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rejoin) ProtoMessage() {}
+
+func (x *Rejoin) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rejoin.ProtoReflect.Descriptor instead.
+func (*Rejoin) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Rejoin) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Rejoin) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *Rejoin) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *Rejoin) GetSpectator() bool {
+	if x != nil {
+		return x.Spectator
+	}
+	return false
+}
+
 type Choose struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ClassId  ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
-	User     *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
-	ChosenId []byte     `protobuf:"bytes,3,opt,name=chosen_id,json=chosenId,proto3" json:"chosen_id,omitempty"`
+	ClassId      ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User         *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	ChosenId     []byte     `protobuf:"bytes,3,opt,name=chosen_id,json=chosenId,proto3" json:"chosen_id,omitempty"`
+	CardId       uint64     `protobuf:"varint,4,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`
+	CustomCardId uint64     `protobuf:"varint,5,opt,name=custom_card_id,json=customCardId,proto3" json:"custom_card_id,omitempty"`
 }
 
 func (x *Choose) Reset() {
 	*x = Choose{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_utils_proto_msgTypes[7]
+		mi := &file_utils_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -563,7 +895,7 @@ func (x *Choose) String() string {
 func (*Choose) ProtoMessage() {}
 
 func (x *Choose) ProtoReflect() protoreflect.Message {
-	mi := &file_utils_proto_msgTypes[7]
+	mi := &file_utils_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -576,7 +908,7 @@ func (x *Choose) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Choose.ProtoReflect.Descriptor instead.
 func (*Choose) Descriptor() ([]byte, []int) {
-	return file_utils_proto_rawDescGZIP(), []int{7}
+	return file_utils_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Choose) GetClassId() ClassTypes {
@@ -600,21 +932,37 @@ func (x *Choose) GetChosenId() []byte {
 	return nil
 }
 
+func (x *Choose) GetCardId() uint64 {
+	if x != nil {
+		return x.CardId
+	}
+	return 0
+}
+
+func (x *Choose) GetCustomCardId() uint64 {
+	if x != nil {
+		return x.CustomCardId
+	}
+	return 0
+}
+
 type Action struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ClassId ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
-	User    *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
-	Turn    bool       `protobuf:"varint,3,opt,name=turn,proto3" json:"turn,omitempty"`
-	Image   []byte     `protobuf:"bytes,4,opt,name=image,proto3" json:"image,omitempty"`
+	ClassId      ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User         *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Turn         bool       `protobuf:"varint,3,opt,name=turn,proto3" json:"turn,omitempty"`
+	Image        []byte     `protobuf:"bytes,4,opt,name=image,proto3" json:"image,omitempty"`
+	CardId       uint64     `protobuf:"varint,5,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`
+	CustomCardId uint64     `protobuf:"varint,6,opt,name=custom_card_id,json=customCardId,proto3" json:"custom_card_id,omitempty"`
 }
 
 func (x *Action) Reset() {
 	*x = Action{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_utils_proto_msgTypes[8]
+		mi := &file_utils_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -627,7 +975,7 @@ func (x *Action) String() string {
 func (*Action) ProtoMessage() {}
 
 func (x *Action) ProtoReflect() protoreflect.Message {
-	mi := &file_utils_proto_msgTypes[8]
+	mi := &file_utils_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -640,7 +988,7 @@ func (x *Action) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Action.ProtoReflect.Descriptor instead.
 func (*Action) Descriptor() ([]byte, []int) {
-	return file_utils_proto_rawDescGZIP(), []int{8}
+	return file_utils_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *Action) GetClassId() ClassTypes {
@@ -671,6 +1019,20 @@ func (x *Action) GetImage() []byte {
 	return nil
 }
 
+func (x *Action) GetCardId() uint64 {
+	if x != nil {
+		return x.CardId
+	}
+	return 0
+}
+
+func (x *Action) GetCustomCardId() uint64 {
+	if x != nil {
+		return x.CustomCardId
+	}
+	return 0
+}
+
 type DeleteUser struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -683,7 +1045,7 @@ type DeleteUser struct {
 func (x *DeleteUser) Reset() {
 	*x = DeleteUser{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_utils_proto_msgTypes[9]
+		mi := &file_utils_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -696,7 +1058,7 @@ func (x *DeleteUser) String() string {
 func (*DeleteUser) ProtoMessage() {}
 
 func (x *DeleteUser) ProtoReflect() protoreflect.Message {
-	mi := &file_utils_proto_msgTypes[9]
+	mi := &file_utils_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -709,7 +1071,7 @@ func (x *DeleteUser) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteUser.ProtoReflect.Descriptor instead.
 func (*DeleteUser) Descriptor() ([]byte, []int) {
-	return file_utils_proto_rawDescGZIP(), []int{9}
+	return file_utils_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *DeleteUser) GetClassId() ClassTypes {
@@ -731,13 +1093,14 @@ type DeleteCards struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ClassId ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	ClassId  ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	Deadline int64      `protobuf:"varint,2,opt,name=deadline,proto3" json:"deadline,omitempty"`
 }
 
 func (x *DeleteCards) Reset() {
 	*x = DeleteCards{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_utils_proto_msgTypes[10]
+		mi := &file_utils_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -750,7 +1113,7 @@ func (x *DeleteCards) String() string {
 func (*DeleteCards) ProtoMessage() {}
 
 func (x *DeleteCards) ProtoReflect() protoreflect.Message {
-	mi := &file_utils_proto_msgTypes[10]
+	mi := &file_utils_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -763,7 +1126,7 @@ func (x *DeleteCards) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteCards.ProtoReflect.Descriptor instead.
 func (*DeleteCards) Descriptor() ([]byte, []int) {
-	return file_utils_proto_rawDescGZIP(), []int{10}
+	return file_utils_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *DeleteCards) GetClassId() ClassTypes {
@@ -773,6 +1136,13 @@ func (x *DeleteCards) GetClassId() ClassTypes {
 	return ClassTypes_PROTO_TYPE_INVALID
 }
 
+func (x *DeleteCards) GetDeadline() int64 {
+	if x != nil {
+		return x.Deadline
+	}
+	return 0
+}
+
 type BaseMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -780,12 +1150,13 @@ type BaseMessage struct {
 
 	ClassId ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
 	Data    []byte     `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Seq     uint32     `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
 }
 
 func (x *BaseMessage) Reset() {
 	*x = BaseMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_utils_proto_msgTypes[11]
+		mi := &file_utils_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -798,7 +1169,7 @@ func (x *BaseMessage) String() string {
 func (*BaseMessage) ProtoMessage() {}
 
 func (x *BaseMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_utils_proto_msgTypes[11]
+	mi := &file_utils_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -811,7 +1182,7 @@ func (x *BaseMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BaseMessage.ProtoReflect.Descriptor instead.
 func (*BaseMessage) Descriptor() ([]byte, []int) {
-	return file_utils_proto_rawDescGZIP(), []int{11}
+	return file_utils_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *BaseMessage) GetClassId() ClassTypes {
@@ -828,20 +1199,34 @@ func (x *BaseMessage) GetData() []byte {
 	return nil
 }
 
+func (x *BaseMessage) GetSeq() uint32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
 type ChatMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ClassId ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
-	User    *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
-	Message []byte     `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	ClassId  ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User     *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Message  []byte     `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Scope    ChatScope  `protobuf:"varint,4,opt,name=scope,proto3,enum=game.ChatScope" json:"scope,omitempty"`
+	Id       uint64     `protobuf:"varint,5,opt,name=id,proto3" json:"id,omitempty"`
+	SentAt   int64      `protobuf:"varint,6,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	Receipts bool       `protobuf:"varint,7,opt,name=receipts,proto3" json:"receipts,omitempty"`
+	EditedAt int64      `protobuf:"varint,8,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`
+	ImageId  uint64     `protobuf:"varint,9,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	ImageUrl []byte     `protobuf:"bytes,10,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
 }
 
 func (x *ChatMessage) Reset() {
 	*x = ChatMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_utils_proto_msgTypes[12]
+		mi := &file_utils_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -854,7 +1239,7 @@ func (x *ChatMessage) String() string {
 func (*ChatMessage) ProtoMessage() {}
 
 func (x *ChatMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_utils_proto_msgTypes[12]
+	mi := &file_utils_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -867,7 +1252,7 @@ func (x *ChatMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
 func (*ChatMessage) Descriptor() ([]byte, []int) {
-	return file_utils_proto_rawDescGZIP(), []int{12}
+	return file_utils_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ChatMessage) GetClassId() ClassTypes {
@@ -891,115 +1276,2065 @@ func (x *ChatMessage) GetMessage() []byte {
 	return nil
 }
 
-var File_utils_proto protoreflect.FileDescriptor
+func (x *ChatMessage) GetScope() ChatScope {
+	if x != nil {
+		return x.Scope
+	}
+	return ChatScope_CHAT_SCOPE_ALL
+}
 
-var file_utils_proto_rawDesc = []byte{
-	0x0a, 0x0b, 0x75, 0x74, 0x69, 0x6c, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x67,
-	0x61, 0x6d, 0x65, 0x22, 0x54, 0x0a, 0x04, 0x55, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6c,
-	0x6f, 0x67, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6c, 0x6f, 0x67, 0x69,
-	0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64,
-	0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x06, 0x67, 0x61, 0x6d, 0x65, 0x49, 0x64, 0x22, 0x92, 0x01, 0x0a, 0x08, 0x47, 0x61,
-	0x6d, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49,
-	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43,
-	0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73,
-	0x49, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x64, 0x65, 0x73, 0x74, 0x69,
-	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73,
-	0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67,
-	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x22, 0x58,
-	0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2a, 0x0a, 0x07,
-	0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e,
-	0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52,
-	0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73,
-	0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x58, 0x0a, 0x0a, 0x44, 0x69, 0x73, 0x63,
-	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49,
-	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43,
-	0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73,
-	0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73,
-	0x65, 0x72, 0x22, 0x74, 0x0a, 0x08, 0x55, 0x73, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2a,
+func (x *ChatMessage) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetSentAt() int64 {
+	if x != nil {
+		return x.SentAt
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetReceipts() bool {
+	if x != nil {
+		return x.Receipts
+	}
+	return false
+}
+
+func (x *ChatMessage) GetEditedAt() int64 {
+	if x != nil {
+		return x.EditedAt
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetImageId() uint64 {
+	if x != nil {
+		return x.ImageId
+	}
+	return 0
+}
+
+func (x *ChatMessage) GetImageUrl() []byte {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return nil
+}
+
+type ChatEdit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId   ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User      *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	MessageId uint64     `protobuf:"varint,3,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Message   []byte     `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	EditedAt  int64      `protobuf:"varint,5,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`
+}
+
+func (x *ChatEdit) Reset() {
+	*x = ChatEdit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChatEdit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatEdit) ProtoMessage() {}
+
+func (x *ChatEdit) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatEdit.ProtoReflect.Descriptor instead.
+func (*ChatEdit) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ChatEdit) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *ChatEdit) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *ChatEdit) GetMessageId() uint64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *ChatEdit) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *ChatEdit) GetEditedAt() int64 {
+	if x != nil {
+		return x.EditedAt
+	}
+	return 0
+}
+
+type ChatDelete struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId   ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User      *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	MessageId uint64     `protobuf:"varint,3,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+}
+
+func (x *ChatDelete) Reset() {
+	*x = ChatDelete{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChatDelete) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatDelete) ProtoMessage() {}
+
+func (x *ChatDelete) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatDelete.ProtoReflect.Descriptor instead.
+func (*ChatDelete) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ChatDelete) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *ChatDelete) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *ChatDelete) GetMessageId() uint64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+type ChatReceipt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId   ClassTypes    `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User      *User         `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	MessageId uint64        `protobuf:"varint,3,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Status    ReceiptStatus `protobuf:"varint,4,opt,name=status,proto3,enum=game.ReceiptStatus" json:"status,omitempty"`
+	Delivered uint32        `protobuf:"varint,5,opt,name=delivered,proto3" json:"delivered,omitempty"`
+}
+
+func (x *ChatReceipt) Reset() {
+	*x = ChatReceipt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChatReceipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatReceipt) ProtoMessage() {}
+
+func (x *ChatReceipt) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatReceipt.ProtoReflect.Descriptor instead.
+func (*ChatReceipt) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ChatReceipt) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *ChatReceipt) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *ChatReceipt) GetMessageId() uint64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *ChatReceipt) GetStatus() ReceiptStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ReceiptStatus_RECEIPT_DELIVERED
+}
+
+func (x *ChatReceipt) GetDelivered() uint32 {
+	if x != nil {
+		return x.Delivered
+	}
+	return 0
+}
+
+type ChatSettings struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId   ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User      *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	CrossTalk bool       `protobuf:"varint,3,opt,name=cross_talk,json=crossTalk,proto3" json:"cross_talk,omitempty"`
+}
+
+func (x *ChatSettings) Reset() {
+	*x = ChatSettings{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChatSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatSettings) ProtoMessage() {}
+
+func (x *ChatSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatSettings.ProtoReflect.Descriptor instead.
+func (*ChatSettings) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ChatSettings) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *ChatSettings) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *ChatSettings) GetCrossTalk() bool {
+	if x != nil {
+		return x.CrossTalk
+	}
+	return false
+}
+
+type LobbySettings struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId      ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User         *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	ReadyTimeout uint32     `protobuf:"varint,3,opt,name=ready_timeout,json=readyTimeout,proto3" json:"ready_timeout,omitempty"`
+	KickUnready  bool       `protobuf:"varint,4,opt,name=kick_unready,json=kickUnready,proto3" json:"kick_unready,omitempty"`
+	MinPlayers   uint32     `protobuf:"varint,5,opt,name=min_players,json=minPlayers,proto3" json:"min_players,omitempty"`
+	TurnTimeout  uint32     `protobuf:"varint,6,opt,name=turn_timeout,json=turnTimeout,proto3" json:"turn_timeout,omitempty"`
+}
+
+func (x *LobbySettings) Reset() {
+	*x = LobbySettings{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LobbySettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LobbySettings) ProtoMessage() {}
+
+func (x *LobbySettings) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LobbySettings.ProtoReflect.Descriptor instead.
+func (*LobbySettings) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *LobbySettings) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *LobbySettings) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *LobbySettings) GetReadyTimeout() uint32 {
+	if x != nil {
+		return x.ReadyTimeout
+	}
+	return 0
+}
+
+func (x *LobbySettings) GetKickUnready() bool {
+	if x != nil {
+		return x.KickUnready
+	}
+	return false
+}
+
+func (x *LobbySettings) GetMinPlayers() uint32 {
+	if x != nil {
+		return x.MinPlayers
+	}
+	return 0
+}
+
+func (x *LobbySettings) GetTurnTimeout() uint32 {
+	if x != nil {
+		return x.TurnTimeout
+	}
+	return 0
+}
+
+type RoomCapacity struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId  ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User     *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Capacity uint32     `protobuf:"varint,3,opt,name=capacity,proto3" json:"capacity,omitempty"`
+}
+
+func (x *RoomCapacity) Reset() {
+	*x = RoomCapacity{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RoomCapacity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomCapacity) ProtoMessage() {}
+
+func (x *RoomCapacity) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomCapacity.ProtoReflect.Descriptor instead.
+func (*RoomCapacity) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RoomCapacity) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *RoomCapacity) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *RoomCapacity) GetCapacity() uint32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+type StartRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User    *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+}
+
+func (x *StartRequest) Reset() {
+	*x = StartRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRequest) ProtoMessage() {}
+
+func (x *StartRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRequest.ProtoReflect.Descriptor instead.
+func (*StartRequest) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *StartRequest) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *StartRequest) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type AfkNotice struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId     ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User        *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	MissedTurns uint32     `protobuf:"varint,3,opt,name=missed_turns,json=missedTurns,proto3" json:"missed_turns,omitempty"`
+}
+
+func (x *AfkNotice) Reset() {
+	*x = AfkNotice{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AfkNotice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AfkNotice) ProtoMessage() {}
+
+func (x *AfkNotice) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AfkNotice.ProtoReflect.Descriptor instead.
+func (*AfkNotice) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AfkNotice) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *AfkNotice) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *AfkNotice) GetMissedTurns() uint32 {
+	if x != nil {
+		return x.MissedTurns
+	}
+	return 0
+}
+
+type PlayerState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	User      *User  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Spectator bool   `protobuf:"varint,2,opt,name=spectator,proto3" json:"spectator,omitempty"`
+	Ready     bool   `protobuf:"varint,3,opt,name=ready,proto3" json:"ready,omitempty"`
+	Played    bool   `protobuf:"varint,4,opt,name=played,proto3" json:"played,omitempty"`
+	Voted     bool   `protobuf:"varint,5,opt,name=voted,proto3" json:"voted,omitempty"`
+	Score     uint32 `protobuf:"varint,6,opt,name=score,proto3" json:"score,omitempty"`
+}
+
+func (x *PlayerState) Reset() {
+	*x = PlayerState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PlayerState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerState) ProtoMessage() {}
+
+func (x *PlayerState) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerState.ProtoReflect.Descriptor instead.
+func (*PlayerState) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *PlayerState) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *PlayerState) GetSpectator() bool {
+	if x != nil {
+		return x.Spectator
+	}
+	return false
+}
+
+func (x *PlayerState) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+func (x *PlayerState) GetPlayed() bool {
+	if x != nil {
+		return x.Played
+	}
+	return false
+}
+
+func (x *PlayerState) GetVoted() bool {
+	if x != nil {
+		return x.Voted
+	}
+	return false
+}
+
+func (x *PlayerState) GetScore() uint32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type HandCard struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CardId       uint64 `protobuf:"varint,1,opt,name=card_id,json=cardId,proto3" json:"card_id,omitempty"`
+	CustomCardId uint64 `protobuf:"varint,2,opt,name=custom_card_id,json=customCardId,proto3" json:"custom_card_id,omitempty"`
+	DeckId       uint64 `protobuf:"varint,3,opt,name=deck_id,json=deckId,proto3" json:"deck_id,omitempty"`
+	Image        []byte `protobuf:"bytes,4,opt,name=image,proto3" json:"image,omitempty"`
+	Url          []byte `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
+	Played       bool   `protobuf:"varint,6,opt,name=played,proto3" json:"played,omitempty"`
+}
+
+func (x *HandCard) Reset() {
+	*x = HandCard{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HandCard) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HandCard) ProtoMessage() {}
+
+func (x *HandCard) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HandCard.ProtoReflect.Descriptor instead.
+func (*HandCard) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *HandCard) GetCardId() uint64 {
+	if x != nil {
+		return x.CardId
+	}
+	return 0
+}
+
+func (x *HandCard) GetCustomCardId() uint64 {
+	if x != nil {
+		return x.CustomCardId
+	}
+	return 0
+}
+
+func (x *HandCard) GetDeckId() uint64 {
+	if x != nil {
+		return x.DeckId
+	}
+	return 0
+}
+
+func (x *HandCard) GetImage() []byte {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *HandCard) GetUrl() []byte {
+	if x != nil {
+		return x.Url
+	}
+	return nil
+}
+
+func (x *HandCard) GetPlayed() bool {
+	if x != nil {
+		return x.Played
+	}
+	return false
+}
+
+type StateSync struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId   ClassTypes     `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	GameId    []byte         `protobuf:"bytes,2,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Round     uint32         `protobuf:"varint,3,opt,name=round,proto3" json:"round,omitempty"`
+	Situation []byte         `protobuf:"bytes,4,opt,name=situation,proto3" json:"situation,omitempty"`
+	Started   bool           `protobuf:"varint,5,opt,name=started,proto3" json:"started,omitempty"`
+	Players   []*PlayerState `protobuf:"bytes,6,rep,name=players,proto3" json:"players,omitempty"`
+	Hand      []*HandCard    `protobuf:"bytes,7,rep,name=hand,proto3" json:"hand,omitempty"`
+	Deadline  int64          `protobuf:"varint,8,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Moderated bool           `protobuf:"varint,9,opt,name=moderated,proto3" json:"moderated,omitempty"`
+}
+
+func (x *StateSync) Reset() {
+	*x = StateSync{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StateSync) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateSync) ProtoMessage() {}
+
+func (x *StateSync) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateSync.ProtoReflect.Descriptor instead.
+func (*StateSync) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *StateSync) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *StateSync) GetGameId() []byte {
+	if x != nil {
+		return x.GameId
+	}
+	return nil
+}
+
+func (x *StateSync) GetRound() uint32 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *StateSync) GetSituation() []byte {
+	if x != nil {
+		return x.Situation
+	}
+	return nil
+}
+
+func (x *StateSync) GetStarted() bool {
+	if x != nil {
+		return x.Started
+	}
+	return false
+}
+
+func (x *StateSync) GetPlayers() []*PlayerState {
+	if x != nil {
+		return x.Players
+	}
+	return nil
+}
+
+func (x *StateSync) GetHand() []*HandCard {
+	if x != nil {
+		return x.Hand
+	}
+	return nil
+}
+
+func (x *StateSync) GetDeadline() int64 {
+	if x != nil {
+		return x.Deadline
+	}
+	return 0
+}
+
+func (x *StateSync) GetModerated() bool {
+	if x != nil {
+		return x.Moderated
+	}
+	return false
+}
+
+type RoomClosed struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	GameId  []byte     `protobuf:"bytes,2,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Reason  []byte     `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *RoomClosed) Reset() {
+	*x = RoomClosed{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RoomClosed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomClosed) ProtoMessage() {}
+
+func (x *RoomClosed) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomClosed.ProtoReflect.Descriptor instead.
+func (*RoomClosed) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *RoomClosed) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *RoomClosed) GetGameId() []byte {
+	if x != nil {
+		return x.GameId
+	}
+	return nil
+}
+
+func (x *RoomClosed) GetReason() []byte {
+	if x != nil {
+		return x.Reason
+	}
+	return nil
+}
+
+type Announcement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId    ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	Message    []byte     `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Level      []byte     `protobuf:"bytes,3,opt,name=level,proto3" json:"level,omitempty"`
+	GameId     []byte     `protobuf:"bytes,4,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	ShutdownAt int64      `protobuf:"varint,5,opt,name=shutdown_at,json=shutdownAt,proto3" json:"shutdown_at,omitempty"`
+}
+
+func (x *Announcement) Reset() {
+	*x = Announcement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Announcement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Announcement) ProtoMessage() {}
+
+func (x *Announcement) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Announcement.ProtoReflect.Descriptor instead.
+func (*Announcement) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Announcement) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Announcement) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *Announcement) GetLevel() []byte {
+	if x != nil {
+		return x.Level
+	}
+	return nil
+}
+
+func (x *Announcement) GetGameId() []byte {
+	if x != nil {
+		return x.GameId
+	}
+	return nil
+}
+
+func (x *Announcement) GetShutdownAt() int64 {
+	if x != nil {
+		return x.ShutdownAt
+	}
+	return 0
+}
+
+type Ping struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId    ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	Seq        uint32     `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	ClientTime int64      `protobuf:"varint,3,opt,name=client_time,json=clientTime,proto3" json:"client_time,omitempty"`
+}
+
+func (x *Ping) Reset() {
+	*x = Ping{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Ping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ping) ProtoMessage() {}
+
+func (x *Ping) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ping.ProtoReflect.Descriptor instead.
+func (*Ping) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *Ping) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Ping) GetSeq() uint32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Ping) GetClientTime() int64 {
+	if x != nil {
+		return x.ClientTime
+	}
+	return 0
+}
+
+type Pong struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId    ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	Seq        uint32     `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	ClientTime int64      `protobuf:"varint,3,opt,name=client_time,json=clientTime,proto3" json:"client_time,omitempty"`
+	ServerTime int64      `protobuf:"varint,4,opt,name=server_time,json=serverTime,proto3" json:"server_time,omitempty"`
+}
+
+func (x *Pong) Reset() {
+	*x = Pong{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Pong) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pong) ProtoMessage() {}
+
+func (x *Pong) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pong.ProtoReflect.Descriptor instead.
+func (*Pong) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Pong) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Pong) GetSeq() uint32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Pong) GetClientTime() int64 {
+	if x != nil {
+		return x.ClientTime
+	}
+	return 0
+}
+
+func (x *Pong) GetServerTime() int64 {
+	if x != nil {
+		return x.ServerTime
+	}
+	return 0
+}
+
+type TimeSync struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId    ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	ServerTime int64      `protobuf:"varint,2,opt,name=server_time,json=serverTime,proto3" json:"server_time,omitempty"`
+	ClientTime int64      `protobuf:"varint,3,opt,name=client_time,json=clientTime,proto3" json:"client_time,omitempty"`
+}
+
+func (x *TimeSync) Reset() {
+	*x = TimeSync{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TimeSync) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeSync) ProtoMessage() {}
+
+func (x *TimeSync) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeSync.ProtoReflect.Descriptor instead.
+func (*TimeSync) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TimeSync) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *TimeSync) GetServerTime() int64 {
+	if x != nil {
+		return x.ServerTime
+	}
+	return 0
+}
+
+func (x *TimeSync) GetClientTime() int64 {
+	if x != nil {
+		return x.ClientTime
+	}
+	return 0
+}
+
+type TurnDeadline struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId  ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	GameId   []byte     `protobuf:"bytes,2,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Deadline int64      `protobuf:"varint,3,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Voting   bool       `protobuf:"varint,4,opt,name=voting,proto3" json:"voting,omitempty"`
+}
+
+func (x *TurnDeadline) Reset() {
+	*x = TurnDeadline{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TurnDeadline) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnDeadline) ProtoMessage() {}
+
+func (x *TurnDeadline) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnDeadline.ProtoReflect.Descriptor instead.
+func (*TurnDeadline) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *TurnDeadline) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *TurnDeadline) GetGameId() []byte {
+	if x != nil {
+		return x.GameId
+	}
+	return nil
+}
+
+func (x *TurnDeadline) GetDeadline() int64 {
+	if x != nil {
+		return x.Deadline
+	}
+	return 0
+}
+
+func (x *TurnDeadline) GetVoting() bool {
+	if x != nil {
+		return x.Voting
+	}
+	return false
+}
+
+type Countdown struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId   ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	GameId    []byte     `protobuf:"bytes,2,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Remaining uint32     `protobuf:"varint,3,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	StartAt   int64      `protobuf:"varint,4,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`
+}
+
+func (x *Countdown) Reset() {
+	*x = Countdown{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Countdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Countdown) ProtoMessage() {}
+
+func (x *Countdown) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Countdown.ProtoReflect.Descriptor instead.
+func (*Countdown) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *Countdown) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Countdown) GetGameId() []byte {
+	if x != nil {
+		return x.GameId
+	}
+	return nil
+}
+
+func (x *Countdown) GetRemaining() uint32 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *Countdown) GetStartAt() int64 {
+	if x != nil {
+		return x.StartAt
+	}
+	return 0
+}
+
+type Pause struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId  ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	User     *User      `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Paused   bool       `protobuf:"varint,3,opt,name=paused,proto3" json:"paused,omitempty"`
+	Reason   []byte     `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	Deadline int64      `protobuf:"varint,5,opt,name=deadline,proto3" json:"deadline,omitempty"`
+}
+
+func (x *Pause) Reset() {
+	*x = Pause{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Pause) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pause) ProtoMessage() {}
+
+func (x *Pause) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pause.ProtoReflect.Descriptor instead.
+func (*Pause) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *Pause) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Pause) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *Pause) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *Pause) GetReason() []byte {
+	if x != nil {
+		return x.Reason
+	}
+	return nil
+}
+
+func (x *Pause) GetDeadline() int64 {
+	if x != nil {
+		return x.Deadline
+	}
+	return 0
+}
+
+type Moderated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId   ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	GameId    []byte     `protobuf:"bytes,2,opt,name=game_id,json=gameId,proto3" json:"game_id,omitempty"`
+	Moderated bool       `protobuf:"varint,3,opt,name=moderated,proto3" json:"moderated,omitempty"`
+}
+
+func (x *Moderated) Reset() {
+	*x = Moderated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Moderated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Moderated) ProtoMessage() {}
+
+func (x *Moderated) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Moderated.ProtoReflect.Descriptor instead.
+func (*Moderated) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *Moderated) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Moderated) GetGameId() []byte {
+	if x != nil {
+		return x.GameId
+	}
+	return nil
+}
+
+func (x *Moderated) GetModerated() bool {
+	if x != nil {
+		return x.Moderated
+	}
+	return false
+}
+
+type Hello struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId      ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	Capabilities uint64     `protobuf:"varint,2,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	Agreed       uint64     `protobuf:"varint,3,opt,name=agreed,proto3" json:"agreed,omitempty"`
+	Client       []byte     `protobuf:"bytes,4,opt,name=client,proto3" json:"client,omitempty"`
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *Hello) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Hello) GetCapabilities() uint64 {
+	if x != nil {
+		return x.Capabilities
+	}
+	return 0
+}
+
+func (x *Hello) GetAgreed() uint64 {
+	if x != nil {
+		return x.Agreed
+	}
+	return 0
+}
+
+func (x *Hello) GetClient() []byte {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+type Error struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClassId ClassTypes `protobuf:"varint,1,opt,name=classId,proto3,enum=game.ClassTypes" json:"classId,omitempty"`
+	Code    []byte     `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message []byte     `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Seq     uint32     `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_utils_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_utils_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_utils_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Error) GetClassId() ClassTypes {
+	if x != nil {
+		return x.ClassId
+	}
+	return ClassTypes_PROTO_TYPE_INVALID
+}
+
+func (x *Error) GetCode() []byte {
+	if x != nil {
+		return x.Code
+	}
+	return nil
+}
+
+func (x *Error) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *Error) GetSeq() uint32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+var File_utils_proto protoreflect.FileDescriptor
+
+var file_utils_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x75, 0x74, 0x69, 0x6c, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x67,
+	0x61, 0x6d, 0x65, 0x22, 0x54, 0x0a, 0x04, 0x55, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6c,
+	0x6f, 0x67, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6c, 0x6f, 0x67, 0x69,
+	0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64,
+	0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x06, 0x67, 0x61, 0x6d, 0x65, 0x49, 0x64, 0x22, 0x92, 0x01, 0x0a, 0x08, 0x47, 0x61,
+	0x6d, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43,
+	0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73,
+	0x49, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x64, 0x65, 0x73, 0x74, 0x69,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73,
+	0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x22, 0x58,
+	0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2a, 0x0a, 0x07,
+	0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e,
+	0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52,
+	0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73,
+	0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x58, 0x0a, 0x0a, 0x44, 0x69, 0x73, 0x63,
+	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43,
+	0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73,
+	0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73,
+	0x65, 0x72, 0x22, 0x92, 0x01, 0x0a, 0x08, 0x55, 0x73, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70,
+	0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75,
+	0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65,
+	0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x63,
+	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09,
+	0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x70, 0x65,
+	0x63, 0x74, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x70,
+	0x65, 0x63, 0x74, 0x61, 0x74, 0x6f, 0x72, 0x22, 0x6b, 0x0a, 0x05, 0x52, 0x65, 0x61, 0x64, 0x79,
+	0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79,
+	0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d,
+	0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x22, 0xf3, 0x01, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x2a,
+	0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65,
+	0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61,
+	0x6d, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x67, 0x61, 0x6d,
+	0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x21, 0x0a,
+	0x0c, 0x72, 0x65, 0x6a, 0x6f, 0x69, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0b, 0x72, 0x65, 0x6a, 0x6f, 0x69, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
+	0x12, 0x1b, 0x0a, 0x09, 0x72, 0x6f, 0x6f, 0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x08, 0x72, 0x6f, 0x6f, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a,
+	0x0b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x22, 0x88, 0x01, 0x0a, 0x06, 0x52,
+	0x65, 0x6a, 0x6f, 0x69, 0x6e, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c,
+	0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49,
+	0x64, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65,
+	0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x70, 0x65, 0x63, 0x74,
+	0x61, 0x74, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x70, 0x65, 0x63,
+	0x74, 0x61, 0x74, 0x6f, 0x72, 0x22, 0xb0, 0x01, 0x0a, 0x06, 0x43, 0x68, 0x6f, 0x6f, 0x73, 0x65,
+	0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79,
+	0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d,
+	0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09,
+	0x63, 0x68, 0x6f, 0x73, 0x65, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x08, 0x63, 0x68, 0x6f, 0x73, 0x65, 0x6e, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x61, 0x72,
+	0x64, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x63, 0x61, 0x72, 0x64,
+	0x49, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x5f, 0x63, 0x61, 0x72,
+	0x64, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x63, 0x75, 0x73, 0x74,
+	0x6f, 0x6d, 0x43, 0x61, 0x72, 0x64, 0x49, 0x64, 0x22, 0xbd, 0x01, 0x0a, 0x06, 0x41, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73,
+	0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12,
+	0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e,
+	0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x75, 0x72, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x74,
+	0x75, 0x72, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x61, 0x72,
+	0x64, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x63, 0x61, 0x72, 0x64,
+	0x49, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x5f, 0x63, 0x61, 0x72,
+	0x64, 0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x63, 0x75, 0x73, 0x74,
+	0x6f, 0x6d, 0x43, 0x61, 0x72, 0x64, 0x49, 0x64, 0x22, 0x57, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43,
+	0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73,
+	0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49,
+	0x64, 0x22, 0x55, 0x0a, 0x0b, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x43, 0x61, 0x72, 0x64, 0x73,
+	0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79,
+	0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08,
+	0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08,
+	0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x22, 0x5f, 0x0a, 0x0b, 0x42, 0x61, 0x73, 0x65,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73,
+	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e,
+	0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73,
+	0x73, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0xb4, 0x02, 0x0a, 0x0b, 0x43, 0x68,
+	0x61, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61,
+	0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d,
+	0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c,
+	0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52,
+	0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x25, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f,
+	0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x68, 0x61, 0x74, 0x53, 0x63, 0x6f, 0x70, 0x65, 0x52,
+	0x05, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x65, 0x6e, 0x74, 0x5f, 0x61,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x74, 0x41, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x65,
+	0x64, 0x69, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08,
+	0x65, 0x64, 0x69, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x69, 0x6d, 0x61, 0x67,
+	0x65, 0x5f, 0x69, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x69, 0x6d, 0x61, 0x67,
+	0x65, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x75, 0x72, 0x6c,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x55, 0x72, 0x6c,
+	0x22, 0xac, 0x01, 0x0a, 0x08, 0x43, 0x68, 0x61, 0x74, 0x45, 0x64, 0x69, 0x74, 0x12, 0x2a, 0x0a,
+	0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10,
+	0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73,
+	0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65,
+	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55,
+	0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x65, 0x64, 0x69, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x65, 0x64, 0x69, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22,
+	0x77, 0x0a, 0x0a, 0x43, 0x68, 0x61, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x2a, 0x0a,
+	0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10,
+	0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73,
+	0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65,
+	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55,
+	0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x22, 0xc3, 0x01, 0x0a, 0x0b, 0x43, 0x68, 0x61,
+	0x74, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73,
+	0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65,
+	0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61,
+	0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x49, 0x64, 0x12, 0x2b, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x69,
+	0x70, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x1c, 0x0a, 0x09, 0x64, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x65, 0x64, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x09, 0x64, 0x65, 0x6c, 0x69, 0x76, 0x65, 0x72, 0x65, 0x64, 0x22, 0x79,
+	0x0a, 0x0c, 0x43, 0x68, 0x61, 0x74, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x2a,
 	0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
 	0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65,
 	0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73,
 	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e,
-	0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f,
-	0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x63,
-	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x22, 0x6b, 0x0a, 0x05, 0x52, 0x65, 0x61, 0x64,
-	0x79, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54,
-	0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a,
-	0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61,
-	0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x16, 0x0a,
-	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x76, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x2a,
+	0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x72,
+	0x6f, 0x73, 0x73, 0x5f, 0x74, 0x61, 0x6c, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09,
+	0x63, 0x72, 0x6f, 0x73, 0x73, 0x54, 0x61, 0x6c, 0x6b, 0x22, 0xe7, 0x01, 0x0a, 0x0d, 0x4c, 0x6f,
+	0x62, 0x62, 0x79, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x2a, 0x0a, 0x07, 0x63,
+	0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67,
+	0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07,
+	0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65,
+	0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x61, 0x64, 0x79,
+	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c,
+	0x72, 0x65, 0x61, 0x64, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x21, 0x0a, 0x0c,
+	0x6b, 0x69, 0x63, 0x6b, 0x5f, 0x75, 0x6e, 0x72, 0x65, 0x61, 0x64, 0x79, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0b, 0x6b, 0x69, 0x63, 0x6b, 0x55, 0x6e, 0x72, 0x65, 0x61, 0x64, 0x79, 0x12,
+	0x1f, 0x0a, 0x0b, 0x6d, 0x69, 0x6e, 0x5f, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73,
+	0x12, 0x21, 0x0a, 0x0c, 0x74, 0x75, 0x72, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x74, 0x75, 0x72, 0x6e, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x22, 0x76, 0x0a, 0x0c, 0x52, 0x6f, 0x6f, 0x6d, 0x43, 0x61, 0x70, 0x61, 0x63,
+	0x69, 0x74, 0x79, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73,
+	0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12,
+	0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e,
+	0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12,
+	0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x22, 0x5a, 0x0a, 0x0c, 0x53,
+	0x74, 0x61, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x07, 0x63,
+	0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67,
+	0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07,
+	0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65,
+	0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x7a, 0x0a, 0x09, 0x41, 0x66, 0x6b, 0x4e, 0x6f,
+	0x74, 0x69, 0x63, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61,
+	0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64,
+	0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a,
+	0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x5f, 0x74, 0x75, 0x72, 0x6e, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x54, 0x75,
+	0x72, 0x6e, 0x73, 0x22, 0xa5, 0x01, 0x0a, 0x0b, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75,
+	0x73, 0x65, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x70, 0x65, 0x63, 0x74, 0x61, 0x74, 0x6f, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x70, 0x65, 0x63, 0x74, 0x61, 0x74, 0x6f,
+	0x72, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6c, 0x61, 0x79, 0x65,
+	0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x6f, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05,
+	0x76, 0x6f, 0x74, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x22, 0xa2, 0x01, 0x0a, 0x08,
+	0x48, 0x61, 0x6e, 0x64, 0x43, 0x61, 0x72, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x61, 0x72, 0x64,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x63, 0x61, 0x72, 0x64, 0x49,
+	0x64, 0x12, 0x24, 0x0a, 0x0e, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x5f, 0x63, 0x61, 0x72, 0x64,
+	0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x63, 0x75, 0x73, 0x74, 0x6f,
+	0x6d, 0x43, 0x61, 0x72, 0x64, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x64, 0x65, 0x63, 0x6b, 0x5f,
+	0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x64, 0x65, 0x63, 0x6b, 0x49, 0x64,
+	0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6c, 0x61, 0x79,
+	0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x64,
+	0x22, 0xa9, 0x02, 0x0a, 0x09, 0x53, 0x74, 0x61, 0x74, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x2a,
 	0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
 	0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65,
 	0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61,
 	0x6d, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x67, 0x61, 0x6d,
-	0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x08, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78,
-	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x22, 0x71, 0x0a,
-	0x06, 0x43, 0x68, 0x6f, 0x6f, 0x73, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73,
-	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e,
-	0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73,
-	0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75,
-	0x73, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x68, 0x6f, 0x73, 0x65, 0x6e, 0x5f, 0x69, 0x64,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x63, 0x68, 0x6f, 0x73, 0x65, 0x6e, 0x49, 0x64,
-	0x22, 0x7e, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c,
+	0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x74,
+	0x75, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69,
+	0x74, 0x75, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65,
+	0x64, 0x12, 0x2b, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x07, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x22,
+	0x0a, 0x04, 0x68, 0x61, 0x6e, 0x64, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x67,
+	0x61, 0x6d, 0x65, 0x2e, 0x48, 0x61, 0x6e, 0x64, 0x43, 0x61, 0x72, 0x64, 0x52, 0x04, 0x68, 0x61,
+	0x6e, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x09, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64, 0x22, 0x69, 0x0a, 0x0a,
+	0x52, 0x6f, 0x6f, 0x6d, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c,
 	0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61,
 	0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63,
-	0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72,
-	0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x75, 0x72, 0x6e, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x74, 0x75, 0x72, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d,
-	0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65,
-	0x22, 0x57, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x2a,
+	0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x69,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x67, 0x61, 0x6d, 0x65, 0x49, 0x64, 0x12,
+	0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0xa4, 0x01, 0x0a, 0x0c, 0x41, 0x6e, 0x6e, 0x6f,
+	0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73,
+	0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65,
+	0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61,
+	0x73, 0x73, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x6c,
+	0x65, 0x76, 0x65, 0x6c, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x69, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x67, 0x61, 0x6d, 0x65, 0x49, 0x64, 0x12, 0x1f, 0x0a,
+	0x0b, 0x73, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x73, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x41, 0x74, 0x22, 0x65,
+	0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43,
+	0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73,
+	0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x03, 0x73, 0x65, 0x71, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x86, 0x01, 0x0a, 0x04, 0x50, 0x6f, 0x6e, 0x67, 0x12, 0x2a,
 	0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
 	0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65,
-	0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65,
-	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
-	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x22, 0x39, 0x0a, 0x0b, 0x44, 0x65, 0x6c,
-	0x65, 0x74, 0x65, 0x43, 0x61, 0x72, 0x64, 0x73, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73,
+	0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65,
+	0x71, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x1f, 0x0a, 0x0b,
+	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0a, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1f, 0x0a,
+	0x0b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x78,
+	0x0a, 0x08, 0x54, 0x69, 0x6d, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c,
+	0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61,
+	0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63,
+	0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x63, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x87, 0x01, 0x0a, 0x0c, 0x54, 0x75, 0x72,
+	0x6e, 0x44, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61,
+	0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d,
+	0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c,
+	0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x67, 0x61, 0x6d, 0x65, 0x49, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x76, 0x6f,
+	0x74, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x76, 0x6f, 0x74, 0x69,
+	0x6e, 0x67, 0x22, 0x89, 0x01, 0x0a, 0x09, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x64, 0x6f, 0x77, 0x6e,
+	0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79,
+	0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07,
+	0x67, 0x61, 0x6d, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x67,
+	0x61, 0x6d, 0x65, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69,
+	0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e,
+	0x69, 0x6e, 0x67, 0x12, 0x19, 0x0a, 0x08, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x61, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x74, 0x61, 0x72, 0x74, 0x41, 0x74, 0x22, 0x9f,
+	0x01, 0x0a, 0x05, 0x50, 0x61, 0x75, 0x73, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73,
 	0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65,
 	0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61,
-	0x73, 0x73, 0x49, 0x64, 0x22, 0x4d, 0x0a, 0x0b, 0x42, 0x61, 0x73, 0x65, 0x4d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01,
+	0x73, 0x73, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06,
+	0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x72, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65,
+	0x22, 0x6e, 0x0a, 0x09, 0x4d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64, 0x12, 0x2a, 0x0a,
+	0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10,
+	0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73,
+	0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x67, 0x61, 0x6d,
+	0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x67, 0x61, 0x6d, 0x65,
+	0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x65, 0x64,
+	0x22, 0x87, 0x01, 0x0a, 0x05, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c,
+	0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61,
+	0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63,
+	0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69,
+	0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x63, 0x61,
+	0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x67,
+	0x72, 0x65, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x61, 0x67, 0x72, 0x65,
+	0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x06, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x22, 0x73, 0x0a, 0x05, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01,
 	0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73,
 	0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12,
-	0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64,
-	0x61, 0x74, 0x61, 0x22, 0x73, 0x0a, 0x0b, 0x43, 0x68, 0x61, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x6c, 0x61, 0x73, 0x73,
-	0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1e,
-	0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x67,
-	0x61, 0x6d, 0x65, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x18,
-	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2a, 0xab, 0x02, 0x0a, 0x0a, 0x43, 0x6c, 0x61,
-	0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x12, 0x50, 0x52, 0x4f, 0x54, 0x4f,
-	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12,
-	0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x53,
-	0x45, 0x52, 0x49, 0x4e, 0x46, 0x4f, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x54,
-	0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x02, 0x12,
-	0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45,
-	0x4c, 0x45, 0x54, 0x45, 0x10, 0x03, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f,
-	0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x10, 0x04, 0x12, 0x14, 0x0a,
-	0x10, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x52,
-	0x54, 0x10, 0x05, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50,
-	0x45, 0x5f, 0x43, 0x48, 0x4f, 0x4f, 0x53, 0x45, 0x10, 0x06, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x52,
-	0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x41, 0x52, 0x44, 0x10, 0x07, 0x12,
-	0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x50,
-	0x44, 0x41, 0x54, 0x45, 0x10, 0x08, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f,
-	0x54, 0x59, 0x50, 0x45, 0x5f, 0x47, 0x41, 0x4d, 0x45, 0x49, 0x4e, 0x46, 0x4f, 0x10, 0x09, 0x12,
-	0x19, 0x0a, 0x15, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x49,
-	0x53, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43, 0x54, 0x10, 0x0a, 0x12, 0x1a, 0x0a, 0x16, 0x50, 0x52,
-	0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x48, 0x41, 0x54, 0x4d, 0x45, 0x53,
-	0x53, 0x41, 0x47, 0x45, 0x10, 0x0b, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x2e, 0x2f, 0x42, 0x75, 0x69,
-	0x6c, 0x64, 0x47, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x63,
+	0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x65, 0x71, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x73, 0x65, 0x71, 0x2a,
+	0xbf, 0x06, 0x0a, 0x0a, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x12, 0x16,
+	0x0a, 0x12, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x49, 0x4e, 0x56,
+	0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x53, 0x45, 0x52, 0x49, 0x4e, 0x46, 0x4f, 0x10, 0x01, 0x12,
+	0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x41, 0x43,
+	0x54, 0x49, 0x4f, 0x4e, 0x10, 0x02, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x03, 0x12, 0x15, 0x0a,
+	0x11, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x55, 0x53, 0x10, 0x04, 0x12, 0x14, 0x0a, 0x10, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x10, 0x05, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x52,
+	0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x48, 0x4f, 0x4f, 0x53, 0x45, 0x10,
+	0x06, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x43, 0x41, 0x52, 0x44, 0x10, 0x07, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x10, 0x08, 0x12, 0x17, 0x0a,
+	0x13, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x47, 0x41, 0x4d, 0x45,
+	0x49, 0x4e, 0x46, 0x4f, 0x10, 0x09, 0x12, 0x19, 0x0a, 0x15, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x49, 0x53, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43, 0x54, 0x10,
+	0x0a, 0x12, 0x1a, 0x0a, 0x16, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x43, 0x48, 0x41, 0x54, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45, 0x10, 0x0b, 0x12, 0x14, 0x0a,
+	0x10, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f,
+	0x52, 0x10, 0x0c, 0x12, 0x1b, 0x0a, 0x17, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x43, 0x48, 0x41, 0x54, 0x53, 0x45, 0x54, 0x54, 0x49, 0x4e, 0x47, 0x53, 0x10, 0x0d,
+	0x12, 0x1c, 0x0a, 0x18, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4c,
+	0x4f, 0x42, 0x42, 0x59, 0x53, 0x45, 0x54, 0x54, 0x49, 0x4e, 0x47, 0x53, 0x10, 0x0e, 0x12, 0x12,
+	0x0a, 0x0e, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x41, 0x46, 0x4b,
+	0x10, 0x0f, 0x12, 0x1b, 0x0a, 0x17, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x10, 0x12,
+	0x1a, 0x0a, 0x16, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x48,
+	0x41, 0x54, 0x52, 0x45, 0x43, 0x45, 0x49, 0x50, 0x54, 0x10, 0x11, 0x12, 0x17, 0x0a, 0x13, 0x50,
+	0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x48, 0x41, 0x54, 0x45, 0x44,
+	0x49, 0x54, 0x10, 0x12, 0x12, 0x19, 0x0a, 0x15, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x43, 0x48, 0x41, 0x54, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x13, 0x12,
+	0x1b, 0x0a, 0x17, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x4f,
+	0x4f, 0x4d, 0x43, 0x41, 0x50, 0x41, 0x43, 0x49, 0x54, 0x59, 0x10, 0x14, 0x12, 0x15, 0x0a, 0x11,
+	0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x45, 0x4a, 0x4f, 0x49,
+	0x4e, 0x10, 0x15, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x53, 0x59, 0x4e, 0x43, 0x10, 0x16, 0x12, 0x19, 0x0a,
+	0x15, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x4f, 0x4f, 0x4d,
+	0x43, 0x4c, 0x4f, 0x53, 0x45, 0x44, 0x10, 0x17, 0x12, 0x1b, 0x0a, 0x17, 0x50, 0x52, 0x4f, 0x54,
+	0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x41, 0x4e, 0x4e, 0x4f, 0x55, 0x4e, 0x43, 0x45, 0x4d,
+	0x45, 0x4e, 0x54, 0x10, 0x18, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54,
+	0x59, 0x50, 0x45, 0x5f, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x19, 0x12, 0x13, 0x0a, 0x0f, 0x50, 0x52,
+	0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x4f, 0x4e, 0x47, 0x10, 0x1a, 0x12,
+	0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x54, 0x49,
+	0x4d, 0x45, 0x53, 0x59, 0x4e, 0x43, 0x10, 0x1b, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x54,
+	0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45, 0x41, 0x44, 0x4c, 0x49, 0x4e, 0x45, 0x10,
+	0x1c, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x43, 0x4f, 0x55, 0x4e, 0x54, 0x44, 0x4f, 0x57, 0x4e, 0x10, 0x1d, 0x12, 0x14, 0x0a, 0x10, 0x50,
+	0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x41, 0x55, 0x53, 0x45, 0x10,
+	0x1e, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x4d, 0x4f, 0x44, 0x45, 0x52, 0x41, 0x54, 0x45, 0x44, 0x10, 0x1f, 0x12, 0x14, 0x0a, 0x10, 0x50,
+	0x52, 0x4f, 0x54, 0x4f, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x48, 0x45, 0x4c, 0x4c, 0x4f, 0x10,
+	0x20, 0x2a, 0x3a, 0x0a, 0x09, 0x43, 0x68, 0x61, 0x74, 0x53, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x12,
+	0x0a, 0x0e, 0x43, 0x48, 0x41, 0x54, 0x5f, 0x53, 0x43, 0x4f, 0x50, 0x45, 0x5f, 0x41, 0x4c, 0x4c,
+	0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x43, 0x48, 0x41, 0x54, 0x5f, 0x53, 0x43, 0x4f, 0x50, 0x45,
+	0x5f, 0x53, 0x50, 0x45, 0x43, 0x54, 0x41, 0x54, 0x4f, 0x52, 0x53, 0x10, 0x01, 0x2a, 0x38, 0x0a,
+	0x0d, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x15,
+	0x0a, 0x11, 0x52, 0x45, 0x43, 0x45, 0x49, 0x50, 0x54, 0x5f, 0x44, 0x45, 0x4c, 0x49, 0x56, 0x45,
+	0x52, 0x45, 0x44, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x52, 0x45, 0x43, 0x45, 0x49, 0x50, 0x54,
+	0x5f, 0x52, 0x45, 0x41, 0x44, 0x10, 0x01, 0x2a, 0xc9, 0x01, 0x0a, 0x0a, 0x43, 0x61, 0x70, 0x61,
+	0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x13, 0x0a, 0x0f, 0x43, 0x41, 0x50, 0x41, 0x42, 0x49,
+	0x4c, 0x49, 0x54, 0x59, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x11, 0x43,
+	0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x5f, 0x52, 0x45, 0x4a, 0x4f, 0x49, 0x4e,
+	0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x43, 0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59,
+	0x5f, 0x53, 0x50, 0x45, 0x43, 0x54, 0x41, 0x54, 0x4f, 0x52, 0x10, 0x02, 0x12, 0x16, 0x0a, 0x12,
+	0x43, 0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x5f, 0x4d, 0x53, 0x47, 0x50, 0x41,
+	0x43, 0x4b, 0x10, 0x04, 0x12, 0x18, 0x0a, 0x14, 0x43, 0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49,
+	0x54, 0x59, 0x5f, 0x52, 0x45, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x53, 0x10, 0x08, 0x12, 0x15,
+	0x0a, 0x11, 0x43, 0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x5f, 0x54, 0x49, 0x4d,
+	0x45, 0x52, 0x53, 0x10, 0x10, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x41, 0x50, 0x41, 0x42, 0x49, 0x4c,
+	0x49, 0x54, 0x59, 0x5f, 0x50, 0x41, 0x55, 0x53, 0x45, 0x10, 0x20, 0x12, 0x16, 0x0a, 0x12, 0x43,
+	0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x5f, 0x4e, 0x4f, 0x54, 0x49, 0x43, 0x45,
+	0x53, 0x10, 0x40, 0x42, 0x0c, 0x5a, 0x0a, 0x2e, 0x2e, 0x2f, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x47,
+	0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1014,50 +3349,112 @@ func file_utils_proto_rawDescGZIP() []byte {
 	return file_utils_proto_rawDescData
 }
 
-var file_utils_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_utils_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_utils_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_utils_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_utils_proto_goTypes = []any{
-	(ClassTypes)(0),     // 0: game.ClassTypes
-	(*User)(nil),        // 1: game.User
-	(*GameInfo)(nil),    // 2: game.GameInfo
-	(*UpdateInfo)(nil),  // 3: game.UpdateInfo
-	(*Disconnect)(nil),  // 4: game.Disconnect
-	(*UserInfo)(nil),    // 5: game.UserInfo
-	(*Ready)(nil),       // 6: game.Ready
-	(*Start)(nil),       // 7: game.Start
-	(*Choose)(nil),      // 8: game.Choose
-	(*Action)(nil),      // 9: game.Action
-	(*DeleteUser)(nil),  // 10: game.DeleteUser
-	(*DeleteCards)(nil), // 11: game.DeleteCards
-	(*BaseMessage)(nil), // 12: game.BaseMessage
-	(*ChatMessage)(nil), // 13: game.ChatMessage
+	(ClassTypes)(0),       // 0: game.ClassTypes
+	(ChatScope)(0),        // 1: game.ChatScope
+	(ReceiptStatus)(0),    // 2: game.ReceiptStatus
+	(Capability)(0),       // 3: game.Capability
+	(*User)(nil),          // 4: game.User
+	(*GameInfo)(nil),      // 5: game.GameInfo
+	(*UpdateInfo)(nil),    // 6: game.UpdateInfo
+	(*Disconnect)(nil),    // 7: game.Disconnect
+	(*UserInfo)(nil),      // 8: game.UserInfo
+	(*Ready)(nil),         // 9: game.Ready
+	(*Start)(nil),         // 10: game.Start
+	(*Rejoin)(nil),        // 11: game.Rejoin
+	(*Choose)(nil),        // 12: game.Choose
+	(*Action)(nil),        // 13: game.Action
+	(*DeleteUser)(nil),    // 14: game.DeleteUser
+	(*DeleteCards)(nil),   // 15: game.DeleteCards
+	(*BaseMessage)(nil),   // 16: game.BaseMessage
+	(*ChatMessage)(nil),   // 17: game.ChatMessage
+	(*ChatEdit)(nil),      // 18: game.ChatEdit
+	(*ChatDelete)(nil),    // 19: game.ChatDelete
+	(*ChatReceipt)(nil),   // 20: game.ChatReceipt
+	(*ChatSettings)(nil),  // 21: game.ChatSettings
+	(*LobbySettings)(nil), // 22: game.LobbySettings
+	(*RoomCapacity)(nil),  // 23: game.RoomCapacity
+	(*StartRequest)(nil),  // 24: game.StartRequest
+	(*AfkNotice)(nil),     // 25: game.AfkNotice
+	(*PlayerState)(nil),   // 26: game.PlayerState
+	(*HandCard)(nil),      // 27: game.HandCard
+	(*StateSync)(nil),     // 28: game.StateSync
+	(*RoomClosed)(nil),    // 29: game.RoomClosed
+	(*Announcement)(nil),  // 30: game.Announcement
+	(*Ping)(nil),          // 31: game.Ping
+	(*Pong)(nil),          // 32: game.Pong
+	(*TimeSync)(nil),      // 33: game.TimeSync
+	(*TurnDeadline)(nil),  // 34: game.TurnDeadline
+	(*Countdown)(nil),     // 35: game.Countdown
+	(*Pause)(nil),         // 36: game.Pause
+	(*Moderated)(nil),     // 37: game.Moderated
+	(*Hello)(nil),         // 38: game.Hello
+	(*Error)(nil),         // 39: game.Error
 }
 var file_utils_proto_depIdxs = []int32{
 	0,  // 0: game.GameInfo.classId:type_name -> game.ClassTypes
-	1,  // 1: game.GameInfo.user:type_name -> game.User
+	4,  // 1: game.GameInfo.user:type_name -> game.User
 	0,  // 2: game.UpdateInfo.classId:type_name -> game.ClassTypes
-	1,  // 3: game.UpdateInfo.user:type_name -> game.User
+	4,  // 3: game.UpdateInfo.user:type_name -> game.User
 	0,  // 4: game.Disconnect.classId:type_name -> game.ClassTypes
-	1,  // 5: game.Disconnect.user:type_name -> game.User
+	4,  // 5: game.Disconnect.user:type_name -> game.User
 	0,  // 6: game.UserInfo.classId:type_name -> game.ClassTypes
-	1,  // 7: game.UserInfo.user:type_name -> game.User
+	4,  // 7: game.UserInfo.user:type_name -> game.User
 	0,  // 8: game.Ready.classId:type_name -> game.ClassTypes
-	1,  // 9: game.Ready.user:type_name -> game.User
+	4,  // 9: game.Ready.user:type_name -> game.User
 	0,  // 10: game.Start.classId:type_name -> game.ClassTypes
-	0,  // 11: game.Choose.classId:type_name -> game.ClassTypes
-	1,  // 12: game.Choose.user:type_name -> game.User
-	0,  // 13: game.Action.classId:type_name -> game.ClassTypes
-	1,  // 14: game.Action.user:type_name -> game.User
-	0,  // 15: game.DeleteUser.classId:type_name -> game.ClassTypes
-	0,  // 16: game.DeleteCards.classId:type_name -> game.ClassTypes
-	0,  // 17: game.BaseMessage.classId:type_name -> game.ClassTypes
-	0,  // 18: game.ChatMessage.classId:type_name -> game.ClassTypes
-	1,  // 19: game.ChatMessage.user:type_name -> game.User
-	20, // [20:20] is the sub-list for method output_type
-	20, // [20:20] is the sub-list for method input_type
-	20, // [20:20] is the sub-list for extension type_name
-	20, // [20:20] is the sub-list for extension extendee
-	0,  // [0:20] is the sub-list for field type_name
+	0,  // 11: game.Rejoin.classId:type_name -> game.ClassTypes
+	4,  // 12: game.Rejoin.user:type_name -> game.User
+	0,  // 13: game.Choose.classId:type_name -> game.ClassTypes
+	4,  // 14: game.Choose.user:type_name -> game.User
+	0,  // 15: game.Action.classId:type_name -> game.ClassTypes
+	4,  // 16: game.Action.user:type_name -> game.User
+	0,  // 17: game.DeleteUser.classId:type_name -> game.ClassTypes
+	0,  // 18: game.DeleteCards.classId:type_name -> game.ClassTypes
+	0,  // 19: game.BaseMessage.classId:type_name -> game.ClassTypes
+	0,  // 20: game.ChatMessage.classId:type_name -> game.ClassTypes
+	4,  // 21: game.ChatMessage.user:type_name -> game.User
+	1,  // 22: game.ChatMessage.scope:type_name -> game.ChatScope
+	0,  // 23: game.ChatEdit.classId:type_name -> game.ClassTypes
+	4,  // 24: game.ChatEdit.user:type_name -> game.User
+	0,  // 25: game.ChatDelete.classId:type_name -> game.ClassTypes
+	4,  // 26: game.ChatDelete.user:type_name -> game.User
+	0,  // 27: game.ChatReceipt.classId:type_name -> game.ClassTypes
+	4,  // 28: game.ChatReceipt.user:type_name -> game.User
+	2,  // 29: game.ChatReceipt.status:type_name -> game.ReceiptStatus
+	0,  // 30: game.ChatSettings.classId:type_name -> game.ClassTypes
+	4,  // 31: game.ChatSettings.user:type_name -> game.User
+	0,  // 32: game.LobbySettings.classId:type_name -> game.ClassTypes
+	4,  // 33: game.LobbySettings.user:type_name -> game.User
+	0,  // 34: game.RoomCapacity.classId:type_name -> game.ClassTypes
+	4,  // 35: game.RoomCapacity.user:type_name -> game.User
+	0,  // 36: game.StartRequest.classId:type_name -> game.ClassTypes
+	4,  // 37: game.StartRequest.user:type_name -> game.User
+	0,  // 38: game.AfkNotice.classId:type_name -> game.ClassTypes
+	4,  // 39: game.AfkNotice.user:type_name -> game.User
+	4,  // 40: game.PlayerState.user:type_name -> game.User
+	0,  // 41: game.StateSync.classId:type_name -> game.ClassTypes
+	26, // 42: game.StateSync.players:type_name -> game.PlayerState
+	27, // 43: game.StateSync.hand:type_name -> game.HandCard
+	0,  // 44: game.RoomClosed.classId:type_name -> game.ClassTypes
+	0,  // 45: game.Announcement.classId:type_name -> game.ClassTypes
+	0,  // 46: game.Ping.classId:type_name -> game.ClassTypes
+	0,  // 47: game.Pong.classId:type_name -> game.ClassTypes
+	0,  // 48: game.TimeSync.classId:type_name -> game.ClassTypes
+	0,  // 49: game.TurnDeadline.classId:type_name -> game.ClassTypes
+	0,  // 50: game.Countdown.classId:type_name -> game.ClassTypes
+	0,  // 51: game.Pause.classId:type_name -> game.ClassTypes
+	4,  // 52: game.Pause.user:type_name -> game.User
+	0,  // 53: game.Moderated.classId:type_name -> game.ClassTypes
+	0,  // 54: game.Hello.classId:type_name -> game.ClassTypes
+	0,  // 55: game.Error.classId:type_name -> game.ClassTypes
+	56, // [56:56] is the sub-list for method output_type
+	56, // [56:56] is the sub-list for method input_type
+	56, // [56:56] is the sub-list for extension type_name
+	56, // [56:56] is the sub-list for extension extendee
+	0,  // [0:56] is the sub-list for field type_name
 }
 
 func init() { file_utils_proto_init() }
@@ -1151,7 +3548,7 @@ func file_utils_proto_init() {
 			}
 		}
 		file_utils_proto_msgTypes[7].Exporter = func(v any, i int) any {
-			switch v := v.(*Choose); i {
+			switch v := v.(*Rejoin); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1163,7 +3560,7 @@ func file_utils_proto_init() {
 			}
 		}
 		file_utils_proto_msgTypes[8].Exporter = func(v any, i int) any {
-			switch v := v.(*Action); i {
+			switch v := v.(*Choose); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1175,7 +3572,7 @@ func file_utils_proto_init() {
 			}
 		}
 		file_utils_proto_msgTypes[9].Exporter = func(v any, i int) any {
-			switch v := v.(*DeleteUser); i {
+			switch v := v.(*Action); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1187,7 +3584,7 @@ func file_utils_proto_init() {
 			}
 		}
 		file_utils_proto_msgTypes[10].Exporter = func(v any, i int) any {
-			switch v := v.(*DeleteCards); i {
+			switch v := v.(*DeleteUser); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1199,7 +3596,7 @@ func file_utils_proto_init() {
 			}
 		}
 		file_utils_proto_msgTypes[11].Exporter = func(v any, i int) any {
-			switch v := v.(*BaseMessage); i {
+			switch v := v.(*DeleteCards); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1211,6 +3608,18 @@ func file_utils_proto_init() {
 			}
 		}
 		file_utils_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*BaseMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[13].Exporter = func(v any, i int) any {
 			switch v := v.(*ChatMessage); i {
 			case 0:
 				return &v.state
@@ -1222,14 +3631,278 @@ func file_utils_proto_init() {
 				return nil
 			}
 		}
+		file_utils_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*ChatEdit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*ChatDelete); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[16].Exporter = func(v any, i int) any {
+			switch v := v.(*ChatReceipt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[17].Exporter = func(v any, i int) any {
+			switch v := v.(*ChatSettings); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[18].Exporter = func(v any, i int) any {
+			switch v := v.(*LobbySettings); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[19].Exporter = func(v any, i int) any {
+			switch v := v.(*RoomCapacity); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[20].Exporter = func(v any, i int) any {
+			switch v := v.(*StartRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[21].Exporter = func(v any, i int) any {
+			switch v := v.(*AfkNotice); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[22].Exporter = func(v any, i int) any {
+			switch v := v.(*PlayerState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[23].Exporter = func(v any, i int) any {
+			switch v := v.(*HandCard); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[24].Exporter = func(v any, i int) any {
+			switch v := v.(*StateSync); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[25].Exporter = func(v any, i int) any {
+			switch v := v.(*RoomClosed); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[26].Exporter = func(v any, i int) any {
+			switch v := v.(*Announcement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[27].Exporter = func(v any, i int) any {
+			switch v := v.(*Ping); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[28].Exporter = func(v any, i int) any {
+			switch v := v.(*Pong); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[29].Exporter = func(v any, i int) any {
+			switch v := v.(*TimeSync); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[30].Exporter = func(v any, i int) any {
+			switch v := v.(*TurnDeadline); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[31].Exporter = func(v any, i int) any {
+			switch v := v.(*Countdown); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[32].Exporter = func(v any, i int) any {
+			switch v := v.(*Pause); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[33].Exporter = func(v any, i int) any {
+			switch v := v.(*Moderated); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[34].Exporter = func(v any, i int) any {
+			switch v := v.(*Hello); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_utils_proto_msgTypes[35].Exporter = func(v any, i int) any {
+			switch v := v.(*Error); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_utils_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   13,
+			NumEnums:      4,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// Subprotocols a client can ask for in Sec-WebSocket-Protocol. Each name
+// fixes both the protocol version and how frames are encoded, so neither has
+// to be worked out from the first message.
+const (
+	subprotocolProto   = "game.v1.proto"
+	subprotocolMsgpack = "game.v1.msgpack"
+)
+
+// supportedSubprotocols is offered to clients in order of preference.
+var supportedSubprotocols = []string{subprotocolProto, subprotocolMsgpack}
+
+// defaultSubprotocol is assumed for clients that don't ask for one, which is
+// how every client connected before subprotocols were negotiated.
+const defaultSubprotocol = subprotocolProto
+
+// offeredSubprotocols is supportedSubprotocols less any that feature flags
+// turn off for the client making r.
+func offeredSubprotocols(r *http.Request) []string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	offered := make([]string, 0, len(supportedSubprotocols))
+	for _, name := range supportedSubprotocols {
+		if name == subprotocolMsgpack && !featureEnabled(flagWSMsgpack, host) {
+			continue
+		}
+		offered = append(offered, name)
+	}
+	return offered
+}
+
+// acceptableSubprotocols reports whether the upgrade request either asks for
+// no subprotocol or for at least one of offered.
+func acceptableSubprotocols(r *http.Request, offered []string) bool {
+	requested := websocket.Subprotocols(r)
+	if len(requested) == 0 {
+		return true
+	}
+	for _, name := range requested {
+		for _, supported := range offered {
+			if name == supported {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rejectSubprotocols answers an upgrade that only asked for subprotocols
+// this server doesn't offer it, listing the ones it does.
+func rejectSubprotocols(w http.ResponseWriter, offered []string) {
+	w.Header().Set("Sec-WebSocket-Protocol", strings.Join(offered, ", "))
+	http.Error(w, "unsupported subprotocol, expected one of: "+strings.Join(offered, ", "), http.StatusBadRequest)
+}
+
+// connSubprotocol is the subprotocol agreed with a connection at handshake.
+func connSubprotocol(conn *websocket.Conn) string {
+	if name := conn.Subprotocol(); name != "" {
+		return name
+	}
+	return defaultSubprotocol
+}
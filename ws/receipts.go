@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// handleChatReceipt forwards a read receipt to the author of the chat
+// message it names. Receipts for messages that didn't ask for them, or that
+// have aged out of tracking, are dropped.
+func handleChatReceipt(conn *websocket.Conn, data []byte) {
+	var receipt game.ChatReceipt
+	if err := proto.Unmarshal(data, &receipt); err != nil {
+		errorf("Error unmarshaling ChatReceipt: %v", err)
+		return
+	}
+	if receipt.Status != game.ReceiptStatus_RECEIPT_READ {
+		return
+	}
+
+	gameID := string(receipt.User.GameId)
+	mu.Lock()
+	var author *websocket.Conn
+	if record := getGameStateLocked(gameID).chats[receipt.MessageId]; record != nil && record.msg.Receipts {
+		author = record.conn
+	}
+	mu.Unlock()
+	if author == nil || author == conn {
+		return
+	}
+
+	sendChatReceipt(author, &game.ChatReceipt{
+		User:      receipt.User,
+		MessageId: receipt.MessageId,
+		Status:    game.ReceiptStatus_RECEIPT_READ,
+	})
+}
+
+func sendChatReceipt(conn *websocket.Conn, receipt *game.ChatReceipt) {
+	receipt.ClassId = game.ClassTypes_PROTO_TYPE_CHATRECEIPT
+	data, err := SerializeToString(receipt)
+	if err != nil {
+		errorf("Error serializing ChatReceipt: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_CHATRECEIPT,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := SendMessageToClient(conn, serializedBaseMessage); err != nil {
+		errorf("Failed to send chat receipt: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// redisTimeout bounds each command, so a stalled Redis fails the call
+// instead of holding up every room waiting on it.
+const redisTimeout = 2 * time.Second
+
+// redisError is an error reply from Redis. Unlike I/O errors it leaves the
+// connection usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn is a minimal Redis client speaking RESP over one connection,
+// enough for the room store's hashes and pub/sub without pulling in a
+// client library.
+type redisConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialRedis(addr string) (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, redisTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &redisConn{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// Do sends one command and returns its reply: a string, []byte, int64, nil
+// or []interface{} of those. Error replies are returned as errors. A
+// command that takes longer than redisTimeout fails with a timeout, which
+// leaves the connection unusable.
+func (c *redisConn) Do(args ...interface{}) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.conn.SetDeadline(time.Now().Add(redisTimeout)); err != nil {
+		return nil, err
+	}
+	if err := c.write(args); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+func (c *redisConn) write(args []interface{}) error {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		var b []byte
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		case int:
+			b = []byte(strconv.Itoa(v))
+		default:
+			return fmt.Errorf("redis: unsupported argument type %T", arg)
+		}
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(b)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, b...)
+		buf = append(buf, "\r\n"...)
+	}
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, errors.New("redis: short reply")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+// subscribe puts the connection in pub/sub mode on channel and calls handle
+// with each message until the connection fails. The connection can't be
+// used for anything else afterwards.
+func (c *redisConn) subscribe(channel string, handle func([]byte)) error {
+	if _, err := c.Do("SUBSCRIBE", channel); err != nil {
+		return err
+	}
+	// Messages come whenever they are published, however long that takes.
+	if err := c.conn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+	for {
+		reply, err := c.read()
+		if err != nil {
+			return err
+		}
+		items, ok := reply.([]interface{})
+		if !ok || len(items) != 3 {
+			continue
+		}
+		if kind, _ := items[0].([]byte); string(kind) != "message" {
+			continue
+		}
+		if payload, ok := items[2].([]byte); ok {
+			handle(payload)
+		}
+	}
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// pipeRedis returns a client wired to a fake server, which checks each
+// request against the bytes it expects and answers with a canned reply.
+func pipeRedis(t *testing.T, exchanges ...[2]string) *redisConn {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	go func() {
+		for _, exchange := range exchanges {
+			request := make([]byte, len(exchange[0]))
+			if _, err := io.ReadFull(server, request); err != nil {
+				return
+			}
+			if string(request) != exchange[0] {
+				t.Errorf("request %q, want %q", request, exchange[0])
+				server.Close()
+				return
+			}
+			if _, err := server.Write([]byte(exchange[1])); err != nil {
+				return
+			}
+		}
+	}()
+	return &redisConn{conn: client, r: bufio.NewReader(client)}
+}
+
+func TestRedisDo(t *testing.T) {
+	tests := []struct {
+		args    []interface{}
+		request string
+		reply   string
+		want    interface{}
+	}{
+		{
+			[]interface{}{"PING"},
+			"*1\r\n$4\r\nPING\r\n",
+			"+PONG\r\n",
+			"PONG",
+		},
+		{
+			[]interface{}{"HSET", "room:1", []byte("a\r\nb"), 42},
+			"*4\r\n$4\r\nHSET\r\n$6\r\nroom:1\r\n$4\r\na\r\nb\r\n$2\r\n42\r\n",
+			":1\r\n",
+			int64(1),
+		},
+		{
+			[]interface{}{"GET", "k"},
+			"*2\r\n$3\r\nGET\r\n$1\r\nk\r\n",
+			"$5\r\nhe\r\no\r\n",
+			[]byte("he\r\no"),
+		},
+		{
+			[]interface{}{"GET", "missing"},
+			"*2\r\n$3\r\nGET\r\n$7\r\nmissing\r\n",
+			"$-1\r\n",
+			nil,
+		},
+		{
+			[]interface{}{"GET", ""},
+			"*2\r\n$3\r\nGET\r\n$0\r\n\r\n",
+			"$0\r\n\r\n",
+			[]byte{},
+		},
+		{
+			[]interface{}{"HGETALL", "room:1"},
+			"*2\r\n$7\r\nHGETALL\r\n$6\r\nroom:1\r\n",
+			"*3\r\n$1\r\na\r\n:-7\r\n*1\r\n+OK\r\n",
+			[]interface{}{[]byte("a"), int64(-7), []interface{}{"OK"}},
+		},
+		{
+			[]interface{}{"KEYS", "none:*"},
+			"*2\r\n$4\r\nKEYS\r\n$6\r\nnone:*\r\n",
+			"*0\r\n",
+			[]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		c := pipeRedis(t, [2]string{tt.request, tt.reply})
+		got, err := c.Do(tt.args...)
+		if err != nil {
+			t.Errorf("Do(%q): %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Do(%q) = %#v, want %#v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRedisErrorReply(t *testing.T) {
+	c := pipeRedis(t,
+		[2]string{"*1\r\n$5\r\nBOGUS\r\n", "-ERR unknown command 'BOGUS'\r\n"},
+		[2]string{"*1\r\n$4\r\nPING\r\n", "+PONG\r\n"},
+	)
+	_, err := c.Do("BOGUS")
+	if want := redisError("ERR unknown command 'BOGUS'"); err != want {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+	// An error reply leaves the connection usable.
+	if reply, err := c.Do("PING"); err != nil || reply != "PONG" {
+		t.Errorf("Do(PING) = %#v, %v after an error reply", reply, err)
+	}
+}
+
+func TestRedisUnsupportedArgument(t *testing.T) {
+	c := pipeRedis(t)
+	if _, err := c.Do("SET", "k", 1.5); err == nil {
+		t.Error("Do with a float64 argument succeeded")
+	}
+}
+
+func TestRedisSubscribe(t *testing.T) {
+	c := pipeRedis(t, [2]string{
+		"*2\r\n$9\r\nSUBSCRIBE\r\n$5\r\nrooms\r\n",
+		"*3\r\n$9\r\nsubscribe\r\n$5\r\nrooms\r\n:1\r\n" +
+			"*3\r\n$7\r\nmessage\r\n$5\r\nrooms\r\n$3\r\none\r\n" +
+			"*3\r\n$10\r\npsubscribe\r\n$5\r\nroom*\r\n:2\r\n" +
+			"*3\r\n$7\r\nmessage\r\n$5\r\nrooms\r\n$3\r\ntwo\r\n",
+	})
+	var got []string
+	err := c.subscribe("rooms", func(payload []byte) {
+		got = append(got, string(payload))
+		if len(got) == 2 {
+			c.Close()
+		}
+	})
+	if err == nil {
+		t.Error("subscribe returned without an error")
+	}
+	if want := []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("messages %q, want %q", got, want)
+	}
+}
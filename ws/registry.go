@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// registrySyncInterval is how often changed game membership is written
+	// to the room store.
+	registrySyncInterval = 2 * time.Second
+	// registryGracePeriod is how long after a restart members reloaded from
+	// the registry have to reconnect before they are disconnected.
+	registryGracePeriod = 2 * time.Minute
+)
+
+// registryMember is one session's membership of a game as persisted in the
+// room store.
+type registryMember struct {
+	GameID    string `json:"game_id"`
+	SessionID string `json:"session_id"`
+	Login     string `json:"login"`
+	Spectator bool   `json:"spectator"`
+	Ready     bool   `json:"ready"`
+	Turn      bool   `json:"turn"`
+	Voted     bool   `json:"voted"`
+	InGame    bool   `json:"in_game"`
+	Started   bool   `json:"started"`
+	// Pending marks a member reloaded after a restart who hasn't reconnected
+	// yet, and so isn't counted in the game's rounds.
+	Pending bool `json:"pending,omitempty"`
+}
+
+var (
+	// restoredMembers holds members reloaded at startup that haven't
+	// reconnected yet, by session. Guarded by mu.
+	restoredMembers = make(map[string]registryMember)
+	// shareMu orders writes of membership to the room store, so an older
+	// snapshot can't overwrite a newer one.
+	shareMu sync.Mutex
+	// syncedMembers is the last membership written per game, so unchanged
+	// games aren't rewritten. Guarded by shareMu.
+	syncedMembers = make(map[string]string)
+	// emptiedGames holds games this instance cleared that haven't been
+	// reported empty yet. Only touched by runRegistrySync.
+	emptiedGames = make(map[string]bool)
+)
+
+// loadRegistry reloads the membership persisted before a restart. Reloaded
+// members keep their seats for registryGracePeriod; anyone who hasn't
+// reconnected by then is disconnected so their REST-side rooms don't linger.
+func loadRegistry() {
+	members, err := roomStore.LoadMembers()
+	if err != nil {
+		errorf("Error loading session registry: %v", err)
+		return
+	}
+
+	mu.Lock()
+	for _, member := range members {
+		restoredMembers[member.SessionID] = member
+	}
+	mu.Unlock()
+	games := make(map[string][]registryMember)
+	for _, member := range members {
+		games[member.GameID] = append(games[member.GameID], member)
+	}
+	for gameID, members := range games {
+		syncedMembers[gameID] = membershipKey(members)
+	}
+	infof("Reloaded %d sessions from the registry", len(members))
+
+	if len(members) > 0 {
+		time.AfterFunc(registryGracePeriod, expireRestoredMembers)
+	}
+}
+
+// takeRestoredMemberLocked returns and forgets the reloaded membership of a
+// session reconnecting to the game. mu must be held.
+func takeRestoredMemberLocked(gameID, sessionID string) (registryMember, bool) {
+	member, ok := restoredMembers[sessionID]
+	if !ok || member.GameID != gameID {
+		return registryMember{}, false
+	}
+	delete(restoredMembers, sessionID)
+	return member, true
+}
+
+func expireRestoredMembers() {
+	mu.Lock()
+	expired := make([]registryMember, 0, len(restoredMembers))
+	for _, member := range restoredMembers {
+		expired = append(expired, member)
+	}
+	restoredMembers = make(map[string]registryMember)
+	mu.Unlock()
+
+	for _, member := range expired {
+		infof("Session %s didn't reconnect to game_id %s after restart", member.SessionID, member.GameID)
+		if err := disconnectUserFromDB(context.Background(), member.SessionID); err != nil {
+			errorf("Error disconnecting user from DB: %v", err)
+		}
+		if err := sendUserDisconnectMessage(member.Login, member.SessionID, member.GameID); err != nil {
+			errorf("Error sending user disconnect message: %v", err)
+		}
+	}
+}
+
+// snapshotMembersLocked collects every game's membership, counting reloaded
+// members who haven't reconnected yet. mu must be held.
+func snapshotMembersLocked() map[string][]registryMember {
+	games := make(map[string][]registryMember)
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			for _, user := range room.Users {
+				games[room.GameID] = append(games[room.GameID], registryMember{
+					GameID:    room.GameID,
+					SessionID: user.SessionID,
+					Login:     user.Login,
+					Spectator: user.Spectator,
+					Ready:     user.Ready,
+					Turn:      user.Turn,
+					Voted:     user.Voted,
+					InGame:    user.InGame,
+					Started:   room.started,
+				})
+			}
+		}
+	}
+	for _, member := range restoredMembers {
+		member.Pending = true
+		games[member.GameID] = append(games[member.GameID], member)
+	}
+	return games
+}
+
+// shareGame writes the game's membership to the room store straight away
+// when other instances count its rounds too, rather than waiting for the
+// next sync.
+func shareGame(gameID string) {
+	if !roomStore.Shared() {
+		return
+	}
+	shareMu.Lock()
+	defer shareMu.Unlock()
+
+	mu.Lock()
+	members := snapshotMembersLocked()[gameID]
+	mu.Unlock()
+	if members == nil {
+		members = []registryMember{}
+	}
+	if err := roomStore.SaveGame(gameID, members); err != nil {
+		errorf("Failed to share sessions for game_id %s: %v", gameID, err)
+		return
+	}
+	// A game this instance has left is still the sync's to forget, and to
+	// report empty if nobody is left on any instance.
+	if len(members) > 0 {
+		syncedMembers[gameID] = membershipKey(members)
+	}
+}
+
+// runRegistrySync periodically writes the membership of games that changed
+// since the last sync, and forgets games that are gone, until the server
+// starts draining.
+func runRegistrySync() {
+	for range time.Tick(registrySyncInterval) {
+		if draining.Load() {
+			return
+		}
+		shareMu.Lock()
+		mu.Lock()
+		games := snapshotMembersLocked()
+		mu.Unlock()
+
+		current := make(map[string]string, len(games))
+		for gameID, members := range games {
+			current[gameID] = membershipKey(members)
+		}
+
+		for gameID, key := range current {
+			if syncedMembers[gameID] == key {
+				continue
+			}
+			if err := roomStore.SaveGame(gameID, games[gameID]); err != nil {
+				errorf("Failed to sync sessions for game_id %s: %v", gameID, err)
+				continue
+			}
+			syncedMembers[gameID] = key
+		}
+		for gameID := range syncedMembers {
+			if _, ok := current[gameID]; ok {
+				continue
+			}
+			if err := roomStore.SaveGame(gameID, []registryMember{}); err != nil {
+				errorf("Failed to clear sessions for game_id %s: %v", gameID, err)
+				continue
+			}
+			delete(syncedMembers, gameID)
+			emptiedGames[gameID] = true
+		}
+		shareMu.Unlock()
+		for gameID := range emptiedGames {
+			if _, ok := current[gameID]; ok {
+				delete(emptiedGames, gameID)
+				continue
+			}
+			if reportEmptyGame(gameID) {
+				delete(emptiedGames, gameID)
+			}
+		}
+	}
+}
+
+// reportEmptyGame closes a game nobody is left in on any instance, on the
+// REST side as well as here, and returns false to be tried again later.
+// Games with players who can still rejoin are left alone until the tokens
+// run out.
+func reportEmptyGame(gameID string) bool {
+	mu.Lock()
+	pending := hasRejoinGrantLocked(gameID)
+	mu.Unlock()
+	if pending {
+		return false
+	}
+
+	empty, err := roomStore.Empty(gameID)
+	if err != nil {
+		errorf("Failed to check whether game_id %s is empty: %v", gameID, err)
+		return false
+	}
+	if !empty {
+		return true
+	}
+	if err := postGameEmpty(gameID); err != nil {
+		errorf("Failed to report game_id %s empty: %v", gameID, err)
+		return false
+	}
+	infof("Game_id %s is empty, closed it", gameID)
+	forgetGame(gameID)
+	return true
+}
+
+// forgetGame drops the state kept for a game, unless someone joined it again
+// in the meantime.
+func forgetGame(gameID string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(snapshotMembersLocked()[gameID]) > 0 {
+		return
+	}
+	state, ok := gameStates[gameID]
+	if !ok {
+		return
+	}
+	if state.readyTimer != nil {
+		state.readyTimer.Stop()
+	}
+	if state.turnTimer != nil {
+		state.turnTimer.Stop()
+	}
+	if state.pauseTimer != nil {
+		state.pauseTimer.Stop()
+	}
+	delete(gameStates, gameID)
+}
+
+// membershipKey sorts a game's members by session and returns an encoding
+// of them that compares equal exactly when nothing changed.
+func membershipKey(members []registryMember) string {
+	sort.Slice(members, func(i, j int) bool { return members[i].SessionID < members[j].SessionID })
+	data, _ := json.Marshal(members)
+	return string(data)
+}
+
+func fetchRegistry() ([]registryMember, error) {
+	url := restURL("/ws/members")
+	ctx, cancel := restContext(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	var members []registryMember
+	if err := json.NewDecoder(resp.Body).Decode(&members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func putRegistry(gameID string, members []registryMember) error {
+	url := restURL("/ws/games/" + neturl.PathEscape(gameID) + "/members")
+
+	jsonData, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := restContext(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postGameEmpty tells the REST service nobody is left in the game.
+func postGameEmpty(gameID string) error {
+	url := restURL("/ws/games/" + neturl.PathEscape(gameID) + "/empty")
+
+	ctx, cancel := restContext(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return err
+	}
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return nil
+}
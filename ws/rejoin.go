@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// issueRejoinTokenLocked hands out a new rejoin token for the player,
+// replacing any earlier one, and sweeps expired grants. mu must be held.
+func issueRejoinTokenLocked(room *Room, user *User) string {
+	now := time.Now()
+	for token, grant := range rejoinGrants {
+		if now.After(grant.expires) || grant.user.SessionID == user.SessionID {
+			delete(rejoinGrants, token)
+		}
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		errorf("Error generating rejoin token: %v", err)
+		return ""
+	}
+	token := hex.EncodeToString(buf)
+	rejoinGrants[token] = &rejoinGrant{room: room, user: user, expires: now.Add(rejoinTokenTTL)}
+	return token
+}
+
+// revokeRejoinTokensLocked drops the session's rejoin tokens once it leaves
+// the game for good. mu must be held.
+func revokeRejoinTokensLocked(sessionID string) {
+	for token, grant := range rejoinGrants {
+		if grant.user.SessionID == sessionID {
+			delete(rejoinGrants, token)
+		}
+	}
+}
+
+// hasRejoinGrantLocked reports whether anyone still holds a live rejoin
+// token for the game. mu must be held.
+func hasRejoinGrantLocked(gameID string) bool {
+	now := time.Now()
+	for _, grant := range rejoinGrants {
+		if grant.user.GameID == gameID && now.Before(grant.expires) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejoinTokenLocked returns the live rejoin token of a session in the game,
+// if it has one. mu must be held.
+func rejoinTokenLocked(gameID, sessionID string) string {
+	now := time.Now()
+	for token, grant := range rejoinGrants {
+		if grant.user.GameID == gameID && grant.user.SessionID == sessionID && now.Before(grant.expires) {
+			return token
+		}
+	}
+	return ""
+}
+
+// handleRejoin moves a player's room, with their round state, onto a new
+// connection. The token alone identifies the player, so a client that
+// crashed mid-round doesn't need to go through UserInfo and connect again.
+func handleRejoin(ctx context.Context, conn *websocket.Conn, data []byte) {
+	var rejoin game.Rejoin
+	if err := proto.Unmarshal(data, &rejoin); err != nil {
+		errorf("Error unmarshaling Rejoin: %v", err)
+		return
+	}
+	rejoinGame(ctx, conn, &rejoin)
+}
+
+// rejoinGame reattaches the player named by rejoin.Token to conn, confirms
+// with a Rejoin carrying their identity and follows up with a state sync.
+func rejoinGame(ctx context.Context, conn *websocket.Conn, rejoin *game.Rejoin) {
+	mu.Lock()
+	grant := rejoinGrants[string(rejoin.Token)]
+	if grant == nil || time.Now().After(grant.expires) {
+		mu.Unlock()
+		sendErrorMessage(conn, errCodeInvalidRejoin, "Rejoin token is invalid or expired")
+		return
+	}
+
+	// The old connection may not have noticed it's dead yet; detach the room
+	// from it so the player isn't sent everything twice.
+	for oldConn, rooms := range clients {
+		for i, room := range rooms {
+			if room == grant.room && oldConn != conn {
+				clients[oldConn] = append(rooms[:i], rooms[i+1:]...)
+				closeConn(oldConn, closeReplaced, "Connected from elsewhere", 0)
+				break
+			}
+		}
+	}
+	attached := false
+	for _, room := range clients[conn] {
+		attached = attached || room == grant.room
+	}
+	if !attached {
+		clients[conn] = append(clients[conn], grant.room)
+	}
+
+	user := grant.user
+	rejoin.ClassId = game.ClassTypes_PROTO_TYPE_REJOIN
+	rejoin.User = &game.User{
+		Login:     []byte(user.Login),
+		SessionId: []byte(user.SessionID),
+		GameId:    []byte(user.GameID),
+	}
+	rejoin.Spectator = user.Spectator
+	mu.Unlock()
+
+	infof("Session %s rejoined game_id %s", user.SessionID, user.GameID)
+	logEvent("rejoin", rejoin.User, game.ClassTypes_PROTO_TYPE_REJOIN, nil)
+
+	serializedData, err := SerializeToString(rejoin)
+	if err != nil {
+		errorf("Error serializing Rejoin: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_REJOIN,
+		Data:    serializedData,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+
+	mu.Lock()
+	if err := SendMessageToClient(conn, serializedBaseMessage); err != nil {
+		errorf("Failed to send rejoin confirmation: %v", err)
+	}
+	mu.Unlock()
+
+	sendStateSync(ctx, conn, user.GameID, user.SessionID)
+	resumeAfterRejoin(conn, rejoin.User)
+}
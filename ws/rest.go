@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultRestAddr is where the REST service listens unless REST_ADDR says
+// otherwise.
+const defaultRestAddr = "localhost:8080"
+
+// restBase and restClient reach the REST service at REST_ADDR, which takes
+// the same form as its LISTEN: a TCP host:port or unix:/path/to/socket.
+var restBase, restClient = restEndpoint(os.Getenv("REST_ADDR"))
+
+func restEndpoint(addr string) (string, *http.Client) {
+	if addr == "" {
+		addr = defaultRestAddr
+	}
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return "http://" + addr, http.DefaultClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", path)
+	}
+	// Every request goes to the socket, so the host is only a placeholder.
+	return "http://rest", &http.Client{Transport: transport}
+}
+
+// serviceToken is SERVICE_TOKEN, shared with the REST service, which only
+// takes it on the routes this server calls. Neither service starts without
+// it.
+var serviceToken = os.Getenv("SERVICE_TOKEN")
+
+// asService has req carry serviceToken.
+func asService(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+serviceToken)
+}
+
+// restURL is the URL of path, with its query, on the REST service.
+func restURL(path string) string {
+	return restBase + path
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	game "ws_server/proto"
+)
+
+// fakeREST points the REST client at handler for the test, behind the same
+// service token check the REST service makes, with SERVICE_TOKEN set as the
+// services are deployed.
+func fakeREST(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	const token = "test-service-token"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Invalid service token","code":"invalid_service_token"}`))
+			return
+		}
+		handler(w, r)
+	}))
+	oldBase, oldClient, oldToken := restBase, restClient, serviceToken
+	restBase, restClient, serviceToken = srv.URL, srv.Client(), token
+	t.Cleanup(func() {
+		restBase, restClient, serviceToken = oldBase, oldClient, oldToken
+		srv.Close()
+	})
+}
+
+func TestValidatePlay(t *testing.T) {
+	fakeREST(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/hand/play" {
+			t.Errorf("request %s %s, want POST /hand/play", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"message":"Card played","round":1}`))
+	})
+
+	code, message, err := validatePlay(context.Background(), &game.Action{
+		User:   &game.User{SessionId: []byte("s1"), GameId: []byte("g1")},
+		CardId: 7,
+	})
+	if err != nil || code != "" {
+		t.Fatalf("validatePlay = %q, %q, %v; want the play accepted", code, message, err)
+	}
+}
+
+func TestValidatePlayWrongToken(t *testing.T) {
+	fakeREST(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the handler without the service token")
+	})
+	serviceToken = "other"
+
+	code, _, err := validatePlay(context.Background(), &game.Action{
+		User:   &game.User{SessionId: []byte("s1"), GameId: []byte("g1")},
+		CardId: 7,
+	})
+	if err != nil || code != "invalid_service_token" {
+		t.Fatalf("validatePlay = %q, %v; want invalid_service_token", code, err)
+	}
+}
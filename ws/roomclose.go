@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	game "ws_server/proto"
+)
+
+// adminToken guards the endpoints the REST service's admin API calls here.
+// It is the REST service's ADMIN_TOKEN; with none set they are off.
+var adminToken = os.Getenv("ADMIN_TOKEN")
+
+// authorizeAdmin checks r carries adminToken, answering 403 when it doesn't.
+func authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func registerRoomClose(mux *http.ServeMux) {
+	mux.HandleFunc("POST /games/{id}/close", closeRoomHandler)
+}
+
+// closeRoomHandler force-closes a game on behalf of an admin. The REST
+// service deletes its own rows; this only clears the game out of the
+// WebSocket side.
+func closeRoomHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r) {
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	gameID := r.PathValue("id")
+	closed := closeRoom(gameID, body.Reason)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"game_id": gameID, "clients": closed})
+}
+
+// closeRoom tells everyone in the game it was closed, drops the game's rooms
+// from their connections and forgets everything kept for it, including
+// rejoin tokens so nobody can take a seat back. Connections left without a
+// room are closed. The notice also goes to other instances, whose clients
+// leave on receiving it. It returns how many local connections were in the
+// game.
+func closeRoom(gameID, reason string) int {
+	infof("Closing game_id %s: %s", gameID, reason)
+
+	data, err := SerializeToString(&game.RoomClosed{
+		ClassId: game.ClassTypes_PROTO_TYPE_ROOMCLOSED,
+		GameId:  []byte(gameID),
+		Reason:  []byte(reason),
+	})
+	if err != nil {
+		errorf("Error serializing RoomClosed: %v", err)
+		return 0
+	}
+	notice, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_ROOMCLOSED,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return 0
+	}
+
+	SendMessageToGameClients(gameID, notice, nil)
+
+	mu.Lock()
+
+	closed := 0
+	for conn, rooms := range clients {
+		kept := rooms[:0]
+		for _, room := range rooms {
+			if room.GameID != gameID {
+				kept = append(kept, room)
+			}
+		}
+		if len(kept) == len(rooms) {
+			continue
+		}
+		closed++
+		clients[conn] = kept
+		if len(kept) == 0 {
+			closeConn(conn, closeRoomClosed, reason, 0)
+		}
+	}
+	for token, grant := range rejoinGrants {
+		if grant.user.GameID == gameID {
+			delete(rejoinGrants, token)
+		}
+	}
+	for sessionID, member := range restoredMembers {
+		if member.GameID == gameID {
+			delete(restoredMembers, sessionID)
+		}
+	}
+	closeObserversLocked(gameID)
+	mu.Unlock()
+
+	forgetGame(gameID)
+	return closed
+}
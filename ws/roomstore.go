@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// RoomStore is what WebSocket instances share about rooms, so players
+// connected to different instances behind a load balancer play the same
+// game: each instance's membership with its players' round flags (who is
+// ready, has played or has voted), which also survives a restart; the
+// frames broadcast to each game; and the game's round events. A round is
+// counted across every instance's players, and each transition is claimed
+// by one instance, which carries it out and announces it for the others to
+// apply to their own players.
+type RoomStore interface {
+	// LoadMembers returns the membership this instance last saved.
+	LoadMembers() ([]registryMember, error)
+	// SaveGame replaces this instance's membership of a game. An empty list
+	// forgets the game.
+	SaveGame(gameID string, members []registryMember) error
+	// OtherMembers returns the membership of a game the other instances
+	// last saved.
+	OtherMembers(gameID string) ([]registryMember, error)
+	// Publish hands a frame broadcast to a game to the other instances. An
+	// empty gameID sends it to every connection they hold.
+	Publish(gameID string, frame []byte) error
+	// Subscribe calls deliver with frames published by other instances. It
+	// blocks for as long as the store is in use.
+	Subscribe(deliver func(gameID string, frame []byte))
+	// PublishRound hands a round event to the other instances.
+	PublishRound(event roundEvent) error
+	// SubscribeRounds calls apply with round events published by other
+	// instances. It blocks for as long as the store is in use.
+	SubscribeRounds(apply func(event roundEvent))
+	// Claim reports whether this instance is the first to claim key within
+	// ttl, so a round transition is only carried out once.
+	Claim(key string, ttl time.Duration) (bool, error)
+	// Shared reports whether other instances may hold players of the same
+	// games.
+	Shared() bool
+	// Empty reports whether no instance has members left in a game.
+	Empty(gameID string) (bool, error)
+}
+
+const publishQueueSize = 1024
+
+var (
+	roomStore RoomStore = restRoomStore{}
+
+	publishQueue = make(chan publishedFrame, publishQueueSize)
+)
+
+type publishedFrame struct {
+	gameID string
+	frame  []byte
+}
+
+// newRoomStore picks Redis when REDIS_ADDR is set, which lets several
+// instances share games, and otherwise keeps membership in the REST service
+// for a single instance.
+func newRoomStore() RoomStore {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		return restRoomStore{}
+	}
+	instance := os.Getenv("WS_INSTANCE")
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	infof("Using Redis room store at %s as instance %s", addr, instance)
+	return &redisRoomStore{addr: addr, instance: instance}
+}
+
+// publishFrame queues a game broadcast for the other instances without
+// blocking the caller, who usually holds mu.
+func publishFrame(gameID string, frame []byte) {
+	select {
+	case publishQueue <- publishedFrame{gameID, frame}:
+	default:
+		warnf("Publish queue full, dropping frame for game_id %s", gameID)
+	}
+}
+
+func runPublisher() {
+	for f := range publishQueue {
+		if err := roomStore.Publish(f.gameID, f.frame); err != nil {
+			errorf("Failed to publish frame for game_id %s: %v", f.gameID, err)
+		}
+	}
+}
+
+// deliverRemoteFrame passes a frame another instance broadcast on to this
+// instance's connections in the game, or to all of them when gameID is
+// empty.
+func deliverRemoteFrame(gameID string, frame []byte) {
+	if gameID == "" {
+		sendToAllLocalClients(frame)
+		return
+	}
+	sendToLocalGameClients(gameID, frame)
+}
+
+// restRoomStore keeps membership in the REST service's database. It has no
+// way to relay frames, so it only suits a single instance.
+type restRoomStore struct{}
+
+func (restRoomStore) LoadMembers() ([]registryMember, error) {
+	return fetchRegistry()
+}
+
+func (restRoomStore) SaveGame(gameID string, members []registryMember) error {
+	return putRegistry(gameID, members)
+}
+
+func (restRoomStore) OtherMembers(gameID string) ([]registryMember, error) {
+	return nil, nil
+}
+
+func (restRoomStore) Publish(gameID string, frame []byte) error {
+	return nil
+}
+
+func (restRoomStore) Subscribe(deliver func(gameID string, frame []byte)) {}
+
+func (restRoomStore) PublishRound(event roundEvent) error {
+	return nil
+}
+
+func (restRoomStore) SubscribeRounds(apply func(event roundEvent)) {}
+
+func (restRoomStore) Claim(key string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (restRoomStore) Shared() bool {
+	return false
+}
+
+// Empty is always true once this instance has cleared the game, as there is
+// no other instance.
+func (restRoomStore) Empty(gameID string) (bool, error) {
+	return true, nil
+}
+
+const (
+	redisFramesChannel = "ws:frames"
+	redisRoundsChannel = "ws:rounds"
+)
+
+// redisRoomStore keeps each instance's membership in a hash keyed by game
+// and relays frames and round events over pub/sub channels. Both carry the
+// publishing instance so it can skip its own.
+type redisRoomStore struct {
+	addr     string
+	instance string
+
+	mu   sync.Mutex
+	conn *redisConn
+}
+
+func (s *redisRoomStore) membersKey() string {
+	return "ws:members:" + s.instance
+}
+
+// instancesKey names the set of instances with members in a game.
+func instancesKey(gameID string) string {
+	return "ws:instances:" + gameID
+}
+
+// do runs a command, redialing first if the last command broke the
+// connection.
+func (s *redisRoomStore) do(args ...interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := dialRedis(s.addr)
+		if err != nil {
+			return nil, err
+		}
+		s.conn = conn
+	}
+	reply, err := s.conn.Do(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return reply, err
+}
+
+func (s *redisRoomStore) LoadMembers() ([]registryMember, error) {
+	reply, err := s.do("HGETALL", s.membersKey())
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.([]interface{})
+	var members []registryMember
+	for i := 1; i < len(items); i += 2 {
+		data, _ := items[i].([]byte)
+		var game []registryMember
+		if err := json.Unmarshal(data, &game); err != nil {
+			warnf("Skipping unreadable room store entry: %v", err)
+			continue
+		}
+		members = append(members, game...)
+	}
+	return members, nil
+}
+
+func (s *redisRoomStore) SaveGame(gameID string, members []registryMember) error {
+	if len(members) == 0 {
+		if _, err := s.do("HDEL", s.membersKey(), gameID); err != nil {
+			return err
+		}
+		_, err := s.do("SREM", instancesKey(gameID), s.instance)
+		return err
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+	if _, err := s.do("HSET", s.membersKey(), gameID, data); err != nil {
+		return err
+	}
+	_, err = s.do("SADD", instancesKey(gameID), s.instance)
+	return err
+}
+
+func (s *redisRoomStore) OtherMembers(gameID string) ([]registryMember, error) {
+	reply, err := s.do("SMEMBERS", instancesKey(gameID))
+	if err != nil {
+		return nil, err
+	}
+	instances, _ := reply.([]interface{})
+	var members []registryMember
+	for _, item := range instances {
+		instance, _ := item.([]byte)
+		if string(instance) == s.instance {
+			continue
+		}
+		reply, err := s.do("HGET", "ws:members:"+string(instance), gameID)
+		if err != nil {
+			return nil, err
+		}
+		data, ok := reply.([]byte)
+		if !ok {
+			continue
+		}
+		var held []registryMember
+		if err := json.Unmarshal(data, &held); err != nil {
+			warnf("Skipping unreadable room store entry: %v", err)
+			continue
+		}
+		members = append(members, held...)
+	}
+	return members, nil
+}
+
+func (s *redisRoomStore) Claim(key string, ttl time.Duration) (bool, error) {
+	reply, err := s.do("SET", "ws:claim:"+key, s.instance, "NX", "PX", int(ttl.Milliseconds()))
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+func (s *redisRoomStore) Shared() bool {
+	return true
+}
+
+func (s *redisRoomStore) Empty(gameID string) (bool, error) {
+	reply, err := s.do("SCARD", instancesKey(gameID))
+	if err != nil {
+		return false, err
+	}
+	count, _ := reply.(int64)
+	return count == 0, nil
+}
+
+func (s *redisRoomStore) Publish(gameID string, frame []byte) error {
+	payload := make([]byte, 0, len(s.instance)+len(gameID)+len(frame)+2)
+	payload = append(payload, s.instance...)
+	payload = append(payload, 0)
+	payload = append(payload, gameID...)
+	payload = append(payload, 0)
+	payload = append(payload, frame...)
+	_, err := s.do("PUBLISH", redisFramesChannel, payload)
+	return err
+}
+
+func (s *redisRoomStore) Subscribe(deliver func(gameID string, frame []byte)) {
+	s.listen(redisFramesChannel, func(payload []byte) {
+		parts := bytes.SplitN(payload, []byte{0}, 3)
+		if len(parts) != 3 || string(parts[0]) == s.instance {
+			return
+		}
+		deliver(string(parts[1]), parts[2])
+	})
+}
+
+func (s *redisRoomStore) PublishRound(event roundEvent) error {
+	event.Instance = s.instance
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.do("PUBLISH", redisRoundsChannel, payload)
+	return err
+}
+
+func (s *redisRoomStore) SubscribeRounds(apply func(event roundEvent)) {
+	s.listen(redisRoundsChannel, func(payload []byte) {
+		var event roundEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			warnf("Skipping unreadable round event: %v", err)
+			return
+		}
+		if event.Instance == s.instance {
+			return
+		}
+		apply(event)
+	})
+}
+
+// listen subscribes to channel on a connection of its own, resubscribing
+// whenever the connection is lost.
+func (s *redisRoomStore) listen(channel string, handle func(payload []byte)) {
+	for {
+		conn, err := dialRedis(s.addr)
+		if err == nil {
+			err = conn.subscribe(channel, handle)
+			conn.Close()
+		}
+		warnf("Room store subscription to %s lost, retrying: %v", channel, err)
+		time.Sleep(time.Second)
+	}
+}
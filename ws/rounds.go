@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of round event. Each is a transition of a game's round that every
+// instance holding its players applies to them.
+const (
+	roundStart    = "start"
+	roundVote     = "vote"
+	roundClear    = "clear"
+	roundDeadline = "deadline"
+	roundBench    = "bench"
+	roundSettings = "settings"
+	roundPause    = "pause"
+)
+
+// startClaimTTL covers fetching a round's situation and counting down to
+// it, during which nobody else may start the round.
+const startClaimTTL = roundCountdown*time.Second + 2*restTimeout
+
+// phaseClaimTTL is how long other claims are kept. Their keys name the round
+// they belong to, so they only have to outlast the instances racing for
+// them.
+const phaseClaimTTL = 10 * time.Minute
+
+// roundEvent is a round transition, as published to the other instances.
+type roundEvent struct {
+	Instance string `json:"instance,omitempty"`
+	GameID   string `json:"game_id"`
+	Kind     string `json:"kind"`
+	// Text is a starting round's situation.
+	Text string `json:"text,omitempty"`
+	// Deadline is when the phase the event opens or extends runs out, in
+	// Unix milliseconds.
+	Deadline int64 `json:"deadline,omitempty"`
+	// Kick says unready players are dropped rather than benched.
+	Kick bool `json:"kick,omitempty"`
+	// Paused is a pause or resume: the host's, or with SessionID one held
+	// for a player who is reconnecting.
+	Paused    bool   `json:"paused,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	// Settings are the host's new lobby settings.
+	Settings *lobbySettings `json:"settings,omitempty"`
+}
+
+type lobbySettings struct {
+	ReadyTimeout time.Duration `json:"ready_timeout"`
+	KickUnready  bool          `json:"kick_unready"`
+	MinPlayers   int           `json:"min_players"`
+	TurnTimeout  time.Duration `json:"turn_timeout"`
+}
+
+func (e roundEvent) deadline() time.Time {
+	if e.Deadline == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(e.Deadline)
+}
+
+// roundTally counts a game's members on every instance: its players, and
+// how many of them are ready, in the round and have played.
+type roundTally struct {
+	players int
+	ready   int
+	inGame  int
+	moved   int
+}
+
+func (t *roundTally) add(m registryMember) {
+	if m.Pending {
+		return
+	}
+	if !m.Spectator {
+		t.players++
+	}
+	if m.Ready {
+		t.ready++
+	}
+	if m.InGame {
+		t.inGame++
+	}
+	if m.Turn {
+		t.moved++
+	}
+}
+
+// tallyRound counts the game's members here as they are now and on the
+// other instances as they last shared them. Callers that changed a member
+// share the game first, so whichever instance makes the last change sees
+// the round complete.
+func tallyRound(gameID string) roundTally {
+	others, err := roomStore.OtherMembers(gameID)
+	if err != nil {
+		errorf("Failed to count other instances' members of game_id %s: %v", gameID, err)
+	}
+	var remote roundTally
+	for _, member := range others {
+		remote.add(member)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	state := getGameStateLocked(gameID)
+	state.otherPlayers = remote.players
+	state.otherInGame = remote.inGame
+	tally := remote
+	for _, member := range snapshotMembersLocked()[gameID] {
+		tally.add(member)
+	}
+	debugf("Round tally for game_id %s: %+v", gameID, tally)
+	return tally
+}
+
+// claimRound reports whether this instance gets to carry out a transition
+// of the game's current round. A store that can't be reached lets it go
+// ahead, as a game split across instances can't go on then anyway.
+func claimRound(gameID, kind string, ttl time.Duration) bool {
+	mu.Lock()
+	round := getGameStateLocked(gameID).round
+	mu.Unlock()
+
+	ok, err := roomStore.Claim(fmt.Sprintf("%s:%d:%s", gameID, round, kind), ttl)
+	if err != nil {
+		errorf("Failed to claim %s for game_id %s: %v", kind, gameID, err)
+		return true
+	}
+	return ok
+}
+
+// publishRound hands a round event to the other instances, for callers that
+// apply it here themselves. mu must not be held.
+func publishRound(event roundEvent) {
+	if err := roomStore.PublishRound(event); err != nil {
+		errorf("Failed to publish %s for game_id %s: %v", event.Kind, event.GameID, err)
+	}
+}
+
+// announceRound applies a round event here and on the other instances. mu
+// must not be held.
+func announceRound(event roundEvent) {
+	publishRound(event)
+	applyRoundEvent(event)
+}
+
+// applyRemoteRound applies a round event another instance published, if any
+// of the game's connections are here.
+func applyRemoteRound(event roundEvent) {
+	mu.Lock()
+	_, here := gameStates[event.GameID]
+	mu.Unlock()
+	if here {
+		applyRoundEvent(event)
+	}
+}
+
+func applyRoundEvent(event roundEvent) {
+	gameID := event.GameID
+	switch event.Kind {
+	case roundStart:
+		startRoundHere(gameID, event.Text, event.deadline())
+	case roundVote:
+		openVotingHere(gameID, event.deadline())
+	case roundClear:
+		clearTableHere(gameID)
+	case roundDeadline:
+		// The TurnDeadline frame itself reaches every instance as a game
+		// broadcast.
+		armTurnTimer(gameID, event.deadline())
+	case roundBench:
+		benchUnready(gameID, event.Kick)
+	case roundSettings:
+		if event.Settings != nil {
+			applyLobbySettings(gameID, *event.Settings)
+		}
+	case roundPause:
+		applyPause(gameID, event.Paused, event.SessionID)
+	default:
+		warnf("Ignoring unknown round event %q for game_id %s", event.Kind, gameID)
+	}
+}
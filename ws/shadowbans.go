@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// shadowBanRefreshInterval is how often the shadow-banned sessions are
+// fetched from the REST service.
+const shadowBanRefreshInterval = 30 * time.Second
+
+var (
+	shadowBansMu sync.RWMutex
+	// shadowBans holds the sessions of shadow-banned players. Their chat
+	// and votes go back to them alone, so nothing looks different to them
+	// while nobody else sees either.
+	shadowBans map[string]bool
+)
+
+func fetchShadowBans() (map[string]bool, error) {
+	ctx, cancel := restContext(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", restURL("/ws/shadow-bans"), nil)
+	if err != nil {
+		return nil, err
+	}
+	asService(req)
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	var sessions []string
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return nil, err
+	}
+	bans := make(map[string]bool, len(sessions))
+	for _, session := range sessions {
+		bans[session] = true
+	}
+	return bans, nil
+}
+
+// runShadowBanRefresh keeps shadowBans current, so bans placed through the
+// REST service's admin API apply here without a restart.
+func runShadowBanRefresh() {
+	for {
+		if bans, err := fetchShadowBans(); err != nil {
+			errorf("Failed to fetch shadow bans: %v", err)
+		} else {
+			shadowBansMu.Lock()
+			shadowBans = bans
+			shadowBansMu.Unlock()
+		}
+		time.Sleep(shadowBanRefreshInterval)
+	}
+}
+
+func shadowBanned(sessionID string) bool {
+	shadowBansMu.RLock()
+	defer shadowBansMu.RUnlock()
+	return shadowBans[sessionID]
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gorilla/websocket"
+
+	game "ws_server/proto"
+)
+
+// sendStateSync sends conn a snapshot of the game: who is in it and how far
+// they are through the round, the scores, the prompt and, if sessionID was
+// dealt one, their hand. It lets a client that missed broadcasts draw the
+// table straight away. Scores and the hand come from the REST service; if it
+// can't be reached the snapshot goes out without them.
+func sendStateSync(ctx context.Context, conn *websocket.Conn, gameID, sessionID string) {
+	round, scores, err := fetchScores(ctx, gameID)
+	if err != nil {
+		errorf("Error fetching scores for game_id %s: %v", gameID, err)
+	}
+	var hand []*game.HandCard
+	if sessionID != "" {
+		if hand, err = fetchHand(ctx, gameID, sessionID); err != nil {
+			errorf("Error fetching hand for session_id %s: %v", sessionID, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	state := &game.StateSync{
+		ClassId:   game.ClassTypes_PROTO_TYPE_STATESYNC,
+		GameId:    []byte(gameID),
+		Round:     uint32(round),
+		Situation: []byte(getGameStateLocked(gameID).Situation),
+		Started:   roundStartedLocked(gameID),
+		Hand:      hand,
+		Deadline:  unixMilli(getGameStateLocked(gameID).turnDeadline),
+		Moderated: moderatedLocked(gameID) > 0,
+	}
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			if room.GameID != gameID {
+				continue
+			}
+			for _, user := range room.Users {
+				state.Players = append(state.Players, &game.PlayerState{
+					User: &game.User{
+						Login:     []byte(user.Login),
+						SessionId: []byte(user.SessionID),
+						GameId:    []byte(user.GameID),
+					},
+					Spectator: user.Spectator,
+					Ready:     user.Ready,
+					Played:    user.Turn,
+					Voted:     user.Voted,
+					Score:     uint32(scores[user.Login]),
+				})
+			}
+		}
+	}
+
+	data, err := SerializeToString(state)
+	if err != nil {
+		errorf("Error serializing StateSync: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_STATESYNC,
+		Data:    data,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+	if err := SendMessageToClient(conn, serializedBaseMessage); err != nil {
+		errorf("Failed to send state sync: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// handleTimeSync answers a client's TimeSync with the server's clock,
+// echoing the client's so it can allow for the trip.
+func handleTimeSync(conn *websocket.Conn, data []byte) {
+	var timeSync game.TimeSync
+	if err := proto.Unmarshal(data, &timeSync); err != nil {
+		errorf("Error unmarshaling TimeSync: %v", err)
+		return
+	}
+	sendTimeSync(conn, timeSync.ClientTime)
+}
+
+// sendTimeSync tells conn the server's time in Unix milliseconds. Clients
+// work out their clock's offset from it and time round countdowns by the
+// server instead of their own clock. It is sent on joining a game and
+// whenever a client asks; clientTime echoes the asking client's clock.
+func sendTimeSync(conn *websocket.Conn, clientTime int64) {
+	serializedData, err := SerializeToString(&game.TimeSync{
+		ClassId:    game.ClassTypes_PROTO_TYPE_TIMESYNC,
+		ServerTime: time.Now().UnixMilli(),
+		ClientTime: clientTime,
+	})
+	if err != nil {
+		errorf("Error serializing TimeSync: %v", err)
+		return
+	}
+	serializedBaseMessage, err := SerializeToString(&game.BaseMessage{
+		ClassId: game.ClassTypes_PROTO_TYPE_TIMESYNC,
+		Data:    serializedData,
+	})
+	if err != nil {
+		errorf("Error serializing BaseMessage: %v", err)
+		return
+	}
+	if err := SendMessageToClient(conn, serializedBaseMessage); err != nil {
+		errorf("Failed to send time sync: %v", err)
+	}
+}
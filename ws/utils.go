@@ -6,12 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	neturl "net/url"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
 )
 
 func SerializeToString(msg proto.Message) ([]byte, error) {
@@ -35,208 +37,621 @@ func removeUserFromRoom(room *Room, sessionID string) {
 	}
 }
 
-func clientsMoved(gameID string) int {
-	var turnCount int
-
-	mu.Lock()
-	defer mu.Unlock()
-
+// findUserLocked looks a game member up by session. mu must be held.
+func findUserLocked(gameID, sessionID string) *User {
 	for _, rooms := range clients {
 		for _, room := range rooms {
 			if room.GameID == gameID {
 				for _, user := range room.Users {
-					if user.Turn {
-						turnCount++
+					if user.SessionID == sessionID {
+						return user
 					}
 				}
 			}
 		}
 	}
-
-	return turnCount
+	return nil
 }
 
-func ClientsInGame(gameID string) int {
-	var clientsCount int
-
-	mu.Lock()
-	defer mu.Unlock()
+// getGameStateLocked returns the game's shared state, creating it on first
+// use. mu must be held.
+func getGameStateLocked(gameID string) *GameState {
+	state, ok := gameStates[gameID]
+	if !ok {
+		state = &GameState{}
+		gameStates[gameID] = state
+	}
+	return state
+}
 
+// gameStartedLocked reports whether any member of the game, including one
+// reloaded after a restart who hasn't reconnected yet or one on another
+// instance as last counted, has been dealt into a round. mu must be held.
+func gameStartedLocked(gameID string) bool {
 	for _, rooms := range clients {
 		for _, room := range rooms {
 			if room.GameID == gameID {
 				for _, user := range room.Users {
 					if user.InGame {
-						log.Printf("Client in game: User: %v", user)
-						clientsCount++
+						return true
 					}
 				}
 			}
 		}
 	}
+	for _, member := range restoredMembers {
+		if member.GameID == gameID && member.InGame {
+			return true
+		}
+	}
+	return getGameStateLocked(gameID).otherInGame > 0
+}
 
-	log.Printf("Total clients in game: %d", clientsCount)
-	return clientsCount
+// roundStartedLocked reports whether the game is mid-round. mu must be held.
+func roundStartedLocked(gameID string) bool {
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			if room.GameID == gameID && room.started {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-func ClientsInRoom(gameID string) int {
-	var count int
+func roomHasSpectator(room *Room) bool {
+	for _, user := range room.Users {
+		if user.Spectator {
+			return true
+		}
+	}
+	return false
+}
 
+func userHasTurned(gameID, sessionID string) bool {
 	mu.Lock()
 	defer mu.Unlock()
 
 	for _, rooms := range clients {
 		for _, room := range rooms {
 			if room.GameID == gameID {
-				count += len(room.Users)
+				for _, user := range room.Users {
+					if user.SessionID == sessionID {
+						return user.Turn
+					}
+				}
 			}
 		}
 	}
 
-	return count
+	return false
 }
 
-func clientsReady(gameID string) int {
-	var readyCount int
-
-	mu.Lock()
-	defer mu.Unlock()
-
+// clientsInRoomLocked counts the game's players, not its spectators. mu must
+// be held.
+func clientsInRoomLocked(gameID string) int {
+	var count int
 	for _, rooms := range clients {
 		for _, room := range rooms {
 			if room.GameID == gameID {
 				for _, user := range room.Users {
-					if user.Ready {
-						log.Printf("Client ready: User: %v", user)
-						readyCount++
+					if !user.Spectator {
+						count++
 					}
 				}
 			}
 		}
 	}
 
-	log.Printf("Total clients ready: %d", readyCount)
-	return readyCount
+	return count
+}
+
+// roomFullLocked reports whether every player seat in the game is taken,
+// here or on the other instances as last counted. mu must be held.
+func roomFullLocked(gameID string) bool {
+	state := getGameStateLocked(gameID)
+	return state.Capacity > 0 && clientsInRoomLocked(gameID)+state.otherPlayers >= state.Capacity
 }
 
-func GetText(gameID string) (string, error) {
-	url := "http://localhost:8080/text"
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func GetText(ctx context.Context, gameID string) (string, error) {
+	url := restURL("/text?game_id=" + neturl.QueryEscape(gameID))
+	ctx, cancel := restContext(ctx)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		log.Printf("Error creating request for game_id %s: %v", gameID, err)
+		errorf("Error creating request for game_id %s: %v", gameID, err)
 		return "", err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := restClient.Do(req)
 	if err != nil {
-		log.Printf("Request failed for game_id %s: %v", gameID, err)
+		errorf("Request failed for game_id %s: %v", gameID, err)
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Error for game_id %s: Status Code %d", gameID, resp.StatusCode)
+		errorf("Error for game_id %s: Status Code %d", gameID, resp.StatusCode)
 		return "", fmt.Errorf("status code: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Error reading response body for game_id %s: %v", gameID, err)
+		errorf("Error reading response body for game_id %s: %v", gameID, err)
 		return "", err
 	}
 
 	var data TextResponse
 	if err := json.Unmarshal(body, &data); err != nil {
-		log.Printf("Error unmarshaling JSON for game_id %s: %v", gameID, err)
+		errorf("Error unmarshaling JSON for game_id %s: %v", gameID, err)
 		return "", err
 	}
 
 	if data.Text == "" {
-		log.Printf("Error for game_id %s: No text received", gameID)
+		errorf("Error for game_id %s: No text received", gameID)
 		return "", fmt.Errorf("no text received")
 	}
 
-	log.Printf("Received text for game_id %s: %s", gameID, data.Text)
+	debugf("Received text for game_id %s: %s", gameID, data.Text)
 	return data.Text, nil
 }
 
-func disconnectUserFromDB(sessionID string) error {
-	url := "http://localhost:8080/disconnect"
+// postRoundStart tells the REST service the game's next round has started,
+// so hands and votes are counted against it.
+func postRoundStart(ctx context.Context, gameID string) error {
+	url := restURL("/ws/games/" + neturl.PathEscape(gameID) + "/rounds")
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return err
+	}
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func disconnectUserFromDB(ctx context.Context, sessionID string) error {
+	url := restURL("/disconnect")
 
 	data := map[string]string{"session_id": sessionID}
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %v", err)
+		errorf("Error marshalling JSON: %v", err)
 		return err
 	}
 
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		log.Printf("Error creating request: %v", err)
+		errorf("Error creating request: %v", err)
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := restClient.Do(req)
 	if err != nil {
-		log.Printf("Request failed for session_id %s: %v", sessionID, err)
+		errorf("Request failed for session_id %s: %v", sessionID, err)
 		return err
 	}
 	defer resp.Body.Close()
 
 
 	if resp.StatusCode == http.StatusOK {
-		log.Println("Request was successful.")
+		debugf("Request was successful")
 
 		var response map[string]interface{}
 		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-			log.Printf("Error decoding response JSON: %v", err)
+			errorf("Error decoding response JSON: %v", err)
 			return err
 		}
-		log.Printf("Response JSON: %v", response)
+		debugf("Response JSON: %v", response)
 	} else {
-		log.Printf("Request failed. Status Code: %d", resp.StatusCode)
+		errorf("Request failed. Status Code: %d", resp.StatusCode)
 
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			log.Printf("Error reading response body: %v", err)
+			errorf("Error reading response body: %v", err)
 			return err
 		}
-		log.Printf("Response Text: %s", body)
+		errorf("Response Text: %s", body)
 	}
 
 	return nil
 }
 
-func disconnectUser(sessionID string) {
-	log.Printf("Disconnecting user with session_id: %s", sessionID)
+// validatePlay asks the REST service to mark the played card as used. It
+// returns a non-empty error code when the card isn't in the player's hand,
+// or when the action names no card at all.
+func validatePlay(ctx context.Context, action *game.Action) (string, string, error) {
+	url := restURL("/hand/play")
+
+	data := map[string]interface{}{
+		"game_id":        string(action.User.GameId),
+		"session_id":     string(action.User.SessionId),
+		"card_id":        action.CardId,
+		"custom_card_id": action.CustomCardId,
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		errorf("Play validation failed for session_id %s: %v", action.User.SessionId, err)
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return "", "", nil
+	}
+
+	var response struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", "", fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	if response.Code == "" {
+		return "", "", fmt.Errorf("status code: %d: %s", resp.StatusCode, response.Error)
+	}
+	return response.Code, response.Error, nil
+}
+
+// choosesOwnCard reports whether a vote is for the voter's own card, either
+// by chosen_id naming the voter's session or by the chosen card having been
+// played by them.
+func choosesOwnCard(ctx context.Context, choose *game.Choose) (bool, error) {
+	sessionID := string(choose.User.SessionId)
+	if string(choose.ChosenId) == sessionID {
+		return true, nil
+	}
+	if choose.CardId == 0 && choose.CustomCardId == 0 {
+		return false, nil
+	}
+
+	query := neturl.Values{}
+	query.Set("game_id", string(choose.User.GameId))
+	query.Set("session_id", sessionID)
+	if choose.CardId != 0 {
+		query.Set("card_id", fmt.Sprint(choose.CardId))
+	} else {
+		query.Set("custom_card_id", fmt.Sprint(choose.CustomCardId))
+	}
+	url := restURL("/hand/owner?" + query.Encode())
+
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		errorf("Card owner lookup failed for game_id %s: %v", choose.User.GameId, err)
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Own bool `json:"own"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return false, err
+	}
+	return data.Own, nil
+}
+
+// reportVote records an accepted vote with the REST service so the game can
+// be scored when it ends. It runs once the vote has been answered, so it
+// isn't bound to the frame's context.
+func reportVote(choose *game.Choose) {
+	url := restURL("/votes")
+
+	data := map[string]string{
+		"game_id":    string(choose.User.GameId),
+		"session_id": string(choose.User.SessionId),
+		"chosen_id":  string(choose.ChosenId),
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		errorf("Error marshalling JSON: %v", err)
+		return
+	}
+
+	ctx, cancel := restContext(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		errorf("Error creating request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		errorf("Failed to record vote for game_id %s: %v", choose.User.GameId, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		errorf("Failed to record vote for game_id %s: status code %d", choose.User.GameId, resp.StatusCode)
+	}
+}
+
+// roomInfo is what the REST service reports about a room.
+type roomInfo struct {
+	Host     string `json:"session_id"`
+	Capacity int    `json:"capacity"`
+	Name     string `json:"name"`
+}
+
+// fetchRoomInfo asks the REST service who hosts the game, how many players
+// it takes and what it is called.
+func fetchRoomInfo(ctx context.Context, gameID string) (roomInfo, error) {
+	url := restURL("/room/" + neturl.PathEscape(gameID) + "/host")
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return roomInfo{}, err
+	}
+	asService(req)
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return roomInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return roomInfo{}, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	var info roomInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return roomInfo{}, err
+	}
+	return info, nil
+}
+
+// fetchRoomHost asks the REST service which session hosts the game.
+func fetchRoomHost(ctx context.Context, gameID string) (string, error) {
+	info, err := fetchRoomInfo(ctx, gameID)
+	return info.Host, err
+}
+
+// updateRoomCapacity stores a new player cap with the REST service, which
+// checks that the session is the host and the cap is within bounds. A
+// rejection comes back as an error code and message.
+func updateRoomCapacity(ctx context.Context, gameID, sessionID string, capacity uint32) (string, string, error) {
+	url := restURL("/room/capacity")
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"game_id":    gameID,
+		"session_id": sessionID,
+		"capacity":   capacity,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return "", "", nil
+	}
+
+	var response struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil || response.Code == "" {
+		return "", "", fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return response.Code, response.Error, nil
+}
+
+// fetchScores asks the REST service for the game's current round and each
+// player's score so far, keyed by login.
+func fetchScores(ctx context.Context, gameID string) (int, map[string]int, error) {
+	url := restURL("/room/" + neturl.PathEscape(gameID) + "/scores")
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Round  int `json:"round"`
+		Scores []struct {
+			Login string `json:"login"`
+			Score int    `json:"score"`
+		} `json:"scores"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return 0, nil, err
+	}
+	scores := make(map[string]int, len(data.Scores))
+	for _, s := range data.Scores {
+		scores[s.Login] = s.Score
+	}
+	return data.Round, scores, nil
+}
+
+// fetchHand asks the REST service for the cards the session was dealt in the
+// latest round.
+func fetchHand(ctx context.Context, gameID, sessionID string) ([]*game.HandCard, error) {
+	url := restURL("/hand?game_id=" + neturl.QueryEscape(gameID) + "&session_id=" + neturl.QueryEscape(sessionID))
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Cards []struct {
+			CardID       uint64 `json:"card_id"`
+			CustomCardID uint64 `json:"custom_card_id"`
+			DeckID       uint64 `json:"deck_id"`
+			Image        []byte `json:"card_img"`
+			URL          string `json:"card_url"`
+			Played       bool   `json:"played"`
+		} `json:"cards"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	hand := make([]*game.HandCard, 0, len(data.Cards))
+	for _, card := range data.Cards {
+		hand = append(hand, &game.HandCard{
+			CardId:       card.CardID,
+			CustomCardId: card.CustomCardID,
+			DeckId:       card.DeckID,
+			Image:        card.Image,
+			Url:          []byte(card.URL),
+			Played:       card.Played,
+		})
+	}
+	return hand, nil
+}
+
+// fetchChatImageURL asks the REST service for a signed URL to a chat image,
+// which it only hands out if the sender uploaded the image into this game.
+func fetchChatImageURL(ctx context.Context, gameID, sessionID string, imageID uint64) (string, error) {
+	url := restURL(fmt.Sprintf("/chat/images/%d?game_id=%s&session_id=%s",
+		imageID, neturl.QueryEscape(gameID), neturl.QueryEscape(sessionID)))
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	var data struct {
+		ImageURL string `json:"image_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", err
+	}
+	return data.ImageURL, nil
+}
+
+// disconnectUser drops the session from its room and closes its connection
+// with a close frame of kind.
+func disconnectUser(sessionID string, kind closeKind, message string) {
+	infof("Disconnecting user with session_id: %s", sessionID)
 
 	mu.Lock()
 	defer mu.Unlock()
 
+	revokeRejoinTokensLocked(sessionID)
 	found := false
 
 	for conn, rooms := range clients {
 		for _, room := range rooms {
 			for i, user := range room.Users {
 				if user.SessionID == sessionID {
-					log.Printf("Found user to disconnect: %v", user)
+					debugf("Found user to disconnect: %v", user)
 
 					room.Users = append(room.Users[:i], room.Users[i+1:]...)
-					log.Printf("User removed from clients")
+					debugf("User removed from clients")
 
-					if err := conn.Close(); err != nil {
-						log.Printf("Error closing WebSocket connection: %v", err)
-					} else {
-						log.Printf("WebSocket connection closed for user: %s", user.Login)
-					}
+					closeConn(conn, kind, message, 0)
+					infof("WebSocket connection closed for user: %s", user.Login)
 
 					found = true
 					break
@@ -252,108 +667,81 @@ func disconnectUser(sessionID string) {
 	}
 
 	if !found {
-		log.Printf("User with session_id %s not found in clients", sessionID)
+		warnf("User with session_id %s not found in clients", sessionID)
 	}
 }
 
-func deleteUser(sessionID string) error {
-	url := "http://localhost:8080/exit"
+// deleteUser tells the REST service the session has left. It doesn't touch
+// clients, so it's safe to call without mu; callers drop the user from their
+// rooms themselves.
+func deleteUser(ctx context.Context, sessionID string) error {
+	url := restURL("/exit")
 
 	data := map[string]string{"session_id": sessionID}
 	payload, err := json.Marshal(data)
 	if err != nil {
-		log.Printf("Failed to marshal request payload: %v", err)
+		errorf("Failed to marshal request payload: %v", err)
 		return err
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(payload))
+	ctx, cancel := restContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
 	if err != nil {
-		log.Printf("Request failed for session_id %s: %v", sessionID, err)
+		errorf("Failed to create request: %v", err)
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		log.Println("Request was successful.")
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Failed to read response body: %v", err)
-			return err
-		}
-
-		var responseJSON map[string]interface{}
-		if err := json.Unmarshal(body, &responseJSON); err != nil {
-			log.Printf("Failed to unmarshal response JSON: %v", err)
-			return err
-		}
+	req.Header.Set("Content-Type", "application/json")
 
-		log.Printf("Response JSON: %v", responseJSON)
+	resp, err := restClient.Do(req)
+	if err != nil {
+		errorf("Request failed for session_id %s: %v", sessionID, err)
+		return err
+	}
+	defer resp.Body.Close()
 
-		mu.Lock()
-		for _, rooms := range clients {
-			for _, room := range rooms {
-				for i, user := range room.Users {
-					if user.SessionID == sessionID {
-						room.Users = append(room.Users[:i], room.Users[i+1:]...)
-						break
-					}
-				}
-			}
-		}
-		mu.Unlock()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		debugf("Exit recorded for session_id %s", sessionID)
 	} else {
-		log.Printf("Request failed.")
-		log.Printf("Status Code: %d", resp.StatusCode)
+		errorf("Request failed.")
+		errorf("Status Code: %d", resp.StatusCode)
 
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			log.Printf("Failed to read response body: %v", err)
+			errorf("Failed to read response body: %v", err)
 			return err
 		}
-		log.Printf("Response Text: %s", body)
+		errorf("Response Text: %s", body)
 	}
 
 	return nil
 }
 
 func updateGame(game_id string, senderWebSocket *websocket.Conn) {
-	log.Printf("Updating game after disconecting user")
-	if ClientsInGame(game_id) == clientsMoved(game_id) {
-		SendDeleteMessage(string(game_id))
-		mu.Lock()
-		for _, rooms := range clients {
-			for _, room := range rooms {
-				if room.GameID == string(game_id) {
-					for _, user := range room.Users {
-						user.Turn = false
-						user.Voted = false
-					}
-				}
-			}
-		}
-		mu.Unlock()
+	debugf("Updating game after disconecting user")
+	shareGame(game_id)
+	tally := tallyRound(game_id)
+	if tally.inGame == tally.moved {
+		announceRound(roundEvent{GameID: game_id, Kind: roundClear})
 	}
+}
 
-	if ClientsInRoom(game_id) == clientsReady(game_id) {
-		text, err := GetText(string(game_id))
-		if err != nil {
-			log.Printf("Error fetching text for game_id %s: %v", game_id, err)
-			return
-		}
-		SendStartGameMessage(string(game_id), text)
-
-		mu.Lock()
-		for _, rooms := range clients {
-			for _, room := range rooms {
-				if room.GameID == string(game_id) {
-					for _, user := range room.Users {
-						user.Ready = false
-						sendUserStatus(user.SessionID, user.GameID, senderWebSocket)
-					}
+// clearTableHere clears the table for this instance's players and resets
+// their turns and votes.
+func clearTableHere(gameID string) {
+	SendDeleteMessage(gameID, time.Time{})
+	mu.Lock()
+	for _, rooms := range clients {
+		for _, room := range rooms {
+			if room.GameID == gameID {
+				for _, user := range room.Users {
+					user.Turn = false
+					user.Voted = false
 				}
 			}
 		}
-		mu.Unlock()
 	}
+	mu.Unlock()
+	shareGame(gameID)
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	game "ws_server/proto"
+)
+
+// errInvalidPayload marks a message that decoded but lacks fields its
+// handler needs.
+var errInvalidPayload = errors.New("invalid payload")
+
+// maxSessionIDLength bounds session IDs, which the REST service issues as
+// UUIDs.
+const maxSessionIDLength = 64
+
+// validatePayload checks the fields handlers rely on before they touch them:
+// every message naming a player needs one with a game and a session, and
+// IDs that point at other players must look like session IDs.
+func validatePayload(msg proto.Message) error {
+	switch m := msg.(type) {
+	case *game.Rejoin:
+		// The token alone says who is rejoining; any User is replaced.
+		if len(m.Token) == 0 {
+			return invalidPayload("token is required")
+		}
+		return nil
+	case *game.Choose:
+		if err := validateUser(m.User); err != nil {
+			return err
+		}
+		if !validSessionID(m.ChosenId) {
+			return invalidPayload("chosen_id is not a session ID")
+		}
+		return nil
+	case *game.GameInfo:
+		if err := validateUser(m.User); err != nil {
+			return err
+		}
+		if !validSessionID(m.DestinationId) {
+			return invalidPayload("destination_id is not a session ID")
+		}
+		return nil
+	case interface{ GetUser() *game.User }:
+		return validateUser(m.GetUser())
+	}
+	return nil
+}
+
+func validateUser(user *game.User) error {
+	switch {
+	case user == nil:
+		return invalidPayload("user is required")
+	case len(user.GameId) == 0:
+		return invalidPayload("user.game_id is required")
+	case !validSessionID(user.SessionId):
+		return invalidPayload("user.session_id is not a session ID")
+	}
+	return nil
+}
+
+// validSessionID reports whether id is shaped like a session ID: letters,
+// digits and dashes.
+func validSessionID(id []byte) bool {
+	if len(id) == 0 || len(id) > maxSessionIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func invalidPayload(reason string) error {
+	return fmt.Errorf("%w: %s", errInvalidPayload, reason)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Frames go out through a write pump per connection. Sending queues the
+// frame and returns at once; a fixed pool of writers drains the queues, one
+// connection at a time per writer, so each connection still gets its frames
+// in order. A broadcast therefore neither starts a goroutine per recipient
+// nor keeps mu held while it waits on the network, and a client too slow to
+// keep up with its queue is dropped instead of holding everyone up.
+const (
+	defaultWriteWorkers = 32
+	writeQueueSize      = 256
+	writeTimeout        = 10 * time.Second
+	// writeBatch is how many frames a writer sends to one connection
+	// before moving on to the next that has some waiting.
+	writeBatch = 16
+)
+
+var errWriteQueueFull = errors.New("write queue full")
+
+// outboundFrame is a message waiting in a write pump.
+type outboundFrame struct {
+	messageType int
+	data        []byte
+}
+
+type writePump struct {
+	conn *websocket.Conn
+
+	mu    sync.Mutex
+	queue []outboundFrame
+	// scheduled is set while the pump is waiting for or held by a writer.
+	scheduled bool
+	// closing closes the connection once the queue is drained.
+	closing bool
+	closed  bool
+}
+
+var (
+	pumpsMu sync.Mutex
+	pumps   = make(map[*websocket.Conn]*writePump)
+
+	readyMu    sync.Mutex
+	readyCond  = sync.NewCond(&readyMu)
+	readyPumps []*writePump
+)
+
+// startWriteWorkers starts the writers, WS_WRITE_WORKERS of them if set.
+func startWriteWorkers() {
+	workers := defaultWriteWorkers
+	if n, err := strconv.Atoi(os.Getenv("WS_WRITE_WORKERS")); err == nil && n > 0 {
+		workers = n
+	}
+	for i := 0; i < workers; i++ {
+		go runWriteWorker()
+	}
+}
+
+// attachWritePump routes what is sent to conn through a write pump.
+func attachWritePump(conn *websocket.Conn) {
+	pumpsMu.Lock()
+	defer pumpsMu.Unlock()
+	pumps[conn] = &writePump{conn: conn}
+}
+
+func pumpFor(conn *websocket.Conn) *writePump {
+	pumpsMu.Lock()
+	defer pumpsMu.Unlock()
+	return pumps[conn]
+}
+
+// queueMessage sends a message to conn through its write pump, or straight
+// away if it has none.
+func queueMessage(conn *websocket.Conn, messageType int, data []byte) error {
+	pump := pumpFor(conn)
+	if pump == nil {
+		return conn.WriteMessage(messageType, data)
+	}
+	return pump.push(outboundFrame{messageType: messageType, data: data})
+}
+
+// closeWhenFlushed closes conn once the frames queued for it are written, so
+// a notice sent just before it is dropped still arrives.
+func closeWhenFlushed(conn *websocket.Conn) {
+	pump := pumpFor(conn)
+	if pump == nil {
+		conn.Close()
+		return
+	}
+
+	pump.mu.Lock()
+	if pump.closed {
+		pump.mu.Unlock()
+		return
+	}
+	pump.closing = true
+	idle := !pump.scheduled
+	if idle {
+		pump.closed = true
+	}
+	pump.mu.Unlock()
+	if idle {
+		pump.shut()
+	}
+}
+
+func (p *writePump) push(frame outboundFrame) error {
+	p.mu.Lock()
+	if p.closed || p.closing {
+		p.mu.Unlock()
+		return websocket.ErrCloseSent
+	}
+	if len(p.queue) >= writeQueueSize {
+		p.closed = true
+		p.queue = nil
+		p.mu.Unlock()
+		warnf("Dropping client %s: its write queue is full", p.conn.RemoteAddr())
+		p.conn.WriteControl(websocket.CloseMessage, closeTooSlow.frame("Too far behind", 0), time.Now().Add(time.Second))
+		p.shut()
+		return errWriteQueueFull
+	}
+	p.queue = append(p.queue, frame)
+	schedule := !p.scheduled
+	p.scheduled = true
+	p.mu.Unlock()
+
+	if schedule {
+		readyMu.Lock()
+		readyPumps = append(readyPumps, p)
+		readyCond.Signal()
+		readyMu.Unlock()
+	}
+	return nil
+}
+
+func runWriteWorker() {
+	for {
+		readyMu.Lock()
+		for len(readyPumps) == 0 {
+			readyCond.Wait()
+		}
+		p := readyPumps[0]
+		readyPumps = readyPumps[1:]
+		readyMu.Unlock()
+
+		p.drain()
+	}
+}
+
+// drain writes up to writeBatch queued frames and puts the pump back in line
+// if more are waiting.
+func (p *writePump) drain() {
+	for i := 0; i < writeBatch; i++ {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return
+		}
+		if len(p.queue) == 0 {
+			p.scheduled = false
+			closing := p.closing
+			p.closed = closing
+			p.mu.Unlock()
+			if closing {
+				p.shut()
+			}
+			return
+		}
+		frame := p.queue[0]
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+
+		p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := p.conn.WriteMessage(frame.messageType, frame.data); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				errorf("Error sending message to client: %v", err)
+			}
+			p.mu.Lock()
+			p.closed = true
+			p.queue = nil
+			p.mu.Unlock()
+			p.shut()
+			return
+		}
+	}
+
+	readyMu.Lock()
+	readyPumps = append(readyPumps, p)
+	readyCond.Signal()
+	readyMu.Unlock()
+}
+
+// shut closes the connection and forgets its pump. The reader notices and
+// cleans up after the client as usual.
+func (p *writePump) shut() {
+	pumpsMu.Lock()
+	if pumps[p.conn] == p {
+		delete(pumps, p.conn)
+	}
+	pumpsMu.Unlock()
+	p.conn.Close()
+}